@@ -1,18 +1,62 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/ax-vasquez/wedding-site-api/controllers"
+	"github.com/ax-vasquez/wedding-site-api/helper"
 	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// Environment flags that swap out the database or relax checks for testing; none of these may be set in release mode
+var testOnlyFlags = []string{"USE_MOCK_DB", "TEST_ENV"}
+
+// checkReleaseEnv returns an error if the app is running in release mode with any test-only flag enabled
+func checkReleaseEnv() error {
+	if gin.Mode() != gin.ReleaseMode {
+		return nil
+	}
+	for _, flag := range testOnlyFlags {
+		if enabled, _ := strconv.ParseBool(os.Getenv(flag)); enabled {
+			return fmt.Errorf("%s is set while running in release mode; refusing to start", flag)
+		}
+	}
+	return nil
+}
+
+// loadSigningKeys loads the keys tokens are signed and verified with from JWT_KEYS_DIR (see helper.LoadKeySet)
+//
+// Outside of release mode, a throwaway key is used if JWT_KEYS_DIR isn't set.
+func loadSigningKeys() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		if gin.Mode() == gin.ReleaseMode {
+			return fmt.Errorf("JWT_KEYS_DIR must be set in release mode; refusing to start")
+		}
+		log.Println("WARNING! JWT_KEYS_DIR is not set; tokens will be signed with a throwaway key and stop working when the application restarts.")
+		return nil
+	}
+	keys, err := helper.LoadKeySet(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+	if err != nil {
+		return fmt.Errorf("could not load signing keys: %w", err)
+	}
+	helper.SetKeySet(keys)
+	return nil
+}
+
 func main() {
 	var err error
 	log.Print(os.Getenv("GIN_MODE"))
+	err = checkReleaseEnv()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 	// Configure dev environment
 	if gin.Mode() == "debug" {
 		log.Println("Running in local development mode...")
@@ -22,9 +66,14 @@ func main() {
 		}
 	}
 
+	err = loadSigningKeys()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	models.Setup()
 	models.Migrate()
-	err = controllers.SetupRoutes()
+	err = controllers.SetupRoutes(controllers.NewHandler(repository.NewGormStore()))
 	if err != nil {
 		log.Panic("Encountered an error while setting up routes: ", err.Error())
 	}
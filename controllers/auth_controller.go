@@ -2,16 +2,22 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/middleware"
 	"github.com/ax-vasquez/wedding-site-api/models"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Signup signs up a new user with the provided credentials
@@ -26,7 +32,7 @@ import (
 //	@Failure      400  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /signup [post]
-func Signup(c *gin.Context) {
+func (h *Handler) Signup(c *gin.Context) {
 	var response types.V1_API_RESPONSE_AUTH
 	var status int
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -41,7 +47,7 @@ func Signup(c *gin.Context) {
 		return
 	}
 
-	count, err := models.CountUsersByEmail(ctx, uInput.Email)
+	count, err := h.Users.CountUsersByEmail(ctx, uInput.Email)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error when checking if user exists"
@@ -67,30 +73,23 @@ func Signup(c *gin.Context) {
 		return
 	}
 
-	verifyPwResult := helper.VerifyPasswordComplexity(uInput.Password, 2, 2, 2, 8)
-	if !verifyPwResult.HasExpectedDigitCt || !verifyPwResult.HasExpectedSpecialCaseCt || !verifyPwResult.HasExpectedUpperCaseCt || !verifyPwResult.HasMinLength {
-		var b strings.Builder
-		b.WriteString("Password failed complexity requirement(s): ")
-		if !verifyPwResult.HasExpectedDigitCt {
-			b.WriteString("must have 2 or more digits; ")
-		}
-		if !verifyPwResult.HasExpectedSpecialCaseCt {
-			b.WriteString("must have 2 or more special characters; ")
-		}
-		if !verifyPwResult.HasExpectedUpperCaseCt {
-			b.WriteString("must have 2 or more capital letters; ")
-		}
-		if !verifyPwResult.HasMinLength {
-			b.WriteString("must be at least 8 characters in length")
-		}
+	if problems := h.PasswordPolicy.Check(uInput.Password, uInput.Email, uInput.FirstName, uInput.LastName); len(problems) > 0 {
 		status = http.StatusUnprocessableEntity
 		response.Status = status
-		response.Message = b.String()
+		response.Message = passwordProblemsMessage(problems)
 		c.JSON(status, response)
 		return
 	}
 
-	hashedPassword := helper.HashPassword(uInput.Password)
+	hashedPassword, err := h.PasswordHashing.Hash(uInput.Password)
+	if err != nil {
+		log.Println("ERROR: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error while creating user"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
 	var newUser = models.User{}
 	newUser.FirstName = uInput.FirstName
 	newUser.LastName = uInput.LastName
@@ -98,7 +97,7 @@ func Signup(c *gin.Context) {
 	newUser.Password = hashedPassword
 
 	createUserInput := []models.User{newUser}
-	err = models.CreateUsers(ctx, &createUserInput)
+	err = h.Users.CreateUsers(ctx, &createUserInput)
 	if err != nil {
 		log.Println("ERROR: ", err.Error())
 		status = http.StatusInternalServerError
@@ -108,7 +107,15 @@ func Signup(c *gin.Context) {
 		return
 	}
 
-	token, refreshToken, err := helper.GenerateAllTokens(createUserInput[0].Email, createUserInput[0].FirstName, createUserInput[0].LastName, createUserInput[0].Role, createUserInput[0].ID)
+	// The account works right away, but routes that require a verified email address won't until the link is used
+	if err := h.sendEmailVerification(ctx, &createUserInput[0]); err != nil {
+		log.Println("Error sending email verification: ", err.Error())
+	}
+
+	token, refreshToken, err := h.startSession(ctx, c, &createUserInput[0])
+	if err == nil {
+		err = h.sendAuthTokens(c, &response.Data, token, refreshToken)
+	}
 	if err != nil {
 		log.Println("ERROR: ", err.Error())
 		status = http.StatusInternalServerError
@@ -119,8 +126,6 @@ func Signup(c *gin.Context) {
 	}
 
 	status = http.StatusCreated
-	response.Data.Token = token
-	response.Data.RefreshToken = refreshToken
 	response.Status = status
 	c.JSON(status, response)
 }
@@ -128,17 +133,18 @@ func Signup(c *gin.Context) {
 // Login logs in a user and returns the user details for the user (if authentication is successful)
 //
 //	@Summary      Logs in a user
-//	@Description  Logs in a user and returns the user details for the user (if authentication is successful)
+//	@Description  Logs in a user and returns the user details for the user (if authentication is successful). In cookie mode, the tokens are set as HttpOnly cookies (along with a `csrf_token` cookie whose value has to be sent in the `X-CSRF-Token` header of mutating requests) instead. Users with two-factor authentication enabled get an `mfa_token` instead of auth tokens, which they exchange (along with a code) at `/auth/mfa/verify`.
 //	@Tags         auth
 //	@Accept       json
 //	@Produce      json
 //	@Param		  data body types.UserLoginInput true "Log in details"
-//	@Param		  X-CSRF-Token	header	string	true "Anti CSRF token"
 //	@Success      202  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      400  {object}  types.V1_API_RESPONSE_USERS
+//	@Failure      401  {object}  types.V1_API_RESPONSE_USERS
+//	@Failure      429  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /login [post]
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var response types.V1_API_RESPONSE_AUTH
 	var status int
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -155,21 +161,55 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse the attempt outright if the account or client IP has failed too often recently
+	accountKey := strings.ToLower(inputUser.Email)
+	ipKey := c.ClientIP()
+	now := time.Now()
+	accountThrottle, accountErr := h.LoginThrottles.FindLoginThrottle(ctx, models.LoginThrottleAccount, accountKey)
+	ipThrottle, ipErr := h.LoginThrottles.FindLoginThrottle(ctx, models.LoginThrottleIP, ipKey)
+	if accountErr != nil || ipErr != nil {
+		log.Println("ERROR: ", errors.Join(accountErr, ipErr).Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	retryAfter := max(helper.AccountLoginPolicy.RetryAfter(accountThrottle, now), helper.IPLoginPolicy.RetryAfter(ipThrottle, now))
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		status = http.StatusTooManyRequests
+		response.Message = "Too many failed login attempts. Try again later."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
 	dbUser.Email = inputUser.Email
 	// Load the user details from the DB
-	err := models.FindUser(ctx, &dbUser)
-	if err != nil {
+	var passIsValid bool
+	err := h.Users.FindUser(ctx, &dbUser)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Respond exactly as we would for a wrong password (after the same amount of work) so emails can't be enumerated
+		passIsValid = helper.VerifyDummyPassword(inputUser.Password)
+	case err != nil:
 		log.Println("ERROR: ", err.Error())
-		status = http.StatusNotFound
-		response.Message = "User not found"
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error."
 		response.Status = status
 		c.JSON(status, response)
 		return
+	default:
+		// Check password validity for user (the DB user Password is a hash, the input password is the plain-text password)
+		passIsValid = helper.VerifyPassword(dbUser.Password, inputUser.Password)
 	}
-
-	// Check password validity for user (the DB user Password is a hash, the input password is the plain-text password)
-	passIsValid := helper.VerifyPassword(dbUser.Password, inputUser.Password)
 	if !passIsValid {
+		_, accountErr = h.LoginThrottles.RecordLoginFailure(ctx, models.LoginThrottleAccount, accountKey, now, helper.AccountLoginPolicy.StaleBefore(now))
+		_, ipErr = h.LoginThrottles.RecordLoginFailure(ctx, models.LoginThrottleIP, ipKey, now, helper.IPLoginPolicy.StaleBefore(now))
+		if accountErr != nil || ipErr != nil {
+			log.Println("Error recording failed login: ", errors.Join(accountErr, ipErr).Error())
+		}
 		status = http.StatusUnauthorized
 		response.Message = "Invalid credentials."
 		response.Status = status
@@ -177,8 +217,22 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Generate new tokens for the user once we know the pass is valid
-	token, refreshToken, err := helper.GenerateAllTokens(dbUser.Email, dbUser.FirstName, dbUser.LastName, dbUser.Role, dbUser.ID)
+	// Upgrade the stored hash if it was made with an outdated algorithm or cost (we only have the plain-text password now)
+	if h.PasswordHashing.NeedsRehash(dbUser.Password) {
+		if newHash, err := h.PasswordHashing.Hash(inputUser.Password); err != nil {
+			log.Println("Error rehashing password: ", err.Error())
+		} else if err := h.Users.UpdateUser(ctx, &models.User{BaseModel: models.BaseModel{ID: dbUser.ID}, Password: newHash}); err != nil {
+			log.Println("Error saving rehashed password: ", err.Error())
+		}
+	}
+
+	// Only the account's counter is reset; resetting the IP's would let one valid login clear the way for guessing other accounts
+	if err := h.LoginThrottles.ResetLoginThrottle(ctx, models.LoginThrottleAccount, accountKey); err != nil {
+		log.Println("Error resetting failed login count: ", err.Error())
+	}
+
+	// Users with two-factor authentication enabled only get a challenge here; they get their tokens once they enter a code
+	mfaToken, err := h.startMfaChallenge(ctx, &dbUser)
 	if err != nil {
 		log.Println("ERROR: ", err.Error())
 		status = http.StatusInternalServerError
@@ -187,9 +241,20 @@ func Login(c *gin.Context) {
 		c.JSON(status, response)
 		return
 	}
+	if mfaToken != "" {
+		status = http.StatusAccepted
+		response.Status = status
+		response.Message = mfaRequiredMessage
+		response.Data.MfaToken = mfaToken
+		c.JSON(status, response)
+		return
+	}
 
-	// Update signed tokens in DB for user
-	err = helper.UpdateAllTokens(token, refreshToken, &dbUser)
+	// Start a session for the user once we know the pass is valid
+	token, refreshToken, err := h.startSession(ctx, c, &dbUser)
+	if err == nil {
+		err = h.sendAuthTokens(c, &response.Data, token, refreshToken)
+	}
 	if err != nil {
 		log.Println("ERROR: ", err.Error())
 		status = http.StatusInternalServerError
@@ -201,7 +266,87 @@ func Login(c *gin.Context) {
 
 	status = http.StatusAccepted
 	response.Status = status
-	response.Data.Token = token
-	response.Data.RefreshToken = refreshToken
 	c.JSON(status, response)
 }
+
+// Logout ends the caller's session and clears the auth cookies set in cookie mode
+//
+//	@Summary      Logs out a user
+//	@Description  Ends the session the access token (from the Authorization header, or the access token cookie in cookie mode) or refresh token (from the request body, or the refresh token cookie) belongs to, so its tokens stop working, and clears the auth cookies (scripts can't clear them, since they're HttpOnly). Works without a valid access token so expired cookies can still be cleared.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.RefreshTokensInput false "The refresh token (leave it out in cookie mode or when sending the access token)"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /auth/logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	h.AuthCookies.ClearAuthCookies(c)
+
+	var input types.RefreshTokensInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+			status = http.StatusBadRequest
+			response.Status = status
+			response.Message = err.Error()
+			c.JSON(status, response)
+			return
+		}
+	}
+
+	sessionId, userId, err := h.findSessionToEnd(ctx, c, input.RefreshToken)
+	if err == nil && sessionId != uuid.Nil {
+		_, err = h.Sessions.DeleteSessionForUser(ctx, sessionId, userId)
+	}
+	if err != nil {
+		log.Println("Error ending session: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Logged out"
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Finds the session (and its user) that the request's access token or the given refresh token (falling back to the
+// refresh token cookie) belongs to; returns uuid.Nil if neither token belongs to a session
+func (h *Handler) findSessionToEnd(ctx context.Context, c *gin.Context, refreshToken string) (uuid.UUID, uuid.UUID, error) {
+	claims, msg := helper.ValidateToken(middleware.BearerToken(c))
+	if msg == "" && claims.TokenType == helper.AccessToken && claims.SessionID != uuid.Nil {
+		return claims.SessionID, claims.ID, nil
+	}
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(middleware.RefreshTokenCookie)
+	}
+	if refreshToken == "" {
+		return uuid.Nil, uuid.Nil, nil
+	}
+	session, err := h.Sessions.FindSession(ctx, helper.HashOneTimeToken(refreshToken))
+	if err != nil || session == nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return session.ID, session.UserId, nil
+}
+
+// Sends the user's new tokens: as cookies in cookie mode (leaving them out of the response body so scripts can't read
+// them), otherwise in the response body
+func (h *Handler) sendAuthTokens(c *gin.Context, data *types.AuthDetails, token string, refreshToken string) error {
+	if h.AuthCookies.Enabled {
+		return h.AuthCookies.SetAuthCookies(c, token, refreshToken)
+	}
+	data.Token = token
+	data.RefreshToken = refreshToken
+	return nil
+}
+
+// Formats the unmet password requirements as a single response message
+func passwordProblemsMessage(problems []string) string {
+	return "Password failed complexity requirement(s): " + strings.Join(problems, "; ")
+}
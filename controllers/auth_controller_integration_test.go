@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -19,7 +20,7 @@ import (
 func Test_AuthController_Integration(t *testing.T) {
 	inviteCode := os.Getenv("INVITE_CODE")
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	t.Run("POST /api/v1/signup - successful signup", func(t *testing.T) {
 		newUserInput := types.UserSignupInput{
 			FirstName:  "Test",
@@ -4,29 +4,28 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"regexp"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/middleware"
 	"github.com/ax-vasquez/wedding-site-api/models"
-	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
 	"github.com/ax-vasquez/wedding-site-api/types"
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_AuthController_Unit(t *testing.T) {
-	os.Setenv("USE_MOCK_DB", "true")
 	os.Setenv("INVITE_CODE", "SomeCode")
 	assert := assert.New(t)
-	router := paveRoutes()
 	errMsg := "arbitrary database error"
 	t.Run("POST /api/v1/signup - internal server error when checking if user exists", func(t *testing.T) {
 		signupInput := types.UserSignupInput{
@@ -38,8 +37,7 @@ func Test_AuthController_Unit(t *testing.T) {
 			LastName:   "Lastname",
 			InviteCode: "SomeCode",
 		}
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE email = $1 AND "users"."deleted_at" IS NULL`)).WithArgs("some@email.com").WillReturnError(fmt.Errorf(errMsg))
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg), "CountUsersByEmail")))
 
 		signupInputJson, _ := json.Marshal(signupInput)
 
@@ -63,26 +61,7 @@ func Test_AuthController_Unit(t *testing.T) {
 			LastName:   "Lastname",
 			InviteCode: "SomeCode",
 		}
-
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE email = $1 AND "users"."deleted_at" IS NULL`)).WithArgs("some@email.com").WillReturnRows(sqlmock.NewRows([]string{"count"}))
-		mock.ExpectBegin()
-		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("created_at","updated_at","deleted_at","role","is_going","first_name","last_name","email","password","token","refresh_token","hors_doeuvres_selection_id","entree_selection_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13) RETURNING "id"`)).WithArgs(
-			test.AnyTime{},
-			test.AnyTime{},
-			nil,
-			"GUEST",
-			false,
-			signupInput.FirstName,
-			signupInput.LastName,
-			signupInput.Email,
-			test.AnyString{},
-			"",
-			"",
-			nil,
-			nil,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg), "CreateUsers")))
 
 		signupInputJson, _ := json.Marshal(signupInput)
 
@@ -101,44 +80,33 @@ func Test_AuthController_Unit(t *testing.T) {
 			Email:    "some@email.com",
 			Password: "ASdf12#$",
 		}
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).WithArgs("some@email.com", 1).WillReturnError(fmt.Errorf(errMsg))
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg), "FindUser")))
 		loginJson, _ := json.Marshal(loginInput)
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(loginJson)))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
-		assert.Equal(http.StatusNotFound, w.Code)
+		assert.Equal(http.StatusInternalServerError, w.Code)
 		loginResponse := types.V1_API_RESPONSE_AUTH{}
 		err = json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
-		assert.Equal(http.StatusNotFound, loginResponse.Status)
-		assert.Equal("User not found", loginResponse.Message)
+		assert.Equal(http.StatusInternalServerError, loginResponse.Status)
+		assert.Equal("Internal server error.", loginResponse.Message)
 	})
-	t.Run("POST /api/v1/login - internal server error when saving token and refresh token for user", func(t *testing.T) {
-		fakeUserId := uuid.New()
+	t.Run("POST /api/v1/login - internal server error when starting a session for user", func(t *testing.T) {
 		loginInput := types.UserLoginInput{
 			Email:    "some@email.com",
 			Password: "ASdf12#$",
 		}
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).WithArgs("some@email.com", 1).WillReturnRows(
-			sqlmock.NewRows([]string{"id", "role", "first_name", "last_name", "email", "password"}).AddRow(
-				fakeUserId.String(),
-				"GUEST",
-				"Firstname",
-				"Lastname",
-				loginInput.Email,
-				helper.HashPassword(loginInput.Password),
-			))
-		mock.ExpectBegin()
-		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE "users" SET "updated_at"=$1,"token"=$2,"refresh_token"=$3 WHERE "users"."deleted_at" IS NULL AND "id" = $4 RETURNING "users"."role","users"."first_name","users"."last_name","users"."email","users"."hors_doeuvres_selection_id","users"."entree_selection_id`)).WithArgs(
-			test.AnyTime{},
-			test.AnyString{},
-			test.AnyString{},
-			fakeUserId.String(),
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
+		store := repositorytest.NewFailingStore(errors.New(errMsg), "CreateSession")
+		hashedPassword, _ := helper.HashPassword(loginInput.Password)
+		store.CreateUsers(context.Background(), &[]models.User{{
+			FirstName: "Firstname",
+			LastName:  "Lastname",
+			Email:     loginInput.Email,
+			Password:  hashedPassword,
+		}})
+		router := paveRoutes(NewHandler(store))
 
 		loginJson, _ := json.Marshal(loginInput)
 
@@ -152,4 +120,261 @@ func Test_AuthController_Unit(t *testing.T) {
 		assert.Equal(http.StatusInternalServerError, loginResponse.Status)
 		assert.Equal("Internal server error while saving auth details", loginResponse.Message)
 	})
+	t.Run("POST /api/v1/login - fixture user can log in and use the token", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		loginJson, _ := json.Marshal(types.UserLoginInput{
+			Email:    fixtures.Guest().Email,
+			Password: models.TestUserPassword,
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(loginJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+		loginResponse := types.V1_API_RESPONSE_AUTH{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		assert.NotEmpty(loginResponse.Data.Token)
+
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", loginResponse.Data.Token)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+		usersResponse := types.V1_API_RESPONSE_USERS{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &usersResponse)
+		assert.Equal(fixtures.Guest().ID, usersResponse.Data.Users[0].ID)
+	})
+	t.Run("POST /api/v1/login - unknown email looks like a wrong password", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		loginJson, _ := json.Marshal(types.UserLoginInput{
+			Email:    "nobody@fakedomain.com",
+			Password: models.TestUserPassword,
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(loginJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		loginResponse := types.V1_API_RESPONSE_AUTH{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		assert.Equal("Invalid credentials.", loginResponse.Message)
+	})
+	t.Run("POST /api/v1/login - throttled account is refused with Retry-After", func(t *testing.T) {
+		store := fixtures.NewStore()
+		now := time.Now()
+		for i := 0; i <= helper.AccountLoginPolicy.FreeAttempts; i++ {
+			store.RecordLoginFailure(context.Background(), models.LoginThrottleAccount, fixtures.Guest().Email, now, now)
+		}
+		router := paveRoutes(NewHandler(store))
+		loginJson, _ := json.Marshal(types.UserLoginInput{
+			Email:    strings.ToUpper(fixtures.Guest().Email),
+			Password: models.TestUserPassword,
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(loginJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusTooManyRequests, w.Code)
+		assert.Equal("1", w.Header().Get("Retry-After"))
+	})
+	t.Run("GET /api/v1/lockouts - admin - can see and lift a lockout", func(t *testing.T) {
+		store := fixtures.NewStore()
+		now := time.Now()
+		for i := 0; i < helper.AccountLoginPolicy.LockoutThreshold; i++ {
+			store.RecordLoginFailure(context.Background(), models.LoginThrottleAccount, fixtures.Guest().Email, now, now)
+		}
+		router := paveRoutes(NewHandler(store))
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/lockouts", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+		lockoutResponse := types.V1_API_RESPONSE_LOCKOUTS{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &lockoutResponse)
+		assert.Equal(1, len(lockoutResponse.Data.Lockouts))
+		assert.Equal(fixtures.Guest().Email, lockoutResponse.Data.Lockouts[0].Email)
+
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("DELETE", "/api/v1/lockouts/"+fixtures.Guest().Email, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		throttle, _ := store.FindLoginThrottle(context.Background(), models.LoginThrottleAccount, fixtures.Guest().Email)
+		assert.Equal(0, throttle.Failures)
+	})
+	t.Run("GET /api/v1/lockouts - planner - cannot see lockouts", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/lockouts", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("POST /api/v1/signup - rate limited per client IP", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		for i := 0; i < 10; i++ {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/signup", strings.NewReader("bad input"))
+			router.ServeHTTP(w, req)
+			assert.Equal(http.StatusBadRequest, w.Code)
+		}
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/signup", strings.NewReader("bad input"))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(w.Header().Get("Retry-After"))
+		signupResponse := types.V1_API_RESPONSE_AUTH{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &signupResponse)
+		assert.Equal(http.StatusTooManyRequests, signupResponse.Status)
+	})
+	t.Run("POST /api/v1/login - outdated password hash is upgraded", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		loginJson, _ := json.Marshal(types.UserLoginInput{
+			Email:    fixtures.Guest().Email,
+			Password: models.TestUserPassword,
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(loginJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		u := models.User{Email: fixtures.Guest().Email}
+		store.FindUser(context.Background(), &u)
+		assert.True(strings.HasPrefix(u.Password, "$argon2id$"))
+		assert.True(helper.VerifyPassword(u.Password, models.TestUserPassword))
+	})
+	t.Run("POST /api/v1/signup - common password is rejected", func(t *testing.T) {
+		signupJson, _ := json.Marshal(types.UserSignupInput{
+			UserLoginInput: types.UserLoginInput{
+				Email:    "some@email.com",
+				Password: "P@SSWORD123",
+			},
+			FirstName:  "Firstname",
+			LastName:   "Lastname",
+			InviteCode: "SomeCode",
+		})
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/signup", strings.NewReader(string(signupJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnprocessableEntity, w.Code)
+		signupResponse := types.V1_API_RESPONSE_AUTH{}
+		json.Unmarshal([]byte(w.Body.Bytes()), &signupResponse)
+		assert.Contains(signupResponse.Message, "is too common")
+	})
+}
+
+func Test_AuthCookies_Unit(t *testing.T) {
+	assert := assert.New(t)
+	cookieHandler := func() *Handler {
+		h := NewHandler(fixtures.NewStore())
+		h.AuthCookies = middleware.AuthCookieSettings{Enabled: true, SameSite: http.SameSiteStrictMode}
+		return h
+	}
+	// Logs in as the guest, returning the cookies set by the response
+	login := func(router http.Handler) (*httptest.ResponseRecorder, map[string]*http.Cookie) {
+		body, _ := json.Marshal(types.UserLoginInput{Email: fixtures.Guest().Email, Password: models.TestUserPassword})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", strings.NewReader(string(body)))
+		router.ServeHTTP(w, req)
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		return w, cookies
+	}
+	serve := func(router http.Handler, method string, path string, cookies map[string]*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(""))
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfToken != "" {
+			req.Header.Set(middleware.CSRFTokenHeader, csrfToken)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	t.Run("POST /api/v1/login - cookie mode sets HttpOnly token cookies instead of returning tokens", func(t *testing.T) {
+		w, cookies := login(paveRoutes(cookieHandler()))
+		assert.Equal(http.StatusAccepted, w.Code)
+		var loginResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		assert.Empty(loginResponse.Data.Token)
+		assert.Empty(loginResponse.Data.RefreshToken)
+		for _, name := range []string{middleware.AccessTokenCookie, middleware.RefreshTokenCookie} {
+			assert.NotEmpty(cookies[name].Value)
+			assert.True(cookies[name].HttpOnly)
+			assert.True(cookies[name].Secure)
+			assert.Equal(http.SameSiteStrictMode, cookies[name].SameSite)
+		}
+		assert.NotEmpty(cookies[middleware.CSRFTokenCookie].Value)
+		assert.False(cookies[middleware.CSRFTokenCookie].HttpOnly)
+	})
+	t.Run("POST /api/v1/login - tokens are returned in the body without cookie mode", func(t *testing.T) {
+		w, cookies := login(paveRoutes(NewHandler(fixtures.NewStore())))
+		assert.Equal(http.StatusAccepted, w.Code)
+		var loginResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		assert.NotEmpty(loginResponse.Data.Token)
+		assert.Empty(cookies)
+	})
+	t.Run("Cookie-authenticated requests - reads work and writes need the CSRF token", func(t *testing.T) {
+		router := paveRoutes(cookieHandler())
+		_, cookies := login(router)
+
+		w := serve(router, "GET", "/api/v1/auth/mfa", cookies, "")
+		assert.Equal(http.StatusOK, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll", cookies, "")
+		assert.Equal(http.StatusForbidden, w.Code)
+		var csrfResponse types.V1_API_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &csrfResponse)
+		assert.Equal("Missing or invalid CSRF token", csrfResponse.Message)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll", cookies, "not-the-token")
+		assert.Equal(http.StatusForbidden, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll", cookies, cookies[middleware.CSRFTokenCookie].Value)
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
+	t.Run("Header-authenticated requests - writes don't need a CSRF token", func(t *testing.T) {
+		router := paveRoutes(cookieHandler())
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/auth/mfa/enroll", strings.NewReader(""))
+		req.Header.Set("Authorization", "Bearer "+fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
+	t.Run("POST /api/v1/auth/logout - clears the auth cookies", func(t *testing.T) {
+		router := paveRoutes(cookieHandler())
+		_, cookies := login(router)
+		w := serve(router, "POST", "/api/v1/auth/logout", cookies, "")
+		assert.Equal(http.StatusAccepted, w.Code)
+		cleared := w.Result().Cookies()
+		assert.Len(cleared, 3)
+		for _, cookie := range cleared {
+			assert.Empty(cookie.Value)
+			assert.Negative(cookie.MaxAge)
+		}
+		// The session is gone too, so a copy of the refresh token cookie can't be used
+		w = serve(router, "POST", "/api/v1/auth/refresh", cookies, "")
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
 }
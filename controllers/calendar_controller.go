@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// Sends the events as an iCalendar file
+func sendCalendar(c *gin.Context, events []models.Event) error {
+	loc, err := helper.CalendarLocation()
+	if err != nil {
+		return err
+	}
+	c.Data(http.StatusOK, calendarContentType, []byte(helper.BuildCalendar(events, loc, time.Now())))
+	return nil
+}
+
+// Builds the link to a calendar feed; the API's address is read from API_URL, which defaults to the address the
+// request was sent to
+func calendarFeedLink(c *gin.Context, token string) string {
+	base := strings.TrimSuffix(os.Getenv("API_URL"), "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/api/v1/calendar/" + token + ".ics"
+}
+
+// GetCalendar gets the events the logged in user is invited to as an iCalendar file
+//
+//	@Summary      downloads the logged in user's events as a calendar
+//	@Description  Gets the events the logged in user (and their invitees) are invited to as an iCalendar (.ics) file that can be imported into calendar apps. Each event keeps the same UID, so importing the file again updates the events instead of duplicating them.
+//	@Tags         events
+//	@Produce      text/calendar
+//	@Success      200  {string}  string
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /calendar.ics [get]
+func (h *Handler) GetCalendar(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	events, err := h.Events.FindEventsForUser(ctx, uid)
+	if err == nil {
+		c.Header("Content-Disposition", `attachment; filename="calendar.ics"`)
+		err = sendCalendar(c, events)
+	}
+	if err != nil {
+		c.Header("Content-Disposition", "")
+		log.Println("Error creating calendar: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+	}
+}
+
+// GetCalendarFeed gets the events the feed's user is attending as an iCalendar file
+//
+//	@Summary      gets a calendar feed
+//	@Description  Gets the events the feed's user is invited to and has said they (or one of their invitees) are attending as an iCalendar (.ics) file. This is the link calendar apps subscribe to, so it works without logging in; the token in the link is the only credential.
+//	@Tags         events
+//	@Produce      text/calendar
+//	@Param 		  feed  path string true "The feed's token, followed by .ics"
+//	@Success      200  {string}  string
+//	@Failure      404  {object}  types.V1_API_RESPONSE
+//	@Failure      429  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /calendar/{feed} [get]
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+
+	token := strings.TrimSuffix(c.Param("feed"), ".ics")
+	feed, err := h.CalendarFeeds.FindCalendarFeed(ctx, helper.HashOneTimeToken(token))
+	var events []models.Event
+	if err == nil && feed != nil {
+		// Feeds stop working when their user is deleted
+		err = h.Users.FindUser(ctx, &models.User{BaseModel: models.BaseModel{ID: feed.UserId}})
+		var rsvps []models.EventRsvp
+		if err == nil {
+			rsvps, err = h.Events.FindEventRsvpsForUser(ctx, feed.UserId)
+		}
+		if err == nil {
+			events, err = h.Events.FindEventsForUser(ctx, feed.UserId)
+		}
+		events = slices.DeleteFunc(events, func(e models.Event) bool {
+			return !slices.ContainsFunc(rsvps, func(r models.EventRsvp) bool { return r.EventId == e.ID && r.Attending })
+		})
+	}
+	if err == nil && feed != nil {
+		err = sendCalendar(c, events)
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Println("Error creating calendar feed: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case feed == nil || err != nil:
+		status = http.StatusNotFound
+		response.Message = "Calendar not found"
+	default:
+		return
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateCalendarFeedForLoggedInUser creates a calendar feed for the logged in user
+//
+//	@Summary      creates a calendar feed for the logged in user
+//	@Description  Creates a secret link that calendar apps can subscribe to, which lists the events the logged in user is invited to and has said they (or one of their invitees) are attending. The link is only shown once; creating another feed stops the previous link from working.
+//	@Tags         user
+//	@Produce      json
+//	@Success      201  {object}  types.V1_API_RESPONSE_CALENDAR_FEED
+//	@Failure      500  {object}  types.V1_API_RESPONSE_CALENDAR_FEED
+//	@Router       /user/calendar-feed [post]
+func (h *Handler) CreateCalendarFeedForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_CALENDAR_FEED{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	token, hash, err := helper.NewOneTimeToken()
+	if err == nil {
+		err = h.CalendarFeeds.CreateCalendarFeed(ctx, &models.CalendarFeed{UserId: uid, TokenHash: hash})
+	}
+	if err != nil {
+		log.Println("Error creating calendar feed: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created calendar feed"
+		response.Data.Url = calendarFeedLink(c, token)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteCalendarFeedForLoggedInUser deletes the logged in user's calendar feed
+//
+//	@Summary      deletes the logged in user's calendar feed
+//	@Description  Stops the logged in user's calendar feed link from working
+//	@Tags         user
+//	@Produce      json
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /user/calendar-feed [delete]
+func (h *Handler) DeleteCalendarFeedForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	result, err := h.CalendarFeeds.DeleteCalendarFeedForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error deleting calendar feed: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted calendar feed"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
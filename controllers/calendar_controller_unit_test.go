@@ -0,0 +1,140 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalendarController_Unit(t *testing.T) {
+	t.Setenv("CALENDAR_TIMEZONE", "UTC")
+	t.Setenv("API_URL", "https://api.wedding.test")
+	assert := assert.New(t)
+	ctx := context.Background()
+	guest := fixtures.Guest()
+	admin := fixtures.Admin()
+	// Creates a calendar feed for the guest, returning the path of its link
+	createFeed := func(router http.Handler) string {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/user/calendar-feed", guest, nil)
+		assert.Equal(http.StatusCreated, w.Code)
+		var feedResponse types.V1_API_RESPONSE_CALENDAR_FEED
+		json.Unmarshal([]byte(w.Body.Bytes()), &feedResponse)
+		assert.True(strings.HasPrefix(feedResponse.Data.Url, "https://api.wedding.test/api/v1/calendar/"))
+		assert.True(strings.HasSuffix(feedResponse.Data.Url, ".ics"))
+		return strings.TrimPrefix(feedResponse.Data.Url, "https://api.wedding.test")
+	}
+	startsAt := time.Date(2027, time.June, 12, 21, 0, 0, 0, time.UTC)
+	newStore := func() (*models.Event, *models.Event, http.Handler) {
+		store := fixtures.NewStore()
+		ceremony := models.Event{Name: "Ceremony", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour)}
+		store.CreateEvent(ctx, &ceremony)
+		rehearsal := models.Event{Name: "Rehearsal dinner", StartsAt: startsAt.Add(-24 * time.Hour), EndsAt: startsAt.Add(-20 * time.Hour), InviteScope: models.EventScopeSelected}
+		store.CreateEvent(ctx, &rehearsal)
+		return &ceremony, &rehearsal, paveRoutes(NewHandler(store))
+	}
+	attending := true
+	t.Run("GET /api/v1/calendar.ics - downloads the events the user is invited to", func(t *testing.T) {
+		ceremony, _, router := newStore()
+		w := fixtures.Serve(t, router, "GET", "/api/v1/calendar.ics", guest, nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Equal("text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(`attachment; filename="calendar.ics"`, w.Header().Get("Content-Disposition"))
+		assert.Contains(w.Body.String(), "UID:"+helper.CalendarEventUID(*ceremony)+"\r\n")
+		assert.Contains(w.Body.String(), "DTSTART:20270612T210000Z\r\n")
+		assert.NotContains(w.Body.String(), "Rehearsal dinner")
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/calendar.ics", models.User{}, nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/calendar/:feed - only lists the events the user is attending", func(t *testing.T) {
+		ceremony, _, router := newStore()
+		path := createFeed(router)
+
+		w := fixtures.Serve(t, router, "GET", path, models.User{}, nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.NotContains(w.Body.String(), "BEGIN:VEVENT")
+
+		fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+ceremony.ID.String()+"/rsvp", guest, types.EventRsvpInput{Attending: &attending})
+		w = fixtures.Serve(t, router, "GET", path, models.User{}, nil)
+		assert.Contains(w.Body.String(), "UID:"+helper.CalendarEventUID(*ceremony)+"\r\n")
+		assert.Contains(w.Body.String(), "SEQUENCE:0\r\n")
+
+		// Updates keep the same UID, so calendar apps replace the event instead of adding another
+		update := types.EventInput{Name: "Ceremony", StartsAt: startsAt.Add(time.Hour), EndsAt: startsAt.Add(2 * time.Hour), Location: "The Chapel"}
+		fixtures.Serve(t, router, "PUT", "/api/v1/event/"+ceremony.ID.String(), admin, update)
+		w = fixtures.Serve(t, router, "GET", path, models.User{}, nil)
+		assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+		assert.Contains(w.Body.String(), "UID:"+helper.CalendarEventUID(*ceremony)+"\r\n")
+		assert.Contains(w.Body.String(), "SEQUENCE:1\r\n")
+		assert.Contains(w.Body.String(), "LOCATION:The Chapel\r\n")
+
+		declining := false
+		fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+ceremony.ID.String()+"/rsvp", guest, types.EventRsvpInput{Attending: &declining})
+		w = fixtures.Serve(t, router, "GET", path, models.User{}, nil)
+		assert.NotContains(w.Body.String(), "BEGIN:VEVENT")
+	})
+	t.Run("GET /api/v1/calendar/:feed - replaced and deleted feeds stop working", func(t *testing.T) {
+		_, _, router := newStore()
+		first := createFeed(router)
+		second := createFeed(router)
+
+		w := fixtures.Serve(t, router, "GET", first, models.User{}, nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+		var errResponse types.V1_API_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+		assert.Equal("Calendar not found", errResponse.Message)
+		w = fixtures.Serve(t, router, "GET", second, models.User{}, nil)
+		assert.Equal(http.StatusOK, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/user/calendar-feed", guest, nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(1, deleteResponse.Data.DeletedRecords)
+		w = fixtures.Serve(t, router, "GET", second, models.User{}, nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("GET /api/v1/calendar/:feed - feeds stop working when their user is deleted", func(t *testing.T) {
+		_, _, router := newStore()
+		path := createFeed(router)
+		w := fixtures.Serve(t, router, "DELETE", "/api/v1/user/"+guest.ID.String(), admin, nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", path, models.User{}, nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("Calendar routes - internal server errors", func(t *testing.T) {
+		var router http.Handler = paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/calendar.ics", guest, nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/calendar/token.ics", models.User{}, nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/user/calendar-feed", guest, nil),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/user/calendar-feed", guest, nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+
+		t.Setenv("CALENDAR_TIMEZONE", "Not/AZone")
+		_, _, router = newStore()
+		w := fixtures.Serve(t, router, "GET", "/api/v1/calendar.ics", guest, nil)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+		assert.Empty(w.Header().Get("Content-Disposition"))
+	})
+}
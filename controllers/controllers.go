@@ -1,24 +1,94 @@
 package controllers
 
 import (
+	"log"
 	"os"
 	"time"
 
 	docs "github.com/ax-vasquez/wedding-site-api/docs"
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
 	"github.com/ax-vasquez/wedding-site-api/middleware"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// Handler holds the dependencies used by the route handlers
+//
+// Handlers never touch the database directly; they go through the repositories so the persistence layer can be
+// swapped (e.g., for the in-memory store in unit tests).
+type Handler struct {
+	Users          repository.UserRepository
+	Invitees       repository.InviteeRepository
+	Menu           repository.MenuRepository
+	LoginThrottles repository.LoginThrottleRepository
+	VerifiedRoutes repository.VerifiedRouteRepository
+	MagicLinks     repository.MagicLinkRepository
+	Mfa            repository.MfaRepository
+	Sessions       repository.SessionRepository
+	Events         repository.EventRepository
+	CalendarFeeds  repository.CalendarFeedRepository
+	Venues         repository.VenueRepository
+	Stays          repository.StayRepository
+	Shuttles       repository.ShuttleRepository
+	Registry       repository.RegistryRepository
+	Gifts          repository.GiftRepository
+	Households     repository.HouseholdRepository
+	Songs          repository.SongRepository
+	Guestbook      repository.GuestbookRepository
+	RateLimits     middleware.RateLimitStore
+	// The requirements for new passwords
+	PasswordPolicy helper.PasswordPolicy
+	// How new password hashes are made (older hashes are upgraded when users log in)
+	PasswordHashing helper.PasswordHashing
+	// Sends emails to users (e.g., to confirm a new email address)
+	Mail mail.Sender
+	// Whether auth tokens are sent as HttpOnly cookies instead of in response bodies
+	AuthCookies middleware.AuthCookieSettings
+	// The proxies (e.g., the load balancer) whose X-Forwarded-For header is used for the client IP
+	TrustedProxies []string
+}
+
+// NewHandler creates a Handler that uses the given store for all of its repositories
+func NewHandler(store repository.Store) *Handler {
+	return &Handler{
+		Users:           store,
+		Invitees:        store,
+		Menu:            store,
+		LoginThrottles:  store,
+		VerifiedRoutes:  store,
+		MagicLinks:      store,
+		Mfa:             store,
+		Sessions:        store,
+		Events:          store,
+		CalendarFeeds:   store,
+		Venues:          store,
+		Stays:           store,
+		Shuttles:        store,
+		Registry:        store,
+		Gifts:           store,
+		Households:      store,
+		Songs:           store,
+		Guestbook:       store,
+		RateLimits:      middleware.NewMemoryRateLimitStore(),
+		PasswordPolicy:  helper.DefaultPasswordPolicy,
+		PasswordHashing: helper.DefaultPasswordHashing,
+		Mail:            mail.NewSenderFromEnv(),
+		AuthCookies:     middleware.AuthCookieSettingsFromEnv(),
+		TrustedProxies:  middleware.TrustedProxiesFromEnv(),
+	}
+}
+
 //@securityDefinitions.Bearer.type JWT
 //@in header
 //@name Authorization
 
 // @BasePath /api/v1
 
-func paveRoutes() *gin.Engine {
+func paveRoutes(h *Handler) *gin.Engine {
 	r := gin.Default()
 
 	corsOrigin := os.Getenv("CORS_ORIGIN")
@@ -28,87 +98,259 @@ func paveRoutes() *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{corsOrigin},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.CSRFTokenHeader},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
-	// Disable trusting all proxies for now since there aren't any concerns around using a load balancer (app is small scale).
-	r.SetTrustedProxies(nil)
+	// The client IP is used for rate limits and lockouts, so behind the load balancer it has to come from X-Forwarded-For;
+	// it's only read from requests the trusted proxies sent (so clients can't pick their own IP)
+	if err := r.SetTrustedProxies(h.TrustedProxies); err != nil {
+		log.Println("Error setting trusted proxies; no proxies will be trusted: ", err.Error())
+		r.SetTrustedProxies(nil)
+	}
 	docs.SwaggerInfo.BasePath = "/api/v1"
+	// Lets other services verify our tokens without sharing a secret
+	r.GET("/.well-known/jwks.json", h.GetJWKS)
 	v1 := r.Group("/api/v1")
 
+	// Signing up and logging in are expensive (they hash passwords), so they get a much lower limit than the rest of the API
+	authRate := middleware.Rate{Requests: 10, Per: time.Minute}
+	// Every authenticated route group uses these; the IP limit runs first so floods don't reach the token checks
+	authenticated := []gin.HandlerFunc{
+		middleware.RateLimit(h.RateLimits, "api", middleware.ByClientIP, middleware.Rate{Requests: 300, Per: time.Minute}),
+		middleware.AuthenticateV1(h.Users, h.Sessions),
+		middleware.RateLimit(h.RateLimits, "api", middleware.ByUser, middleware.Rate{Requests: 120, Per: time.Minute}),
+		middleware.RequireCSRFToken(),
+		middleware.RequireVerifiedEmail(h.Users, h.VerifiedRoutes),
+		middleware.RequireMfaEnrollment(h.Mfa),
+	}
+
 	// Routes without auth middleware (these are used to set/update the user's token, used by the auth middleware)
 	{
-		v1.POST("/signup", Signup)
-		v1.POST("/login", Login)
+		v1.POST("/signup", middleware.RateLimit(h.RateLimits, "signup", middleware.ByClientIP, authRate), h.Signup)
+		v1.POST("/login", middleware.RateLimit(h.RateLimits, "login", middleware.ByClientIP, authRate), h.Login)
+		// Works without a valid token so expired cookies can still be cleared
+		v1.POST("/auth/logout", h.Logout)
+		v1.POST("/auth/refresh", middleware.RateLimit(h.RateLimits, "refresh", middleware.ByClientIP, authRate), h.RefreshTokens)
+		// Opened from the link emailed to the new address, which may be on a device the user isn't logged in on
+		v1.POST("/user/email/confirm", middleware.RateLimit(h.RateLimits, "confirm-email", middleware.ByClientIP, authRate), h.ConfirmEmailChange)
+		v1.POST("/auth/verify-email", middleware.RateLimit(h.RateLimits, "verify-email", middleware.ByClientIP, authRate), h.VerifyEmail)
+		v1.POST("/auth/magic-link", middleware.RateLimit(h.RateLimits, "magic-link", middleware.ByClientIP, authRate), h.RequestMagicLink)
+		v1.POST("/auth/magic-link/consume", middleware.RateLimit(h.RateLimits, "magic-link-consume", middleware.ByClientIP, authRate), h.ConsumeMagicLink)
+		v1.POST("/auth/mfa/verify", middleware.RateLimit(h.RateLimits, "mfa-verify", middleware.ByClientIP, authRate), h.VerifyMfa)
+		// Calendar apps can't log in, so the token in the link is the credential
+		v1.GET("/calendar/:feed", middleware.RateLimit(h.RateLimits, "calendar-feed", middleware.ByClientIP, authRate), h.GetCalendarFeed)
 	}
 
-	// Routes for obtaining full or partial data sets for the base data types (admin-only)
+	authRoutesV1 := v1.Group("/auth")
+	{
+		authRoutesV1.Use(authenticated...)
+		authRoutesV1.POST("/verify-email/resend", middleware.RateLimit(h.RateLimits, "resend-verification", middleware.ByUser, authRate), h.ResendEmailVerification)
+		authRoutesV1.GET("/mfa", h.GetMfaStatus)
+		authRoutesV1.POST("/mfa/enroll", h.StartMfaEnrollment)
+		// These check a code, so they get the same low limit as logging in (per user, to stop guessing)
+		authRoutesV1.POST("/mfa/enroll/confirm", middleware.RateLimit(h.RateLimits, "mfa", middleware.ByUser, authRate), h.ConfirmMfaEnrollment)
+		authRoutesV1.POST("/mfa/recovery-codes", middleware.RateLimit(h.RateLimits, "mfa", middleware.ByUser, authRate), h.RegenerateRecoveryCodes)
+		authRoutesV1.POST("/mfa/disable", middleware.RateLimit(h.RateLimits, "mfa", middleware.ByUser, authRate), h.DisableMfa)
+	}
+
+	// Routes for obtaining full or partial data sets for the base data types
 	resourceRoutesV1 := v1.Group("")
 	{
-		resourceRoutesV1.Use(middleware.AuthenticateV1())
-		resourceRoutesV1.GET("/entrees", GetEntrees)
-		resourceRoutesV1.GET("/users", GetUsers)
-		resourceRoutesV1.GET("/horsdoeuvres", GetHorsDoeuvres)
+		resourceRoutesV1.Use(authenticated...)
+		resourceRoutesV1.GET("/entrees", middleware.RequirePermission(helper.PermMenuRead), h.GetEntrees)
+		resourceRoutesV1.GET("/users", middleware.RequireOwnerOrPermission(helper.PermUsersReadAll, middleware.FromQuery("ids")), h.GetUsers)
+		resourceRoutesV1.GET("/horsdoeuvres", middleware.RequirePermission(helper.PermMenuRead), h.GetHorsDoeuvres)
+		resourceRoutesV1.GET("/events", middleware.RequirePermission(helper.PermEventsReadAll), h.GetEvents)
+		resourceRoutesV1.GET("/events/headcounts", middleware.RequirePermission(helper.PermReportsRead), h.GetEventHeadcounts)
+		resourceRoutesV1.GET("/calendar.ics", middleware.RequirePermission(helper.PermEventsRespond), h.GetCalendar)
+	}
+
+	eventRoutesV1 := v1.Group("/event")
+	{
+		eventRoutesV1.Use(authenticated...)
+		eventRoutesV1.POST("", middleware.RequirePermission(helper.PermEventsWrite), h.CreateEvent)
+		eventRoutesV1.PUT("/:id", middleware.RequirePermission(helper.PermEventsWrite), h.UpdateEvent)
+		eventRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermEventsWrite), h.DeleteEvent)
+		eventRoutesV1.GET("/:id/guests", middleware.RequirePermission(helper.PermEventsReadAll), h.GetEventGuests)
+		eventRoutesV1.PUT("/:id/guests", middleware.RequirePermission(helper.PermEventsWrite), h.UpdateEventGuests)
 	}
 
 	horsDoeuvresRoutesV1 := v1.Group("/horsdoeuvres")
 	{
-		horsDoeuvresRoutesV1.Use(middleware.AuthenticateV1())
-		horsDoeuvresRoutesV1.GET("/:id", GetHorsDoeuvres)
-		horsDoeuvresRoutesV1.POST("", middleware.IsAdmin(), CreateHorsDoeuvres)
-		horsDoeuvresRoutesV1.DELETE("/:id", middleware.IsAdmin(), DeleteHorsDoeuvres)
+		horsDoeuvresRoutesV1.Use(authenticated...)
+		horsDoeuvresRoutesV1.GET("/:id", middleware.RequirePermission(helper.PermMenuRead), h.GetHorsDoeuvres)
+		horsDoeuvresRoutesV1.POST("", middleware.RequirePermission(helper.PermMenuWrite), h.CreateHorsDoeuvres)
+		horsDoeuvresRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermMenuWrite), h.DeleteHorsDoeuvres)
 	}
 
 	entreeRoutesV1 := v1.Group("/entree")
 	{
-		entreeRoutesV1.Use(middleware.AuthenticateV1())
-		entreeRoutesV1.GET("/:id", GetEntrees)
-		entreeRoutesV1.POST("", middleware.IsAdmin(), CreateEntree)
-		entreeRoutesV1.DELETE("/:id", middleware.IsAdmin(), DeleteEntree)
+		entreeRoutesV1.Use(authenticated...)
+		entreeRoutesV1.GET("/:id", middleware.RequirePermission(helper.PermMenuRead), h.GetEntrees)
+		entreeRoutesV1.POST("", middleware.RequirePermission(helper.PermMenuWrite), h.CreateEntree)
+		entreeRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermMenuWrite), h.DeleteEntree)
 	}
 
 	userRoutesV1 := v1.Group("/user")
 	{
-		userRoutesV1.Use(middleware.AuthenticateV1())
-		userRoutesV1.GET("", middleware.IsAdminOrLoggedInUser(), GetLoggedInUser)
-		userRoutesV1.GET("/invitees", middleware.IsAdminOrLoggedInUser(), GetInviteesForLoggedInUser)
+		userRoutesV1.Use(authenticated...)
+		userRoutesV1.GET("", middleware.RequirePermission(helper.PermProfileManageOwn), h.GetLoggedInUser)
+		userRoutesV1.GET("/invitees", middleware.RequirePermission(helper.PermInviteesManageOwn), h.GetInviteesForLoggedInUser)
+		userRoutesV1.GET("/sessions", middleware.RequirePermission(helper.PermProfileManageOwn), h.GetSessionsForLoggedInUser)
+		userRoutesV1.DELETE("/sessions/:id", middleware.RequirePermission(helper.PermProfileManageOwn), h.DeleteSessionForLoggedInUser)
+		userRoutesV1.GET("/events", middleware.RequirePermission(helper.PermEventsRespond), h.GetEventsForLoggedInUser)
+		userRoutesV1.PUT("/events/:id/rsvp", middleware.RequirePermission(helper.PermEventsRespond), h.RsvpToEventForLoggedInUser)
+		userRoutesV1.POST("/calendar-feed", middleware.RequirePermission(helper.PermEventsRespond), h.CreateCalendarFeedForLoggedInUser)
+		userRoutesV1.DELETE("/calendar-feed", middleware.RequirePermission(helper.PermEventsRespond), h.DeleteCalendarFeedForLoggedInUser)
+		userRoutesV1.GET("/shuttles", middleware.RequirePermission(helper.PermShuttlesRide), h.GetShuttlesForLoggedInUser)
+		userRoutesV1.PUT("/shuttles/:id/signup", middleware.RequirePermission(helper.PermShuttlesRide), h.SignUpForShuttleForLoggedInUser)
+		userRoutesV1.GET("/registry", middleware.RequirePermission(helper.PermRegistryClaim), h.GetRegistryClaimsForLoggedInUser)
+		userRoutesV1.PUT("/registry/:id/claim", middleware.RequirePermission(helper.PermRegistryClaim), h.ClaimRegistryItemForLoggedInUser)
+		userRoutesV1.GET("/songs", middleware.RequirePermission(helper.PermSongsRequest), h.GetSongRequestsForLoggedInUser)
+		userRoutesV1.POST("/songs", middleware.RequirePermission(helper.PermSongsRequest), h.RequestSongForLoggedInUser)
+		userRoutesV1.PUT("/songs/:id/vote", middleware.RequirePermission(helper.PermSongsRequest), h.VoteForSongForLoggedInUser)
+		userRoutesV1.DELETE("/songs/:id/vote", middleware.RequirePermission(helper.PermSongsRequest), h.DeleteVoteForSongForLoggedInUser)
+		userRoutesV1.GET("/guestbook", middleware.RequirePermission(helper.PermGuestbookWrite), h.GetGuestbookEntriesForLoggedInUser)
+		userRoutesV1.POST("/guestbook", middleware.RequirePermission(helper.PermGuestbookWrite), h.CreateGuestbookEntryForLoggedInUser)
+		userRoutesV1.DELETE("/guestbook/:id", middleware.RequirePermission(helper.PermGuestbookWrite), h.DeleteGuestbookEntryForLoggedInUser)
 		// TODO: I've fixed the API that this was using before - it's better to have a specific "EntreeForUser" controller since GetEntrees gets one or all entrees, now
-		userRoutesV1.GET("/:id/entrees", middleware.IsAdminOrLoggedInUser(), GetEntrees)
+		userRoutesV1.GET("/:id/entrees", middleware.RequireOwnerOrPermission(helper.PermUsersReadAll, middleware.FromParam("id")), h.GetEntrees)
 		// TODO: Same note as for entrees - should use a different controller to get hors doeuvres for a user
-		userRoutesV1.GET("/:id/horsdoeuvres", middleware.IsAdminOrLoggedInUser(), GetHorsDoeuvres)
-		userRoutesV1.PATCH("", middleware.IsAdminOrLoggedInUser(), UpdateLoggedInUser)
-		userRoutesV1.PATCH("/update-other", middleware.IsAdmin(), AdminUpdateUser)
-		userRoutesV1.PATCH("/invitees/:id", middleware.IsAdminOrLoggedInUser(), UpdateInviteeForLoggedInUser)
-		userRoutesV1.POST("", middleware.IsAdmin(), CreateUser)
-		userRoutesV1.POST("/add-invitee", middleware.IsAdminOrLoggedInUser(), CreateUserInvitee)
-		userRoutesV1.DELETE("/:id", middleware.IsAdmin(), DeleteUser)
-		userRoutesV1.DELETE("/invitees/:id", middleware.IsAdminOrLoggedInUser(), DeleteInviteeForLoggedInUser)
+		userRoutesV1.GET("/:id/horsdoeuvres", middleware.RequireOwnerOrPermission(helper.PermUsersReadAll, middleware.FromParam("id")), h.GetHorsDoeuvres)
+		userRoutesV1.PATCH("", middleware.RequirePermission(helper.PermProfileManageOwn), h.UpdateLoggedInUser)
+		// These check the current password, so they get the same low limit as logging in (per user, to stop guessing)
+		userRoutesV1.POST("/password", middleware.RateLimit(h.RateLimits, "credentials", middleware.ByUser, authRate), middleware.RequirePermission(helper.PermProfileManageOwn), h.ChangePassword)
+		userRoutesV1.POST("/email", middleware.RateLimit(h.RateLimits, "credentials", middleware.ByUser, authRate), middleware.RequirePermission(helper.PermProfileManageOwn), h.RequestEmailChange)
+		userRoutesV1.PATCH("/update-other", middleware.RequireOwnerOrPermission(helper.PermUsersWrite, middleware.FromBodyField("id")), h.AdminUpdateUser)
+		userRoutesV1.PATCH("/invitees/:id", middleware.RequirePermission(helper.PermInviteesManageOwn), h.UpdateInviteeForLoggedInUser)
+		userRoutesV1.POST("", middleware.RequirePermission(helper.PermUsersWrite), h.CreateUser)
+		userRoutesV1.POST("/add-invitee", middleware.RequirePermission(helper.PermInviteesManageOwn), h.CreateUserInvitee)
+		userRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermUsersDelete), h.DeleteUser)
+		userRoutesV1.PUT("/:id/role", middleware.RequirePermission(helper.PermUsersManageRoles), h.ChangeUserRole)
+		userRoutesV1.GET("/:id/role-changes", middleware.RequirePermission(helper.PermUsersManageRoles), h.GetRoleChanges)
+		userRoutesV1.DELETE("/invitees/:id", middleware.RequirePermission(helper.PermInviteesManageOwn), h.DeleteInviteeForLoggedInUser)
 	}
 
 	inviteeRoutesV1 := v1.Group("/invitee")
 	{
-		inviteeRoutesV1.Use(middleware.AuthenticateV1())
-		inviteeRoutesV1.DELETE("/:id", middleware.IsAdmin(), DeleteInvitee)
+		inviteeRoutesV1.Use(authenticated...)
+		inviteeRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermInviteesManageAll), h.DeleteInvitee)
+	}
+
+	lockoutRoutesV1 := v1.Group("/lockouts")
+	{
+		lockoutRoutesV1.Use(authenticated...)
+		lockoutRoutesV1.GET("", middleware.RequirePermission(helper.PermLockoutsManage), h.GetLockouts)
+		lockoutRoutesV1.DELETE("/:email", middleware.RequirePermission(helper.PermLockoutsManage), h.DeleteLockout)
+	}
+
+	settingsRoutesV1 := v1.Group("/settings")
+	{
+		settingsRoutesV1.Use(authenticated...)
+		settingsRoutesV1.GET("/verified-routes", middleware.RequirePermission(helper.PermSettingsManage), h.GetVerifiedRoutes)
+		settingsRoutesV1.PUT("/verified-routes", middleware.RequirePermission(helper.PermSettingsManage), h.UpdateVerifiedRoutes)
+		settingsRoutesV1.GET("/magic-link-roles", middleware.RequirePermission(helper.PermSettingsManage), h.GetMagicLinkRoles)
+		settingsRoutesV1.PUT("/magic-link-roles", middleware.RequirePermission(helper.PermSettingsManage), h.UpdateMagicLinkRoles)
+		settingsRoutesV1.GET("/mfa-required-roles", middleware.RequirePermission(helper.PermSettingsManage), h.GetMfaRequiredRoles)
+		settingsRoutesV1.PUT("/mfa-required-roles", middleware.RequirePermission(helper.PermSettingsManage), h.UpdateMfaRequiredRoles)
+		settingsRoutesV1.GET("/guestbook-blocked-words", middleware.RequirePermission(helper.PermSettingsManage), h.GetGuestbookBlockedWords)
+		settingsRoutesV1.PUT("/guestbook-blocked-words", middleware.RequirePermission(helper.PermSettingsManage), h.UpdateGuestbookBlockedWords)
 	}
 
 	venueGroupV1 := v1.Group("/venue")
 	{
-		venueGroupV1.Use(middleware.AuthenticateV1())
-		venueGroupV1.GET("/reservation-link", GetHotelRoomReservationBlockLink)
+		venueGroupV1.Use(authenticated...)
+		venueGroupV1.GET("", middleware.RequirePermission(helper.PermVenueRead), h.GetVenueDetails)
+		venueGroupV1.GET("/reservation-link", middleware.RequirePermission(helper.PermVenueRead), h.GetHotelRoomReservationBlockLink)
+		venueGroupV1.POST("/venues", middleware.RequirePermission(helper.PermVenueWrite), h.CreateVenue)
+		venueGroupV1.PUT("/venues/:id", middleware.RequirePermission(helper.PermVenueWrite), h.UpdateVenue)
+		venueGroupV1.DELETE("/venues/:id", middleware.RequirePermission(helper.PermVenueWrite), h.DeleteVenue)
+		venueGroupV1.POST("/accommodations", middleware.RequirePermission(helper.PermVenueWrite), h.CreateAccommodation)
+		venueGroupV1.PUT("/accommodations/:id", middleware.RequirePermission(helper.PermVenueWrite), h.UpdateAccommodation)
+		venueGroupV1.DELETE("/accommodations/:id", middleware.RequirePermission(helper.PermVenueWrite), h.DeleteAccommodation)
+		venueGroupV1.GET("/stay", middleware.RequirePermission(helper.PermProfileManageOwn), h.GetStayForLoggedInUser)
+		venueGroupV1.PUT("/stay", middleware.RequirePermission(helper.PermProfileManageOwn), h.SaveStayForLoggedInUser)
+		venueGroupV1.DELETE("/stay", middleware.RequirePermission(helper.PermProfileManageOwn), h.DeleteStayForLoggedInUser)
+		venueGroupV1.GET("/stays", middleware.RequirePermission(helper.PermReportsRead), h.GetGuestStays)
+		venueGroupV1.GET("/room-blocks", middleware.RequirePermission(helper.PermReportsRead), h.GetRoomBlockReport)
+	}
+
+	shuttleRoutesV1 := v1.Group("/shuttle")
+	{
+		shuttleRoutesV1.Use(authenticated...)
+		shuttleRoutesV1.GET("", middleware.RequirePermission(helper.PermShuttlesRide), h.GetShuttleRuns)
+		shuttleRoutesV1.POST("", middleware.RequirePermission(helper.PermShuttlesWrite), h.CreateShuttleRun)
+		shuttleRoutesV1.PUT("/:id", middleware.RequirePermission(helper.PermShuttlesWrite), h.UpdateShuttleRun)
+		shuttleRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermShuttlesWrite), h.DeleteShuttleRun)
+		shuttleRoutesV1.GET("/:id/manifest", middleware.RequirePermission(helper.PermReportsRead), h.GetShuttleManifest)
+	}
+
+	registryRoutesV1 := v1.Group("/registry")
+	{
+		registryRoutesV1.Use(authenticated...)
+		registryRoutesV1.GET("", middleware.RequirePermission(helper.PermRegistryClaim), h.GetRegistryItems)
+		registryRoutesV1.POST("", middleware.RequirePermission(helper.PermRegistryWrite), h.CreateRegistryItem)
+		registryRoutesV1.GET("/claims", middleware.RequirePermission(helper.PermReportsRead), h.GetRegistryClaimReport)
+		registryRoutesV1.PUT("/:id", middleware.RequirePermission(helper.PermRegistryWrite), h.UpdateRegistryItem)
+		registryRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermRegistryWrite), h.DeleteRegistryItem)
+	}
+
+	giftRoutesV1 := v1.Group("/gift")
+	{
+		giftRoutesV1.Use(authenticated...)
+		giftRoutesV1.GET("", middleware.RequirePermission(helper.PermGiftsManage), h.GetGifts)
+		giftRoutesV1.POST("", middleware.RequirePermission(helper.PermGiftsManage), h.CreateGift)
+		giftRoutesV1.POST("/import-registry", middleware.RequirePermission(helper.PermGiftsManage), h.ImportRegistryGifts)
+		giftRoutesV1.GET("/thank-you-queue", middleware.RequirePermission(helper.PermGiftsManage), h.GetThankYouQueue)
+		giftRoutesV1.PUT("/:id", middleware.RequirePermission(helper.PermGiftsManage), h.UpdateGift)
+		giftRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermGiftsManage), h.DeleteGift)
+		giftRoutesV1.PUT("/:id/thank-you", middleware.RequirePermission(helper.PermGiftsManage), h.UpdateGiftThankYou)
+	}
+
+	householdRoutesV1 := v1.Group("/household")
+	{
+		householdRoutesV1.Use(authenticated...)
+		householdRoutesV1.GET("", middleware.RequirePermission(helper.PermHouseholdsManage), h.GetHouseholds)
+		householdRoutesV1.POST("", middleware.RequirePermission(helper.PermHouseholdsManage), h.CreateHousehold)
+		householdRoutesV1.GET("/labels.csv", middleware.RequirePermission(helper.PermReportsRead), h.GetMailingLabelsCsv)
+		householdRoutesV1.GET("/labels.pdf", middleware.RequirePermission(helper.PermReportsRead), h.GetMailingLabelsPdf)
+		householdRoutesV1.PUT("/:id", middleware.RequirePermission(helper.PermHouseholdsManage), h.UpdateHousehold)
+		householdRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermHouseholdsManage), h.DeleteHousehold)
+		householdRoutesV1.PUT("/:id/members", middleware.RequirePermission(helper.PermHouseholdsManage), h.UpdateHouseholdMembers)
+		householdRoutesV1.PUT("/:id/mailings", middleware.RequirePermission(helper.PermHouseholdsManage), h.UpdateHouseholdMailings)
+	}
+
+	songRoutesV1 := v1.Group("/song")
+	{
+		songRoutesV1.Use(authenticated...)
+		songRoutesV1.GET("", middleware.RequirePermission(helper.PermSongsModerate), h.GetSongRequests)
+		songRoutesV1.POST("/do-not-play", middleware.RequirePermission(helper.PermSongsModerate), h.AddSongToDoNotPlayList)
+		songRoutesV1.GET("/playlist.csv", middleware.RequirePermission(helper.PermReportsRead), h.GetSongPlaylistCsv)
+		songRoutesV1.PUT("/:id/moderation", middleware.RequirePermission(helper.PermSongsModerate), h.ModerateSongRequest)
+		songRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermSongsModerate), h.DeleteSongRequest)
+	}
+
+	guestbookRoutesV1 := v1.Group("/guestbook")
+	{
+		guestbookRoutesV1.Use(authenticated...)
+		guestbookRoutesV1.GET("", middleware.RequirePermission(helper.PermGuestbookModerate), h.GetGuestbookEntries)
+		guestbookRoutesV1.GET("/public", middleware.RequirePermission(helper.PermGuestbookWrite), h.GetPublicGuestbookEntries)
+		guestbookRoutesV1.PUT("/:id/moderation", middleware.RequirePermission(helper.PermGuestbookModerate), h.ModerateGuestbookEntry)
+		guestbookRoutesV1.DELETE("/:id", middleware.RequirePermission(helper.PermGuestbookModerate), h.DeleteGuestbookEntry)
 	}
 
 	return r
 }
 
-func SetupRoutes() error {
+func SetupRoutes(h *Handler) error {
 	port := os.Getenv("PORT")
 	if port == "" {
 		// Set to 5000 since that's what EB listens to by default
 		port = "5000"
 	}
-	r := paveRoutes()
+	r := paveRoutes(h)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	return r.Run(":" + port)
 }
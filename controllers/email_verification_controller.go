@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// How long the link sent to verify an email address works for
+const emailVerificationTTL = 48 * time.Hour
+
+// VerifyEmail verifies a user's email address
+//
+//	@Summary      verifies an email address
+//	@Description  Marks the user's email address as verified using the token from the link emailed to them when they signed up (or asked for a new link)
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.VerifyEmailInput true "The token from the verification link"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /auth/verify-email [post]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	var input types.VerifyEmailInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	now := time.Now()
+	verification, err := h.Users.FindEmailVerification(ctx, helper.HashOneTimeToken(input.Token))
+	if err == nil && verification != nil && now.Before(verification.ExpiresAt) {
+		err = h.Users.VerifyEmail(ctx, verification, now)
+	}
+	if err != nil {
+		log.Println("Error verifying email: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else if verification == nil || !now.Before(verification.ExpiresAt) {
+		status = http.StatusBadRequest
+		response.Message = "The verification link is invalid or has expired."
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Verified email address"
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ResendEmailVerification sends a new verification link to the logged in user
+//
+//	@Summary      resends the email verification link
+//	@Description  Sends a new verification link to the logged in user's email address (links sent before stop working)
+//	@Tags         auth
+//	@Produce      json
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      409  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /auth/verify-email/resend [post]
+func (h *Handler) ResendEmailVerification(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	users, err := h.Users.FindUsers(ctx, []uuid.UUID{uid})
+	if err == nil && len(users) > 0 && users[0].EmailVerifiedAt == nil {
+		err = h.sendEmailVerification(ctx, &users[0])
+	}
+	if err != nil {
+		log.Println("Error resending email verification: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else if len(users) == 0 || users[0].EmailVerifiedAt != nil {
+		status = http.StatusConflict
+		response.Message = "Email address is already verified."
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Sent a new verification link"
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Emails a link the user can use to verify their email address (replacing any link sent before)
+func (h *Handler) sendEmailVerification(ctx context.Context, u *models.User) error {
+	token, tokenHash, err := helper.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+	err = h.Users.CreateEmailVerification(ctx, &models.EmailVerification{
+		UserId:    u.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return h.Mail.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nVerify your email address by opening the link below within %d hours:\n\n%s\n\nIf you didn't sign up, you can ignore this email.\n",
+			u.FirstName,
+			int(emailVerificationTTL.Hours()),
+			appLink("/verify-email", token),
+		),
+	})
+}
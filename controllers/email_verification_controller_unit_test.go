@@ -0,0 +1,147 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmailVerificationController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	t.Setenv("APP_URL", "https://wedding.test")
+	t.Setenv("INVITE_CODE", "SomeCode")
+	tokenInLink := regexp.MustCompile(`https://wedding\.test/verify-email\?token=([A-Za-z0-9_-]+)`)
+	verifyJson := func(token string) *strings.Reader {
+		body, _ := json.Marshal(types.VerifyEmailInput{Token: token})
+		return strings.NewReader(string(body))
+	}
+	t.Run("POST /api/v1/signup - emails a link that verifies the address", func(t *testing.T) {
+		store := fixtures.NewStore()
+		h := NewHandler(store)
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+		signupJson, _ := json.Marshal(types.UserSignupInput{
+			UserLoginInput: types.UserLoginInput{
+				Email:    "new@fakedomain.com",
+				Password: "Wed!ding#2O26",
+			},
+			FirstName:  "Firstname",
+			LastName:   "Lastname",
+			InviteCode: "SomeCode",
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/signup", strings.NewReader(string(signupJson)))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusCreated, w.Code)
+		u := models.User{Email: "new@fakedomain.com"}
+		store.FindUser(context.Background(), &u)
+		assert.Nil(u.EmailVerifiedAt)
+
+		sent := sender.Sent()
+		assert.Equal(1, len(sent))
+		assert.Equal("new@fakedomain.com", sent[0].To)
+		match := tokenInLink.FindStringSubmatch(sent[0].Body)
+		assert.Equal(2, len(match))
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/auth/verify-email", verifyJson(match[1]))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusAccepted, w.Code)
+		store.FindUser(context.Background(), &u)
+		assert.NotNil(u.EmailVerifiedAt)
+
+		// Links only work once
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/auth/verify-email", verifyJson(match[1]))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/verify-email - expired link is rejected", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateEmailVerification(context.Background(), &models.EmailVerification{
+			UserId:    fixtures.Guest().ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/auth/verify-email", verifyJson(token))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Nil(users[0].EmailVerifiedAt)
+	})
+	t.Run("POST /api/v1/auth/verify-email - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/auth/verify-email", verifyJson("some-token"))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("POST /api/v1/auth/verify-email/resend - sends a new link until the address is verified", func(t *testing.T) {
+		store := fixtures.NewStore()
+		h := NewHandler(store)
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/auth/verify-email/resend", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+		sent := sender.Sent()
+		assert.Equal(1, len(sent))
+		assert.Equal(fixtures.Guest().Email, sent[0].To)
+
+		verification, _ := store.FindEmailVerification(context.Background(), helper.HashOneTimeToken(tokenInLink.FindStringSubmatch(sent[0].Body)[1]))
+		store.VerifyEmail(context.Background(), verification, time.Now())
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/auth/verify-email/resend", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusConflict, w.Code)
+		assert.Equal(1, len(sender.Sent()))
+	})
+	t.Run("POST /api/v1/auth/verify-email/resend - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/auth/verify-email/resend", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
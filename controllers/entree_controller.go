@@ -24,7 +24,7 @@ import (
 //	@Router       	/entrees [get]
 //	@Router       	/user/{user_id}/entrees [get]
 //	@Security	JWT
-func GetEntrees(c *gin.Context) {
+func (h *Handler) GetEntrees(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	idStr := c.Param("id")
@@ -42,8 +42,7 @@ func GetEntrees(c *gin.Context) {
 			c.JSON(status, response)
 			return
 		}
-		entree, err := models.FindEntreeById(ctx, id)
-		entrees = append(entrees, *entree)
+		entree, err := h.Menu.FindEntreeById(ctx, id)
 		// If an error occurs in the DB during lookup, return error response
 		if err != nil {
 			status = http.StatusInternalServerError
@@ -53,6 +52,9 @@ func GetEntrees(c *gin.Context) {
 			c.JSON(status, response)
 			return
 		}
+		if entree != nil {
+			entrees = append(entrees, *entree)
+		}
 		status = http.StatusOK
 		response.Status = status
 		response.Data.Entrees = entrees
@@ -60,7 +62,7 @@ func GetEntrees(c *gin.Context) {
 		return
 	}
 	// If no ID param was given, return all entrees (which will be empty should an error occur)
-	entrees, err := models.FindEntrees(ctx)
+	entrees, err := h.Menu.FindEntrees(ctx)
 	if err != nil {
 		status = http.StatusInternalServerError
 		log.Println(err.Error())
@@ -85,7 +87,7 @@ func GetEntrees(c *gin.Context) {
 //	@Failure      400  {object}  types.V1_API_RESPONSE_ENTREE
 //	@Failure      500  {object}  types.V1_API_RESPONSE_ENTREE
 //	@Router       /entree [post]
-func CreateEntree(c *gin.Context) {
+func (h *Handler) CreateEntree(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_ENTREE{}
@@ -96,7 +98,7 @@ func CreateEntree(c *gin.Context) {
 		response.Message = err.Error()
 	} else {
 		entrees := []models.Entree{input}
-		err := models.CreateEntrees(ctx, &entrees)
+		err := h.Menu.CreateEntrees(ctx, &entrees)
 		if err != nil {
 			status = http.StatusInternalServerError
 			log.Println(err.Error())
@@ -121,13 +123,13 @@ func CreateEntree(c *gin.Context) {
 //	@Success      202  {object}  types.V1_API_RESPONSE_ENTREE
 //	@Failure      500  {object}  types.V1_API_RESPONSE_ENTREE
 //	@Router       /entree [delete]
-func DeleteEntree(c *gin.Context) {
+func (h *Handler) DeleteEntree(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_DELETE_RESPONSE{}
 	var status int
 	id, _ := uuid.Parse(c.Param("id"))
-	result, err := models.DeleteEntree(ctx, id)
+	result, err := h.Menu.DeleteEntree(ctx, id)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -135,7 +137,7 @@ func DeleteEntree(c *gin.Context) {
 		status = http.StatusAccepted
 		response.Message = "Deleted entree"
 		response.Data = types.DeleteRecordResponse{
-			DeletedRecords: int(*result),
+			DeletedRecords: int(result),
 		}
 	}
 	response.Status = status
@@ -12,13 +12,14 @@ import (
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_EntreeController_NoAuth_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	t.Run("GET /api/v1/entrees - no auth - reject request", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
@@ -61,7 +62,7 @@ func Test_EntreeController_NoAuth_Integration(t *testing.T) {
 
 func Test_EntreeController_Admin_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "admin@admin.admin")
 	t.Run("GET /api/v1/entrees - admin - can get all entrees", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -159,7 +160,7 @@ func Test_EntreeController_Admin_Integration(t *testing.T) {
 
 func Test_EntreeController_Guest_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "user_1@fakedomain.com")
 	t.Run("GET /api/v1/entrees - guest - can get all entrees", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -4,38 +4,35 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ax-vasquez/wedding-site-api/helper"
 	"github.com/ax-vasquez/wedding-site-api/models"
-	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
 	"github.com/ax-vasquez/wedding-site-api/types"
-	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_EntreeController_Unit(t *testing.T) {
-	os.Setenv("USE_MOCK_DB", "true")
 	assert := assert.New(t)
-	router := paveRoutes()
 	errMsg := "arbitrary database error"
 	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
 	t.Run("GET /api/v1/entrees - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "entrees" WHERE "entrees"."deleted_at" IS NULL`)).WillReturnError(fmt.Errorf(errMsg))
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "ADMIN")
-		req, err := http.NewRequestWithContext(ctx, "GET", "/api/v1/entrees", nil)
+		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -45,15 +42,10 @@ func Test_EntreeController_Unit(t *testing.T) {
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
 	t.Run("GET /api/v1/user/:id/entrees - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "entrees"."created_at","entrees"."updated_at","entrees"."deleted_at","entrees"."id","entrees"."option_name" FROM "entrees" JOIN users ON entrees.id = users.entree_selection_id AND users.id = $1 WHERE "entrees"."deleted_at" IS NULL`)).WithArgs(models.NilUuid).WillReturnError(fmt.Errorf(errMsg))
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "GUEST")
-		route := fmt.Sprintf("/api/v1/user/%s/entrees", models.NilUuid)
-		req, err := http.NewRequestWithContext(ctx, "GET", route, nil)
+		route := fmt.Sprintf("/api/v1/user/%s/entrees", fixtures.Guest().ID)
+		req, err := http.NewRequest("GET", route, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -63,27 +55,14 @@ func Test_EntreeController_Unit(t *testing.T) {
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
 	t.Run("POST /api/v1/entrees - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
 		testEntree := models.Entree{
 			OptionName: "Banana Steak",
 		}
-		mock.ExpectBegin()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`INSERT INTO "entrees" ("created_at","updated_at","deleted_at","option_name") VALUES ($1,$2,$3,$4) RETURNING *`)).WithArgs(
-			test.AnyTime{},
-			test.AnyTime{},
-			nil,
-			testEntree.OptionName,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
 
 		entreeJson, _ := json.Marshal(testEntree)
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "ADMIN")
-		req, err := http.NewRequestWithContext(ctx, "POST", "/api/v1/entree", strings.NewReader(string(entreeJson)))
+		req, err := http.NewRequest("POST", "/api/v1/entree", strings.NewReader(string(entreeJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -94,22 +73,11 @@ func Test_EntreeController_Unit(t *testing.T) {
 	})
 	t.Run("DELETE /api/v1/entrees - internal server error", func(t *testing.T) {
 		someId := uuid.New()
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectExec(
-			regexp.QuoteMeta(`UPDATE "entrees" SET "deleted_at"=$1 WHERE "entrees"."id" = $2 AND "entrees"."deleted_at" IS NULL`)).WithArgs(
-			test.AnyTime{},
-			someId,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
 
 		w := httptest.NewRecorder()
 		routePath := fmt.Sprintf("/api/v1/entree/%s", someId)
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", someId.String())
-		ctx.Set("user_role", "ADMIN")
-		req, err := http.NewRequestWithContext(ctx, "DELETE", routePath, nil)
+		req, err := http.NewRequest("DELETE", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -118,4 +86,106 @@ func Test_EntreeController_Unit(t *testing.T) {
 		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
+	t.Run("GET /api/v1/entree/:id - can get a single entree", func(t *testing.T) {
+		store := fixtures.NewStore()
+		entrees := []models.Entree{{OptionName: "Banana Steak"}, {OptionName: "Caprese pasta"}}
+		store.CreateEntrees(context.Background(), &entrees)
+		router := paveRoutes(NewHandler(store))
+
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/entree/%s", entrees[1].ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_ENTREE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(1, len(jsonResponse.Data.Entrees))
+		assert.Equal("Caprese pasta", jsonResponse.Data.Entrees[0].OptionName)
+	})
+	t.Run("GET /api/v1/entree/:id - unknown ID returns no entrees", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/entree/%s", uuid.New())
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_ENTREE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Empty(jsonResponse.Data.Entrees)
+	})
+	t.Run("POST /api/v1/entree - admin - can create an entree", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+
+		entreeJson, _ := json.Marshal(models.Entree{OptionName: "Banana Steak"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/entree", strings.NewReader(string(entreeJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusCreated, w.Code)
+
+		entrees, _ := store.FindEntrees(context.Background())
+		assert.Equal(1, len(entrees))
+		assert.Equal("Banana Steak", entrees[0].OptionName)
+	})
+	t.Run("GET /api/v1/entrees - no token - unauthorized", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/entrees - token signed with another key - unauthorized", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		admin := fixtures.Admin()
+		forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &helper.CustomClaims{
+			ID:               admin.ID,
+			Role:             admin.Role,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		}).SignedString([]byte("not-the-secret-key"))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
+		req.Header.Set("auth-token", forged)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.NotEqual(http.StatusOK, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_ENTREE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(http.StatusUnauthorized, jsonResponse.Status)
+		assert.Empty(jsonResponse.Data.Entrees)
+	})
+	t.Run("GET /api/v1/entrees - Authorization bearer token - ok", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
+		req.Header.Set("Authorization", "Bearer "+fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+	})
+	t.Run("GET /api/v1/entrees - refresh token - unauthorized", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		guest := fixtures.Guest()
+		_, refreshToken, _ := helper.GenerateAllTokens(guest.Email, guest.FirstName, guest.LastName, guest.Role, guest.ID, guest.TokenVersion, uuid.New())
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/entrees", nil)
+		req.Header.Set("Authorization", "Bearer "+refreshToken)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+
+		var jsonResponse types.V1_API_RESPONSE_ENTREE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(http.StatusUnauthorized, jsonResponse.Status)
+		assert.Equal("the token is not an access token", jsonResponse.Message)
+	})
 }
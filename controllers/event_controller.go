@@ -0,0 +1,458 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Builds the event described by the input; the invite scope defaults to "ALL"
+func eventFromInput(input types.EventInput) models.Event {
+	inviteScope := input.InviteScope
+	if inviteScope == "" {
+		inviteScope = models.EventScopeAll
+	}
+	return models.Event{
+		Name:        input.Name,
+		StartsAt:    input.StartsAt,
+		EndsAt:      input.EndsAt,
+		Location:    input.Location,
+		DressCode:   input.DressCode,
+		Description: input.Description,
+		InviteScope: inviteScope,
+	}
+}
+
+// GetEvents gets every event
+//
+//	@Summary      gets every event
+//	@Description  Gets every event, earliest first, whether or not the user is invited to it
+//	@Tags         events
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Router       /events [get]
+func (h *Handler) GetEvents(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENTS{}
+	var status int
+
+	events, err := h.Events.FindEvents(ctx)
+	if err != nil {
+		log.Println("Error finding events: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Events = events
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateEvent creates an event
+//
+//	@Summary      admin-only operation to create an event
+//	@Description  Creates an event and returns the new record's data to the caller. Events are open to everyone unless `invite_scope` is "SELECTED", in which case only the users on the event's guest list (and their invitees) are invited.
+//	@Tags         events
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.EventInput true "The event (`ends_at` must be after `starts_at`)"
+//	@Success      201  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Router       /event [post]
+func (h *Handler) CreateEvent(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENTS{}
+	var status int
+
+	var input types.EventInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	event := eventFromInput(input)
+	if err := h.Events.CreateEvent(ctx, &event); err != nil {
+		log.Println("Error creating event: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created event"
+		response.Data.Events = []models.Event{event}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateEvent replaces an event's details
+//
+//	@Summary      admin-only operation to update an event
+//	@Description  Replaces every detail of an event. When the event is limited to its guest list, responses from people who aren't on it are deleted.
+//	@Tags         events
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Event ID" Format(uuid)
+//	@Param		  data body types.EventInput true "The event (`ends_at` must be after `starts_at`)"
+//	@Success      202  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENTS
+//	@Router       /event/{id} [put]
+func (h *Handler) UpdateEvent(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.EventInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	event := eventFromInput(input)
+	event.ID = id
+	err = h.Events.UpdateEvent(ctx, &event)
+	if err == nil {
+		var updated *models.Event
+		updated, err = h.Events.FindEventById(ctx, id)
+		if updated != nil {
+			event = *updated
+		}
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Event not found"
+	case err != nil:
+		log.Println("Error updating event: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated event"
+		response.Data.Events = []models.Event{event}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteEvent deletes an event
+//
+//	@Summary      admin-only operation to delete an event
+//	@Description  Deletes an event along with its guest list and every response to it
+//	@Tags         events
+//	@Produce      json
+//	@Param 		  id  path string true "Event ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /event/{id} [delete]
+func (h *Handler) DeleteEvent(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Events.DeleteEvent(ctx, id)
+	if err != nil {
+		log.Println("Error deleting event: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted event"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetEventGuests gets an event's guest list
+//
+//	@Summary      gets an event's guest list
+//	@Description  Gets the IDs of the users on an event's guest list (their invitees are invited too). The guest list only matters when the event's `invite_scope` is "SELECTED".
+//	@Tags         events
+//	@Produce      json
+//	@Param 		  id  path string true "Event ID" Format(uuid)
+//	@Success      200  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Router       /event/{id}/guests [get]
+func (h *Handler) GetEventGuests(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENT_GUESTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	event, err := h.Events.FindEventById(ctx, id)
+	userIds := []uuid.UUID{}
+	if err == nil && event != nil {
+		var guests []uuid.UUID
+		guests, err = h.Events.FindEventGuests(ctx, id)
+		userIds = append(userIds, guests...)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error finding event guests: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case event == nil:
+		status = http.StatusNotFound
+		response.Message = "Event not found"
+	default:
+		status = http.StatusOK
+		response.Data.UserIds = userIds
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateEventGuests replaces an event's guest list
+//
+//	@Summary      admin-only operation to replace an event's guest list
+//	@Description  Replaces the users on an event's guest list (their invitees are invited too). When the event is limited to its guest list, responses from people who are no longer on it are deleted.
+//	@Tags         events
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Event ID" Format(uuid)
+//	@Param		  data body types.UpdateEventGuestsInput true "The IDs of the users on the guest list"
+//	@Success      202  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENT_GUESTS
+//	@Router       /event/{id}/guests [put]
+func (h *Handler) UpdateEventGuests(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENT_GUESTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.UpdateEventGuestsInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	// Each user can only be on the guest list once
+	userIds := []uuid.UUID{}
+	for _, userId := range input.UserIds {
+		if !slices.Contains(userIds, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+	err = h.Events.ReplaceEventGuests(ctx, id, userIds)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Event not found"
+	case err != nil:
+		log.Println("Error updating event guests: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated event guests"
+		response.Data.UserIds = userIds
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetEventHeadcounts counts the people invited to and attending each event
+//
+//	@Summary      gets the headcount for each event
+//	@Description  Counts the users and invitees invited to each event, along with how many have said they're attending or not attending, earliest event first
+//	@Tags         events
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_EVENT_HEADCOUNTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENT_HEADCOUNTS
+//	@Router       /events/headcounts [get]
+func (h *Handler) GetEventHeadcounts(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENT_HEADCOUNTS{}
+	var status int
+
+	headcounts, err := h.Events.FindEventHeadcounts(ctx)
+	if err != nil {
+		log.Println("Error counting event guests: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Headcounts = headcounts
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetEventsForLoggedInUser gets the events the logged in user is invited to
+//
+//	@Summary      gets the logged in user's events
+//	@Description  Gets the events the logged in user (and their invitees) are invited to, earliest first, each with the responses the user has given for it
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_USER_EVENTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_USER_EVENTS
+//	@Router       /user/events [get]
+func (h *Handler) GetEventsForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_USER_EVENTS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	events, err := h.Events.FindEventsForUser(ctx, uid)
+	var rsvps []models.EventRsvp
+	if err == nil {
+		rsvps, err = h.Events.FindEventRsvpsForUser(ctx, uid)
+	}
+	if err != nil {
+		log.Println("Error finding events for user: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+
+	userEvents := []types.UserEvent{}
+	for _, event := range events {
+		userEvent := types.UserEvent{Event: event, Rsvps: []models.EventRsvp{}}
+		for _, rsvp := range rsvps {
+			if rsvp.EventId == event.ID {
+				userEvent.Rsvps = append(userEvent.Rsvps, rsvp)
+			}
+		}
+		userEvents = append(userEvents, userEvent)
+	}
+	status = http.StatusOK
+	response.Status = status
+	response.Data.Events = userEvents
+	c.JSON(status, response)
+}
+
+// RsvpToEventForLoggedInUser saves whether the logged in user (or one of their invitees) is attending an event
+//
+//	@Summary      responds to an event for the logged in user or one of their invitees
+//	@Description  Saves whether the logged in user (or, when `invitee_id` is given, one of their invitees) is attending an event they're invited to, replacing any earlier response
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Event ID" Format(uuid)
+//	@Param		  data body types.EventRsvpInput true "The response"
+//	@Success      202  {object}  types.V1_API_RESPONSE_EVENT_RSVP
+//	@Failure      400  {object}  types.V1_API_RESPONSE_EVENT_RSVP
+//	@Failure      404  {object}  types.V1_API_RESPONSE_EVENT_RSVP
+//	@Failure      500  {object}  types.V1_API_RESPONSE_EVENT_RSVP
+//	@Router       /user/events/{id}/rsvp [put]
+func (h *Handler) RsvpToEventForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_EVENT_RSVP{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	eventId, err := uuid.Parse(c.Param("id"))
+	var input types.EventRsvpInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	// Events the user isn't invited to are treated as if they don't exist
+	events, err := h.Events.FindEventsForUser(ctx, uid)
+	invited := slices.ContainsFunc(events, func(e models.Event) bool { return e.ID == eventId })
+	attendeeId := uid
+	ownsInvitee := true
+	if err == nil && invited && input.InviteeId != nil {
+		var invitees []models.UserInvitee
+		invitees, err = h.Invitees.FindInviteesForUser(ctx, uid)
+		attendeeId = *input.InviteeId
+		ownsInvitee = slices.ContainsFunc(invitees, func(i models.UserInvitee) bool { return i.ID == attendeeId })
+	}
+	rsvp := models.EventRsvp{
+		EventId:    eventId,
+		UserId:     uid,
+		AttendeeId: attendeeId,
+		Attending:  *input.Attending,
+	}
+	if err == nil && invited && ownsInvitee {
+		err = h.Events.SaveEventRsvp(ctx, &rsvp)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error saving event response: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case !invited:
+		status = http.StatusNotFound
+		response.Message = "Event not found"
+	case !ownsInvitee:
+		status = http.StatusNotFound
+		response.Message = "Invitee not found"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Saved response"
+		response.Data.Rsvp = rsvp
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
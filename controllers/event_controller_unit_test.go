@@ -0,0 +1,195 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EventController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := fixtures.NewStore()
+	router := paveRoutes(NewHandler(store))
+	startsAt := time.Date(2027, time.June, 12, 16, 0, 0, 0, time.UTC)
+	ceremony := types.EventInput{Name: "Ceremony", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), Location: "The Garden", DressCode: "Black tie"}
+	attending := true
+	t.Run("POST /api/v1/event - creates an event open to everyone by default", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Admin(), ceremony)
+		assert.Equal(http.StatusCreated, w.Code)
+		var eventResponse types.V1_API_RESPONSE_EVENTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &eventResponse)
+		assert.Equal(1, len(eventResponse.Data.Events))
+		assert.NotEqual(uuid.Nil, eventResponse.Data.Events[0].ID)
+		assert.Equal(models.EventScopeAll, eventResponse.Data.Events[0].InviteScope)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/events", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		json.Unmarshal([]byte(w.Body.Bytes()), &eventResponse)
+		assert.Equal("Ceremony", eventResponse.Data.Events[0].Name)
+	})
+	t.Run("POST /api/v1/event - rejects events that end before they start and unknown scopes", func(t *testing.T) {
+		backwards := ceremony
+		backwards.EndsAt = startsAt.Add(-time.Hour)
+		w := fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Admin(), backwards)
+		assert.Equal(http.StatusBadRequest, w.Code)
+
+		unknownScope := ceremony
+		unknownScope.InviteScope = "FAMILY"
+		w = fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Admin(), unknownScope)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("Event routes - guests and planners can't manage events", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Guest(), ceremony)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Planner(), ceremony)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/events", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/events/headcounts", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/user/events - only lists events on the user's guest list", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		rehearsal := models.Event{Name: "Rehearsal dinner", StartsAt: startsAt.Add(-24 * time.Hour), EndsAt: startsAt.Add(-20 * time.Hour), InviteScope: models.EventScopeSelected}
+		store.CreateEvent(ctx, &rehearsal)
+		reception := models.Event{Name: "Reception", StartsAt: startsAt, EndsAt: startsAt.Add(5 * time.Hour)}
+		store.CreateEvent(ctx, &reception)
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/user/events", fixtures.Guest(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var userEventResponse types.V1_API_RESPONSE_USER_EVENTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &userEventResponse)
+		assert.Equal(1, len(userEventResponse.Data.Events))
+		assert.Equal("Reception", userEventResponse.Data.Events[0].Name)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/event/"+rehearsal.ID.String()+"/guests", fixtures.Admin(), types.UpdateEventGuestsInput{UserIds: []uuid.UUID{fixtures.Guest().ID, fixtures.Guest().ID}})
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/event/"+rehearsal.ID.String()+"/guests", fixtures.Planner(), nil)
+		var guestResponse types.V1_API_RESPONSE_EVENT_GUESTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &guestResponse)
+		assert.Equal([]uuid.UUID{fixtures.Guest().ID}, guestResponse.Data.UserIds)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/events", fixtures.Guest(), nil)
+		json.Unmarshal([]byte(w.Body.Bytes()), &userEventResponse)
+		assert.Equal(2, len(userEventResponse.Data.Events))
+		assert.Equal("Rehearsal dinner", userEventResponse.Data.Events[0].Name)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/events", fixtures.Admin(), nil)
+		json.Unmarshal([]byte(w.Body.Bytes()), &userEventResponse)
+		assert.Equal(1, len(userEventResponse.Data.Events))
+	})
+	t.Run("PUT /api/v1/user/events/:id/rsvp - responds for the user and their invitees", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		invitee := models.UserInvitee{InviterId: fixtures.Guest().ID, FirstName: "Plus", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+		dinner := models.Event{Name: "Welcome dinner", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), InviteScope: models.EventScopeSelected}
+		store.CreateEvent(ctx, &dinner)
+		store.ReplaceEventGuests(ctx, dinner.ID, []uuid.UUID{fixtures.Guest().ID})
+		path := "/api/v1/user/events/" + dinner.ID.String() + "/rsvp"
+
+		w := fixtures.Serve(t, router, "PUT", path, fixtures.Guest(), types.EventRsvpInput{Attending: &attending})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var rsvpResponse types.V1_API_RESPONSE_EVENT_RSVP
+		json.Unmarshal([]byte(w.Body.Bytes()), &rsvpResponse)
+		assert.Equal(fixtures.Guest().ID, rsvpResponse.Data.Rsvp.AttendeeId)
+		declining := false
+		w = fixtures.Serve(t, router, "PUT", path, fixtures.Guest(), types.EventRsvpInput{Attending: &declining, InviteeId: &invitee.ID})
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/events", fixtures.Guest(), nil)
+		var userEventResponse types.V1_API_RESPONSE_USER_EVENTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &userEventResponse)
+		assert.Equal(2, len(userEventResponse.Data.Events[0].Rsvps))
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/events/headcounts", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var headcountResponse types.V1_API_RESPONSE_EVENT_HEADCOUNTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &headcountResponse)
+		assert.Equal([]models.EventHeadcount{{EventId: dinner.ID, Name: "Welcome dinner", Invited: 2, Attending: 1, Declined: 1}}, headcountResponse.Data.Headcounts)
+	})
+	t.Run("PUT /api/v1/user/events/:id/rsvp - events the user isn't invited to and other users' invitees are not found", func(t *testing.T) {
+		invitee := models.UserInvitee{InviterId: fixtures.Admin().ID, FirstName: "Someone", LastName: "Else"}
+		store.CreateUserInvitee(ctx, &invitee)
+		private := models.Event{Name: "Getting ready", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), InviteScope: models.EventScopeSelected}
+		store.CreateEvent(ctx, &private)
+		open := models.Event{Name: "Reception", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour)}
+		store.CreateEvent(ctx, &open)
+
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+private.ID.String()+"/rsvp", fixtures.Guest(), types.EventRsvpInput{Attending: &attending})
+		assert.Equal(http.StatusNotFound, w.Code)
+		var rsvpResponse types.V1_API_RESPONSE_EVENT_RSVP
+		json.Unmarshal([]byte(w.Body.Bytes()), &rsvpResponse)
+		assert.Equal("Event not found", rsvpResponse.Message)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+open.ID.String()+"/rsvp", fixtures.Guest(), types.EventRsvpInput{Attending: &attending, InviteeId: &invitee.ID})
+		assert.Equal(http.StatusNotFound, w.Code)
+		json.Unmarshal([]byte(w.Body.Bytes()), &rsvpResponse)
+		assert.Equal("Invitee not found", rsvpResponse.Message)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+open.ID.String()+"/rsvp", fixtures.Guest(), types.EventRsvpInput{})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/event/:id - updates and deletes events", func(t *testing.T) {
+		event := models.Event{Name: "Ceremony", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour)}
+		store.CreateEvent(ctx, &event)
+
+		updated := ceremony
+		updated.Location = "The Chapel"
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/event/"+event.ID.String(), fixtures.Admin(), updated)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var eventResponse types.V1_API_RESPONSE_EVENTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &eventResponse)
+		assert.Equal("The Chapel", eventResponse.Data.Events[0].Location)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/event/"+uuid.New().String(), fixtures.Admin(), updated)
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/event/"+uuid.New().String()+"/guests", fixtures.Admin(), types.UpdateEventGuestsInput{UserIds: []uuid.UUID{}})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/event/"+uuid.New().String()+"/guests", fixtures.Admin(), nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/event/"+event.ID.String(), fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(1, deleteResponse.Data.DeletedRecords)
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/event/not-a-uuid", fixtures.Admin(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("Event routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/events", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/events/headcounts", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/event", fixtures.Admin(), ceremony),
+			fixtures.Serve(t, router, "PUT", "/api/v1/event/"+id, fixtures.Admin(), ceremony),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/event/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/event/"+id+"/guests", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/event/"+id+"/guests", fixtures.Admin(), types.UpdateEventGuestsInput{UserIds: []uuid.UUID{}}),
+			fixtures.Serve(t, router, "GET", "/api/v1/user/events", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/user/events/"+id+"/rsvp", fixtures.Guest(), types.EventRsvpInput{Attending: &attending}),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
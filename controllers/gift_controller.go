@@ -0,0 +1,475 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func giftFromInput(input types.GiftInput) models.Gift {
+	return models.Gift{
+		Description:    input.Description,
+		CashCents:      input.CashCents,
+		UserId:         input.UserId,
+		InviteeId:      input.InviteeId,
+		ReceivedAt:     input.ReceivedAt,
+		MailingAddress: input.MailingAddress,
+		Notes:          input.Notes,
+	}
+}
+
+// Checks that the user and invitee a gift is from exist, and that the invitee is one of the user's when both are given;
+// returns the status and message to respond with if they don't (or 0 if they do)
+func (h *Handler) checkGiftGivers(ctx context.Context, input types.GiftInput) (int, string, error) {
+	if input.UserId != nil {
+		users, err := h.Users.FindUsers(ctx, []uuid.UUID{*input.UserId})
+		if err != nil {
+			return 0, "", err
+		}
+		if len(users) == 0 {
+			return http.StatusNotFound, "User not found", nil
+		}
+	}
+	if input.InviteeId != nil {
+		invitee, err := h.Invitees.FindInviteeById(ctx, *input.InviteeId)
+		if err != nil {
+			return 0, "", err
+		}
+		if invitee == nil {
+			return http.StatusNotFound, "Invitee not found", nil
+		}
+		if input.UserId != nil && invitee.InviterId != *input.UserId {
+			return http.StatusBadRequest, "The invitee was not invited by the user", nil
+		}
+	}
+	return 0, "", nil
+}
+
+// Fills in the mailing address of gifts that weren't given one with the address of the giver's household (the user's,
+// or the invitee's if the user doesn't have one)
+func (h *Handler) addHouseholdAddresses(ctx context.Context, queue []models.ThankYouQueueEntry) error {
+	if !slices.ContainsFunc(queue, func(entry models.ThankYouQueueEntry) bool { return entry.MailingAddress == "" }) {
+		return nil
+	}
+	households, err := h.Households.FindHouseholds(ctx)
+	var members []models.HouseholdMember
+	if err == nil {
+		members, err = h.Households.FindHouseholdMembers(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	homeCountry := helper.MailingHomeCountry()
+	householdOf := func(matches func(models.HouseholdMember) bool) *models.Household {
+		i := slices.IndexFunc(members, matches)
+		if i < 0 {
+			return nil
+		}
+		j := slices.IndexFunc(households, func(household models.Household) bool { return household.ID == members[i].HouseholdId })
+		if j < 0 {
+			return nil
+		}
+		return &households[j]
+	}
+	for i, entry := range queue {
+		if entry.MailingAddress != "" {
+			continue
+		}
+		var household *models.Household
+		if entry.UserId != nil {
+			household = householdOf(func(m models.HouseholdMember) bool { return m.UserId != nil && *m.UserId == *entry.UserId })
+		}
+		if household == nil && entry.InviteeId != nil {
+			household = householdOf(func(m models.HouseholdMember) bool { return m.InviteeId != nil && *m.InviteeId == *entry.InviteeId })
+		}
+		if household != nil {
+			queue[i].MailingAddress = strings.Join(helper.MailingLabel(*household, homeCountry), "\n")
+		}
+	}
+	return nil
+}
+
+// Leaves out who gave a gift imported from the registry (that's who claimed it) while registry claims are hidden; see
+// registryClaimsHiddenUntil
+func hideRegistryGiver(gift *models.Gift) {
+	if gift.Source != models.GiftSourceRegistry {
+		return
+	}
+	gift.UserId = nil
+	gift.InviteeId = nil
+	gift.MailingAddress = ""
+}
+
+// GetGifts gets every gift
+//
+//	@Summary      admin-only operation to get the gifts the couple received
+//	@Description  Gets every gift, in the order they were recorded, with the status of its thank-you note. While `REGISTRY_HIDE_CLAIMS_UNTIL` hides registry claims, who gave the gifts imported from the registry is left out.
+//	@Tags         gift
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Router       /gift [get]
+func (h *Handler) GetGifts(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GIFTS{}
+	var status int
+
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	var gifts []models.Gift
+	if err == nil {
+		gifts, err = h.Gifts.FindGifts(ctx)
+	}
+	if err != nil {
+		log.Println("Error finding gifts: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		if hiddenUntil != nil {
+			for i := range gifts {
+				hideRegistryGiver(&gifts[i])
+			}
+		}
+		response.Data.Gifts = append([]models.Gift{}, gifts...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateGift records a gift
+//
+//	@Summary      admin-only operation to record a gift
+//	@Description  Records a gift that wasn't claimed on the registry (e.g., cash), from a user, one of their invitees, or both. Its thank-you note starts out not started.
+//	@Tags         gift
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.GiftInput true "The gift"
+//	@Success      201  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Router       /gift [post]
+func (h *Handler) CreateGift(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GIFTS{}
+	var status int
+
+	var input types.GiftInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	gift := giftFromInput(input)
+	gift.Source = models.GiftSourceManual
+	givenStatus, message, err := h.checkGiftGivers(ctx, input)
+	if err == nil && givenStatus == 0 {
+		err = h.Gifts.CreateGift(ctx, &gift)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error creating gift: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case givenStatus != 0:
+		status = givenStatus
+		response.Message = message
+	default:
+		status = http.StatusCreated
+		response.Message = "Created gift"
+		response.Data.Gifts = []models.Gift{gift}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ImportRegistryGifts records a gift for each registry claim
+//
+//	@Summary      admin-only operation to import gifts from the registry
+//	@Description  Records a gift for each registry claim that doesn't have one yet, so it's safe to import more than once. Returns only the new gifts. Gifts can't be imported while `REGISTRY_HIDE_CLAIMS_UNTIL` hides registry claims, since that would show who claimed what.
+//	@Tags         gift
+//	@Produce      json
+//	@Success      201  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      409  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Router       /gift/import-registry [post]
+func (h *Handler) ImportRegistryGifts(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GIFTS{}
+	var status int
+
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	var gifts []models.Gift
+	if err == nil && hiddenUntil == nil {
+		gifts, err = h.Gifts.ImportRegistryGifts(ctx)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error importing registry gifts: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case hiddenUntil != nil:
+		status = http.StatusConflict
+		response.Message = "Registry claims are hidden until " + hiddenUntil.Format(time.RFC3339)
+	default:
+		status = http.StatusCreated
+		response.Message = "Imported registry gifts"
+		response.Data.Gifts = append([]models.Gift{}, gifts...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetThankYouQueue gets the gifts that still need a thank-you note
+//
+//	@Summary      admin-only operation to get the outstanding thank-you notes
+//	@Description  Gets every gift whose thank-you note hasn't been sent, with who gave it and where to mail the note (the address of the giver's household, unless the gift has its own); gifts that arrived first come first, and gifts that haven't arrived yet come last. While `REGISTRY_HIDE_CLAIMS_UNTIL` hides registry claims, who gave the gifts imported from the registry is left out.
+//	@Tags         gift
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_THANK_YOU_QUEUE
+//	@Failure      500  {object}  types.V1_API_RESPONSE_THANK_YOU_QUEUE
+//	@Router       /gift/thank-you-queue [get]
+func (h *Handler) GetThankYouQueue(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_THANK_YOU_QUEUE{}
+	var status int
+
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	var queue []models.ThankYouQueueEntry
+	if err == nil {
+		queue, err = h.Gifts.FindThankYouQueue(ctx)
+	}
+	if err == nil {
+		for i, entry := range queue {
+			if hiddenUntil != nil && entry.Source == models.GiftSourceRegistry {
+				queue[i] = models.ThankYouQueueEntry{Gift: entry.Gift}
+				hideRegistryGiver(&queue[i].Gift)
+			}
+		}
+		err = h.addHouseholdAddresses(ctx, queue)
+	}
+	if err != nil {
+		log.Println("Error finding thank-you queue: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Notes = append([]models.ThankYouQueueEntry{}, queue...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateGift replaces a gift's details
+//
+//	@Summary      admin-only operation to update a gift
+//	@Description  Replaces the details of a gift. Where the gift came from and its thank-you note are left as they are, as is who gave a gift imported from the registry while `REGISTRY_HIDE_CLAIMS_UNTIL` hides registry claims (it's left out of the response too).
+//	@Tags         gift
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Gift ID" Format(uuid)
+//	@Param		  data body types.GiftInput true "The gift"
+//	@Success      202  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Router       /gift/{id} [put]
+func (h *Handler) UpdateGift(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GIFTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.GiftInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	gift := giftFromInput(input)
+	gift.ID = id
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	var existing *models.Gift
+	if err == nil && hiddenUntil != nil {
+		existing, err = h.Gifts.FindGiftById(ctx, id)
+	}
+	var givenStatus int
+	var message string
+	if err == nil {
+		if existing != nil && existing.Source == models.GiftSourceRegistry {
+			// The giver wasn't shown, so it can't have been sent back; keep the one recorded
+			gift.UserId = existing.UserId
+			gift.InviteeId = existing.InviteeId
+			gift.MailingAddress = existing.MailingAddress
+		} else {
+			givenStatus, message, err = h.checkGiftGivers(ctx, input)
+		}
+	}
+	var updated *models.Gift
+	if err == nil && givenStatus == 0 {
+		err = h.Gifts.UpdateGift(ctx, &gift)
+		if err == nil {
+			updated, err = h.Gifts.FindGiftById(ctx, id)
+		}
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Gift not found"
+	case err != nil:
+		log.Println("Error updating gift: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case givenStatus != 0:
+		status = givenStatus
+		response.Message = message
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated gift"
+		response.Data.Gifts = []models.Gift{}
+		if updated != nil {
+			if hiddenUntil != nil {
+				hideRegistryGiver(updated)
+			}
+			response.Data.Gifts = append(response.Data.Gifts, *updated)
+		}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteGift deletes a gift
+//
+//	@Summary      admin-only operation to delete a gift
+//	@Description  Deletes a gift along with its thank-you note status. A deleted registry gift is recorded again the next time gifts are imported from the registry.
+//	@Tags         gift
+//	@Produce      json
+//	@Param 		  id  path string true "Gift ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /gift/{id} [delete]
+func (h *Handler) DeleteGift(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Gifts.DeleteGift(ctx, id)
+	if err != nil {
+		log.Println("Error deleting gift: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted gift"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateGiftThankYou sets the status of a gift's thank-you note
+//
+//	@Summary      admin-only operation to update a thank-you note
+//	@Description  Sets whether a gift's thank-you note is not started, drafted or sent. Notes marked sent without a `sent_at` are recorded as sent now; any other status clears the date. While `REGISTRY_HIDE_CLAIMS_UNTIL` hides registry claims, who gave a gift imported from the registry is left out.
+//	@Tags         gift
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Gift ID" Format(uuid)
+//	@Param		  data body types.ThankYouInput true "The thank-you note's status"
+//	@Success      202  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GIFTS
+//	@Router       /gift/{id}/thank-you [put]
+func (h *Handler) UpdateGiftThankYou(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GIFTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.ThankYouInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	sentAt := input.SentAt
+	if input.Status != models.ThankYouSent {
+		sentAt = nil
+	} else if sentAt == nil {
+		now := time.Now()
+		sentAt = &now
+	}
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	if err == nil {
+		err = h.Gifts.UpdateGiftThankYou(ctx, id, input.Status, sentAt)
+	}
+	var updated *models.Gift
+	if err == nil {
+		updated, err = h.Gifts.FindGiftById(ctx, id)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Gift not found"
+	case err != nil:
+		log.Println("Error updating thank-you note: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated thank-you note"
+		response.Data.Gifts = []models.Gift{}
+		if updated != nil {
+			if hiddenUntil != nil {
+				hideRegistryGiver(updated)
+			}
+			response.Data.Gifts = append(response.Data.Gifts, *updated)
+		}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
@@ -0,0 +1,229 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GiftController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	gifts := func(w *httptest.ResponseRecorder) []models.Gift {
+		var giftResponse types.V1_API_RESPONSE_GIFTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &giftResponse)
+		return giftResponse.Data.Gifts
+	}
+	queue := func(router http.Handler) []models.ThankYouQueueEntry {
+		w := fixtures.Serve(t, router, "GET", "/api/v1/gift/thank-you-queue", fixtures.Admin(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var queueResponse types.V1_API_RESPONSE_THANK_YOU_QUEUE
+		json.Unmarshal([]byte(w.Body.Bytes()), &queueResponse)
+		return queueResponse.Data.Notes
+	}
+	guestId := fixtures.Guest().ID
+	t.Run("POST /api/v1/gift/import-registry - records each registry claim once", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Admin(), types.RegistryItemInput{Name: "Bath towels", QuantityDesired: 4})
+		var itemResponse types.V1_API_RESPONSE_REGISTRY_ITEMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &itemResponse)
+		quantity := 2
+		fixtures.Serve(t, router, "PUT", "/api/v1/user/registry/"+itemResponse.Data.Items[0].ID.String()+"/claim", fixtures.Guest(), types.RegistryClaimInput{Quantity: &quantity})
+
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil)
+		assert.Equal(http.StatusCreated, w.Code)
+		imported := gifts(w)
+		assert.Equal(1, len(imported))
+		assert.Equal(models.GiftSourceRegistry, imported[0].Source)
+		assert.Equal("Bath towels (2)", imported[0].Description)
+		assert.Equal(guestId, *imported[0].UserId)
+		assert.Equal(models.ThankYouNotStarted, imported[0].ThankYouStatus)
+
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil)
+		assert.Equal(http.StatusCreated, w.Code)
+		assert.Equal(0, len(gifts(w)))
+
+		// Deleting the gift lets it be imported again
+		fixtures.Serve(t, router, "DELETE", "/api/v1/gift/"+imported[0].ID.String(), fixtures.Admin(), nil)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil)
+		assert.Equal(1, len(gifts(w)))
+	})
+	t.Run("Gift routes - don't show who claimed registry items while claims are hidden", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Admin(), types.RegistryItemInput{Name: "Bath towels", QuantityDesired: 4})
+		var itemResponse types.V1_API_RESPONSE_REGISTRY_ITEMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &itemResponse)
+		quantity := 1
+		fixtures.Serve(t, router, "PUT", "/api/v1/user/registry/"+itemResponse.Data.Items[0].ID.String()+"/claim", fixtures.Guest(), types.RegistryClaimInput{Quantity: &quantity})
+		fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &guestId})
+
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", time.Now().Add(time.Hour).Format(time.RFC3339))
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil)
+		assert.Equal(http.StatusConflict, w.Code)
+
+		// Gifts imported before claims were hidden don't give them away either
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", "")
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil)
+		assert.Equal(http.StatusCreated, w.Code)
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", time.Now().Add(time.Hour).Format(time.RFC3339))
+		listed := gifts(fixtures.Serve(t, router, "GET", "/api/v1/gift", fixtures.Admin(), nil))
+		assert.Equal(2, len(listed))
+		assert.Equal(guestId, *listed[0].UserId)
+		assert.Equal(models.GiftSourceRegistry, listed[1].Source)
+		assert.Nil(listed[1].UserId)
+		notes := queue(router)
+		assert.Equal(2, len(notes))
+		assert.Equal(fixtures.Guest().Email, notes[0].Email)
+		assert.Equal("Bath towels", notes[1].Description)
+		assert.Nil(notes[1].UserId)
+		assert.Empty(notes[1].UserFirstName)
+		assert.Empty(notes[1].Email)
+
+		// Saving the gift keeps who gave it (it can't have been sent back), without showing them
+		adminId := fixtures.Admin().ID
+		registryGift := listed[1]
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+registryGift.ID.String(), fixtures.Admin(), types.GiftInput{Description: "Bath towels, blue", UserId: &adminId})
+		assert.Equal(http.StatusAccepted, w.Code)
+		updated := gifts(w)
+		assert.Equal("Bath towels, blue", updated[0].Description)
+		assert.Nil(updated[0].UserId)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+registryGift.ID.String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouDrafted})
+		assert.Equal(http.StatusAccepted, w.Code)
+		updated = gifts(w)
+		assert.Equal(models.ThankYouDrafted, updated[0].ThankYouStatus)
+		assert.Nil(updated[0].UserId)
+		// Other gifts can still have their giver changed
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+listed[0].ID.String(), fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &adminId})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(adminId, *gifts(w)[0].UserId)
+
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", time.Now().Add(-time.Hour).Format(time.RFC3339))
+		listed = gifts(fixtures.Serve(t, router, "GET", "/api/v1/gift", fixtures.Admin(), nil))
+		assert.Equal(guestId, *listed[1].UserId)
+		assert.Equal("Bath towels, blue", listed[1].Description)
+	})
+	t.Run("POST /api/v1/gift - records gifts from users and their invitees", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		invitee := models.UserInvitee{InviterId: guestId, FirstName: "Plus", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+		otherInvitee := models.UserInvitee{InviterId: fixtures.Admin().ID, FirstName: "Someone", LastName: "Else"}
+		store.CreateUserInvitee(ctx, &otherInvitee)
+
+		w := fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", CashCents: 10000, InviteeId: &invitee.ID, MailingAddress: "1 Main St"})
+		assert.Equal(http.StatusCreated, w.Code)
+		created := gifts(w)[0]
+		assert.Equal(models.GiftSourceManual, created.Source)
+		assert.Equal(int64(10000), created.CashCents)
+
+		missing := uuid.New()
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &missing})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", InviteeId: &missing})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &guestId, InviteeId: &otherInvitee.ID})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Planner(), types.GiftInput{Description: "Card", UserId: &guestId})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+created.ID.String(), fixtures.Admin(), types.GiftInput{Description: "Card and a vase", CashCents: 10000, UserId: &guestId, InviteeId: &invitee.ID})
+		assert.Equal(http.StatusAccepted, w.Code)
+		updated := gifts(w)[0]
+		assert.Equal("Card and a vase", updated.Description)
+		assert.Equal(models.GiftSourceManual, updated.Source)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+uuid.New().String(), fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &guestId})
+		assert.Equal(http.StatusNotFound, w.Code)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/gift", fixtures.Admin(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Equal(1, len(gifts(w)))
+	})
+	t.Run("GET /api/v1/gift/thank-you-queue - lists notes that haven't been sent", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		invitee := models.UserInvitee{InviterId: guestId, FirstName: "Plus", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+		later := time.Now()
+		earlier := later.Add(-24 * time.Hour)
+		fromInvitee := gifts(fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Vase", InviteeId: &invitee.ID, ReceivedAt: &later, MailingAddress: "1 Main St"}))[0]
+		notArrived := gifts(fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Toaster", UserId: &guestId}))[0]
+		fromGuest := gifts(fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &guestId, ReceivedAt: &earlier}))[0]
+
+		notes := queue(router)
+		assert.Equal(3, len(notes))
+		assert.Equal(fromGuest.ID, notes[0].ID)
+		assert.Equal(fromInvitee.ID, notes[1].ID)
+		assert.Equal(notArrived.ID, notes[2].ID)
+		assert.Equal("Plus", notes[1].InviteeFirstName)
+		assert.Equal(fixtures.Guest().Email, notes[1].Email)
+		assert.Equal("1 Main St", notes[1].MailingAddress)
+
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+fromGuest.ID.String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouDrafted})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(models.ThankYouDrafted, gifts(w)[0].ThankYouStatus)
+		assert.Equal(3, len(queue(router)))
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+fromGuest.ID.String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouSent})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.NotNil(gifts(w)[0].ThankYouSentAt)
+		assert.Equal(2, len(queue(router)))
+
+		// Reopening a note clears when it was sent
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+fromGuest.ID.String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouDrafted, SentAt: &later})
+		assert.Nil(gifts(w)[0].ThankYouSentAt)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+fromGuest.ID.String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: "MAILED"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+uuid.New().String()+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouSent})
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("GET /api/v1/gift/thank-you-queue - falls back to the giver's household address", func(t *testing.T) {
+		t.Setenv("MAILING_HOME_COUNTRY", "US")
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		household := models.Household{Name: "The Smiths", AddressLine1: "1 Main St", City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"}
+		store.CreateHousehold(ctx, &household)
+		store.ReplaceHouseholdMembers(ctx, household.ID, []uuid.UUID{guestId}, nil)
+		fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Card", UserId: &guestId})
+		fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), types.GiftInput{Description: "Vase", UserId: &guestId, MailingAddress: "2 Elm St"})
+
+		notes := queue(router)
+		assert.Equal("The Smiths\n1 Main St\nSpringfield, IL 62701", notes[0].MailingAddress)
+		assert.Equal("2 Elm St", notes[1].MailingAddress)
+	})
+	t.Run("Gift routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		gift := types.GiftInput{Description: "Card", UserId: &guestId}
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/gift", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/gift", fixtures.Admin(), gift),
+			fixtures.Serve(t, router, "POST", "/api/v1/gift/import-registry", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/gift/thank-you-queue", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+id, fixtures.Admin(), gift),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/gift/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/gift/"+id+"/thank-you", fixtures.Admin(), types.ThankYouInput{Status: models.ThankYouSent}),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
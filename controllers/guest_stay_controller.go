@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetStayForLoggedInUser gets where the logged in user is staying
+//
+//	@Summary      gets the logged in user's stay
+//	@Description  Gets where the logged in user is staying, when they arrive and leave, and whether they booked from the room block (null if they haven't said)
+//	@Tags         venue
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Router       /venue/stay [get]
+func (h *Handler) GetStayForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUEST_STAY{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	stay, err := h.Stays.FindGuestStayForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error finding stay: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Stay = stay
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// SaveStayForLoggedInUser records where the logged in user is staying
+//
+//	@Summary      saves the logged in user's stay
+//	@Description  Records where the logged in user is staying (one of the accommodations, or somewhere else), when they arrive and leave, and whether they booked from the accommodation's room block, replacing what they said before
+//	@Tags         venue
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.GuestStayInput true "The stay (`departure_date` must be after `arrival_date`)"
+//	@Success      202  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Failure      404  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUEST_STAY
+//	@Router       /venue/stay [put]
+func (h *Handler) SaveStayForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUEST_STAY{}
+	var status int
+
+	var input types.GuestStayInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+	if input.AccommodationId == nil && input.UsedRoomBlock {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = "Only stays at one of the accommodations can use a room block"
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	stay := models.GuestStay{
+		UserId:             uid,
+		AccommodationId:    input.AccommodationId,
+		OtherAccommodation: input.OtherAccommodation,
+		ArrivalDate:        input.ArrivalDate,
+		DepartureDate:      input.DepartureDate,
+		UsedRoomBlock:      input.UsedRoomBlock,
+	}
+	var err error
+	found := true
+	if input.AccommodationId != nil {
+		// The accommodation says where they're staying, so there's nothing else to describe
+		stay.OtherAccommodation = ""
+		var accommodation *models.Accommodation
+		accommodation, err = h.Venues.FindAccommodationById(ctx, *input.AccommodationId)
+		found = accommodation != nil
+	}
+	if err == nil && found {
+		err = h.Stays.SaveGuestStay(ctx, &stay)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error saving stay: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case !found:
+		status = http.StatusNotFound
+		response.Message = "Accommodation not found"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Saved stay"
+		response.Data.Stay = &stay
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteStayForLoggedInUser deletes the logged in user's stay
+//
+//	@Summary      deletes the logged in user's stay
+//	@Description  Forgets where the logged in user said they're staying
+//	@Tags         venue
+//	@Produce      json
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /venue/stay [delete]
+func (h *Handler) DeleteStayForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	result, err := h.Stays.DeleteGuestStayForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error deleting stay: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted stay"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetGuestStays gets where every guest is staying
+//
+//	@Summary      gets every guest's stay
+//	@Description  Gets where every guest who has said is staying, earliest arrival first, for reconciling the room blocks with the hotels
+//	@Tags         venue
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_GUEST_STAYS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUEST_STAYS
+//	@Router       /venue/stays [get]
+func (h *Handler) GetGuestStays(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUEST_STAYS{}
+	var status int
+
+	stays, err := h.Stays.FindGuestStays(ctx)
+	if err != nil {
+		log.Println("Error finding stays: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Stays = stays
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetRoomBlockReport reports how much of each accommodation's room block has been booked
+//
+//	@Summary      gets the room block utilization report
+//	@Description  Compares the rooms guests say they booked from each accommodation's room block with the number of rooms under contract (each guest's stay counts as one room), and counts the guests staying elsewhere
+//	@Tags         venue
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_ROOM_BLOCKS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_ROOM_BLOCKS
+//	@Router       /venue/room-blocks [get]
+func (h *Handler) GetRoomBlockReport(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_ROOM_BLOCKS{}
+	var status int
+
+	utilization, err := h.Stays.FindRoomBlockUtilization(ctx)
+	var stays []models.GuestStay
+	if err == nil {
+		stays, err = h.Stays.FindGuestStays(ctx)
+	}
+	if err != nil {
+		log.Println("Error creating room block report: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+
+	roomBlocks := []types.RoomBlockUsage{}
+	for _, u := range utilization {
+		usage := types.RoomBlockUsage{RoomBlockUtilization: u}
+		if u.ContractedRooms > 0 {
+			usage.Utilization = float64(u.BlockBookings) / float64(u.ContractedRooms)
+		}
+		roomBlocks = append(roomBlocks, usage)
+	}
+	// Stays at accommodations that have since been removed count as staying elsewhere
+	for _, stay := range stays {
+		if stay.AccommodationId == nil || !slices.ContainsFunc(utilization, func(u models.RoomBlockUtilization) bool { return u.AccommodationId == *stay.AccommodationId }) {
+			response.Data.StaysElsewhere++
+		}
+	}
+	status = http.StatusOK
+	response.Status = status
+	response.Data.RoomBlocks = roomBlocks
+	c.JSON(status, response)
+}
@@ -0,0 +1,139 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GuestStayController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	arrival := time.Date(2027, time.June, 11, 0, 0, 0, 0, time.UTC)
+	departure := arrival.Add(48 * time.Hour)
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	addHotel := func(router http.Handler, contractedRooms int) uuid.UUID {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), types.AccommodationInput{
+			VenueInput:      types.VenueInput{Name: "The Grand Hotel"},
+			ContractedRooms: contractedRooms,
+		})
+		assert.Equal(http.StatusCreated, w.Code)
+		var accommodationResponse types.V1_API_RESPONSE_ACCOMMODATIONS
+		json.Unmarshal([]byte(w.Body.Bytes()), &accommodationResponse)
+		return accommodationResponse.Data.Accommodations[0].ID
+	}
+	getStay := func(user models.User) *models.GuestStay {
+		w := fixtures.Serve(t, router, "GET", "/api/v1/venue/stay", user, nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var stayResponse types.V1_API_RESPONSE_GUEST_STAY
+		json.Unmarshal([]byte(w.Body.Bytes()), &stayResponse)
+		return stayResponse.Data.Stay
+	}
+	t.Run("PUT /api/v1/venue/stay - guests record and replace where they're staying", func(t *testing.T) {
+		hotelId := addHotel(router, 10)
+		assert.Nil(getStay(fixtures.Guest()))
+
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{
+			AccommodationId:    &hotelId,
+			OtherAccommodation: "Ignored",
+			ArrivalDate:        arrival,
+			DepartureDate:      departure,
+			UsedRoomBlock:      true,
+		})
+		assert.Equal(http.StatusAccepted, w.Code)
+		stay := getStay(fixtures.Guest())
+		assert.Equal(hotelId, *stay.AccommodationId)
+		assert.Equal("", stay.OtherAccommodation)
+		assert.True(stay.UsedRoomBlock)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{
+			OtherAccommodation: "With family",
+			ArrivalDate:        arrival,
+			DepartureDate:      departure,
+		})
+		assert.Equal(http.StatusAccepted, w.Code)
+		stay = getStay(fixtures.Guest())
+		assert.Nil(stay.AccommodationId)
+		assert.Equal("With family", stay.OtherAccommodation)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/venue/stay", fixtures.Guest(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(1, deleteResponse.Data.DeletedRecords)
+		assert.Nil(getStay(fixtures.Guest()))
+	})
+	t.Run("PUT /api/v1/venue/stay - rejects invalid stays", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{ArrivalDate: departure, DepartureDate: arrival})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		// Only the accommodations have room blocks
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{ArrivalDate: arrival, DepartureDate: departure, UsedRoomBlock: true})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		missing := uuid.New()
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{AccommodationId: &missing, ArrivalDate: arrival, DepartureDate: departure})
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("GET /api/v1/venue/room-blocks - compares block bookings with the contracted rooms", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		hotelId := addHotel(router, 4)
+		overflowId := addHotel(router, 0)
+		fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{AccommodationId: &hotelId, ArrivalDate: arrival, DepartureDate: departure, UsedRoomBlock: true})
+		fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Planner(), types.GuestStayInput{AccommodationId: &hotelId, ArrivalDate: arrival, DepartureDate: departure})
+		fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Admin(), types.GuestStayInput{OtherAccommodation: "At home", ArrivalDate: arrival, DepartureDate: departure})
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/venue/room-blocks", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var reportResponse types.V1_API_RESPONSE_ROOM_BLOCKS
+		json.Unmarshal([]byte(w.Body.Bytes()), &reportResponse)
+		assert.Equal(2, len(reportResponse.Data.RoomBlocks))
+		assert.Equal(hotelId, reportResponse.Data.RoomBlocks[0].AccommodationId)
+		assert.Equal(4, reportResponse.Data.RoomBlocks[0].ContractedRooms)
+		assert.Equal(int64(1), reportResponse.Data.RoomBlocks[0].BlockBookings)
+		assert.Equal(int64(1), reportResponse.Data.RoomBlocks[0].OtherBookings)
+		assert.Equal(0.25, reportResponse.Data.RoomBlocks[0].Utilization)
+		assert.Equal(overflowId, reportResponse.Data.RoomBlocks[1].AccommodationId)
+		assert.Equal(0.0, reportResponse.Data.RoomBlocks[1].Utilization)
+		assert.Equal(1, reportResponse.Data.StaysElsewhere)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/venue/stays", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var staysResponse types.V1_API_RESPONSE_GUEST_STAYS
+		json.Unmarshal([]byte(w.Body.Bytes()), &staysResponse)
+		assert.Equal(3, len(staysResponse.Data.Stays))
+	})
+	t.Run("Stay reports - guests can't see everyone's stays", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "GET", "/api/v1/venue/stays", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/venue/room-blocks", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("Stay routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		hotelId := uuid.New()
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/venue/stay", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{ArrivalDate: arrival, DepartureDate: departure}),
+			fixtures.Serve(t, router, "PUT", "/api/v1/venue/stay", fixtures.Guest(), types.GuestStayInput{AccommodationId: &hotelId, ArrivalDate: arrival, DepartureDate: departure}),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/venue/stay", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/venue/stays", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/venue/room-blocks", fixtures.Admin(), nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
@@ -0,0 +1,433 @@
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// How many public guestbook entries are on a page when the request doesn't say
+	defaultGuestbookPageSize = 20
+	// The most public guestbook entries a page can have
+	maxGuestbookPageSize = 100
+)
+
+var guestbookStatuses = []string{models.GuestbookPending, models.GuestbookApproved, models.GuestbookRejected}
+
+// Reads the page (numbered from 1) and the number of entries per page from the query string. Pages are capped so
+// the offset of the first entry on the page always fits in an int32.
+func guestbookPageFromQuery(c *gin.Context) (int, int, error) {
+	page, perPage := 1, defaultGuestbookPageSize
+	var err error
+	if value := c.Query("per_page"); value != "" {
+		if perPage, err = strconv.Atoi(value); err != nil || perPage < 1 || perPage > maxGuestbookPageSize {
+			return 0, 0, fmt.Errorf("per_page must be a whole number from 1 to %d", maxGuestbookPageSize)
+		}
+	}
+	if value := c.Query("page"); value != "" {
+		maxPage := math.MaxInt32 / perPage
+		if page, err = strconv.Atoi(value); err != nil || page < 1 || page > maxPage {
+			return 0, 0, fmt.Errorf("page must be a whole number from 1 to %d", maxPage)
+		}
+	}
+	return page, perPage, nil
+}
+
+// GetGuestbookEntriesForLoggedInUser gets the guestbook entries the logged in user wrote
+//
+//	@Summary      gets the logged in user's guestbook entries
+//	@Description  Gets the messages the logged in user left in the guestbook, in the order they wrote them, and whether each has been approved
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Router       /user/guestbook [get]
+func (h *Handler) GetGuestbookEntriesForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_ENTRIES{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	entries, err := h.Guestbook.FindGuestbookEntriesForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error finding guestbook entries: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Entries = append([]models.GuestbookEntry{}, entries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateGuestbookEntryForLoggedInUser leaves a message in the guestbook
+//
+//	@Summary      signs the guestbook
+//	@Description  Leaves a message for the couple. Messages are private to the couple unless `visibility` is `PUBLIC`. Messages with a word or phrase from the blocked word list are held until an admin approves them; other messages are approved right away.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.GuestbookEntryInput true "The message"
+//	@Success      201  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Router       /user/guestbook [post]
+func (h *Handler) CreateGuestbookEntryForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_ENTRIES{}
+	var status int
+
+	var input types.GuestbookEntryInput
+	err := c.ShouldBindBodyWithJSON(&input)
+	if err == nil && strings.TrimSpace(input.Message) == "" {
+		err = errors.New("message must not be blank")
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	entry := models.GuestbookEntry{
+		UserId:     uid,
+		Message:    strings.TrimSpace(input.Message),
+		Visibility: cmp.Or(input.Visibility, models.GuestbookPrivate),
+		Status:     models.GuestbookApproved,
+	}
+	blocked, err := h.Guestbook.FindGuestbookBlockedWords(ctx)
+	if err == nil {
+		if len(helper.FindBlockedWords(entry.Message, blocked)) > 0 {
+			entry.Status = models.GuestbookPending
+			entry.Flagged = true
+		}
+		err = h.Guestbook.CreateGuestbookEntry(ctx, &entry)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error creating guestbook entry: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case entry.Flagged:
+		status = http.StatusCreated
+		response.Message = "Signed the guestbook; your message will appear once it has been reviewed"
+		response.Data.Entries = []models.GuestbookEntry{entry}
+	default:
+		status = http.StatusCreated
+		response.Message = "Signed the guestbook"
+		response.Data.Entries = []models.GuestbookEntry{entry}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteGuestbookEntryForLoggedInUser deletes a guestbook entry the logged in user wrote
+//
+//	@Summary      deletes one of the logged in user's guestbook entries
+//	@Description  Deletes a message the logged in user left in the guestbook (messages other users left are never deleted)
+//	@Tags         user
+//	@Produce      json
+//	@Param 		  id  path string true "Guestbook entry ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /user/guestbook/{id} [delete]
+func (h *Handler) DeleteGuestbookEntryForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	result, err := h.Guestbook.DeleteGuestbookEntryForUser(ctx, id, uid)
+	if err != nil {
+		log.Println("Error deleting guestbook entry: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted guestbook entry"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetPublicGuestbookEntries gets a page of the guestbook
+//
+//	@Summary      gets the guestbook
+//	@Description  Gets a page of the approved, public messages in the guestbook, newest first, with the name of who wrote each
+//	@Tags         guestbook
+//	@Produce      json
+//	@Param        page      query int false "The page to get, starting from 1" default(1)
+//	@Param        per_page  query int false "How many messages are on a page (at most 100)" default(20)
+//	@Success      200  {object}  types.V1_API_RESPONSE_PUBLIC_GUESTBOOK
+//	@Failure      400  {object}  types.V1_API_RESPONSE_PUBLIC_GUESTBOOK
+//	@Failure      500  {object}  types.V1_API_RESPONSE_PUBLIC_GUESTBOOK
+//	@Router       /guestbook/public [get]
+func (h *Handler) GetPublicGuestbookEntries(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_PUBLIC_GUESTBOOK{}
+	var status int
+
+	page, perPage, err := guestbookPageFromQuery(c)
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	entries, total, err := h.Guestbook.FindPublicGuestbookEntries(ctx, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Println("Error finding public guestbook entries: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Entries = append([]models.PublicGuestbookEntry{}, entries...)
+		response.Data.Page = page
+		response.Data.PerPage = perPage
+		response.Data.Total = total
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetGuestbookEntries gets every guestbook entry
+//
+//	@Summary      admin-only operation to get the guestbook entries
+//	@Description  Gets every message in the guestbook, public or private, in the order they were written. Pass `status=PENDING` for the messages waiting to be moderated.
+//	@Tags         guestbook
+//	@Produce      json
+//	@Param        status  query string false "Only include entries with this status" Enums(PENDING, APPROVED, REJECTED)
+//	@Success      200  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Router       /guestbook [get]
+func (h *Handler) GetGuestbookEntries(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_ENTRIES{}
+	var status int
+
+	entryStatus := c.Query("status")
+	if entryStatus != "" && !slices.Contains(guestbookStatuses, entryStatus) {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = "status must be one of " + strings.Join(guestbookStatuses, ", ")
+		c.JSON(status, response)
+		return
+	}
+
+	entries, err := h.Guestbook.FindGuestbookEntries(ctx)
+	if err != nil {
+		log.Println("Error finding guestbook entries: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		entries = slices.DeleteFunc(entries, func(entry models.GuestbookEntry) bool { return entryStatus != "" && entry.Status != entryStatus })
+		response.Data.Entries = append([]models.GuestbookEntry{}, entries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ModerateGuestbookEntry sets the status of a guestbook entry
+//
+//	@Summary      admin-only operation to moderate a guestbook entry
+//	@Description  Approves a guestbook entry (so it's shown on the site, if it's public), rejects it or sends it back to pending
+//	@Tags         guestbook
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Guestbook entry ID" Format(uuid)
+//	@Param		  data body types.GuestbookModerationInput true "The entry's new status"
+//	@Success      202  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      404  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+//	@Router       /guestbook/{id}/moderation [put]
+func (h *Handler) ModerateGuestbookEntry(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_ENTRIES{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.GuestbookModerationInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	err = h.Guestbook.UpdateGuestbookEntryStatus(ctx, id, input.Status)
+	var updated *models.GuestbookEntry
+	if err == nil {
+		updated, err = h.Guestbook.FindGuestbookEntryById(ctx, id)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Guestbook entry not found"
+	case err != nil:
+		log.Println("Error moderating guestbook entry: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated guestbook entry"
+		response.Data.Entries = []models.GuestbookEntry{}
+		if updated != nil {
+			response.Data.Entries = append(response.Data.Entries, *updated)
+		}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteGuestbookEntry deletes a guestbook entry
+//
+//	@Summary      admin-only operation to delete a guestbook entry
+//	@Description  Deletes a message from the guestbook
+//	@Tags         guestbook
+//	@Produce      json
+//	@Param 		  id  path string true "Guestbook entry ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /guestbook/{id} [delete]
+func (h *Handler) DeleteGuestbookEntry(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Guestbook.DeleteGuestbookEntry(ctx, id)
+	if err != nil {
+		log.Println("Error deleting guestbook entry: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted guestbook entry"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetGuestbookBlockedWords gets the words that hold guestbook entries for moderation
+//
+//	@Summary      admin-only operation to get the guestbook's blocked words
+//	@Description  Gets the words and phrases that hold guestbook entries containing them until an admin approves them
+//	@Tags         settings
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+//	@Router       /settings/guestbook-blocked-words [get]
+func (h *Handler) GetGuestbookBlockedWords(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS{}
+	var status int
+	words, err := h.Guestbook.FindGuestbookBlockedWords(ctx)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding guestbook blocked words: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.Words = append([]string{}, words...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateGuestbookBlockedWords sets the words that hold guestbook entries for moderation
+//
+//	@Summary      admin-only operation to set the guestbook's blocked words
+//	@Description  Replaces the words and phrases that hold guestbook entries containing them until an admin approves them. Only whole words match, ignoring case and punctuation; entries that were already written aren't checked again.
+//	@Tags         settings
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.UpdateGuestbookBlockedWordsInput true "Every word or phrase that should be blocked"
+//	@Success      202  {object}  types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+//	@Router       /settings/guestbook-blocked-words [put]
+func (h *Handler) UpdateGuestbookBlockedWords(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS{}
+	var status int
+	var input types.UpdateGuestbookBlockedWordsInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	words := helper.NormalizeBlockedWords(input.Words)
+	err := h.Guestbook.ReplaceGuestbookBlockedWords(ctx, words)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error replacing guestbook blocked words: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Updated guestbook blocked words"
+		response.Data.Words = words
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
@@ -0,0 +1,205 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GuestbookController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	entries := func(w *httptest.ResponseRecorder) []models.GuestbookEntry {
+		var entryResponse types.V1_API_RESPONSE_GUESTBOOK_ENTRIES
+		json.Unmarshal([]byte(w.Body.Bytes()), &entryResponse)
+		return entryResponse.Data.Entries
+	}
+	public := func(w *httptest.ResponseRecorder) types.PublicGuestbookData {
+		var publicResponse types.V1_API_RESPONSE_PUBLIC_GUESTBOOK
+		json.Unmarshal([]byte(w.Body.Bytes()), &publicResponse)
+		return publicResponse.Data
+	}
+	sign := func(router http.Handler, user models.User, message string, visibility string) models.GuestbookEntry {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/user/guestbook", user, types.GuestbookEntryInput{Message: message, Visibility: visibility})
+		assert.Equal(http.StatusCreated, w.Code)
+		return entries(w)[0]
+	}
+	blockWords := func(router http.Handler, words ...string) {
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/settings/guestbook-blocked-words", fixtures.Admin(), types.UpdateGuestbookBlockedWordsInput{Words: words})
+		assert.Equal(http.StatusAccepted, w.Code)
+	}
+	t.Run("POST /api/v1/user/guestbook - entries are private unless they're made public", func(t *testing.T) {
+		private := sign(router, fixtures.Guest(), "  Congratulations!  ", "")
+		assert.Equal("Congratulations!", private.Message)
+		assert.Equal(models.GuestbookPrivate, private.Visibility)
+		assert.Equal(models.GuestbookApproved, private.Status)
+		assert.False(private.Flagged)
+		sign(router, fixtures.Guest(), "So happy for you both", models.GuestbookPublic)
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		page := public(w)
+		assert.Equal(int64(1), page.Total)
+		assert.Equal("So happy for you both", page.Entries[0].Message)
+		assert.Equal(fixtures.Guest().FirstName, page.Entries[0].FirstName)
+
+		// The couple sees both, and the guest sees their own
+		assert.Equal(2, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/guestbook", fixtures.Admin(), nil))))
+		assert.Equal(2, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/user/guestbook", fixtures.Guest(), nil))))
+		assert.Equal(0, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/user/guestbook", fixtures.Planner(), nil))))
+
+		for _, input := range []types.GuestbookEntryInput{
+			{Message: "   "},
+			{Message: strings.Repeat("a", 2001)},
+			{Message: "Hello", Visibility: "FRIENDS"},
+		} {
+			w = fixtures.Serve(t, router, "POST", "/api/v1/user/guestbook", fixtures.Guest(), input)
+			assert.Equal(http.StatusBadRequest, w.Code)
+		}
+	})
+	t.Run("POST /api/v1/user/guestbook - entries with blocked words are held for moderation", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		blockWords(router, "Heck", "what the heck")
+		held := sign(router, fixtures.Guest(), "What the HECK, you two got married!", models.GuestbookPublic)
+		assert.Equal(models.GuestbookPending, held.Status)
+		assert.True(held.Flagged)
+		// Only whole words are blocked
+		clean := sign(router, fixtures.Guest(), "Checking in from Heckmondwike", models.GuestbookPublic)
+		assert.Equal(models.GuestbookApproved, clean.Status)
+		assert.Equal(int64(1), public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Guest(), nil)).Total)
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/guestbook?status=PENDING", fixtures.Admin(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		queue := entries(w)
+		assert.Equal(1, len(queue))
+		assert.Equal(held.ID, queue[0].ID)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/guestbook?status=SPAM", fixtures.Admin(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+held.ID.String()+"/moderation", fixtures.Admin(), types.GuestbookModerationInput{Status: models.GuestbookApproved})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(models.GuestbookApproved, entries(w)[0].Status)
+		assert.Equal(int64(2), public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Guest(), nil)).Total)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+held.ID.String()+"/moderation", fixtures.Admin(), types.GuestbookModerationInput{Status: models.GuestbookRejected})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(int64(1), public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Guest(), nil)).Total)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+uuid.New().String()+"/moderation", fixtures.Admin(), types.GuestbookModerationInput{Status: models.GuestbookApproved})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+held.ID.String()+"/moderation", fixtures.Admin(), types.GuestbookModerationInput{Status: "DELETED"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("GET /api/v1/guestbook/public - pages through the newest entries first", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		for i := 1; i <= 5; i++ {
+			sign(router, fixtures.Guest(), fmt.Sprintf("Message %d", i), models.GuestbookPublic)
+		}
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public?page=2&per_page=2", fixtures.Guest(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		page := public(w)
+		assert.Equal(2, page.Page)
+		assert.Equal(2, page.PerPage)
+		assert.Equal(int64(5), page.Total)
+		assert.Equal(2, len(page.Entries))
+		assert.Equal("Message 3", page.Entries[0].Message)
+		assert.Equal("Message 2", page.Entries[1].Message)
+
+		page = public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Guest(), nil))
+		assert.Equal(1, page.Page)
+		assert.Equal(20, page.PerPage)
+		assert.Equal(5, len(page.Entries))
+		assert.Equal("Message 5", page.Entries[0].Message)
+
+		// Pages past the end are empty
+		page = public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public?page=4&per_page=2", fixtures.Guest(), nil))
+		assert.NotNil(page.Entries)
+		assert.Equal(0, len(page.Entries))
+		page = public(fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public?page=21474836&per_page=100", fixtures.Guest(), nil))
+		assert.Equal(0, len(page.Entries))
+
+		for _, query := range []string{"page=0", "page=one", "page=9223372036854775807", "page=21474837&per_page=100", "per_page=0", "per_page=101"} {
+			w = fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public?"+query, fixtures.Guest(), nil)
+			assert.Equal(http.StatusBadRequest, w.Code)
+		}
+	})
+	t.Run("DELETE /api/v1/user/guestbook/:id - guests only delete their own entries", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		entry := sign(router, fixtures.Guest(), "Congratulations!", models.GuestbookPublic)
+
+		w := fixtures.Serve(t, router, "DELETE", "/api/v1/user/guestbook/"+entry.ID.String(), fixtures.Planner(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(1, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/user/guestbook", fixtures.Guest(), nil))))
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/user/guestbook/"+entry.ID.String(), fixtures.Guest(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(0, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/user/guestbook", fixtures.Guest(), nil))))
+
+		other := sign(router, fixtures.Guest(), "See you there", models.GuestbookPrivate)
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/guestbook/"+other.ID.String(), fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(0, len(entries(fixtures.Serve(t, router, "GET", "/api/v1/guestbook", fixtures.Admin(), nil))))
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/user/guestbook/not-an-id", fixtures.Guest(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/guestbook-blocked-words - normalizes the list", func(t *testing.T) {
+		blockWords(router, " Darn", "darn", "", "What  the HECK")
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/settings/guestbook-blocked-words", fixtures.Admin(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var wordResponse types.V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS
+		json.Unmarshal([]byte(w.Body.Bytes()), &wordResponse)
+		assert.Equal([]string{"darn", "what the heck"}, wordResponse.Data.Words)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/settings/guestbook-blocked-words", fixtures.Admin(), map[string]any{})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("Guestbook routes - only admins moderate", func(t *testing.T) {
+		entry := sign(router, fixtures.Guest(), "Congratulations!", models.GuestbookPublic)
+		for _, user := range []models.User{fixtures.Guest(), fixtures.Planner()} {
+			for _, w := range []*httptest.ResponseRecorder{
+				fixtures.Serve(t, router, "GET", "/api/v1/guestbook", user, nil),
+				fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+entry.ID.String()+"/moderation", user, types.GuestbookModerationInput{Status: models.GuestbookRejected}),
+				fixtures.Serve(t, router, "DELETE", "/api/v1/guestbook/"+entry.ID.String(), user, nil),
+				fixtures.Serve(t, router, "GET", "/api/v1/settings/guestbook-blocked-words", user, nil),
+			} {
+				assert.Equal(http.StatusUnauthorized, w.Code)
+			}
+		}
+	})
+	t.Run("Guestbook routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/user/guestbook", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/user/guestbook", fixtures.Guest(), types.GuestbookEntryInput{Message: "Congratulations!"}),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/user/guestbook/"+id, fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/guestbook/public", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/guestbook", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/guestbook/"+id+"/moderation", fixtures.Admin(), types.GuestbookModerationInput{Status: models.GuestbookApproved}),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/guestbook/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/settings/guestbook-blocked-words", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/settings/guestbook-blocked-words", fixtures.Admin(), types.UpdateGuestbookBlockedWordsInput{Words: []string{"darn"}}),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
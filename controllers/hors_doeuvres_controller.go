@@ -23,7 +23,7 @@ import (
 //	@Param 		  user_id  path string true "User ID" Format(uuid)
 //	@Router       /horsdoeuvres [get]
 //	@Router       /user/{user_id}/horsdoeuvres [get]
-func GetHorsDoeuvres(c *gin.Context) {
+func (h *Handler) GetHorsDoeuvres(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	idStr := c.Param("id")
@@ -41,8 +41,7 @@ func GetHorsDoeuvres(c *gin.Context) {
 			c.JSON(status, response)
 			return
 		}
-		horsdoeuvres, err := models.FindHorsDoeuvresById(ctx, id)
-		horsDoeuvres = append(horsDoeuvres, *horsdoeuvres)
+		horsdoeuvres, err := h.Menu.FindHorsDoeuvresById(ctx, id)
 		// If an error occurs in the DB during lookup, return error response
 		if err != nil {
 			status = http.StatusInternalServerError
@@ -52,13 +51,16 @@ func GetHorsDoeuvres(c *gin.Context) {
 			c.JSON(status, response)
 			return
 		}
+		if horsdoeuvres != nil {
+			horsDoeuvres = append(horsDoeuvres, *horsdoeuvres)
+		}
 		status = http.StatusOK
 		response.Status = status
 		response.Data.HorsDoeuvres = horsDoeuvres
 		c.JSON(status, response)
 		return
 	}
-	horsDoeuvres, err := models.FindHorsDoeuvres(ctx)
+	horsDoeuvres, err := h.Menu.FindHorsDoeuvres(ctx)
 	if err != nil {
 		status = http.StatusInternalServerError
 		log.Println(err.Error())
@@ -85,7 +87,7 @@ func GetHorsDoeuvres(c *gin.Context) {
 //	@Failure      400  {object}  types.V1_API_RESPONSE_HORS_DOEUVRES
 //	@Failure      500  {object}  types.V1_API_RESPONSE_HORS_DOEUVRES
 //	@Router       /horsdoeuvres [post]
-func CreateHorsDoeuvres(c *gin.Context) {
+func (h *Handler) CreateHorsDoeuvres(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_HORS_DOEUVRES{}
@@ -96,7 +98,7 @@ func CreateHorsDoeuvres(c *gin.Context) {
 		response.Message = "\"option_name\" is required"
 	} else {
 		horsDoeuvres := []models.HorsDoeuvres{input}
-		err := models.CreateHorsDoeuvres(ctx, &horsDoeuvres)
+		err := h.Menu.CreateHorsDoeuvres(ctx, &horsDoeuvres)
 		if err != nil {
 			status = http.StatusInternalServerError
 			response.Message = "Internal server error"
@@ -122,7 +124,7 @@ func CreateHorsDoeuvres(c *gin.Context) {
 //	@Failure      400  {object}  types.V1_API_RESPONSE_HORS_DOEUVRES
 //	@Failure      500  {object}  types.V1_API_RESPONSE_HORS_DOEUVRES
 //	@Router       /horsdoeuvres [delete]
-func DeleteHorsDoeuvres(c *gin.Context) {
+func (h *Handler) DeleteHorsDoeuvres(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_DELETE_RESPONSE{}
@@ -132,7 +134,7 @@ func DeleteHorsDoeuvres(c *gin.Context) {
 		status = http.StatusBadRequest
 		response.Message = err.Error()
 	} else {
-		result, err := models.DeleteHorsDoeuvres(ctx, id)
+		result, err := h.Menu.DeleteHorsDoeuvres(ctx, id)
 		if err != nil {
 			status = http.StatusInternalServerError
 			response.Message = "Internal server error"
@@ -140,7 +142,7 @@ func DeleteHorsDoeuvres(c *gin.Context) {
 		} else {
 			status = http.StatusAccepted
 			response.Message = "Deleted hors doeuvres"
-			response.Data.DeletedRecords = int(*result)
+			response.Data.DeletedRecords = int(result)
 		}
 	}
 	response.Status = status
@@ -5,40 +5,30 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
-	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
 	"github.com/ax-vasquez/wedding-site-api/types"
-	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_HorsDoeuvresController_Unit(t *testing.T) {
-	os.Setenv("USE_MOCK_DB", "true")
 	assert := assert.New(t)
-	router := paveRoutes()
 	errMsg := "arbitrary database error"
 	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
 	t.Run("GET /api/v1/horsdoeuvres - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`SELECT * FROM "hors_doeuvres" WHERE "hors_doeuvres"."deleted_at" IS NULL`)).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "GUEST")
-		req, err := http.NewRequestWithContext(ctx, "GET", "/api/v1/horsdoeuvres", nil)
+		req, err := http.NewRequest("GET", "/api/v1/horsdoeuvres", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -48,21 +38,10 @@ func Test_HorsDoeuvresController_Unit(t *testing.T) {
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
 	t.Run("GET /api/v1/user/:id/horsdoeuvres - internal server error", func(t *testing.T) {
-		someId := uuid.New()
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`SELECT "hors_doeuvres"."created_at","hors_doeuvres"."updated_at","hors_doeuvres"."deleted_at","hors_doeuvres"."id","hors_doeuvres"."option_name" FROM "hors_doeuvres" JOIN users ON hors_doeuvres.id = users.hors_doeuvres_selection_id AND users.id = $1 WHERE "hors_doeuvres"."deleted_at" IS NUL`)).WithArgs(
-			someId,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", someId.String())
-		ctx.Set("user_role", "GUEST")
-		routePath := fmt.Sprintf("/api/v1/user/%s/horsdoeuvres", someId)
-		req, err := http.NewRequestWithContext(ctx, "GET", routePath, nil)
+		routePath := fmt.Sprintf("/api/v1/user/%s/horsdoeuvres", fixtures.Guest().ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -75,24 +54,10 @@ func Test_HorsDoeuvresController_Unit(t *testing.T) {
 		testHorsDoeuvres := models.HorsDoeuvres{
 			OptionName: "Banana Soup",
 		}
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`INSERT INTO "hors_doeuvres" ("created_at","updated_at","deleted_at","option_name") VALUES ($1,$2,$3,$4) RETURNING *`)).WithArgs(
-			test.AnyTime{},
-			test.AnyTime{},
-			nil,
-			testHorsDoeuvres.OptionName,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "ADMIN")
 		horsDoeuvresJson, _ := json.Marshal(testHorsDoeuvres)
-		req, err := http.NewRequestWithContext(ctx, "POST", "/api/v1/horsdoeuvres", strings.NewReader(string(horsDoeuvresJson)))
+		req, err := http.NewRequest("POST", "/api/v1/horsdoeuvres", strings.NewReader(string(horsDoeuvresJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -103,23 +68,11 @@ func Test_HorsDoeuvresController_Unit(t *testing.T) {
 	})
 	t.Run("DELETE /api/v1/horsdoeuvres/:id - internal server error", func(t *testing.T) {
 		someId := uuid.New()
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectExec(
-			regexp.QuoteMeta(`UPDATE "hors_doeuvres" SET "deleted_at"=$1 WHERE "hors_doeuvres"."id" = $2 AND "hors_doeuvres"."deleted_at" IS NULL`)).WithArgs(
-			test.AnyTime{},
-			someId,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", models.NilUuid)
-		ctx.Set("user_role", "ADMIN")
 		// Route needs to be generated since the ID of the record to delete is embedded within the route itself
 		routePath := fmt.Sprintf("/api/v1/horsdoeuvres/%s", someId)
-		req, err := http.NewRequestWithContext(ctx, "DELETE", routePath, nil)
+		req, err := http.NewRequest("DELETE", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -0,0 +1,561 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The mailings labels can be printed for; each one prints labels for the households that haven't been sent it yet
+const (
+	mailingSaveTheDate = "save_the_date"
+	mailingInvitation  = "invitation"
+)
+
+func householdFromInput(input types.HouseholdInput) models.Household {
+	return models.Household{
+		Name:         input.Name,
+		AddressLine1: input.AddressLine1,
+		AddressLine2: input.AddressLine2,
+		AddressLine3: input.AddressLine3,
+		City:         input.City,
+		Region:       input.Region,
+		PostalCode:   input.PostalCode,
+		Country:      input.Country,
+		Notes:        input.Notes,
+	}
+}
+
+// Adds who lives in each household and how its address is printed on mail
+func (h *Handler) householdSummaries(ctx context.Context, households []models.Household) ([]types.HouseholdSummary, error) {
+	members, err := h.Households.FindHouseholdMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	homeCountry := helper.MailingHomeCountry()
+	summaries := []types.HouseholdSummary{}
+	for _, household := range households {
+		summary := types.HouseholdSummary{
+			Household:        household,
+			FormattedAddress: helper.FormatAddress(household, homeCountry),
+			UserIds:          []uuid.UUID{},
+			InviteeIds:       []uuid.UUID{},
+		}
+		for _, member := range members {
+			switch {
+			case member.HouseholdId != household.ID:
+			case member.UserId != nil:
+				summary.UserIds = append(summary.UserIds, *member.UserId)
+			case member.InviteeId != nil:
+				summary.InviteeIds = append(summary.InviteeIds, *member.InviteeId)
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// Checks whether a household still needs to be sent a mailing: it hasn't been mailed, or it came back and hasn't been
+// mailed again since
+func needsMailing(household models.Household, mailing string) bool {
+	mailedAt, returnedAt := household.InvitationMailedAt, household.InvitationReturnedAt
+	if mailing == mailingSaveTheDate {
+		mailedAt, returnedAt = household.SaveTheDateMailedAt, household.SaveTheDateReturnedAt
+	}
+	return mailedAt == nil || (returnedAt != nil && !mailedAt.After(*returnedAt))
+}
+
+// Finds the households to print labels for: every household, or only the ones that still need the mailing named by
+// the "mailing" query parameter
+func (h *Handler) findMailingLabelHouseholds(ctx context.Context, mailing string) ([]models.Household, error) {
+	households, err := h.Households.FindHouseholds(ctx)
+	if err != nil || mailing == "" {
+		return households, err
+	}
+	var labelled []models.Household
+	for _, household := range households {
+		if needsMailing(household, mailing) {
+			labelled = append(labelled, household)
+		}
+	}
+	return labelled, nil
+}
+
+// Gets the name the label files are downloaded as
+func mailingLabelFilename(mailing string, extension string) string {
+	if mailing == "" {
+		return "mailing-labels." + extension
+	}
+	return "mailing-labels-" + strings.ReplaceAll(mailing, "_", "-") + "." + extension
+}
+
+// GetHouseholds gets every household
+//
+//	@Summary      admin-only operation to get the households
+//	@Description  Gets every household by name, with who lives there, its address as it's printed on mail, and when its save-the-date and invitation were mailed or returned
+//	@Tags         households
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Router       /household [get]
+func (h *Handler) GetHouseholds(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_HOUSEHOLDS{}
+	var status int
+
+	households, err := h.Households.FindHouseholds(ctx)
+	var summaries []types.HouseholdSummary
+	if err == nil {
+		summaries, err = h.householdSummaries(ctx, households)
+	}
+	if err != nil {
+		log.Println("Error finding households: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Households = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateHousehold creates a household
+//
+//	@Summary      admin-only operation to add a household
+//	@Description  Adds a household and returns the new record's data to the caller. The address must have what mail to its country needs (e.g., a state and ZIP code in the US).
+//	@Tags         households
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.HouseholdInput true "The household"
+//	@Success      201  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Router       /household [post]
+func (h *Handler) CreateHousehold(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_HOUSEHOLDS{}
+	var status int
+
+	var input types.HouseholdInput
+	err := c.ShouldBindBodyWithJSON(&input)
+	household := householdFromInput(input)
+	if err == nil {
+		err = helper.ValidateAddress(household)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	if err := h.Households.CreateHousehold(ctx, &household); err != nil {
+		log.Println("Error creating household: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created household"
+		response.Data.Households = []types.HouseholdSummary{{
+			Household:        household,
+			FormattedAddress: helper.FormatAddress(household, helper.MailingHomeCountry()),
+			UserIds:          []uuid.UUID{},
+			InviteeIds:       []uuid.UUID{},
+		}}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateHousehold replaces a household's name and address
+//
+//	@Summary      admin-only operation to update a household
+//	@Description  Replaces a household's name, address and notes. When and what was mailed to it are left as they are.
+//	@Tags         households
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Household ID" Format(uuid)
+//	@Param		  data body types.HouseholdInput true "The household"
+//	@Success      202  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Router       /household/{id} [put]
+func (h *Handler) UpdateHousehold(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_HOUSEHOLDS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.HouseholdInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	household := householdFromInput(input)
+	household.ID = id
+	if err == nil {
+		err = helper.ValidateAddress(household)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	err = h.Households.UpdateHousehold(ctx, &household)
+	var updated *models.Household
+	if err == nil {
+		updated, err = h.Households.FindHouseholdById(ctx, id)
+	}
+	var summaries []types.HouseholdSummary
+	if err == nil && updated != nil {
+		summaries, err = h.householdSummaries(ctx, []models.Household{*updated})
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Household not found"
+	case err != nil:
+		log.Println("Error updating household: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated household"
+		response.Data.Households = append([]types.HouseholdSummary{}, summaries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteHousehold deletes a household
+//
+//	@Summary      admin-only operation to delete a household
+//	@Description  Deletes a household; the users and invitees who lived there are left without one
+//	@Tags         households
+//	@Produce      json
+//	@Param 		  id  path string true "Household ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /household/{id} [delete]
+func (h *Handler) DeleteHousehold(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Households.DeleteHousehold(ctx, id)
+	if err != nil {
+		log.Println("Error deleting household: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted household"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateHouseholdMembers replaces who lives in a household
+//
+//	@Summary      admin-only operation to set who lives in a household
+//	@Description  Replaces the users and invitees who live in a household. Each user and invitee lives in one household, so anyone who lived in another household is moved to this one.
+//	@Tags         households
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Household ID" Format(uuid)
+//	@Param		  data body types.HouseholdMembersInput true "The IDs of the users and invitees who live in the household"
+//	@Success      202  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Router       /household/{id}/members [put]
+func (h *Handler) UpdateHouseholdMembers(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_HOUSEHOLDS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.HouseholdMembersInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	// Each user and invitee can only be added once
+	userIds, inviteeIds := []uuid.UUID{}, []uuid.UUID{}
+	for _, userId := range input.UserIds {
+		if !slices.Contains(userIds, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+	for _, inviteeId := range input.InviteeIds {
+		if !slices.Contains(inviteeIds, inviteeId) {
+			inviteeIds = append(inviteeIds, inviteeId)
+		}
+	}
+	var notFound string
+	if len(userIds) > 0 {
+		var users []models.User
+		users, err = h.Users.FindUsers(ctx, userIds)
+		if err == nil && len(users) < len(userIds) {
+			notFound = "User not found"
+		}
+	}
+	for _, inviteeId := range inviteeIds {
+		if err != nil || notFound != "" {
+			break
+		}
+		var invitee *models.UserInvitee
+		invitee, err = h.Invitees.FindInviteeById(ctx, inviteeId)
+		if err == nil && invitee == nil {
+			notFound = "Invitee not found"
+		}
+	}
+	if err == nil && notFound == "" {
+		err = h.Households.ReplaceHouseholdMembers(ctx, id, userIds, inviteeIds)
+	}
+	var household *models.Household
+	if err == nil && notFound == "" {
+		household, err = h.Households.FindHouseholdById(ctx, id)
+	}
+	var summaries []types.HouseholdSummary
+	if err == nil && household != nil {
+		summaries, err = h.householdSummaries(ctx, []models.Household{*household})
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Household not found"
+	case err != nil:
+		log.Println("Error updating household members: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case notFound != "":
+		status = http.StatusNotFound
+		response.Message = notFound
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated household members"
+		response.Data.Households = append([]types.HouseholdSummary{}, summaries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateHouseholdMailings records when a household's save-the-date and invitation were mailed and returned
+//
+//	@Summary      admin-only operation to track what was mailed to a household
+//	@Description  Replaces when a household's save-the-date and invitation were mailed, and when they came back as undeliverable (dates that are left out are cleared). Mail can't be returned before it was mailed.
+//	@Tags         households
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Household ID" Format(uuid)
+//	@Param		  data body types.HouseholdMailingInput true "When each mailing was mailed and returned"
+//	@Success      202  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_HOUSEHOLDS
+//	@Router       /household/{id}/mailings [put]
+func (h *Handler) UpdateHouseholdMailings(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_HOUSEHOLDS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.HouseholdMailingInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err == nil {
+		for _, mailing := range []struct {
+			name                 string
+			mailedAt, returnedAt *time.Time
+		}{
+			{mailingSaveTheDate, input.SaveTheDateMailedAt, input.SaveTheDateReturnedAt},
+			{mailingInvitation, input.InvitationMailedAt, input.InvitationReturnedAt},
+		} {
+			if mailing.returnedAt != nil && (mailing.mailedAt == nil || mailing.returnedAt.Before(*mailing.mailedAt)) {
+				err = fmt.Errorf("%s_returned_at must not be before %s_mailed_at", mailing.name, mailing.name)
+				break
+			}
+		}
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	household := models.Household{
+		SaveTheDateMailedAt:   input.SaveTheDateMailedAt,
+		SaveTheDateReturnedAt: input.SaveTheDateReturnedAt,
+		InvitationMailedAt:    input.InvitationMailedAt,
+		InvitationReturnedAt:  input.InvitationReturnedAt,
+	}
+	household.ID = id
+	err = h.Households.UpdateHouseholdMailings(ctx, &household)
+	var updated *models.Household
+	if err == nil {
+		updated, err = h.Households.FindHouseholdById(ctx, id)
+	}
+	var summaries []types.HouseholdSummary
+	if err == nil && updated != nil {
+		summaries, err = h.householdSummaries(ctx, []models.Household{*updated})
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Household not found"
+	case err != nil:
+		log.Println("Error updating household mailings: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated household mailings"
+		response.Data.Households = append([]types.HouseholdSummary{}, summaries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Finds the households to print labels for, responding with an error (and returning false) if they can't be found
+func (h *Handler) mailingLabelHouseholdsOrError(c *gin.Context, ctx context.Context) ([]models.Household, bool) {
+	response := types.V1_API_RESPONSE{}
+	var status int
+
+	mailing := c.Query("mailing")
+	if !slices.Contains([]string{"", mailingSaveTheDate, mailingInvitation}, mailing) {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = fmt.Sprintf("mailing must be %s or %s", mailingSaveTheDate, mailingInvitation)
+		c.JSON(status, response)
+		return nil, false
+	}
+
+	households, err := h.findMailingLabelHouseholds(ctx, mailing)
+	if err != nil {
+		log.Println("Error creating mailing labels: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return nil, false
+	}
+	return households, true
+}
+
+// GetMailingLabelsCsv exports mailing labels as a CSV file
+//
+//	@Summary      downloads mailing labels as a CSV file
+//	@Description  Gets the name and address of every household (or, with `mailing`, only the households that haven't been sent it or whose copy came back) as a CSV file for mail merges, by name. The `Label` column has the whole label as it's printed, with the country on the last line of mail to other countries than `MAILING_HOME_COUNTRY`.
+//	@Tags         households
+//	@Produce      text/csv
+//	@Param        mailing  query string false "Only households that still need this mailing" Enums(save_the_date, invitation)
+//	@Success      200  {string}  string
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /household/labels.csv [get]
+func (h *Handler) GetMailingLabelsCsv(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	households, ok := h.mailingLabelHouseholdsOrError(c, ctx)
+	if !ok {
+		return
+	}
+
+	homeCountry := helper.MailingHomeCountry()
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Name", "Address line 1", "Address line 2", "Address line 3", "City", "Region", "Postal code", "Country", "Label"})
+	for _, household := range households {
+		w.Write([]string{
+			spreadsheetSafe(household.Name),
+			spreadsheetSafe(household.AddressLine1),
+			spreadsheetSafe(household.AddressLine2),
+			spreadsheetSafe(household.AddressLine3),
+			spreadsheetSafe(household.City),
+			spreadsheetSafe(household.Region),
+			spreadsheetSafe(household.PostalCode),
+			household.Country,
+			spreadsheetSafe(strings.Join(helper.MailingLabel(household, homeCountry), "\n")),
+		})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, mailingLabelFilename(c.Query("mailing"), "csv")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// GetMailingLabelsPdf exports mailing labels as a PDF to print on label sheets
+//
+//	@Summary      downloads printable mailing labels
+//	@Description  Gets a label for every household (or, with `mailing`, only the households that haven't been sent it or whose copy came back) as a PDF laid out for Avery 5160 sheets (30 labels per US Letter page), by name
+//	@Tags         households
+//	@Produce      application/pdf
+//	@Param        mailing  query string false "Only households that still need this mailing" Enums(save_the_date, invitation)
+//	@Success      200  {string}  string
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /household/labels.pdf [get]
+func (h *Handler) GetMailingLabelsPdf(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	households, ok := h.mailingLabelHouseholdsOrError(c, ctx)
+	if !ok {
+		return
+	}
+
+	homeCountry := helper.MailingHomeCountry()
+	labels := make([][]string, len(households))
+	for i, household := range households {
+		labels[i] = helper.MailingLabel(household, homeCountry)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, mailingLabelFilename(c.Query("mailing"), "pdf")))
+	c.Data(http.StatusOK, "application/pdf", helper.BuildMailingLabels(labels))
+}
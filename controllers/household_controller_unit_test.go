@@ -0,0 +1,171 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HouseholdController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	households := func(w *httptest.ResponseRecorder) []types.HouseholdSummary {
+		var householdResponse types.V1_API_RESPONSE_HOUSEHOLDS
+		json.Unmarshal([]byte(w.Body.Bytes()), &householdResponse)
+		return householdResponse.Data.Households
+	}
+	smiths := types.HouseholdInput{Name: "The Smiths", AddressLine1: "1 Main St", City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"}
+	muellers := types.HouseholdInput{Name: "Familie Müller", AddressLine1: "Hauptstraße 1", City: "Berlin", PostalCode: "10115", Country: "DE"}
+	addHousehold := func(router http.Handler, input types.HouseholdInput) types.HouseholdSummary {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/household", fixtures.Admin(), input)
+		assert.Equal(http.StatusCreated, w.Code)
+		return households(w)[0]
+	}
+	t.Setenv("MAILING_HOME_COUNTRY", "US")
+	t.Run("POST /api/v1/household - validates addresses for their country", func(t *testing.T) {
+		household := addHousehold(router, smiths)
+		assert.Equal([]string{"1 Main St", "Springfield, IL 62701"}, household.FormattedAddress)
+		assert.Equal([]string{"Hauptstraße 1", "10115 Berlin", "GERMANY"}, addHousehold(router, muellers).FormattedAddress)
+
+		noZip := smiths
+		noZip.PostalCode = ""
+		w := fixtures.Serve(t, router, "POST", "/api/v1/household", fixtures.Admin(), noZip)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		assert.Contains(w.Body.String(), "postal_code is required for addresses in US")
+		badCountry := smiths
+		badCountry.Country = "XX"
+		w = fixtures.Serve(t, router, "POST", "/api/v1/household", fixtures.Admin(), badCountry)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/household", fixtures.Planner(), smiths)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		moved := smiths
+		moved.AddressLine1 = "2 Elm St"
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+household.ID.String(), fixtures.Admin(), moved)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal("2 Elm St", households(w)[0].AddressLine1)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+uuid.New().String(), fixtures.Admin(), moved)
+		assert.Equal(http.StatusNotFound, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/household/"+household.ID.String(), fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/household", fixtures.Admin(), nil)
+		assert.Equal(1, len(households(w)))
+	})
+	t.Run("PUT /api/v1/household/:id/members - links users and invitees to one household", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		first := addHousehold(router, smiths)
+		second := addHousehold(router, muellers)
+		invitee := models.UserInvitee{InviterId: fixtures.Guest().ID, FirstName: "Plus", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+
+		members := types.HouseholdMembersInput{UserIds: []uuid.UUID{fixtures.Guest().ID, fixtures.Guest().ID}, InviteeIds: []uuid.UUID{invitee.ID}}
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/household/"+first.ID.String()+"/members", fixtures.Admin(), members)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal([]uuid.UUID{fixtures.Guest().ID}, households(w)[0].UserIds)
+		assert.Equal([]uuid.UUID{invitee.ID}, households(w)[0].InviteeIds)
+
+		// Adding the guest to another household moves them there
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+second.ID.String()+"/members", fixtures.Admin(), types.HouseholdMembersInput{UserIds: []uuid.UUID{fixtures.Guest().ID}})
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/household", fixtures.Admin(), nil)
+		for _, household := range households(w) {
+			if household.ID == first.ID {
+				assert.Equal(0, len(household.UserIds))
+				assert.Equal(1, len(household.InviteeIds))
+			} else {
+				assert.Equal(1, len(household.UserIds))
+			}
+		}
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+first.ID.String()+"/members", fixtures.Admin(), types.HouseholdMembersInput{UserIds: []uuid.UUID{uuid.New()}})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+first.ID.String()+"/members", fixtures.Admin(), types.HouseholdMembersInput{InviteeIds: []uuid.UUID{uuid.New()}})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+uuid.New().String()+"/members", fixtures.Admin(), types.HouseholdMembersInput{})
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("GET /api/v1/household/labels.csv - prints labels for households that still need a mailing", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		smithsId := addHousehold(router, smiths).ID
+		muellersId := addHousehold(router, muellers).ID
+		mailedAt := time.Now().Add(-48 * time.Hour)
+		returnedAt := time.Now().Add(-24 * time.Hour)
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/household/"+smithsId.String()+"/mailings", fixtures.Admin(), types.HouseholdMailingInput{SaveTheDateMailedAt: &mailedAt, InvitationMailedAt: &mailedAt})
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+muellersId.String()+"/mailings", fixtures.Admin(), types.HouseholdMailingInput{SaveTheDateMailedAt: &mailedAt, SaveTheDateReturnedAt: &returnedAt})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.NotNil(households(w)[0].SaveTheDateReturnedAt)
+
+		labels := func(query string) [][]string {
+			w := fixtures.Serve(t, router, "GET", "/api/v1/household/labels.csv"+query, fixtures.Planner(), nil)
+			assert.Equal(http.StatusOK, w.Code)
+			rows, _ := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+			return rows[1:]
+		}
+		all := labels("")
+		assert.Equal(2, len(all))
+		assert.Equal("Familie Müller", all[0][0])
+		assert.Equal("Familie Müller\nHauptstraße 1\n10115 Berlin\nGERMANY", all[0][8])
+		// The Müllers' save-the-date came back, and neither invitation has been mailed
+		saveTheDates := labels("?mailing=save_the_date")
+		assert.Equal(1, len(saveTheDates))
+		assert.Equal("Familie Müller", saveTheDates[0][0])
+		assert.Equal(1, len(labels("?mailing=invitation")))
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/household/labels.csv?mailing=thank_you", fixtures.Planner(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/household/labels.csv", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/household/labels.pdf?mailing=invitation", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Equal("application/pdf", w.Header().Get("Content-Type"))
+		assert.Contains(w.Header().Get("Content-Disposition"), "mailing-labels-invitation.pdf")
+		assert.Contains(w.Body.String(), "(Familie M\xfcller)")
+		assert.NotContains(w.Body.String(), "(The Smiths)")
+
+		// Mail can't come back before it went out
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+smithsId.String()+"/mailings", fixtures.Admin(), types.HouseholdMailingInput{InvitationReturnedAt: &returnedAt})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/household/"+uuid.New().String()+"/mailings", fixtures.Admin(), types.HouseholdMailingInput{})
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("Household routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/household", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/household", fixtures.Admin(), smiths),
+			fixtures.Serve(t, router, "PUT", "/api/v1/household/"+id, fixtures.Admin(), smiths),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/household/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/household/"+id+"/members", fixtures.Admin(), types.HouseholdMembersInput{}),
+			fixtures.Serve(t, router, "PUT", "/api/v1/household/"+id+"/mailings", fixtures.Admin(), types.HouseholdMailingInput{}),
+			fixtures.Serve(t, router, "GET", "/api/v1/household/labels.csv", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/household/labels.pdf", fixtures.Admin(), nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS gets the public keys tokens are signed with
+//
+//	@Summary      gets the public keys tokens are signed with
+//	@Description  Gets every key tokens are currently accepted from, as a JSON Web Key Set (RFC 7517). The key that signed a token is named by its `kid` header. This is served at `/.well-known/jwks.json` (outside of `/api/v1`).
+//	@Tags         auth
+//	@Produce      json
+//	@Success      200  {object}  helper.JWKS
+//	@Router       /.well-known/jwks.json [get]
+func (h *Handler) GetJWKS(c *gin.Context) {
+	// Keys are only rotated on restart, so clients can cache these for a while
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, helper.CurrentJWKS())
+}
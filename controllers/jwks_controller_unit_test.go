@@ -0,0 +1,35 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JWKSController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	t.Run("GET /.well-known/jwks.json - lists the key tokens are signed with", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+
+		var jwks helper.JWKS
+		json.Unmarshal([]byte(w.Body.Bytes()), &jwks)
+		assert.Equal(1, len(jwks.Keys))
+		token, _, _ := jwt.NewParser().ParseUnverified(fixtures.Token(t, fixtures.Guest()), &helper.CustomClaims{})
+		assert.Equal(jwks.Keys[0].Kid, token.Header["kid"])
+		assert.Equal(jwks.Keys[0].Alg, token.Header["alg"])
+	})
+}
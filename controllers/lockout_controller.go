@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLockouts gets the accounts that are locked out
+//
+//	@Summary      admin-only operation to get the locked out accounts
+//	@Description  Gets the accounts that can't log in right now because of too many failed login attempts (whether or not a user has the email)
+//	@Tags         auth
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_LOCKOUTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_LOCKOUTS
+//	@Router       /lockouts [get]
+func (h *Handler) GetLockouts(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_LOCKOUTS{}
+	var status int
+	throttles, err := h.LoginThrottles.FindLoginThrottles(ctx, models.LoginThrottleAccount)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding login throttles: ", err.Error())
+	} else {
+		status = http.StatusOK
+		now := time.Now()
+		response.Data.Lockouts = []types.Lockout{}
+		for _, t := range throttles {
+			if !helper.AccountLoginPolicy.IsLockedOut(t, now) {
+				continue
+			}
+			response.Data.Lockouts = append(response.Data.Lockouts, types.Lockout{
+				Email:         t.Key,
+				Failures:      t.Failures,
+				LastFailureAt: t.LastFailureAt,
+				LockedUntil:   t.LastFailureAt.Add(helper.AccountLoginPolicy.LockoutDuration),
+			})
+		}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteLockout unlock an account
+//
+//	@Summary      admin-only operation to unlock an account
+//	@Description  Resets the failed login attempts for the email so it can log in again right away
+//	@Tags         auth
+//	@Produce      json
+//	@Param 		  email  path string true "Email address"
+//	@Success      202  {object}  types.V1_API_RESPONSE_LOCKOUTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_LOCKOUTS
+//	@Router       /lockouts/{email} [delete]
+func (h *Handler) DeleteLockout(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_LOCKOUTS{}
+	var status int
+	err := h.LoginThrottles.ResetLoginThrottle(ctx, models.LoginThrottleAccount, strings.ToLower(c.Param("email")))
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error resetting login throttle: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Unlocked account"
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/middleware"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// How long a magic link works for
+const magicLinkTTL = 15 * time.Minute
+
+// How many magic links can be requested for a single email address (so nobody's inbox can be flooded)
+var magicLinkEmailRate = middleware.Rate{Requests: 3, Per: 15 * time.Minute}
+
+// The same response is sent whether or not a link was sent, so this can't be used to find out who has an account
+const magicLinkSentMessage = "If that email address belongs to an account that can log in with a link, a link has been sent to it"
+
+// RequestMagicLink emails a link the user can log in with instead of their password
+//
+// The link isn't signed; it carries a random token, and only the token's hash is stored. That makes each link
+// single-use and easy to revoke (by deleting its row) without a signing key, and a copy of the database can't be used
+// to make working links.
+//
+//	@Summary      Emails a magic login link
+//	@Description  Emails a single-use link that logs the user in without their password, if their role is allowed to use magic links. The response is the same whether or not a link was sent.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.MagicLinkInput true "The email address to send the link to"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      429  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /auth/magic-link [post]
+func (h *Handler) RequestMagicLink(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	var input types.MagicLinkInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	allowed, retryAfter, err := h.RateLimits.Take(ctx, "magic-link:email:"+strings.ToLower(input.Email), magicLinkEmailRate, time.Now())
+	if err != nil {
+		log.Println("Error checking rate limit: ", err.Error())
+	} else if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		status = http.StatusTooManyRequests
+		response.Message = "Too many login links requested for this email address. Try again later."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	u := models.User{Email: input.Email}
+	err = h.Users.FindUser(ctx, &u)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		status = http.StatusAccepted
+		response.Message = magicLinkSentMessage
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	var enabled bool
+	if err == nil {
+		enabled, err = h.MagicLinks.IsMagicLinkRole(ctx, u.Role)
+	}
+	if err == nil && enabled {
+		err = h.sendMagicLink(ctx, &u)
+	}
+	if err != nil {
+		log.Println("Error sending magic link: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = magicLinkSentMessage
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ConsumeMagicLink logs in a user with a magic link
+//
+//	@Summary      Logs in a user with a magic link
+//	@Description  Exchanges the token from a magic link for auth tokens (the same ones `/login` returns, including the `mfa_token` for users with two-factor authentication enabled). Each link can only be used once.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ConsumeMagicLinkInput true "The token from the magic link"
+//	@Success      202  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      400  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      403  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      500  {object}  types.V1_API_RESPONSE_AUTH
+//	@Router       /auth/magic-link/consume [post]
+func (h *Handler) ConsumeMagicLink(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_AUTH{}
+	var status int
+	var input types.ConsumeMagicLinkInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	now := time.Now()
+	link, err := h.MagicLinks.ConsumeMagicLink(ctx, helper.HashOneTimeToken(input.Token))
+	var u models.User
+	if err == nil && link != nil {
+		u.ID = link.UserId
+		err = h.Users.FindUser(ctx, &u)
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Println("Error consuming magic link: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	// The link's user may have been deleted since it was sent
+	case link == nil || err != nil || !now.Before(link.ExpiresAt):
+		status = http.StatusBadRequest
+		response.Message = "The login link is invalid or has expired."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	enabled, err := h.MagicLinks.IsMagicLinkRole(ctx, u.Role)
+	if err != nil {
+		log.Println("Error consuming magic link: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	// Admins may have turned magic links off for the user's role since the link was sent
+	if !enabled {
+		status = http.StatusForbidden
+		response.Message = "Logging in with a link isn't enabled for your account."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	// The link was opened from the user's inbox, which proves they own the address
+	if u.EmailVerifiedAt == nil {
+		if err := h.Users.VerifyEmail(ctx, &models.EmailVerification{UserId: u.ID}, now); err != nil {
+			log.Println("Error verifying email: ", err.Error())
+		}
+	}
+
+	// Links stand in for the password, not the second factor
+	mfaToken, err := h.startMfaChallenge(ctx, &u)
+	if err == nil && mfaToken != "" {
+		status = http.StatusAccepted
+		response.Message = mfaRequiredMessage
+		response.Data.MfaToken = mfaToken
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	var token, refreshToken string
+	if err == nil {
+		token, refreshToken, err = h.startSession(ctx, c, &u)
+	}
+	if err == nil {
+		err = h.sendAuthTokens(c, &response.Data, token, refreshToken)
+	}
+	if err != nil {
+		log.Println("Error generating tokens: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetMagicLinkRoles gets the roles allowed to log in with magic links
+//
+//	@Summary      admin-only operation to get the roles allowed to log in with magic links
+//	@Description  Gets the roles whose users can log in with a magic link instead of their password
+//	@Tags         settings
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+//	@Router       /settings/magic-link-roles [get]
+func (h *Handler) GetMagicLinkRoles(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MAGIC_LINK_ROLES{}
+	var status int
+	roles, err := h.MagicLinks.FindMagicLinkRoles(ctx)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding magic link roles: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.Roles = roles
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateMagicLinkRoles sets the roles allowed to log in with magic links
+//
+//	@Summary      admin-only operation to set the roles allowed to log in with magic links
+//	@Description  Replaces the roles whose users can log in with a magic link instead of their password (an empty list turns magic links off)
+//	@Tags         settings
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.UpdateMagicLinkRolesInput true "Every role that should be able to use magic links"
+//	@Success      202  {object}  types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+//	@Router       /settings/magic-link-roles [put]
+func (h *Handler) UpdateMagicLinkRoles(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MAGIC_LINK_ROLES{}
+	var status int
+	var input types.UpdateMagicLinkRolesInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	roles := slices.Clone(input.Roles)
+	slices.Sort(roles)
+	roles = slices.Compact(roles)
+	err := h.MagicLinks.ReplaceMagicLinkRoles(ctx, roles)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error replacing magic link roles: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Updated magic link roles"
+		response.Data.Roles = roles
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Emails a magic link to the user (replacing any unused link sent before)
+func (h *Handler) sendMagicLink(ctx context.Context, u *models.User) error {
+	token, tokenHash, err := helper.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+	err = h.MagicLinks.CreateMagicLink(ctx, &models.MagicLink{
+		UserId:    u.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return h.Mail.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below within %d minutes to log in. It only works once.\n\n%s\n\nIf you didn't ask for this link, you can ignore this email.\n",
+			u.FirstName,
+			int(magicLinkTTL.Minutes()),
+			appLink("/magic-link", token),
+		),
+	})
+}
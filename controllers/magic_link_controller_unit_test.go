@@ -0,0 +1,196 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MagicLinkController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	t.Setenv("APP_URL", "https://wedding.test")
+	tokenInLink := regexp.MustCompile(`https://wedding\.test/magic-link\?token=([A-Za-z0-9_-]+)`)
+	jsonBody := func(input any) *strings.Reader {
+		body, _ := json.Marshal(input)
+		return strings.NewReader(string(body))
+	}
+	requestLink := func(router http.Handler, email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/auth/magic-link", jsonBody(types.MagicLinkInput{Email: email}))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	consumeLink := func(router http.Handler, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/auth/magic-link/consume", jsonBody(types.ConsumeMagicLinkInput{Token: token}))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	t.Run("POST /api/v1/auth/magic-link - no link is sent unless the user's role is enabled", func(t *testing.T) {
+		h := NewHandler(fixtures.NewStore())
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+
+		w := requestLink(router, fixtures.Guest().Email)
+		assert.Equal(http.StatusAccepted, w.Code)
+		// Unknown addresses get the same response
+		unknown := requestLink(router, "nobody@fakedomain.com")
+		assert.Equal(http.StatusAccepted, unknown.Code)
+		assert.Equal(w.Body.String(), unknown.Body.String())
+		assert.Empty(sender.Sent())
+	})
+	t.Run("POST /api/v1/auth/magic-link - link logs the user in once", func(t *testing.T) {
+		store := fixtures.NewStore()
+		store.ReplaceMagicLinkRoles(context.Background(), []string{models.RoleGuest})
+		h := NewHandler(store)
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+
+		w := requestLink(router, fixtures.Guest().Email)
+		assert.Equal(http.StatusAccepted, w.Code)
+		sent := sender.Sent()
+		assert.Equal(1, len(sent))
+		assert.Equal(fixtures.Guest().Email, sent[0].To)
+		match := tokenInLink.FindStringSubmatch(sent[0].Body)
+		assert.Equal(2, len(match))
+
+		w = consumeLink(router, match[1])
+		assert.Equal(http.StatusAccepted, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		claims, msg := helper.ValidateToken(jsonResponse.Data.Token)
+		assert.Empty(msg)
+		assert.Equal(fixtures.Guest().ID, claims.ID)
+		// Opening the link proves the user owns the address
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.NotNil(users[0].EmailVerifiedAt)
+
+		w = consumeLink(router, match[1])
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/magic-link - links are limited per email address", func(t *testing.T) {
+		store := fixtures.NewStore()
+		store.ReplaceMagicLinkRoles(context.Background(), []string{models.RoleGuest})
+		h := NewHandler(store)
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+
+		for range magicLinkEmailRate.Requests {
+			assert.Equal(http.StatusAccepted, requestLink(router, fixtures.Guest().Email).Code)
+		}
+		w := requestLink(router, strings.ToUpper(fixtures.Guest().Email))
+		assert.Equal(http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(w.Header().Get("Retry-After"))
+		assert.Equal(magicLinkEmailRate.Requests, len(sender.Sent()))
+		// Other addresses aren't affected
+		assert.Equal(http.StatusAccepted, requestLink(router, fixtures.Planner().Email).Code)
+	})
+	t.Run("POST /api/v1/auth/magic-link - internal server error", func(t *testing.T) {
+		w := requestLink(router, fixtures.Guest().Email)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("POST /api/v1/auth/magic-link/consume - expired link is rejected", func(t *testing.T) {
+		store := fixtures.NewStore()
+		store.ReplaceMagicLinkRoles(context.Background(), []string{models.RoleGuest})
+		router := paveRoutes(NewHandler(store))
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateMagicLink(context.Background(), &models.MagicLink{
+			UserId:    fixtures.Guest().ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		w := consumeLink(router, token)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/magic-link/consume - role disabled since the link was sent is rejected", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateMagicLink(context.Background(), &models.MagicLink{
+			UserId:    fixtures.Guest().ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+
+		w := consumeLink(router, token)
+		assert.Equal(http.StatusForbidden, w.Code)
+	})
+	t.Run("POST /api/v1/auth/magic-link/consume - internal server error", func(t *testing.T) {
+		w := consumeLink(router, "some-token")
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/magic-link-roles - admin - can enable roles", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/magic-link-roles", jsonBody(types.UpdateMagicLinkRolesInput{
+			Roles: []string{models.RoleInvitee, models.RoleGuest, models.RoleGuest},
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/settings/magic-link-roles", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_MAGIC_LINK_ROLES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal([]string{models.RoleGuest, models.RoleInvitee}, jsonResponse.Data.Roles)
+	})
+	t.Run("PUT /api/v1/settings/magic-link-roles - admin - unknown role returns error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/magic-link-roles", jsonBody(types.UpdateMagicLinkRolesInput{Roles: []string{"SUPERUSER"}}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/magic-link-roles - planner - cannot change settings", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/magic-link-roles", jsonBody(types.UpdateMagicLinkRolesInput{Roles: []string{models.RolePlanner}}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/settings/magic-link-roles - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/settings/magic-link-roles", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
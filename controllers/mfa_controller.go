@@ -0,0 +1,516 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// How long a user has to enter their code after getting their password right
+const mfaChallengeTTL = 5 * time.Minute
+
+// How many codes can be entered for a login challenge before the user has to log in again
+const mfaChallengeMaxAttempts = 5
+
+const mfaRequiredMessage = "Enter the code from your authenticator app to finish logging in"
+
+// GetMfaStatus gets the logged in user's two-factor authentication status
+//
+//	@Summary      gets the logged in user's two-factor authentication status
+//	@Description  Gets whether the logged in user has two-factor authentication enabled, whether their role requires it and how many recovery codes they have left
+//	@Tags         auth
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_MFA_STATUS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MFA_STATUS
+//	@Router       /auth/mfa [get]
+func (h *Handler) GetMfaStatus(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MFA_STATUS{}
+	var status int
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	enrollment, err := h.Mfa.FindMfaEnrollment(ctx, uid)
+	var required bool
+	if err == nil {
+		required, err = h.Mfa.IsMfaRequiredRole(ctx, c.GetString("user_role"))
+	}
+	var remaining int64
+	if err == nil {
+		remaining, err = h.Mfa.CountRecoveryCodes(ctx, uid)
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding two-factor authentication status: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.Enabled = enrollment != nil && enrollment.ConfirmedAt != nil
+		response.Data.Required = required
+		response.Data.RecoveryCodesRemaining = remaining
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// StartMfaEnrollment starts setting up two-factor authentication for the logged in user
+//
+//	@Summary      starts setting up two-factor authentication
+//	@Description  Creates a secret for the logged in user's authenticator app. Two-factor authentication isn't enabled until a code from the app is confirmed at `/auth/mfa/enroll/confirm`. Starting again replaces a secret that wasn't confirmed.
+//	@Tags         auth
+//	@Produce      json
+//	@Success      202  {object}  types.V1_API_RESPONSE_MFA_ENROLLMENT
+//	@Failure      409  {object}  types.V1_API_RESPONSE_MFA_ENROLLMENT
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MFA_ENROLLMENT
+//	@Router       /auth/mfa/enroll [post]
+func (h *Handler) StartMfaEnrollment(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MFA_ENROLLMENT{}
+	var status int
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	existing, err := h.Mfa.FindMfaEnrollment(ctx, uid)
+	if err == nil && existing != nil && existing.ConfirmedAt != nil {
+		status = http.StatusConflict
+		response.Message = "Two-factor authentication is already enabled."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	var users []models.User
+	if err == nil {
+		users, err = h.Users.FindUsers(ctx, []uuid.UUID{uid})
+	}
+	if err == nil && len(users) == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+	var secret string
+	if err == nil {
+		secret, err = helper.NewTOTPSecret()
+	}
+	if err == nil {
+		err = h.Mfa.CreateMfaEnrollment(ctx, &models.MfaEnrollment{UserId: uid, Secret: secret})
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error starting two-factor authentication enrollment: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Confirm a code from your authenticator app to enable two-factor authentication"
+		response.Data.Secret = secret
+		response.Data.OtpauthUri = helper.TOTPURI(users[0].Email, secret)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ConfirmMfaEnrollment enables two-factor authentication for the logged in user
+//
+//	@Summary      enables two-factor authentication
+//	@Description  Enables two-factor authentication once the user proves their authenticator app works, and returns their recovery codes (these are only shown once)
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ConfirmMfaEnrollmentInput true "A code from the authenticator app"
+//	@Success      202  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      403  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Router       /auth/mfa/enroll/confirm [post]
+func (h *Handler) ConfirmMfaEnrollment(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_RECOVERY_CODES{}
+	var status int
+	var input types.ConfirmMfaEnrollmentInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	now := time.Now()
+	enrollment, err := h.Mfa.FindMfaEnrollment(ctx, uid)
+	if err != nil {
+		log.Println("Error finding two-factor authentication enrollment: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	if enrollment == nil || enrollment.ConfirmedAt != nil {
+		status = http.StatusBadRequest
+		response.Message = "Start setting up two-factor authentication first."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	step, ok := helper.ValidateTOTP(enrollment.Secret, input.Code, now)
+	if !ok {
+		status = http.StatusForbidden
+		response.Message = "Invalid code."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	codes, hashes, err := helper.NewRecoveryCodes()
+	if err == nil {
+		err = h.Mfa.ConfirmMfaEnrollment(ctx, uid, now, step, hashes)
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error confirming two-factor authentication enrollment: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Enabled two-factor authentication. Keep your recovery codes somewhere safe; they won't be shown again."
+		response.Data.RecoveryCodes = codes
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// RegenerateRecoveryCodes replaces the logged in user's recovery codes
+//
+//	@Summary      replaces the recovery codes
+//	@Description  Replaces the logged in user's recovery codes (the old ones stop working) and returns the new ones (these are only shown once)
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.MfaCodeInput true "A code from the authenticator app, or a recovery code"
+//	@Success      202  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      403  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_RECOVERY_CODES
+//	@Router       /auth/mfa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_RECOVERY_CODES{}
+	var status int
+	var input types.MfaCodeInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	status, response.Message = h.checkMfaCodeForLoggedInUser(ctx, uid, input)
+	if status != http.StatusOK {
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	codes, hashes, err := helper.NewRecoveryCodes()
+	if err == nil {
+		err = h.Mfa.ReplaceRecoveryCodes(ctx, uid, hashes)
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error replacing recovery codes: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Replaced recovery codes. Keep them somewhere safe; they won't be shown again."
+		response.Data.RecoveryCodes = codes
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DisableMfa turns off two-factor authentication for the logged in user
+//
+//	@Summary      disables two-factor authentication
+//	@Description  Removes the logged in user's authenticator and recovery codes. Users whose role requires two-factor authentication have to set it up again before they can use the rest of the API.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.MfaCodeInput true "A code from the authenticator app, or a recovery code"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      403  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /auth/mfa/disable [post]
+func (h *Handler) DisableMfa(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	var input types.MfaCodeInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	status, response.Message = h.checkMfaCodeForLoggedInUser(ctx, uid, input)
+	if status != http.StatusOK {
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	err := h.Mfa.DeleteMfaEnrollment(ctx, uid)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error disabling two-factor authentication: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Disabled two-factor authentication"
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// VerifyMfa finishes logging in a user who has two-factor authentication enabled
+//
+//	@Summary      finishes a two-factor login
+//	@Description  Exchanges the `mfa_token` returned when logging in (along with a code from the user's authenticator app, or one of their recovery codes) for auth tokens. After too many wrong codes the user has to log in again.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.VerifyMfaInput true "The challenge token from logging in, and a code"
+//	@Success      202  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      400  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      401  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      500  {object}  types.V1_API_RESPONSE_AUTH
+//	@Router       /auth/mfa/verify [post]
+func (h *Handler) VerifyMfa(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_AUTH{}
+	var status int
+	var input types.VerifyMfaInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	now := time.Now()
+	challenge, err := h.Mfa.FindMfaChallenge(ctx, helper.HashOneTimeToken(input.MfaToken))
+	var u models.User
+	var enrollment *models.MfaEnrollment
+	if err == nil && challenge != nil {
+		u.ID = challenge.UserId
+		err = h.Users.FindUser(ctx, &u)
+	}
+	if err == nil && challenge != nil {
+		enrollment, err = h.Mfa.FindMfaEnrollment(ctx, u.ID)
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Println("Error verifying two-factor authentication: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	// The user may have been deleted (or turned two-factor authentication off) since they entered their password
+	case challenge == nil || err != nil || enrollment == nil || enrollment.ConfirmedAt == nil ||
+		!now.Before(challenge.ExpiresAt) || challenge.Attempts >= mfaChallengeMaxAttempts:
+		status = http.StatusBadRequest
+		response.Message = "The login has expired. Log in again."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	// The attempt is counted before the code is checked, so codes sent at the same time can't get past the limit
+	err = h.Mfa.CountMfaChallengeAttempt(ctx, challenge)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && challenge.Attempts > mfaChallengeMaxAttempts) {
+		status = http.StatusBadRequest
+		response.Message = "The login has expired. Log in again."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	var valid bool
+	if err == nil {
+		valid, err = h.checkMfaCode(ctx, enrollment, input.MfaCodeInput, now)
+	}
+	if err == nil && !valid {
+		if challenge.Attempts >= mfaChallengeMaxAttempts {
+			err = h.Mfa.DeleteMfaChallenge(ctx, challenge.ID)
+		}
+		if err == nil {
+			status = http.StatusUnauthorized
+			response.Message = "Invalid code."
+			response.Status = status
+			c.JSON(status, response)
+			return
+		}
+	}
+	if err == nil {
+		err = h.Mfa.DeleteMfaChallenge(ctx, challenge.ID)
+	}
+	var token, refreshToken string
+	if err == nil {
+		token, refreshToken, err = h.startSession(ctx, c, &u)
+	}
+	if err == nil {
+		err = h.sendAuthTokens(c, &response.Data, token, refreshToken)
+	}
+	if err != nil {
+		log.Println("Error verifying two-factor authentication: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetMfaRequiredRoles gets the roles that must use two-factor authentication
+//
+//	@Summary      admin-only operation to get the roles that must use two-factor authentication
+//	@Description  Gets the roles whose users can't use the API (other than the routes to set up two-factor authentication) until they've set up two-factor authentication
+//	@Tags         settings
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+//	@Router       /settings/mfa-required-roles [get]
+func (h *Handler) GetMfaRequiredRoles(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MFA_REQUIRED_ROLES{}
+	var status int
+	roles, err := h.Mfa.FindMfaRequiredRoles(ctx)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding roles that require two-factor authentication: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.Roles = roles
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateMfaRequiredRoles sets the roles that must use two-factor authentication
+//
+//	@Summary      admin-only operation to set the roles that must use two-factor authentication
+//	@Description  Replaces the roles whose users can't use the API (other than the routes to set up two-factor authentication) until they've set up two-factor authentication. An empty list makes it optional for everyone.
+//	@Tags         settings
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.UpdateMfaRequiredRolesInput true "Every role that should have to use two-factor authentication"
+//	@Success      202  {object}  types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+//	@Router       /settings/mfa-required-roles [put]
+func (h *Handler) UpdateMfaRequiredRoles(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_MFA_REQUIRED_ROLES{}
+	var status int
+	var input types.UpdateMfaRequiredRolesInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	roles := slices.Clone(input.Roles)
+	slices.Sort(roles)
+	roles = slices.Compact(roles)
+	err := h.Mfa.ReplaceMfaRequiredRoles(ctx, roles)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error replacing roles that require two-factor authentication: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Updated roles that require two-factor authentication"
+		response.Data.Roles = roles
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Starts a login challenge if the user has two-factor authentication enabled; returns the challenge token, or an
+// empty string if the user can be logged in without one
+func (h *Handler) startMfaChallenge(ctx context.Context, u *models.User) (string, error) {
+	enrollment, err := h.Mfa.FindMfaEnrollment(ctx, u.ID)
+	if err != nil || enrollment == nil || enrollment.ConfirmedAt == nil {
+		return "", err
+	}
+	token, tokenHash, err := helper.NewOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+	err = h.Mfa.CreateMfaChallenge(ctx, &models.MfaChallenge{
+		UserId:    u.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Checks a code from the user's authenticator app (each can only be used once) or one of their recovery codes (which
+// is used up)
+func (h *Handler) checkMfaCode(ctx context.Context, enrollment *models.MfaEnrollment, input types.MfaCodeInput, now time.Time) (bool, error) {
+	if input.Code != "" {
+		step, ok := helper.ValidateTOTP(enrollment.Secret, input.Code, now)
+		if !ok {
+			return false, nil
+		}
+		return h.Mfa.UseMfaStep(ctx, enrollment.UserId, step)
+	}
+	return h.Mfa.UseRecoveryCode(ctx, enrollment.UserId, helper.HashRecoveryCode(input.RecoveryCode))
+}
+
+// Checks a code for a logged in user who has two-factor authentication enabled; returns http.StatusOK if the code is
+// valid, otherwise the status and message to respond with
+func (h *Handler) checkMfaCodeForLoggedInUser(ctx context.Context, uid uuid.UUID, input types.MfaCodeInput) (int, string) {
+	enrollment, err := h.Mfa.FindMfaEnrollment(ctx, uid)
+	var valid bool
+	if err == nil && enrollment != nil && enrollment.ConfirmedAt != nil {
+		valid, err = h.checkMfaCode(ctx, enrollment, input, time.Now())
+	}
+	switch {
+	case err != nil:
+		log.Println("Error checking two-factor authentication code: ", err.Error())
+		return http.StatusInternalServerError, "Internal server error"
+	case enrollment == nil || enrollment.ConfirmedAt == nil:
+		return http.StatusBadRequest, "Two-factor authentication isn't enabled."
+	case !valid:
+		return http.StatusForbidden, "Invalid code."
+	}
+	return http.StatusOK, ""
+}
@@ -0,0 +1,301 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MfaController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	jsonBody := func(input any) *strings.Reader {
+		body, _ := json.Marshal(input)
+		return strings.NewReader(string(body))
+	}
+	serve := func(router http.Handler, method string, path string, token string, input any) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var body *strings.Reader
+		if input != nil {
+			body = jsonBody(input)
+		} else {
+			body = strings.NewReader("")
+		}
+		req, _ := http.NewRequest(method, path, body)
+		if token != "" {
+			req.Header.Set("auth-token", token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// Sets up an authenticator for the user directly in the store; returns its secret and recovery codes
+	enroll := func(store repository.Store, u models.User) (string, []string) {
+		secret, _ := helper.NewTOTPSecret()
+		codes, hashes, _ := helper.NewRecoveryCodes()
+		store.CreateMfaEnrollment(context.Background(), &models.MfaEnrollment{UserId: u.ID, Secret: secret})
+		// Leave the current codes unused
+		store.ConfirmMfaEnrollment(context.Background(), u.ID, time.Now(), helper.TOTPStep(time.Now())-2, hashes)
+		return secret, codes
+	}
+	// Starts a login challenge for the user directly in the store; returns the challenge token
+	challenge := func(store repository.Store, u models.User, expiresAt time.Time) string {
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateMfaChallenge(context.Background(), &models.MfaChallenge{UserId: u.ID, TokenHash: tokenHash, ExpiresAt: expiresAt})
+		return token
+	}
+	codeAt := func(secret string, t time.Time) string {
+		code, _ := helper.TOTPCode(secret, helper.TOTPStep(t))
+		return code
+	}
+	t.Run("POST /api/v1/auth/mfa/enroll - enrolls once a code is confirmed", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		token := fixtures.Token(t, fixtures.Admin())
+
+		w := serve(router, "POST", "/api/v1/auth/mfa/enroll", token, nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var enrollResponse types.V1_API_RESPONSE_MFA_ENROLLMENT
+		json.Unmarshal([]byte(w.Body.Bytes()), &enrollResponse)
+		secret := enrollResponse.Data.Secret
+		assert.NotEmpty(secret)
+		assert.Contains(enrollResponse.Data.OtpauthUri, "secret="+secret)
+
+		w = serve(router, "GET", "/api/v1/auth/mfa", token, nil)
+		var statusResponse types.V1_API_RESPONSE_MFA_STATUS
+		json.Unmarshal([]byte(w.Body.Bytes()), &statusResponse)
+		assert.False(statusResponse.Data.Enabled)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll/confirm", token, types.ConfirmMfaEnrollmentInput{Code: "000000"})
+		assert.Equal(http.StatusForbidden, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll/confirm", token, types.ConfirmMfaEnrollmentInput{Code: codeAt(secret, time.Now())})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var codesResponse types.V1_API_RESPONSE_RECOVERY_CODES
+		json.Unmarshal([]byte(w.Body.Bytes()), &codesResponse)
+		assert.Equal(helper.RecoveryCodeCount, len(codesResponse.Data.RecoveryCodes))
+
+		w = serve(router, "GET", "/api/v1/auth/mfa", token, nil)
+		json.Unmarshal([]byte(w.Body.Bytes()), &statusResponse)
+		assert.True(statusResponse.Data.Enabled)
+		assert.Equal(int64(helper.RecoveryCodeCount), statusResponse.Data.RecoveryCodesRemaining)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/enroll", token, nil)
+		assert.Equal(http.StatusConflict, w.Code)
+	})
+	t.Run("POST /api/v1/auth/mfa/enroll/confirm - nothing to confirm returns error", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := serve(router, "POST", "/api/v1/auth/mfa/enroll/confirm", fixtures.Token(t, fixtures.Admin()), types.ConfirmMfaEnrollmentInput{Code: "123456"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/login - users with two-factor authentication get a challenge instead of tokens", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		secret, _ := enroll(store, fixtures.Admin())
+
+		w := serve(router, "POST", "/api/v1/login", "", types.UserLoginInput{Email: fixtures.Admin().Email, Password: models.TestUserPassword})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var loginResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		assert.Empty(loginResponse.Data.Token)
+		assert.Empty(loginResponse.Data.RefreshToken)
+		assert.NotEmpty(loginResponse.Data.MfaToken)
+
+		code := codeAt(secret, time.Now())
+		w = serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: loginResponse.Data.MfaToken, MfaCodeInput: types.MfaCodeInput{Code: code}})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var verifyResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &verifyResponse)
+		claims, msg := helper.ValidateToken(verifyResponse.Data.Token)
+		assert.Empty(msg)
+		assert.Equal(fixtures.Admin().ID, claims.ID)
+
+		// Challenges and codes only work once
+		w = serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: loginResponse.Data.MfaToken, MfaCodeInput: types.MfaCodeInput{Code: code}})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		mfaToken := challenge(store, fixtures.Admin(), time.Now().Add(time.Minute))
+		w = serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{Code: code}})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - recovery codes work once", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		_, codes := enroll(store, fixtures.Admin())
+
+		mfaToken := challenge(store, fixtures.Admin(), time.Now().Add(time.Minute))
+		w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{RecoveryCode: strings.ToUpper(codes[0])}})
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		mfaToken = challenge(store, fixtures.Admin(), time.Now().Add(time.Minute))
+		w = serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{RecoveryCode: codes[0]}})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		remaining, _ := store.CountRecoveryCodes(context.Background(), fixtures.Admin().ID)
+		assert.Equal(int64(helper.RecoveryCodeCount-1), remaining)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - too many wrong codes ends the challenge", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		secret, _ := enroll(store, fixtures.Admin())
+		mfaToken := challenge(store, fixtures.Admin(), time.Now().Add(time.Minute))
+
+		for range mfaChallengeMaxAttempts {
+			w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{Code: "000000"}})
+			assert.Equal(http.StatusUnauthorized, w.Code)
+		}
+		w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{Code: codeAt(secret, time.Now())}})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - codes sent at the same time can't get past the attempt limit", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		enroll(store, fixtures.Admin())
+		mfaToken := challenge(store, fixtures.Admin(), time.Now().Add(time.Minute))
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 3*mfaChallengeMaxAttempts)
+		for range 3 * mfaChallengeMaxAttempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{Code: "000000"}}).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		checked := 0
+		for code := range codes {
+			if code == http.StatusUnauthorized {
+				checked++
+			}
+		}
+		assert.Equal(mfaChallengeMaxAttempts, checked)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - expired challenge is rejected", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		secret, _ := enroll(store, fixtures.Admin())
+		mfaToken := challenge(store, fixtures.Admin(), time.Now().Add(-time.Minute))
+
+		w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: mfaToken, MfaCodeInput: types.MfaCodeInput{Code: codeAt(secret, time.Now())}})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - code or recovery code is required", func(t *testing.T) {
+		w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: "some-token"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/mfa/verify - internal server error", func(t *testing.T) {
+		w := serve(router, "POST", "/api/v1/auth/mfa/verify", "", types.VerifyMfaInput{MfaToken: "some-token", MfaCodeInput: types.MfaCodeInput{Code: "123456"}})
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("POST /api/v1/auth/magic-link/consume - users with two-factor authentication get a challenge instead of tokens", func(t *testing.T) {
+		store := fixtures.NewStore()
+		store.ReplaceMagicLinkRoles(context.Background(), []string{models.RoleAdmin})
+		router := paveRoutes(NewHandler(store))
+		enroll(store, fixtures.Admin())
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateMagicLink(context.Background(), &models.MagicLink{UserId: fixtures.Admin().ID, TokenHash: tokenHash, ExpiresAt: time.Now().Add(time.Minute)})
+
+		w := serve(router, "POST", "/api/v1/auth/magic-link/consume", "", types.ConsumeMagicLinkInput{Token: token})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Empty(jsonResponse.Data.Token)
+		assert.NotEmpty(jsonResponse.Data.MfaToken)
+	})
+	t.Run("POST /api/v1/auth/mfa/recovery-codes - replaces the recovery codes", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		secret, codes := enroll(store, fixtures.Admin())
+		token := fixtures.Token(t, fixtures.Admin())
+
+		w := serve(router, "POST", "/api/v1/auth/mfa/recovery-codes", token, types.MfaCodeInput{Code: "000000"})
+		assert.Equal(http.StatusForbidden, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/recovery-codes", token, types.MfaCodeInput{Code: codeAt(secret, time.Now())})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_RECOVERY_CODES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(helper.RecoveryCodeCount, len(jsonResponse.Data.RecoveryCodes))
+		used, _ := store.UseRecoveryCode(context.Background(), fixtures.Admin().ID, helper.HashRecoveryCode(codes[0]))
+		assert.False(used)
+	})
+	t.Run("POST /api/v1/auth/mfa/disable - removes the authenticator", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		_, codes := enroll(store, fixtures.Admin())
+		token := fixtures.Token(t, fixtures.Admin())
+
+		w := serve(router, "POST", "/api/v1/auth/mfa/disable", token, types.MfaCodeInput{RecoveryCode: "nope-nope-nope-nope"})
+		assert.Equal(http.StatusForbidden, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/disable", token, types.MfaCodeInput{RecoveryCode: codes[1]})
+		assert.Equal(http.StatusAccepted, w.Code)
+		enrollment, _ := store.FindMfaEnrollment(context.Background(), fixtures.Admin().ID)
+		assert.Nil(enrollment)
+
+		w = serve(router, "POST", "/api/v1/auth/mfa/disable", token, types.MfaCodeInput{RecoveryCode: codes[2]})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/mfa-required-roles - admin - required roles can only use auth routes until they enroll", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		token := fixtures.Token(t, fixtures.Admin())
+
+		w := serve(router, "PUT", "/api/v1/settings/mfa-required-roles", token, types.UpdateMfaRequiredRolesInput{Roles: []string{models.RoleAdmin}})
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		w = serve(router, "GET", "/api/v1/users", token, nil)
+		assert.Equal(http.StatusForbidden, w.Code)
+		w = serve(router, "GET", "/api/v1/settings/mfa-required-roles", token, nil)
+		assert.Equal(http.StatusForbidden, w.Code)
+		w = serve(router, "GET", "/api/v1/auth/mfa", token, nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var statusResponse types.V1_API_RESPONSE_MFA_STATUS
+		json.Unmarshal([]byte(w.Body.Bytes()), &statusResponse)
+		assert.True(statusResponse.Data.Required)
+		// Other roles aren't affected
+		w = serve(router, "GET", "/api/v1/user", fixtures.Token(t, fixtures.Guest()), nil)
+		assert.Equal(http.StatusOK, w.Code)
+
+		enroll(store, fixtures.Admin())
+		w = serve(router, "GET", "/api/v1/settings/mfa-required-roles", token, nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var rolesResponse types.V1_API_RESPONSE_MFA_REQUIRED_ROLES
+		json.Unmarshal([]byte(w.Body.Bytes()), &rolesResponse)
+		assert.Equal([]string{models.RoleAdmin}, rolesResponse.Data.Roles)
+	})
+	t.Run("PUT /api/v1/settings/mfa-required-roles - planner - cannot change settings", func(t *testing.T) {
+		w := serve(router, "PUT", "/api/v1/settings/mfa-required-roles", fixtures.Token(t, fixtures.Planner()), types.UpdateMfaRequiredRolesInput{Roles: []string{models.RolePlanner}})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/auth/mfa - internal server error", func(t *testing.T) {
+		w := serve(router, "GET", "/api/v1/auth/mfa", fixtures.Token(t, fixtures.Admin()), nil)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+	t.Run("GET /api/v1/user - failing to check the role's requirement is an internal server error", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg), "IsMfaRequiredRole")))
+		w := serve(router, "GET", "/api/v1/user", fixtures.Token(t, fixtures.Guest()), nil)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,135 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Permissions_Unit(t *testing.T) {
+	assert := assert.New(t)
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	t.Run("POST /api/v1/entree - planner - can create an entree", func(t *testing.T) {
+		entreeJson, _ := json.Marshal(models.Entree{OptionName: "Banana Steak"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/entree", strings.NewReader(string(entreeJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusCreated, w.Code)
+	})
+	t.Run("POST /api/v1/entree - guest - cannot create an entree", func(t *testing.T) {
+		entreeJson, _ := json.Marshal(models.Entree{OptionName: "Banana Steak"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/entree", strings.NewReader(string(entreeJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/users - planner - can read any user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/users?ids=%s", fixtures.Admin().ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, w.Code)
+	})
+	t.Run("GET /api/v1/users - guest - cannot read other users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/users?ids=%s,%s", fixtures.Guest().ID, fixtures.Admin().ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("DELETE /api/v1/user/:id - planner - cannot delete a user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s", fixtures.Guest().ID)
+		req, err := http.NewRequest("DELETE", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("GET /api/v1/user/:id/entrees - guest - cannot read another user's selection", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/entrees", fixtures.Admin().ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PATCH /api/v1/user/update-other - guest - cannot update another user by body ID", func(t *testing.T) {
+		inputJson, _ := json.Marshal(types.AdminUpdateUserInput{ID: fixtures.Admin().ID, FirstName: "Hacked"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PATCH", "/api/v1/user/update-other", strings.NewReader(string(inputJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PATCH /api/v1/user/update-other - guest - non-string body ID is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PATCH", "/api/v1/user/update-other", strings.NewReader(`{"id": 42}`))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PATCH /api/v1/user/update-other - guest - a body without an ID is rejected", func(t *testing.T) {
+		for _, body := range []string{"", "not json", `{"first_name": "Newname"}`} {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("PATCH", "/api/v1/user/update-other", strings.NewReader(body))
+			req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+			router.ServeHTTP(w, req)
+			assert.Nil(err)
+			assert.Equal(http.StatusUnauthorized, w.Code)
+		}
+	})
+	t.Run("PATCH /api/v1/user/update-other - guest - can update themselves by body ID, except for their email", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		inputJson, _ := json.Marshal(types.AdminUpdateUserInput{ID: fixtures.Guest().ID, FirstName: "Newname", Email: "new@guest.guest"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PATCH", "/api/v1/user/update-other", strings.NewReader(string(inputJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Equal("Newname", users[0].FirstName)
+		assert.Equal(fixtures.Guest().Email, users[0].Email)
+	})
+	t.Run("PATCH /api/v1/user/update-other - admin - can change another user's email", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		inputJson, _ := json.Marshal(types.AdminUpdateUserInput{ID: fixtures.Guest().ID, Email: "new@guest.guest"})
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PATCH", "/api/v1/user/update-other", strings.NewReader(string(inputJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Equal("new@guest.guest", users[0].Email)
+	})
+}
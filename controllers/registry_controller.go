@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func registryItemFromInput(input types.RegistryItemInput) models.RegistryItem {
+	return models.RegistryItem{
+		Name:            input.Name,
+		Description:     input.Description,
+		Url:             input.Url,
+		PriceCents:      input.PriceCents,
+		QuantityDesired: input.QuantityDesired,
+	}
+}
+
+// Gets when who claimed each registry item will be shown, if it's still hidden; the time is read from
+// REGISTRY_HIDE_CLAIMS_UNTIL (an RFC 3339 time, e.g. "2027-06-13T00:00:00-05:00"), and claims are never hidden without it
+func registryClaimsHiddenUntil(now time.Time) (*time.Time, error) {
+	value := os.Getenv("REGISTRY_HIDE_CLAIMS_UNTIL")
+	if value == "" {
+		return nil, nil
+	}
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil || !now.Before(until) {
+		return nil, err
+	}
+	return &until, nil
+}
+
+// Adds how much of each item has been claimed
+func (h *Handler) registryItemSummaries(ctx context.Context, items []models.RegistryItem) ([]types.RegistryItemSummary, error) {
+	totals, err := h.Registry.FindRegistryClaimTotals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []types.RegistryItemSummary{}
+	for _, item := range items {
+		summary := types.RegistryItemSummary{RegistryItem: item}
+		if i := slices.IndexFunc(totals, func(t models.RegistryClaimTotal) bool { return t.RegistryItemId == item.ID }); i >= 0 {
+			summary.Claimed = totals[i].Claimed
+		}
+		// Lowering the quantity desired doesn't take away claims, so more may be claimed than is still wanted
+		summary.Remaining = max(int64(item.QuantityDesired)-summary.Claimed, 0)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// GetRegistryItems gets every registry item
+//
+//	@Summary      gets the gift registry
+//	@Description  Gets every gift on the registry, in the order they were added, with how many have been claimed and how many are still wanted (but not who claimed them)
+//	@Tags         registry
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Router       /registry [get]
+func (h *Handler) GetRegistryItems(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_ITEMS{}
+	var status int
+
+	items, err := h.Registry.FindRegistryItems(ctx)
+	var summaries []types.RegistryItemSummary
+	if err == nil {
+		summaries, err = h.registryItemSummaries(ctx, items)
+	}
+	if err != nil {
+		log.Println("Error finding registry items: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Items = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateRegistryItem creates a registry item
+//
+//	@Summary      admin-only operation to add a gift to the registry
+//	@Description  Adds a gift to the registry and returns the new record's data to the caller
+//	@Tags         registry
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.RegistryItemInput true "The gift"
+//	@Success      201  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Router       /registry [post]
+func (h *Handler) CreateRegistryItem(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_ITEMS{}
+	var status int
+
+	var input types.RegistryItemInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	item := registryItemFromInput(input)
+	if err := h.Registry.CreateRegistryItem(ctx, &item); err != nil {
+		log.Println("Error creating registry item: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created registry item"
+		response.Data.Items = []types.RegistryItemSummary{{RegistryItem: item, Remaining: int64(item.QuantityDesired)}}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateRegistryItem replaces a registry item's details
+//
+//	@Summary      admin-only operation to update a registry item
+//	@Description  Replaces every detail of a gift on the registry. Lowering `quantity_desired` doesn't take away claims guests have already made.
+//	@Tags         registry
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Registry item ID" Format(uuid)
+//	@Param		  data body types.RegistryItemInput true "The gift"
+//	@Success      202  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_ITEMS
+//	@Router       /registry/{id} [put]
+func (h *Handler) UpdateRegistryItem(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_ITEMS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.RegistryItemInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	item := registryItemFromInput(input)
+	item.ID = id
+	err = h.Registry.UpdateRegistryItem(ctx, &item)
+	var summaries []types.RegistryItemSummary
+	if err == nil {
+		summaries, err = h.registryItemSummaries(ctx, []models.RegistryItem{item})
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Registry item not found"
+	case err != nil:
+		log.Println("Error updating registry item: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated registry item"
+		response.Data.Items = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteRegistryItem deletes a registry item
+//
+//	@Summary      admin-only operation to remove a gift from the registry
+//	@Description  Removes a gift from the registry along with every claim on it
+//	@Tags         registry
+//	@Produce      json
+//	@Param 		  id  path string true "Registry item ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /registry/{id} [delete]
+func (h *Handler) DeleteRegistryItem(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Registry.DeleteRegistryItem(ctx, id)
+	if err != nil {
+		log.Println("Error deleting registry item: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted registry item"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetRegistryClaimReport gets who claimed each registry item
+//
+//	@Summary      gets who is giving what
+//	@Description  Gets who claimed each gift on the registry and how many, for writing thank-you notes. When `REGISTRY_HIDE_CLAIMS_UNTIL` is set, claims are left out until then so the couple can be surprised.
+//	@Tags         registry
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIM_REPORT
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIM_REPORT
+//	@Router       /registry/claims [get]
+func (h *Handler) GetRegistryClaimReport(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_CLAIM_REPORT{}
+	var status int
+
+	hiddenUntil, err := registryClaimsHiddenUntil(time.Now())
+	claims := []models.RegistryClaimReportEntry{}
+	if err == nil && hiddenUntil == nil {
+		var report []models.RegistryClaimReportEntry
+		report, err = h.Registry.FindRegistryClaimReport(ctx)
+		claims = append(claims, report...)
+	}
+	if err != nil {
+		log.Println("Error creating registry claim report: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Claims = claims
+		response.Data.HiddenUntil = hiddenUntil
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetRegistryClaimsForLoggedInUser gets the registry items the logged in user has claimed
+//
+//	@Summary      gets the logged in user's registry claims
+//	@Description  Gets the gifts the logged in user has said they'll give, and how many of each
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Router       /user/registry [get]
+func (h *Handler) GetRegistryClaimsForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_CLAIMS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	claims, err := h.Registry.FindRegistryClaimsForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error finding registry claims: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Claims = append([]models.RegistryClaim{}, claims...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ClaimRegistryItemForLoggedInUser reserves some of a registry item for the logged in user to give
+//
+//	@Summary      claims a registry item
+//	@Description  Sets how many of a gift the logged in user will give, replacing their earlier claim (claim 0 to give it up). Claims can't reserve more than the couple asked for, even when guests claim at the same time.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Registry item ID" Format(uuid)
+//	@Param		  data body types.RegistryClaimInput true "How many to give"
+//	@Success      202  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Failure      409  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_REGISTRY_CLAIMS
+//	@Router       /user/registry/{id}/claim [put]
+func (h *Handler) ClaimRegistryItemForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_REGISTRY_CLAIMS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	itemId, err := uuid.Parse(c.Param("id"))
+	var input types.RegistryClaimInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	claim := models.RegistryClaim{
+		RegistryItemId: itemId,
+		UserId:         uid,
+		Quantity:       *input.Quantity,
+	}
+	err = h.Registry.SaveRegistryClaim(ctx, &claim)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Registry item not found"
+	case errors.Is(err, models.ErrRegistryItemUnavailable):
+		status = http.StatusConflict
+		response.Message = "Not enough of this item is left to claim"
+	case err != nil:
+		log.Println("Error saving registry claim: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case claim.Quantity == 0:
+		status = http.StatusAccepted
+		response.Message = "Removed claim"
+		response.Data.Claims = []models.RegistryClaim{}
+	default:
+		status = http.StatusAccepted
+		response.Message = "Saved claim"
+		response.Data.Claims = []models.RegistryClaim{claim}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
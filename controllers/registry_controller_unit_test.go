@@ -0,0 +1,179 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegistryController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	towels := types.RegistryItemInput{Name: "Bath towels", Url: "https://store.example/towels", PriceCents: 2500, QuantityDesired: 4}
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	addItem := func(router http.Handler, input types.RegistryItemInput) string {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Admin(), input)
+		assert.Equal(http.StatusCreated, w.Code)
+		var itemResponse types.V1_API_RESPONSE_REGISTRY_ITEMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &itemResponse)
+		return itemResponse.Data.Items[0].ID.String()
+	}
+	claim := func(router http.Handler, itemId string, user models.User, quantity int) *httptest.ResponseRecorder {
+		return fixtures.Serve(t, router, "PUT", "/api/v1/user/registry/"+itemId+"/claim", user, types.RegistryClaimInput{Quantity: &quantity})
+	}
+	registry := func(router http.Handler) []types.RegistryItemSummary {
+		w := fixtures.Serve(t, router, "GET", "/api/v1/registry", fixtures.Guest(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var itemResponse types.V1_API_RESPONSE_REGISTRY_ITEMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &itemResponse)
+		return itemResponse.Data.Items
+	}
+	t.Run("PUT /api/v1/user/registry/:id/claim - guests can't claim more than the couple asked for", func(t *testing.T) {
+		itemId := addItem(router, towels)
+
+		w := claim(router, itemId, fixtures.Guest(), 3)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = claim(router, itemId, fixtures.Planner(), 2)
+		assert.Equal(http.StatusConflict, w.Code)
+		w = claim(router, itemId, fixtures.Planner(), 1)
+		assert.Equal(http.StatusAccepted, w.Code)
+		items := registry(router)
+		assert.Equal(int64(4), items[0].Claimed)
+		assert.Equal(int64(0), items[0].Remaining)
+
+		// Guests can change their own claim without counting it twice
+		w = claim(router, itemId, fixtures.Guest(), 2)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = claim(router, itemId, fixtures.Guest(), 0)
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(int64(3), registry(router)[0].Remaining)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/registry", fixtures.Planner(), nil)
+		var claimResponse types.V1_API_RESPONSE_REGISTRY_CLAIMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &claimResponse)
+		assert.Equal(1, len(claimResponse.Data.Claims))
+		assert.Equal(1, claimResponse.Data.Claims[0].Quantity)
+
+		w = claim(router, uuid.New().String(), fixtures.Guest(), 1)
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/user/registry/"+itemId+"/claim", fixtures.Guest(), map[string]int{})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/user/registry/:id/claim - concurrent claims can't over-reserve", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		itemId := addItem(router, types.RegistryItemInput{Name: "Stand mixer", QuantityDesired: 1})
+		var wg sync.WaitGroup
+		codes := make(chan int, 3)
+		for _, user := range []models.User{fixtures.Guest(), fixtures.Planner(), fixtures.Admin()} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- claim(router, itemId, user, 1).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		accepted := 0
+		for code := range codes {
+			if code == http.StatusAccepted {
+				accepted++
+			}
+		}
+		assert.Equal(1, accepted)
+		assert.Equal(int64(1), registry(router)[0].Claimed)
+	})
+	t.Run("GET /api/v1/registry/claims - reports who claimed what, unless claims are hidden", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		itemId := addItem(router, towels)
+		claim(router, itemId, fixtures.Guest(), 2)
+
+		report := func() types.RegistryClaimReportData {
+			w := fixtures.Serve(t, router, "GET", "/api/v1/registry/claims", fixtures.Admin(), nil)
+			assert.Equal(http.StatusOK, w.Code)
+			var reportResponse types.V1_API_RESPONSE_REGISTRY_CLAIM_REPORT
+			json.Unmarshal([]byte(w.Body.Bytes()), &reportResponse)
+			return reportResponse.Data
+		}
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", "")
+		data := report()
+		assert.Nil(data.HiddenUntil)
+		assert.Equal(1, len(data.Claims))
+		assert.Equal("Bath towels", data.Claims[0].ItemName)
+		assert.Equal(fixtures.Guest().Email, data.Claims[0].Email)
+		assert.Equal(2, data.Claims[0].Quantity)
+
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", time.Now().Add(time.Hour).Format(time.RFC3339))
+		data = report()
+		assert.NotNil(data.HiddenUntil)
+		assert.Equal(0, len(data.Claims))
+		// Guests still see how much is left, just not who claimed it
+		assert.Equal(int64(2), registry(router)[0].Claimed)
+
+		t.Setenv("REGISTRY_HIDE_CLAIMS_UNTIL", time.Now().Add(-time.Hour).Format(time.RFC3339))
+		assert.Equal(1, len(report().Claims))
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/registry/claims", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PUT /api/v1/registry/:id - updates and deletes registry items", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		itemId := addItem(router, towels)
+		claim(router, itemId, fixtures.Guest(), 3)
+
+		// Lowering the quantity doesn't take away claims
+		fewer := towels
+		fewer.QuantityDesired = 2
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/registry/"+itemId, fixtures.Admin(), fewer)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var itemResponse types.V1_API_RESPONSE_REGISTRY_ITEMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &itemResponse)
+		assert.Equal(int64(3), itemResponse.Data.Items[0].Claimed)
+		assert.Equal(int64(0), itemResponse.Data.Items[0].Remaining)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/registry/"+uuid.New().String(), fixtures.Admin(), towels)
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Admin(), types.RegistryItemInput{Name: "Bath towels", Url: "not a url", QuantityDesired: 1})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Planner(), towels)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/registry/"+itemId, fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/registry", fixtures.Guest(), nil)
+		var claimResponse types.V1_API_RESPONSE_REGISTRY_CLAIMS
+		json.Unmarshal([]byte(w.Body.Bytes()), &claimResponse)
+		assert.Equal(0, len(claimResponse.Data.Claims))
+	})
+	t.Run("Registry routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		quantity := 1
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/registry", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/registry", fixtures.Admin(), towels),
+			fixtures.Serve(t, router, "PUT", "/api/v1/registry/"+id, fixtures.Admin(), towels),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/registry/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/registry/claims", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/user/registry", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/user/registry/"+id+"/claim", fixtures.Guest(), types.RegistryClaimInput{Quantity: &quantity}),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/middleware"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User-Agent headers longer than this are cut short before they're saved on a session
+const maxSessionUserAgentLength = 512
+
+// Starts a session for the user on the device making the request; returns the session's tokens
+func (h *Handler) startSession(ctx context.Context, c *gin.Context, u *models.User) (string, string, error) {
+	// The ID is chosen up front since the access token carries it
+	sessionId := uuid.New()
+	token, refreshToken, err := helper.GenerateAllTokens(u.Email, u.FirstName, u.LastName, u.Role, u.ID, u.TokenVersion, sessionId)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	session := models.Session{
+		BaseModel:        models.BaseModel{ID: sessionId},
+		UserId:           u.ID,
+		RefreshTokenHash: helper.HashOneTimeToken(refreshToken),
+		TokenVersion:     u.TokenVersion,
+		UserAgent:        sessionUserAgent(c),
+		IpAddress:        c.ClientIP(),
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(helper.RefreshTokenTTL),
+	}
+	if err := h.Sessions.CreateSession(ctx, &session); err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+func sessionUserAgent(c *gin.Context) string {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxSessionUserAgentLength {
+		userAgent = userAgent[:maxSessionUserAgentLength]
+	}
+	return userAgent
+}
+
+// RefreshTokens exchanges a refresh token for new tokens
+//
+//	@Summary      Refreshes a user's tokens
+//	@Description  Exchanges a refresh token (from the request body, or the refresh token cookie in cookie mode) for new tokens. Each refresh token can only be used once; the response includes the session's next one. Refresh tokens stop working when their session is revoked, expires or the user's password, email address or role changes.
+//	@Tags         auth
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.RefreshTokensInput false "The refresh token (leave it out in cookie mode)"
+//	@Success      202  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      400  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      401  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      429  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      500  {object}  types.V1_API_RESPONSE_AUTH
+//	@Router       /auth/refresh [post]
+func (h *Handler) RefreshTokens(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_AUTH{}
+	var status int
+
+	var input types.RefreshTokensInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+			status = http.StatusBadRequest
+			response.Status = status
+			response.Message = err.Error()
+			c.JSON(status, response)
+			return
+		}
+	}
+	refreshToken := input.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(middleware.RefreshTokenCookie)
+	}
+	unauthorized := func() {
+		status = http.StatusUnauthorized
+		response.Status = status
+		response.Message = "Invalid or expired refresh token"
+		c.JSON(status, response)
+	}
+	if refreshToken == "" {
+		unauthorized()
+		return
+	}
+	claims, msg := helper.ValidateToken(refreshToken)
+	if msg != "" || claims.TokenType != helper.RefreshToken {
+		unauthorized()
+		return
+	}
+
+	now := time.Now()
+	previousHash := helper.HashOneTimeToken(refreshToken)
+	session, err := h.Sessions.FindSession(ctx, previousHash)
+	u := models.User{}
+	if err == nil && session != nil {
+		u.ID = session.UserId
+		err = h.Users.FindUser(ctx, &u)
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Println("Error finding session: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	// Deleted users can't refresh their tokens, and sessions started before the user's password, email address or role
+	// changed have ended
+	case session == nil || err != nil || !session.ExpiresAt.After(now) || session.TokenVersion != u.TokenVersion:
+		unauthorized()
+		return
+	}
+
+	token, newRefreshToken, err := helper.GenerateAllTokens(u.Email, u.FirstName, u.LastName, u.Role, u.ID, u.TokenVersion, session.ID)
+	refreshed := false
+	if err == nil {
+		session.RefreshTokenHash = helper.HashOneTimeToken(newRefreshToken)
+		session.UserAgent = sessionUserAgent(c)
+		session.IpAddress = c.ClientIP()
+		session.LastUsedAt = now
+		session.ExpiresAt = now.Add(helper.RefreshTokenTTL)
+		refreshed, err = h.Sessions.RefreshSession(ctx, session, previousHash)
+	}
+	if err == nil && refreshed {
+		err = h.sendAuthTokens(c, &response.Data, token, newRefreshToken)
+	}
+	if err != nil {
+		log.Println("Error refreshing session: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+	// Someone else refreshed the session with the same token first
+	if !refreshed {
+		unauthorized()
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetSessionsForLoggedInUser lists the devices the logged in user is logged in on
+//
+//	@Summary      Lists the logged in user's sessions
+//	@Description  Lists the devices the logged in user is logged in on (most recently used first), along with the ID of the session making the request
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_SESSIONS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SESSIONS
+//	@Router       /user/sessions [get]
+func (h *Handler) GetSessionsForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SESSIONS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	tokenVersion, err := h.Users.FindTokenVersion(ctx, uid)
+	var sessions []models.Session
+	if err == nil {
+		sessions, err = h.Sessions.FindSessionsForUser(ctx, uid, tokenVersion, time.Now())
+	}
+	if err != nil {
+		log.Println("Error finding sessions: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusOK
+	response.Status = status
+	response.Data.Sessions = sessions
+	if sid, err := uuid.Parse(c.GetString("sid")); err == nil && sid != uuid.Nil {
+		response.Data.CurrentSessionId = &sid
+	}
+	c.JSON(status, response)
+}
+
+// DeleteSessionForLoggedInUser revokes one of the logged in user's sessions
+//
+//	@Summary      Revokes one of the logged in user's sessions
+//	@Description  Logs the logged in user out on one of their devices; the session's tokens stop working right away. This has no effect on sessions that belong to other users.
+//	@Tags         user
+//	@Produce      json
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Param 		  id  path string true "Session ID" Format(uuid)
+//	@Router       /user/sessions/{id} [delete]
+func (h *Handler) DeleteSessionForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	sessionId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Sessions.DeleteSessionForUser(ctx, sessionId, uid)
+	if err != nil {
+		log.Println("Error deleting session: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Data.DeletedRecords = int(result)
+	response.Status = status
+	c.JSON(status, response)
+}
@@ -0,0 +1,197 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	serve := func(router http.Handler, method string, path string, token string, input any) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := ""
+		if input != nil {
+			encoded, _ := json.Marshal(input)
+			body = string(encoded)
+		}
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("User-Agent", "Wedding Browser/1.0")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// Logs in as the guest, returning the session's tokens
+	login := func(router http.Handler) types.AuthDetails {
+		w := serve(router, "POST", "/api/v1/login", "", types.UserLoginInput{Email: fixtures.Guest().Email, Password: models.TestUserPassword})
+		var loginResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &loginResponse)
+		return loginResponse.Data
+	}
+	sessions := func(router http.Handler, token string) types.SessionData {
+		w := serve(router, "GET", "/api/v1/user/sessions", token, nil)
+		var sessionsResponse types.V1_API_RESPONSE_SESSIONS
+		json.Unmarshal([]byte(w.Body.Bytes()), &sessionsResponse)
+		return sessionsResponse.Data
+	}
+	t.Run("GET /api/v1/user/sessions - lists the sessions started by logging in", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		first := login(router)
+		second := login(router)
+
+		data := sessions(router, second.Token)
+		assert.Equal(2, len(data.Sessions))
+		assert.Equal(data.Sessions[0].ID, *data.CurrentSessionId)
+		assert.Equal("Wedding Browser/1.0", data.Sessions[0].UserAgent)
+		assert.Equal(fixtures.Guest().ID, data.Sessions[0].UserId)
+
+		data = sessions(router, first.Token)
+		assert.Equal(data.Sessions[1].ID, *data.CurrentSessionId)
+	})
+	t.Run("POST /api/v1/auth/refresh - refresh tokens can only be used once", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		tokens := login(router)
+
+		w := serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: tokens.RefreshToken})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var refreshResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &refreshResponse)
+		assert.NotEmpty(refreshResponse.Data.Token)
+		assert.NotEqual(tokens.RefreshToken, refreshResponse.Data.RefreshToken)
+		// The new tokens belong to the same session
+		assert.Equal(1, len(sessions(router, refreshResponse.Data.Token).Sessions))
+
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: tokens.RefreshToken})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: refreshResponse.Data.RefreshToken})
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
+	t.Run("POST /api/v1/auth/refresh - access tokens and missing tokens are rejected", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: fixtures.Token(t, fixtures.Guest())})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		var refreshResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &refreshResponse)
+		assert.Equal("Invalid or expired refresh token", refreshResponse.Message)
+
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("POST /api/v1/auth/refresh - sessions end when the password changes", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		other := login(router)
+		tokens := login(router)
+
+		w := serve(router, "POST", "/api/v1/user/password", tokens.Token, types.ChangePasswordInput{CurrentPassword: models.TestUserPassword, NewPassword: "Wed!ding#2O26"})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var passwordResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &passwordResponse)
+
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: other.RefreshToken})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		// Only the session started by changing the password is left
+		data := sessions(router, passwordResponse.Data.Token)
+		assert.Equal(1, len(data.Sessions))
+		assert.Equal(data.Sessions[0].ID, *data.CurrentSessionId)
+	})
+	t.Run("DELETE /api/v1/user/sessions/:id - revoked sessions can't be used", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		revoked := login(router)
+		current := login(router)
+		revokedId := sessions(router, revoked.Token).CurrentSessionId
+
+		w := serve(router, "DELETE", "/api/v1/user/sessions/"+revokedId.String(), current.Token, nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(1, deleteResponse.Data.DeletedRecords)
+
+		w = serve(router, "GET", "/api/v1/user/sessions", revoked.Token, nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: revoked.RefreshToken})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		assert.Equal(1, len(sessions(router, current.Token).Sessions))
+	})
+	t.Run("DELETE /api/v1/user/sessions/:id - other users' sessions aren't affected", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		tokens := login(router)
+		sessionId := sessions(router, tokens.Token).CurrentSessionId
+
+		w := serve(router, "DELETE", "/api/v1/user/sessions/"+sessionId.String(), fixtures.Token(t, fixtures.Admin()), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(0, deleteResponse.Data.DeletedRecords)
+
+		w = serve(router, "DELETE", "/api/v1/user/sessions/not-a-uuid", tokens.Token, nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/auth/logout - ends the session so its tokens stop working", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		other := login(router)
+		tokens := login(router)
+
+		w := serve(router, "POST", "/api/v1/auth/logout", tokens.Token, nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: tokens.RefreshToken})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = serve(router, "GET", "/api/v1/user/sessions", tokens.Token, nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		// The user's other devices stay logged in
+		assert.Equal(1, len(sessions(router, other.Token).Sessions))
+
+		// Without an access token, the refresh token finds the session
+		w = serve(router, "POST", "/api/v1/auth/logout", "", types.RefreshTokensInput{RefreshToken: other.RefreshToken})
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: other.RefreshToken})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		// Logging out without a session still works (e.g., to clear expired cookies)
+		w = serve(router, "POST", "/api/v1/auth/logout", "", nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
+	t.Run("Session routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+		token := fixtures.Token(t, fixtures.Guest())
+		for _, w := range []*httptest.ResponseRecorder{
+			serve(router, "GET", "/api/v1/user/sessions", token, nil),
+			serve(router, "DELETE", "/api/v1/user/sessions/"+fixtures.Guest().ID.String(), token, nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal(apiErrMsg, errResponse.Message)
+		}
+
+		store := fixtures.NewStore()
+		tokens := login(paveRoutes(NewHandler(store)))
+		router = paveRoutes(NewHandler(&repositorytest.FailingStore{Store: store, Err: errors.New(errMsg), Methods: []string{"FindSession"}}))
+		w := serve(router, "POST", "/api/v1/auth/refresh", "", types.RefreshTokensInput{RefreshToken: tokens.RefreshToken})
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		router = paveRoutes(NewHandler(&repositorytest.FailingStore{Store: store, Err: errors.New(errMsg), Methods: []string{"IsActiveSession"}}))
+		w = serve(router, "GET", "/api/v1/user/sessions", tokens.Token, nil)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		router = paveRoutes(NewHandler(&repositorytest.FailingStore{Store: store, Err: errors.New(errMsg), Methods: []string{"DeleteSessionForUser"}}))
+		w = serve(router, "POST", "/api/v1/auth/logout", tokens.Token, nil)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func shuttleRunFromInput(input types.ShuttleRunInput) models.ShuttleRun {
+	return models.ShuttleRun{
+		Route:     input.Route,
+		DepartsAt: input.DepartsAt,
+		Capacity:  input.Capacity,
+		Notes:     input.Notes,
+	}
+}
+
+// Adds the number of people seated on and waitlisted for each run
+func (h *Handler) shuttleRunSummaries(ctx context.Context, runs []models.ShuttleRun) ([]types.ShuttleRunSummary, error) {
+	headcounts, err := h.Shuttles.FindShuttleHeadcounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []types.ShuttleRunSummary{}
+	for _, run := range runs {
+		summary := types.ShuttleRunSummary{ShuttleRun: run}
+		if i := slices.IndexFunc(headcounts, func(hc models.ShuttleHeadcount) bool { return hc.ShuttleRunId == run.ID }); i >= 0 {
+			summary.Seated = headcounts[i].Seated
+			summary.Waitlisted = headcounts[i].Waitlisted
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// Spreadsheet apps run cells that start with these characters as formulas
+const spreadsheetFormulaPrefixes = "=+-@\t\r"
+
+// Keeps a value guests typed in (e.g., their name) from being run as a formula when an export is opened in a spreadsheet
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune(spreadsheetFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// GetShuttleRuns gets every shuttle run
+//
+//	@Summary      gets the shuttle runs
+//	@Description  Gets every shuttle run, earliest departure first, with the number of people seated on and waitlisted for each
+//	@Tags         shuttles
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Router       /shuttle [get]
+func (h *Handler) GetShuttleRuns(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SHUTTLE_RUNS{}
+	var status int
+
+	runs, err := h.Shuttles.FindShuttleRuns(ctx)
+	var summaries []types.ShuttleRunSummary
+	if err == nil {
+		summaries, err = h.shuttleRunSummaries(ctx, runs)
+	}
+	if err != nil {
+		log.Println("Error finding shuttle runs: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Runs = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateShuttleRun creates a shuttle run
+//
+//	@Summary      admin-only operation to create a shuttle run
+//	@Description  Creates a shuttle run and returns the new record's data to the caller
+//	@Tags         shuttles
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ShuttleRunInput true "The shuttle run"
+//	@Success      201  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Router       /shuttle [post]
+func (h *Handler) CreateShuttleRun(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SHUTTLE_RUNS{}
+	var status int
+
+	var input types.ShuttleRunInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	run := shuttleRunFromInput(input)
+	if err := h.Shuttles.CreateShuttleRun(ctx, &run); err != nil {
+		log.Println("Error creating shuttle run: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created shuttle run"
+		response.Data.Runs = []types.ShuttleRunSummary{{ShuttleRun: run}}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateShuttleRun replaces a shuttle run's details
+//
+//	@Summary      admin-only operation to update a shuttle run
+//	@Description  Replaces every detail of a shuttle run. Raising the capacity gives the new seats to waitlisted riders in the order they signed up; lowering it doesn't take seats from riders who already have them.
+//	@Tags         shuttles
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Shuttle run ID" Format(uuid)
+//	@Param		  data body types.ShuttleRunInput true "The shuttle run"
+//	@Success      202  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SHUTTLE_RUNS
+//	@Router       /shuttle/{id} [put]
+func (h *Handler) UpdateShuttleRun(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SHUTTLE_RUNS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.ShuttleRunInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	run := shuttleRunFromInput(input)
+	run.ID = id
+	err = h.Shuttles.UpdateShuttleRun(ctx, &run)
+	var summaries []types.ShuttleRunSummary
+	if err == nil {
+		summaries, err = h.shuttleRunSummaries(ctx, []models.ShuttleRun{run})
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Shuttle run not found"
+	case err != nil:
+		log.Println("Error updating shuttle run: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated shuttle run"
+		response.Data.Runs = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteShuttleRun deletes a shuttle run
+//
+//	@Summary      admin-only operation to delete a shuttle run
+//	@Description  Deletes a shuttle run along with everyone signed up for it
+//	@Tags         shuttles
+//	@Produce      json
+//	@Param 		  id  path string true "Shuttle run ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /shuttle/{id} [delete]
+func (h *Handler) DeleteShuttleRun(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Shuttles.DeleteShuttleRun(ctx, id)
+	if err != nil {
+		log.Println("Error deleting shuttle run: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted shuttle run"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetShuttleManifest exports the people signed up for a shuttle run as a CSV file
+//
+//	@Summary      downloads a shuttle run's manifest
+//	@Description  Gets the people signed up for a shuttle run as a CSV file for the driver: seated riders first, then the waitlist, each in the order they signed up, with the email address of whoever signed them up
+//	@Tags         shuttles
+//	@Produce      text/csv
+//	@Param 		  id  path string true "Shuttle run ID" Format(uuid)
+//	@Success      200  {string}  string
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      404  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /shuttle/{id}/manifest [get]
+func (h *Handler) GetShuttleManifest(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	run, err := h.Shuttles.FindShuttleRunById(ctx, id)
+	var manifest []models.ShuttleManifestEntry
+	if err == nil && run != nil {
+		manifest, err = h.Shuttles.FindShuttleManifest(ctx, id)
+	}
+	switch {
+	case err != nil:
+		log.Println("Error creating shuttle manifest: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case run == nil:
+		status = http.StatusNotFound
+		response.Message = "Shuttle run not found"
+	}
+	if status != 0 {
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Status", "Position", "First name", "Last name", "Signed up by", "Signed up at"})
+	seat, place := 0, 0
+	for _, entry := range manifest {
+		rowStatus, position := "Seated", 0
+		if entry.Waitlisted {
+			place++
+			rowStatus, position = "Waitlisted", place
+		} else {
+			seat++
+			position = seat
+		}
+		w.Write([]string{
+			rowStatus,
+			fmt.Sprint(position),
+			spreadsheetSafe(entry.FirstName),
+			spreadsheetSafe(entry.LastName),
+			spreadsheetSafe(entry.Email),
+			entry.SignedUpAt.UTC().Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="shuttle-%s.csv"`, run.DepartsAt.UTC().Format("20060102-1504")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// GetShuttlesForLoggedInUser gets the people the logged in user has signed up for shuttles
+//
+//	@Summary      gets the logged in user's shuttle sign-ups
+//	@Description  Gets the people the logged in user has signed up (themselves and their invitees) across every shuttle run, and whether each has a seat or is waitlisted
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Router       /user/shuttles [get]
+func (h *Handler) GetShuttlesForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SHUTTLE_RIDERS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	riders, err := h.Shuttles.FindShuttleRidersForUser(ctx, uid)
+	if err != nil {
+		log.Println("Error finding shuttle sign-ups: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Riders = append([]models.ShuttleRider{}, riders...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// SignUpForShuttleForLoggedInUser signs the logged in user and their invitees up for a shuttle run
+//
+//	@Summary      signs up for a shuttle run
+//	@Description  Replaces the people the logged in user has signed up for a shuttle run (themselves and any of their invitees). People who are still signed up keep their seat or place on the waitlist; new riders get any free seats and are waitlisted once the run is full. Sign up nobody to cancel.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Shuttle run ID" Format(uuid)
+//	@Param		  data body types.ShuttleSignupInput true "Who is riding"
+//	@Success      202  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SHUTTLE_RIDERS
+//	@Router       /user/shuttles/{id}/signup [put]
+func (h *Handler) SignUpForShuttleForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SHUTTLE_RIDERS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	runId, err := uuid.Parse(c.Param("id"))
+	var input types.ShuttleSignupInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	riderIds := []uuid.UUID{}
+	if input.Self {
+		riderIds = append(riderIds, uid)
+	}
+	ownsInvitees := true
+	if len(input.InviteeIds) > 0 {
+		var invitees []models.UserInvitee
+		invitees, err = h.Invitees.FindInviteesForUser(ctx, uid)
+		for _, inviteeId := range input.InviteeIds {
+			if !slices.ContainsFunc(invitees, func(i models.UserInvitee) bool { return i.ID == inviteeId }) {
+				ownsInvitees = false
+			} else if !slices.Contains(riderIds, inviteeId) {
+				riderIds = append(riderIds, inviteeId)
+			}
+		}
+	}
+	if err == nil && ownsInvitees {
+		err = h.Shuttles.ReplaceShuttleRidersForUser(ctx, runId, uid, riderIds)
+	}
+	riders := []models.ShuttleRider{}
+	if err == nil && ownsInvitees {
+		var signups []models.ShuttleRider
+		signups, err = h.Shuttles.FindShuttleRidersForUser(ctx, uid)
+		for _, r := range signups {
+			if r.ShuttleRunId == runId {
+				riders = append(riders, r)
+			}
+		}
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Shuttle run not found"
+	case err != nil:
+		log.Println("Error saving shuttle sign-up: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	case !ownsInvitees:
+		status = http.StatusNotFound
+		response.Message = "Invitee not found"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Saved sign-up"
+		if slices.ContainsFunc(riders, func(r models.ShuttleRider) bool { return r.Waitlisted }) {
+			response.Message = "Saved sign-up; the shuttle is full, so some riders are waitlisted"
+		}
+		response.Data.Riders = riders
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
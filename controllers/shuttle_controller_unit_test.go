@@ -0,0 +1,196 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ShuttleController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	departsAt := time.Date(2027, time.June, 12, 15, 30, 0, 0, time.UTC)
+	store := fixtures.NewStore()
+	router := paveRoutes(NewHandler(store))
+	addRun := func(router http.Handler, capacity int) string {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/shuttle", fixtures.Admin(), types.ShuttleRunInput{Route: "The Grand Hotel to The Garden", DepartsAt: departsAt, Capacity: capacity})
+		assert.Equal(http.StatusCreated, w.Code)
+		var runResponse types.V1_API_RESPONSE_SHUTTLE_RUNS
+		json.Unmarshal([]byte(w.Body.Bytes()), &runResponse)
+		return runResponse.Data.Runs[0].ID.String()
+	}
+	signUp := func(router http.Handler, runId string, user models.User, input types.ShuttleSignupInput) types.V1_API_RESPONSE_SHUTTLE_RIDERS {
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/user/shuttles/"+runId+"/signup", user, input)
+		var ridersResponse types.V1_API_RESPONSE_SHUTTLE_RIDERS
+		json.Unmarshal([]byte(w.Body.Bytes()), &ridersResponse)
+		return ridersResponse
+	}
+	headcount := func(router http.Handler) types.ShuttleRunSummary {
+		w := fixtures.Serve(t, router, "GET", "/api/v1/shuttle", fixtures.Guest(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var runResponse types.V1_API_RESPONSE_SHUTTLE_RUNS
+		json.Unmarshal([]byte(w.Body.Bytes()), &runResponse)
+		return runResponse.Data.Runs[0]
+	}
+	t.Run("PUT /api/v1/user/shuttles/:id/signup - riders are waitlisted once the run is full", func(t *testing.T) {
+		runId := addRun(router, 2)
+		invitee := models.UserInvitee{InviterId: fixtures.Guest().ID, FirstName: "Plus", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+
+		signupResponse := signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{Self: true, InviteeIds: []uuid.UUID{invitee.ID}})
+		assert.Equal(http.StatusAccepted, signupResponse.Status)
+		assert.Equal(2, len(signupResponse.Data.Riders))
+		assert.False(signupResponse.Data.Riders[0].Waitlisted)
+		assert.False(signupResponse.Data.Riders[1].Waitlisted)
+
+		signupResponse = signUp(router, runId, fixtures.Planner(), types.ShuttleSignupInput{Self: true})
+		assert.Equal(http.StatusAccepted, signupResponse.Status)
+		assert.True(signupResponse.Data.Riders[0].Waitlisted)
+		assert.Contains(signupResponse.Message, "waitlisted")
+		run := headcount(router)
+		assert.Equal(int64(2), run.Seated)
+		assert.Equal(int64(1), run.Waitlisted)
+
+		// Dropping the invitee frees their seat for the waitlist, while the guest keeps theirs
+		signupResponse = signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{Self: true})
+		assert.Equal(1, len(signupResponse.Data.Riders))
+		assert.False(signupResponse.Data.Riders[0].Waitlisted)
+		w := fixtures.Serve(t, router, "GET", "/api/v1/user/shuttles", fixtures.Planner(), nil)
+		var ridersResponse types.V1_API_RESPONSE_SHUTTLE_RIDERS
+		json.Unmarshal([]byte(w.Body.Bytes()), &ridersResponse)
+		assert.False(ridersResponse.Data.Riders[0].Waitlisted)
+
+		signupResponse = signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{})
+		assert.Equal(http.StatusAccepted, signupResponse.Status)
+		assert.Equal(0, len(signupResponse.Data.Riders))
+		assert.Equal(int64(1), headcount(router).Seated)
+	})
+	t.Run("PUT /api/v1/user/shuttles/:id/signup - concurrent sign-ups can't overfill a run", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		runId := addRun(router, 3)
+		invitees := make([]uuid.UUID, 5)
+		for i := range invitees {
+			invitee := models.UserInvitee{InviterId: fixtures.Guest().ID, FirstName: "Guest", LastName: "Invitee"}
+			store.CreateUserInvitee(ctx, &invitee)
+			invitees[i] = invitee.ID
+		}
+		var wg sync.WaitGroup
+		for _, user := range []models.User{fixtures.Admin(), fixtures.Planner()} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fixtures.Serve(t, router, "PUT", "/api/v1/user/shuttles/"+runId+"/signup", user, types.ShuttleSignupInput{Self: true})
+			}()
+		}
+		for i := range invitees {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fixtures.Serve(t, router, "PUT", "/api/v1/user/shuttles/"+runId+"/signup", fixtures.Guest(), types.ShuttleSignupInput{InviteeIds: invitees[i : i+1]})
+			}()
+		}
+		wg.Wait()
+		assert.Equal(int64(3), headcount(router).Seated)
+	})
+	t.Run("PUT /api/v1/shuttle/:id - raising the capacity seats the waitlist", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		runId := addRun(router, 1)
+		signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{Self: true})
+		signUp(router, runId, fixtures.Planner(), types.ShuttleSignupInput{Self: true})
+
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/shuttle/"+runId, fixtures.Admin(), types.ShuttleRunInput{Route: "The Grand Hotel to The Garden", DepartsAt: departsAt, Capacity: 2})
+		assert.Equal(http.StatusAccepted, w.Code)
+		var runResponse types.V1_API_RESPONSE_SHUTTLE_RUNS
+		json.Unmarshal([]byte(w.Body.Bytes()), &runResponse)
+		assert.Equal(int64(2), runResponse.Data.Runs[0].Seated)
+		assert.Equal(int64(0), runResponse.Data.Runs[0].Waitlisted)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/shuttle/"+uuid.New().String(), fixtures.Admin(), types.ShuttleRunInput{Route: "Nowhere", DepartsAt: departsAt, Capacity: 2})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/shuttle", fixtures.Admin(), types.ShuttleRunInput{Route: "Nowhere", DepartsAt: departsAt})
+		assert.Equal(http.StatusBadRequest, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/shuttle/"+runId, fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/user/shuttles", fixtures.Guest(), nil)
+		var ridersResponse types.V1_API_RESPONSE_SHUTTLE_RIDERS
+		json.Unmarshal([]byte(w.Body.Bytes()), &ridersResponse)
+		assert.Equal(0, len(ridersResponse.Data.Riders))
+	})
+	t.Run("PUT /api/v1/user/shuttles/:id/signup - missing runs and other users' invitees aren't found", func(t *testing.T) {
+		runId := addRun(router, 2)
+		invitee := models.UserInvitee{InviterId: fixtures.Admin().ID, FirstName: "Someone", LastName: "Else"}
+		store.CreateUserInvitee(ctx, &invitee)
+
+		signupResponse := signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{InviteeIds: []uuid.UUID{invitee.ID}})
+		assert.Equal(http.StatusNotFound, signupResponse.Status)
+		assert.Equal("Invitee not found", signupResponse.Message)
+		signupResponse = signUp(router, uuid.New().String(), fixtures.Guest(), types.ShuttleSignupInput{Self: true})
+		assert.Equal(http.StatusNotFound, signupResponse.Status)
+		assert.Equal("Shuttle run not found", signupResponse.Message)
+	})
+	t.Run("GET /api/v1/shuttle/:id/manifest - exports seated riders, then the waitlist", func(t *testing.T) {
+		runId := addRun(router, 1)
+		invitee := models.UserInvitee{InviterId: fixtures.Guest().ID, FirstName: "=HYPERLINK(\"x\")", LastName: "One"}
+		store.CreateUserInvitee(ctx, &invitee)
+		signUp(router, runId, fixtures.Guest(), types.ShuttleSignupInput{Self: true, InviteeIds: []uuid.UUID{invitee.ID}})
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/shuttle/"+runId+"/manifest", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Equal("text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(`attachment; filename="shuttle-20270612-1530.csv"`, w.Header().Get("Content-Disposition"))
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		assert.Nil(err)
+		assert.Equal(3, len(rows))
+		assert.Equal([]string{"Seated", "1", fixtures.Guest().FirstName, fixtures.Guest().LastName, fixtures.Guest().Email}, rows[1][:5])
+		// Names are kept from being run as formulas in spreadsheet apps
+		assert.Equal([]string{"Waitlisted", "1", "'=HYPERLINK(\"x\")", "One", fixtures.Guest().Email}, rows[2][:5])
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/shuttle/"+runId+"/manifest", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/shuttle/"+uuid.New().String()+"/manifest", fixtures.Admin(), nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+	})
+	t.Run("Shuttle routes - only admins can manage runs", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/shuttle", fixtures.Planner(), types.ShuttleRunInput{Route: "Nowhere", DepartsAt: departsAt, Capacity: 2})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/shuttle/"+uuid.New().String(), fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("Shuttle routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		run := types.ShuttleRunInput{Route: "The Grand Hotel to The Garden", DepartsAt: departsAt, Capacity: 2}
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/shuttle", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/shuttle", fixtures.Admin(), run),
+			fixtures.Serve(t, router, "PUT", "/api/v1/shuttle/"+id, fixtures.Admin(), run),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/shuttle/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/shuttle/"+id+"/manifest", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/user/shuttles", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "PUT", "/api/v1/user/shuttles/"+id+"/signup", fixtures.Guest(), types.ShuttleSignupInput{Self: true}),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var songRequestStatuses = []string{
+	models.SongRequestPending,
+	models.SongRequestApproved,
+	models.SongRequestRejected,
+	models.SongRequestDoNotPlay,
+}
+
+func songRequestFromInput(input types.SongRequestInput, userId uuid.UUID, status string) models.SongRequest {
+	return models.SongRequest{
+		Title:  strings.TrimSpace(input.Title),
+		Artist: strings.TrimSpace(input.Artist),
+		Link:   input.Link,
+		UserId: userId,
+		Status: status,
+	}
+}
+
+// Adds how many votes each song request has and whether the user voted for it
+func (h *Handler) songRequestSummaries(ctx context.Context, songs []models.SongRequest, userId uuid.UUID) ([]types.SongRequestSummary, error) {
+	totals, err := h.Songs.FindSongVoteTotals(ctx)
+	var votes []models.SongVote
+	if err == nil {
+		votes, err = h.Songs.FindSongVotesForUser(ctx, userId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summaries := []types.SongRequestSummary{}
+	for _, song := range songs {
+		summary := types.SongRequestSummary{SongRequest: song}
+		if i := slices.IndexFunc(totals, func(t models.SongVoteTotal) bool { return t.SongRequestId == song.ID }); i >= 0 {
+			summary.Votes = totals[i].Votes
+		}
+		summary.Voted = slices.ContainsFunc(votes, func(v models.SongVote) bool { return v.SongRequestId == song.ID })
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// Creates a song request; returns the status and message to respond with
+func (h *Handler) createSongRequest(ctx context.Context, song *models.SongRequest) (int, string) {
+	err := h.Songs.CreateSongRequest(ctx, song)
+	switch {
+	case errors.Is(err, models.ErrSongDoNotPlay):
+		return http.StatusConflict, "This song is on the do-not-play list"
+	case errors.Is(err, models.ErrSongAlreadyRequested):
+		return http.StatusConflict, "This song has already been requested"
+	case err != nil:
+		log.Println("Error creating song request: ", err.Error())
+		return http.StatusInternalServerError, "Internal server error"
+	}
+	return http.StatusCreated, ""
+}
+
+// GetSongRequestsForLoggedInUser gets the songs the logged in user can vote for, along with their own requests
+//
+//	@Summary      gets the song requests
+//	@Description  Gets every approved song request, the most votes first, along with the logged in user's own requests (whatever their status) and whether the user voted for each
+//	@Tags         user
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /user/songs [get]
+func (h *Handler) GetSongRequestsForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	songs, err := h.Songs.FindSongRequests(ctx)
+	var summaries []types.SongRequestSummary
+	if err == nil {
+		songs = slices.DeleteFunc(songs, func(song models.SongRequest) bool {
+			return song.Status != models.SongRequestApproved && song.UserId != uid
+		})
+		summaries, err = h.songRequestSummaries(ctx, songs, uid)
+	}
+	if err != nil {
+		log.Println("Error finding song requests: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		slices.SortStableFunc(summaries, func(a, b types.SongRequestSummary) int { return cmp.Compare(b.Votes, a.Votes) })
+		response.Data.Songs = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// RequestSongForLoggedInUser asks the DJ to play a song
+//
+//	@Summary      requests a song
+//	@Description  Asks the DJ to play a song. The request is pending until an admin approves it; songs that have already been requested (ignoring case and spacing) or are on the do-not-play list can't be requested.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.SongRequestInput true "The song"
+//	@Success      201  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      409  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /user/songs [post]
+func (h *Handler) RequestSongForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	var input types.SongRequestInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	song := songRequestFromInput(input, uid, models.SongRequestPending)
+	status, response.Message = h.createSongRequest(ctx, &song)
+	if status == http.StatusCreated {
+		response.Message = "Requested song"
+		response.Data.Songs = []types.SongRequestSummary{{SongRequest: song}}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// VoteForSongForLoggedInUser votes for a song request
+//
+//	@Summary      votes for a song
+//	@Description  Adds the logged in user's vote to an approved song request. Each user has one vote per song, so voting again changes nothing.
+//	@Tags         user
+//	@Produce      json
+//	@Param 		  id  path string true "Song request ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /user/songs/{id}/vote [put]
+func (h *Handler) VoteForSongForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	err = h.Songs.SaveSongVote(ctx, &models.SongVote{SongRequestId: id, UserId: uid})
+	var song *models.SongRequest
+	if err == nil {
+		song, err = h.Songs.FindSongRequestById(ctx, id)
+	}
+	var summaries []types.SongRequestSummary
+	if err == nil && song != nil {
+		summaries, err = h.songRequestSummaries(ctx, []models.SongRequest{*song}, uid)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Song request not found"
+	case err != nil:
+		log.Println("Error saving song vote: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Saved vote"
+		response.Data.Songs = append([]types.SongRequestSummary{}, summaries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteVoteForSongForLoggedInUser takes back the logged in user's vote for a song request
+//
+//	@Summary      takes back a vote for a song
+//	@Description  Removes the logged in user's vote from a song request
+//	@Tags         user
+//	@Produce      json
+//	@Param 		  id  path string true "Song request ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /user/songs/{id}/vote [delete]
+func (h *Handler) DeleteVoteForSongForLoggedInUser(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	result, err := h.Songs.DeleteSongVote(ctx, id, uid)
+	if err != nil {
+		log.Println("Error deleting song vote: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Removed vote"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetSongRequests gets every song request
+//
+//	@Summary      admin-only operation to get the song requests
+//	@Description  Gets every song request, in the order they were made, with how many votes each has. Pass `status=PENDING` for the requests waiting to be moderated, or `status=DO_NOT_PLAY` for the do-not-play list.
+//	@Tags         songs
+//	@Produce      json
+//	@Param        status  query string false "Only include requests with this status" Enums(PENDING, APPROVED, REJECTED, DO_NOT_PLAY)
+//	@Success      200  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /song [get]
+func (h *Handler) GetSongRequests(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	songStatus := c.Query("status")
+	if songStatus != "" && !slices.Contains(songRequestStatuses, songStatus) {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = "status must be one of " + strings.Join(songRequestStatuses, ", ")
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	songs, err := h.Songs.FindSongRequests(ctx)
+	var summaries []types.SongRequestSummary
+	if err == nil {
+		songs = slices.DeleteFunc(songs, func(song models.SongRequest) bool { return songStatus != "" && song.Status != songStatus })
+		summaries, err = h.songRequestSummaries(ctx, songs, uid)
+	}
+	if err != nil {
+		log.Println("Error finding song requests: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Songs = summaries
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// AddSongToDoNotPlayList adds a song nobody has requested to the do-not-play list
+//
+//	@Summary      admin-only operation to add a song to the do-not-play list
+//	@Description  Adds a song to the do-not-play list so guests can't request it. Songs that have already been requested are added by moderating their request instead.
+//	@Tags         songs
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.SongRequestInput true "The song"
+//	@Success      201  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      409  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /song/do-not-play [post]
+func (h *Handler) AddSongToDoNotPlayList(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	var input types.SongRequestInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	song := songRequestFromInput(input, uid, models.SongRequestDoNotPlay)
+	status, response.Message = h.createSongRequest(ctx, &song)
+	if status == http.StatusCreated {
+		response.Message = "Added song to the do-not-play list"
+		response.Data.Songs = []types.SongRequestSummary{{SongRequest: song}}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ModerateSongRequest sets the status of a song request
+//
+//	@Summary      admin-only operation to moderate a song request
+//	@Description  Approves a song request (so guests can see it and vote for it), rejects it, moves it to the do-not-play list or sends it back to pending
+//	@Tags         songs
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Song request ID" Format(uuid)
+//	@Param		  data body types.SongModerationInput true "The song request's new status"
+//	@Success      202  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_SONG_REQUESTS
+//	@Router       /song/{id}/moderation [put]
+func (h *Handler) ModerateSongRequest(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_SONG_REQUESTS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.SongModerationInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	uid, _ := uuid.Parse(c.GetString("uid"))
+	err = h.Songs.UpdateSongRequestStatus(ctx, id, input.Status)
+	var song *models.SongRequest
+	if err == nil {
+		song, err = h.Songs.FindSongRequestById(ctx, id)
+	}
+	var summaries []types.SongRequestSummary
+	if err == nil && song != nil {
+		summaries, err = h.songRequestSummaries(ctx, []models.SongRequest{*song}, uid)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Song request not found"
+	case err != nil:
+		log.Println("Error moderating song request: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated song request"
+		response.Data.Songs = append([]types.SongRequestSummary{}, summaries...)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteSongRequest deletes a song request
+//
+//	@Summary      admin-only operation to delete a song request
+//	@Description  Deletes a song request along with its votes, so the song can be requested again
+//	@Tags         songs
+//	@Produce      json
+//	@Param 		  id  path string true "Song request ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /song/{id} [delete]
+func (h *Handler) DeleteSongRequest(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Songs.DeleteSongRequest(ctx, id)
+	if err != nil {
+		log.Println("Error deleting song request: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted song request"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetSongPlaylistCsv exports the songs for the DJ as a CSV file
+//
+//	@Summary      downloads the DJ's playlist
+//	@Description  Gets the approved song requests as a CSV file for the DJ, the most votes first, followed by the do-not-play list
+//	@Tags         songs
+//	@Produce      text/csv
+//	@Success      200  {string}  string
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /song/playlist.csv [get]
+func (h *Handler) GetSongPlaylistCsv(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+
+	playlist, err := h.Songs.FindSongPlaylist(ctx)
+	if err != nil {
+		log.Println("Error creating song playlist: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"List", "Votes", "Title", "Artist", "Link", "Requested by"})
+	for _, entry := range playlist {
+		list, votes := "Play", fmt.Sprint(entry.Votes)
+		if entry.Status == models.SongRequestDoNotPlay {
+			list, votes = "Do not play", ""
+		}
+		w.Write([]string{
+			list,
+			votes,
+			spreadsheetSafe(entry.Title),
+			spreadsheetSafe(entry.Artist),
+			spreadsheetSafe(entry.Link),
+			spreadsheetSafe(strings.TrimSpace(entry.FirstName + " " + entry.LastName)),
+		})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", `attachment; filename="dj-playlist.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
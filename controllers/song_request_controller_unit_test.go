@@ -0,0 +1,157 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SongRequestController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	songs := func(w *httptest.ResponseRecorder) []types.SongRequestSummary {
+		var songResponse types.V1_API_RESPONSE_SONG_REQUESTS
+		json.Unmarshal([]byte(w.Body.Bytes()), &songResponse)
+		return songResponse.Data.Songs
+	}
+	request := func(router http.Handler, user models.User, title string, artist string) types.SongRequestSummary {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/user/songs", user, types.SongRequestInput{Title: title, Artist: artist})
+		assert.Equal(http.StatusCreated, w.Code)
+		return songs(w)[0]
+	}
+	t.Run("POST /api/v1/user/songs - requests are pending until an admin approves them", func(t *testing.T) {
+		song := request(router, fixtures.Guest(), "September", "Earth, Wind & Fire")
+		assert.Equal(models.SongRequestPending, song.Status)
+
+		// The guest sees their own pending request, but nobody else does
+		assert.Equal(1, len(songs(fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Guest(), nil))))
+		assert.Equal(0, len(songs(fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Planner(), nil))))
+		w := fixtures.Serve(t, router, "GET", "/api/v1/song?status=PENDING", fixtures.Admin(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Equal(1, len(songs(w)))
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/song/"+song.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved})
+		assert.Equal(http.StatusAccepted, w.Code)
+		assert.Equal(models.SongRequestApproved, songs(w)[0].Status)
+		assert.Equal(1, len(songs(fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Planner(), nil))))
+
+		// The same song can't be requested twice, however it's typed
+		w = fixtures.Serve(t, router, "POST", "/api/v1/user/songs", fixtures.Planner(), types.SongRequestInput{Title: "september ", Artist: "Earth,  Wind & Fire"})
+		assert.Equal(http.StatusConflict, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/user/songs", fixtures.Guest(), types.SongRequestInput{Title: "Hey Ya!"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/user/songs", fixtures.Guest(), types.SongRequestInput{Title: "Hey Ya!", Artist: "OutKast", Link: "not a link"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/user/songs/:id/vote - each user votes once per song", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		september := request(router, fixtures.Guest(), "September", "Earth, Wind & Fire")
+		heyYa := request(router, fixtures.Guest(), "Hey Ya!", "OutKast")
+		w := fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/"+heyYa.ID.String()+"/vote", fixtures.Guest(), nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+september.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved})
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+heyYa.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved})
+
+		for _, user := range []models.User{fixtures.Guest(), fixtures.Guest(), fixtures.Planner()} {
+			w = fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/"+heyYa.ID.String()+"/vote", user, nil)
+			assert.Equal(http.StatusAccepted, w.Code)
+		}
+		assert.Equal(int64(2), songs(w)[0].Votes)
+		assert.True(songs(w)[0].Voted)
+
+		list := songs(fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Guest(), nil))
+		assert.Equal(heyYa.ID, list[0].ID)
+		assert.Equal(september.ID, list[1].ID)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/user/songs/"+heyYa.ID.String()+"/vote", fixtures.Guest(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		list = songs(fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Guest(), nil))
+		assert.Equal(int64(1), list[0].Votes)
+		assert.False(list[0].Voted)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/"+uuid.New().String()+"/vote", fixtures.Guest(), nil)
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/not-a-uuid/vote", fixtures.Guest(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/song/do-not-play - keeps guests from requesting the song", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/song/do-not-play", fixtures.Admin(), types.SongRequestInput{Title: "Macarena", Artist: "Los del Río"})
+		assert.Equal(http.StatusCreated, w.Code)
+		assert.Equal(models.SongRequestDoNotPlay, songs(w)[0].Status)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/user/songs", fixtures.Guest(), types.SongRequestInput{Title: "MACARENA", Artist: "Los del Río"})
+		assert.Equal(http.StatusConflict, w.Code)
+		assert.Contains(w.Body.String(), "do-not-play")
+
+		w = fixtures.Serve(t, router, "POST", "/api/v1/song/do-not-play", fixtures.Planner(), types.SongRequestInput{Title: "Cha Cha Slide", Artist: "DJ Casper"})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/song?status=PLAYED", fixtures.Admin(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/song/"+uuid.New().String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestRejected})
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/song/"+uuid.New().String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: "PLAYED"})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("GET /api/v1/song/playlist.csv - lists approved songs by votes, then the do-not-play list", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		september := request(router, fixtures.Guest(), "September", "Earth, Wind & Fire")
+		heyYa := request(router, fixtures.Guest(), "=Hey Ya!", "OutKast")
+		rejected := request(router, fixtures.Guest(), "Chicken Dance", "Werner Thomas")
+		banned := request(router, fixtures.Guest(), "Macarena", "Los del Río")
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+september.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved})
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+heyYa.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved})
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+rejected.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestRejected})
+		fixtures.Serve(t, router, "PUT", "/api/v1/song/"+banned.ID.String()+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestDoNotPlay})
+		fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/"+heyYa.ID.String()+"/vote", fixtures.Guest(), nil)
+
+		w := fixtures.Serve(t, router, "GET", "/api/v1/song/playlist.csv", fixtures.Planner(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Contains(w.Header().Get("Content-Disposition"), "dj-playlist.csv")
+		rows, _ := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+		assert.Equal(4, len(rows))
+		assert.Equal([]string{"Play", "1", "'=Hey Ya!", "OutKast", "", fixtures.Guest().FirstName + " " + fixtures.Guest().LastName}, rows[1])
+		assert.Equal("September", rows[2][2])
+		assert.Equal([]string{"Do not play", ""}, rows[3][:2])
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/song/playlist.csv", fixtures.Guest(), nil)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/song/"+banned.ID.String(), fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		request(router, fixtures.Guest(), "Macarena", "Los del Río")
+	})
+	t.Run("Song routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		song := types.SongRequestInput{Title: "September", Artist: "Earth, Wind & Fire"}
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/user/songs", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/user/songs", fixtures.Guest(), song),
+			fixtures.Serve(t, router, "PUT", "/api/v1/user/songs/"+id+"/vote", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/user/songs/"+id+"/vote", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/song", fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/song/do-not-play", fixtures.Admin(), song),
+			fixtures.Serve(t, router, "PUT", "/api/v1/song/"+id+"/moderation", fixtures.Admin(), types.SongModerationInput{Status: models.SongRequestApproved}),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/song/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/song/playlist.csv", fixtures.Admin(), nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/ax-vasquez/wedding-site-api/helper"
 	"github.com/ax-vasquez/wedding-site-api/models"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/gin-gonic/gin"
@@ -22,7 +24,7 @@ import (
 //	@Success      200  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /user [get]
-func GetLoggedInUser(c *gin.Context) {
+func (h *Handler) GetLoggedInUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
@@ -31,7 +33,7 @@ func GetLoggedInUser(c *gin.Context) {
 
 	idStr := c.GetString("uid")
 	id, _ := uuid.Parse(idStr)
-	users, err := models.FindUsers(ctx, []uuid.UUID{id})
+	users, err := h.Users.FindUsers(ctx, []uuid.UUID{id})
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -53,7 +55,7 @@ func GetLoggedInUser(c *gin.Context) {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Param 		  ids  path string true "user search by id" Format(uuid)
 //	@Router       /user [get]
-func GetUsers(c *gin.Context) {
+func (h *Handler) GetUsers(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USERS{}
@@ -64,7 +66,7 @@ func GetUsers(c *gin.Context) {
 		userId, _ := uuid.Parse(userIdStr)
 		userIds = append(userIds, userId)
 	}
-	users, err := models.FindUsers(ctx, userIds)
+	users, err := h.Users.FindUsers(ctx, userIds)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -88,7 +90,7 @@ func GetUsers(c *gin.Context) {
 //	@Failure      400  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /user [post]
-func CreateUser(c *gin.Context) {
+func (h *Handler) CreateUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(c, 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USERS{}
@@ -99,7 +101,7 @@ func CreateUser(c *gin.Context) {
 		response.Message = err.Error()
 	} else {
 		createUserInput := []models.User{input}
-		err := models.CreateUsers(ctx, &createUserInput)
+		err := h.Users.CreateUsers(ctx, &createUserInput)
 		if err != nil {
 			status = http.StatusInternalServerError
 			response.Message = "Internal server error"
@@ -117,16 +119,16 @@ func CreateUser(c *gin.Context) {
 // UpdateLoggedInUser updates the logged in user
 //
 //	@Summary      updates the logged in user
-//	@Description  Updates the logged in user with the given input
+//	@Description  Updates the logged in user with the given input (use `POST /user/email` to change the email address)
 //	@Tags         user
 //	@Accept       json
 //	@Produce      json
-//	@Param		  data body models.User true "Post body"
+//	@Param		  data body types.UpdateUserInput true "Post body"
 //	@Success      202  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      400  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /user [patch]
-func UpdateLoggedInUser(c *gin.Context) {
+func (h *Handler) UpdateLoggedInUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USERS{}
@@ -166,12 +168,11 @@ func UpdateLoggedInUser(c *gin.Context) {
 		IsGoing:                 input.IsGoing,
 		FirstName:               input.FirstName,
 		LastName:                input.LastName,
-		Email:                   input.Email,
 		HorsDoeuvresSelectionId: input.HorsDoeuvresSelectionId,
 		EntreeSelectionId:       input.EntreeSelectionId,
 	}
-	updateErr := models.UpdateUser(ctx, u)
-	setIsGoingErr := models.SetIsGoing(ctx, u)
+	updateErr := h.Users.UpdateUser(ctx, u)
+	setIsGoingErr := h.Users.SetIsGoing(ctx, u)
 	if updateErr != nil || setIsGoingErr != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -193,7 +194,11 @@ func UpdateLoggedInUser(c *gin.Context) {
 	c.JSON(status, response)
 }
 
-func AdminUpdateUser(c *gin.Context) {
+// AdminUpdateUser updates the user with the ID in the body
+//
+// Users without the users:write permission can only update themselves here, and their email is left alone; they change
+// it with POST /user/email, which checks their password and confirms the new address.
+func (h *Handler) AdminUpdateUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USERS{}
@@ -206,6 +211,9 @@ func AdminUpdateUser(c *gin.Context) {
 		c.JSON(status, response)
 		return
 	}
+	if err := helper.CheckPermissions(c, helper.PermUsersWrite); err != nil {
+		input.Email = ""
+	}
 
 	u := &models.User{
 		BaseModel: models.BaseModel{
@@ -218,8 +226,8 @@ func AdminUpdateUser(c *gin.Context) {
 		HorsDoeuvresSelectionId: input.HorsDoeuvresSelectionId,
 		EntreeSelectionId:       input.EntreeSelectionId,
 	}
-	updateErr := models.UpdateUser(ctx, u)
-	setIsGoingErr := models.SetIsGoing(ctx, u)
+	updateErr := h.Users.UpdateUser(ctx, u)
+	setIsGoingErr := h.Users.SetIsGoing(ctx, u)
 	if updateErr != nil || setIsGoingErr != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -244,26 +252,31 @@ func AdminUpdateUser(c *gin.Context) {
 // DeleteUser delete a user
 //
 //	@Summary      admin-only operation to delete a user
-//	@Description  Deletes an user and returns a response to indicate success or failure
+//	@Description  Deletes an user and returns a response to indicate success or failure; the last admin can't be deleted
 //	@Tags         user
 //	@Produce      json
 //	@Param 		  id  path string true "User ID" Format(uuid)
 //	@Success      202  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      400  {object}  types.V1_API_RESPONSE_USERS
+//	@Failure      409  {object}  types.V1_API_RESPONSE_USERS
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USERS
 //	@Router       /user [delete]
-func DeleteUser(c *gin.Context) {
+func (h *Handler) DeleteUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_DELETE_RESPONSE{}
 	var status int
 	id, _ := uuid.Parse(c.Param("id"))
-	result, err := models.DeleteUser(ctx, id)
-	if err != nil {
+	result, err := h.Users.DeleteUser(ctx, id)
+	switch {
+	case errors.Is(err, models.ErrLastAdmin):
+		status = http.StatusConflict
+		response.Message = "Cannot remove the last admin"
+	case err != nil:
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
 		log.Println("Error deleting user: ", err.Error())
-	} else {
+	default:
 		status = http.StatusAccepted
 		response.Message = "Deleted user"
 		response.Data.DeletedRecords = int(result)
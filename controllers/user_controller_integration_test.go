@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +20,7 @@ import (
 
 func Test_UserController_NoAuth_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	t.Run("GET /api/v1/users - no auth - cannot get users", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		routePath := fmt.Sprintf("/api/v1/users?ids=%s", models.FirstUserIdStr)
@@ -66,7 +67,7 @@ func Test_UserController_NoAuth_Integration(t *testing.T) {
 
 func Test_UserController_Admin_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "admin@admin.admin")
 	t.Run("GET /api/v1/users - admin - can get users", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -189,7 +190,7 @@ func Test_UserController_Admin_Integration(t *testing.T) {
 
 func Test_UserController_Guest_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "user_1@fakedomain.com")
 	t.Run("GET /api/v1/user - user can get their own data", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -4,17 +4,18 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
-	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -22,35 +23,16 @@ import (
 )
 
 func Test_UserController_Unit(t *testing.T) {
-	os.Setenv("USE_MOCK_DB", "true")
 	assert := assert.New(t)
-	router := paveRoutes()
 	errMsg := "arbitrary database error"
 	apiErrMsg := "Internal server error"
-	u := models.User{
-		BaseModel: models.BaseModel{
-			ID: uuid.New(),
-		},
-		Role:      "GUEST",
-		IsGoing:   true,
-		FirstName: "Booples",
-		LastName:  "McFadden",
-		Email:     "fake@email.place",
-	}
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	u := fixtures.Guest()
+	u.IsGoing = true
 	t.Run("GET /api/v1/user - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`SELECT "id","role","is_going","first_name","last_name","email","entree_selection_id","hors_doeuvres_selection_id" FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL`)).WithArgs(
-			u.ID,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", u.ID.String())
-		ctx.Set("user_role", "ADMIN")
-		req, err := http.NewRequestWithContext(ctx, "GET", "/api/v1/user", nil)
+		req, err := http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -60,20 +42,10 @@ func Test_UserController_Unit(t *testing.T) {
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
 	t.Run("GET /api/v1/users - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL`)).WithArgs(
-			u.ID,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", u.ID.String())
-		ctx.Set("user_role", "ADMIN")
 		routePath := fmt.Sprintf("/api/v1/users?ids=%s", u.ID)
-		req, err := http.NewRequestWithContext(ctx, "GET", routePath, nil)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -83,34 +55,10 @@ func Test_UserController_Unit(t *testing.T) {
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
 	t.Run("POST /api/v1/user - internal server error", func(t *testing.T) {
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`INSERT INTO "users" ("created_at","updated_at","deleted_at","role","is_going","first_name","last_name","email","password","token","refresh_token","hors_doeuvres_selection_id","entree_selection_id","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "id"`)).WithArgs(
-			test.AnyTime{},
-			test.AnyTime{},
-			nil,
-			u.Role,
-			u.IsGoing,
-			u.FirstName,
-			u.LastName,
-			u.Email,
-			u.Password,
-			u.Token,
-			u.RefreshToken,
-			u.HorsDoeuvresSelectionId,
-			u.EntreeSelectionId,
-			u.ID,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
 		userJson, _ := json.Marshal(u)
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", u.ID.String())
-		ctx.Set("user_role", "ADMIN")
-		req, err := http.NewRequestWithContext(ctx, "POST", "/api/v1/user", strings.NewReader(string(userJson)))
+		req, err := http.NewRequest("POST", "/api/v1/user", strings.NewReader(string(userJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -123,18 +71,16 @@ func Test_UserController_Unit(t *testing.T) {
 		input := types.UpdateUserInput{
 			FirstName: "Newname",
 			LastName:  "Newlastname",
-			Email:     u.Email,
 		}
 
 		w := httptest.NewRecorder()
 
 		updateUserJson, _ := json.Marshal(input)
-		ctx := gin.CreateTestContextOnly(w, router)
+		// A valid token always carries a UUID, so the handler is called directly to emulate a bad value in the context
+		ctx, _ := gin.CreateTestContext(w)
 		ctx.Set("uid", nil)
-		ctx.Set("user_role", "GUEST")
-		req, err := http.NewRequestWithContext(ctx, "PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
-		router.ServeHTTP(w, req)
-		assert.Nil(err)
+		ctx.Request, _ = http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
+		NewHandler(fixtures.NewStore()).UpdateLoggedInUser(ctx)
 		assert.Equal(http.StatusInternalServerError, w.Code)
 
 		var jsonResponse types.V1_API_RESPONSE_ENTREE
@@ -145,18 +91,16 @@ func Test_UserController_Unit(t *testing.T) {
 		input := types.UpdateUserInput{
 			FirstName: "Newname",
 			LastName:  "Newlastname",
-			Email:     u.Email,
 		}
 
 		w := httptest.NewRecorder()
 
 		updateUserJson, _ := json.Marshal(input)
-		ctx := gin.CreateTestContextOnly(w, router)
+		// A valid token always carries a UUID, so the handler is called directly to emulate a bad value in the context
+		ctx, _ := gin.CreateTestContext(w)
 		ctx.Set("uid", "abcdef")
-		ctx.Set("user_role", "GUEST")
-		req, err := http.NewRequestWithContext(ctx, "PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
-		router.ServeHTTP(w, req)
-		assert.Nil(err)
+		ctx.Request, _ = http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
+		NewHandler(fixtures.NewStore()).UpdateLoggedInUser(ctx)
 		assert.Equal(http.StatusInternalServerError, w.Code)
 
 		var jsonResponse types.V1_API_RESPONSE_ENTREE
@@ -167,28 +111,12 @@ func Test_UserController_Unit(t *testing.T) {
 		input := types.UpdateUserInput{
 			FirstName: "Newname",
 			LastName:  "Newlastname",
-			Email:     u.Email,
 		}
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectQuery(
-			regexp.QuoteMeta(`UPDATE "users" SET "updated_at"=$1,"first_name"=$2,"last_name"=$3,"email"=$4 WHERE "users"."deleted_at" IS NULL AND "id" = $5 RETURNING "users"."role","users"."first_name","users"."last_name","users"."email","users"."hors_doeuvres_selection_id","users"."entree_selection_id"`)).WithArgs(
-			test.AnyTime{},
-			input.FirstName,
-			input.LastName,
-			input.Email,
-			test.AnyString{},
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
 
 		updateUserJson, _ := json.Marshal(input)
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", u.ID.String())
-		ctx.Set("user_role", "GUEST")
-		req, err := http.NewRequestWithContext(ctx, "PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
+		req, err := http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -199,22 +127,10 @@ func Test_UserController_Unit(t *testing.T) {
 	})
 	t.Run("DELETE /api/v1/user/:id - internal server error", func(t *testing.T) {
 		someId := uuid.New()
-		_, mock, _ := models.Setup()
-		mock.ExpectBegin()
-		mock.ExpectExec(
-			regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).WithArgs(
-			test.AnyTime{},
-			someId,
-		).WillReturnError(fmt.Errorf(errMsg))
-		mock.ExpectRollback()
-		mock.ExpectCommit()
-
 		w := httptest.NewRecorder()
-		ctx := gin.CreateTestContextOnly(w, router)
-		ctx.Set("uid", u.ID.String())
-		ctx.Set("user_role", "ADMIN")
 		routePath := fmt.Sprintf("/api/v1/user/%s", someId)
-		req, err := http.NewRequestWithContext(ctx, "DELETE", routePath, nil)
+		req, err := http.NewRequest("DELETE", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
 		router.ServeHTTP(w, req)
 		assert.Nil(err)
 		assert.Equal(http.StatusInternalServerError, w.Code)
@@ -223,4 +139,64 @@ func Test_UserController_Unit(t *testing.T) {
 		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
 		assert.Equal(apiErrMsg, jsonResponse.Message)
 	})
+	t.Run("PATCH /api/v1/user - can update the logged in user", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		input := types.UpdateUserInput{
+			IsGoing:   false,
+			FirstName: "Newname",
+		}
+
+		w := httptest.NewRecorder()
+		updateUserJson, _ := json.Marshal(input)
+		req, err := http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(updateUserJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{u.ID})
+		assert.Equal("Newname", users[0].FirstName)
+		assert.Equal(u.LastName, users[0].LastName)
+		assert.False(users[0].IsGoing)
+	})
+	t.Run("DELETE /api/v1/user/:id - admin - can delete a user", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s", u.ID)
+		req, err := http.NewRequest("DELETE", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		var jsonResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(1, jsonResponse.Data.DeletedRecords)
+	})
+	t.Run("DELETE /api/v1/user/:id - admin - cannot delete the last admin", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		admin := fixtures.Admin()
+		deleteAdmin := func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/user/%s", admin.ID), nil)
+			req.Header.Set("auth-token", fixtures.Token(t, admin))
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := deleteAdmin()
+		assert.Equal(http.StatusConflict, w.Code)
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{admin.ID})
+		assert.Equal(1, len(users))
+
+		// Once there's another admin, the first one can delete themselves
+		otherAdmin := []models.User{{Role: models.RoleAdmin, FirstName: "Other", LastName: "Admin", Email: "other@admin.admin"}}
+		assert.Nil(store.CreateUsers(context.Background(), &otherAdmin))
+		w = deleteAdmin()
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
 }
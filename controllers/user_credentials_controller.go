@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// How long the link sent to confirm a new email address works for
+const emailChangeTTL = 24 * time.Hour
+
+// ChangePassword changes the logged in user's password
+//
+//	@Summary      changes the logged in user's password
+//	@Description  Changes the logged in user's password (the current password is required). Tokens issued before the change stop working, so new ones are returned.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ChangePasswordInput true "The current and new passwords"
+//	@Success      202  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      400  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      403  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      422  {object}  types.V1_API_RESPONSE_AUTH
+//	@Failure      500  {object}  types.V1_API_RESPONSE_AUTH
+//	@Router       /user/password [post]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_AUTH{}
+	var status int
+	var input types.ChangePasswordInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	u, status, msg := h.loggedInUserWithPassword(ctx, c, input.CurrentPassword)
+	if u == nil {
+		response.Message = msg
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	if problems := h.PasswordPolicy.Check(input.NewPassword, u.Email, u.FirstName, u.LastName); len(problems) > 0 {
+		status = http.StatusUnprocessableEntity
+		response.Message = passwordProblemsMessage(problems)
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	hashedPassword, err := h.PasswordHashing.Hash(input.NewPassword)
+	if err == nil {
+		err = h.Users.ChangePassword(ctx, u.ID, hashedPassword)
+	}
+	if err != nil {
+		log.Println("Error changing password: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	// The change bumped the token version, so the token used for this request (and any others, along with every session)
+	// no longer works
+	u.TokenVersion++
+	token, refreshToken, err := h.startSession(ctx, c, u)
+	if err == nil {
+		err = h.sendAuthTokens(c, &response.Data, token, refreshToken)
+	}
+	if err != nil {
+		log.Println("Error generating tokens: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Changed password, but could not log you back in; log in using the new password"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Message = "Changed password"
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// RequestEmailChange starts changing the logged in user's email address
+//
+//	@Summary      starts changing the logged in user's email address
+//	@Description  Sends a confirmation link to the new email address (the current password is required); the address is changed once the link is used (see `POST /user/email/confirm`)
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ChangeEmailInput true "The current password and new email address"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      403  {object}  types.V1_API_RESPONSE
+//	@Failure      409  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /user/email [post]
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	var input types.ChangeEmailInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	u, status, msg := h.loggedInUserWithPassword(ctx, c, input.CurrentPassword)
+	if u == nil {
+		response.Message = msg
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	count, err := h.Users.CountUsersByEmail(ctx, input.NewEmail)
+	if err != nil {
+		log.Println("Error checking if email is taken: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	if count > 0 {
+		status = http.StatusConflict
+		response.Message = "A user with this email address already exists."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	token, tokenHash, err := helper.NewOneTimeToken()
+	if err == nil {
+		err = h.Users.CreateEmailChange(ctx, &models.EmailChange{
+			UserId:    u.ID,
+			NewEmail:  input.NewEmail,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(emailChangeTTL),
+		})
+	}
+	if err != nil {
+		log.Println("Error creating email change: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	err = h.Mail.Send(ctx, mail.Message{
+		To:      input.NewEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm this is your new email address by opening the link below within %d hours:\n\n%s\n\nIf you didn't ask to change your email address, you can ignore this email.\n",
+			u.FirstName,
+			int(emailChangeTTL.Hours()),
+			appLink("/confirm-email", token),
+		),
+	})
+	if err != nil {
+		log.Println("Error sending email change confirmation: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error while sending confirmation email"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	status = http.StatusAccepted
+	response.Message = "Sent a confirmation link to the new email address"
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// ConfirmEmailChange finishes changing a user's email address
+//
+//	@Summary      confirms a new email address
+//	@Description  Changes the user's email address using the token from the link sent by `POST /user/email`. Tokens issued before the change stop working, so the user must log in again with the new address.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.ConfirmEmailChangeInput true "The token from the confirmation link"
+//	@Success      202  {object}  types.V1_API_RESPONSE
+//	@Failure      400  {object}  types.V1_API_RESPONSE
+//	@Failure      409  {object}  types.V1_API_RESPONSE
+//	@Failure      500  {object}  types.V1_API_RESPONSE
+//	@Router       /user/email/confirm [post]
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE{}
+	var status int
+	var input types.ConfirmEmailChangeInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	change, err := h.Users.FindEmailChange(ctx, helper.HashOneTimeToken(input.Token))
+	var u models.User
+	if change != nil {
+		u.ID = change.UserId
+		err = h.Users.FindUser(ctx, &u)
+	}
+	if err != nil {
+		log.Println("Error finding email change: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	if change == nil || time.Now().After(change.ExpiresAt) {
+		status = http.StatusBadRequest
+		response.Message = "The confirmation link is invalid or has expired."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	err = h.Users.ConfirmEmailChange(ctx, change)
+	if errors.Is(err, models.ErrEmailTaken) {
+		status = http.StatusConflict
+		response.Message = "A user with this email address already exists."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	if err != nil {
+		log.Println("Error confirming email change: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	// Let the old address know, in case someone else changed it
+	err = h.Mail.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Your email address was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nThe email address for your account was changed to %s. If you didn't do this, contact us right away.\n", u.FirstName, change.NewEmail),
+	})
+	if err != nil {
+		log.Println("Error sending email change notice: ", err.Error())
+	}
+
+	status = http.StatusAccepted
+	response.Message = "Changed email address; log in using the new address"
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Loads the logged in user (including the password hash) if the given password is theirs
+//
+// When it isn't (or the user can't be loaded), the user is nil and the status and message to respond with are returned.
+func (h *Handler) loggedInUserWithPassword(ctx context.Context, c *gin.Context, password string) (*models.User, int, string) {
+	uid, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Invalid UUID detected in context."
+	}
+	u := models.User{BaseModel: models.BaseModel{ID: uid}}
+	if err := h.Users.FindUser(ctx, &u); err != nil {
+		log.Println("Error finding user: ", err.Error())
+		return nil, http.StatusInternalServerError, "Internal server error"
+	}
+	if !helper.VerifyPassword(u.Password, password) {
+		return nil, http.StatusForbidden, "Current password is incorrect."
+	}
+	return &u, 0, ""
+}
+
+// Builds a link to a page of the site (at APP_URL) that is given a one-time token; without APP_URL, only the token is returned
+func appLink(path string, token string) string {
+	base := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if base == "" {
+		return "Token: " + token
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
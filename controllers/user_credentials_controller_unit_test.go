@@ -0,0 +1,242 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/mail"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UserCredentialsController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	t.Setenv("APP_URL", "https://wedding.test/")
+	tokenInLink := regexp.MustCompile(`https://wedding\.test/confirm-email\?token=([A-Za-z0-9_-]+)`)
+	jsonBody := func(input any) *strings.Reader {
+		body, _ := json.Marshal(input)
+		return strings.NewReader(string(body))
+	}
+	newPassword := "Wed!ding#2O26"
+	t.Run("POST /api/v1/user/password - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/password", jsonBody(types.ChangePasswordInput{
+			CurrentPassword: models.TestUserPassword,
+			NewPassword:     newPassword,
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("POST /api/v1/user/password - wrong current password is rejected", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/password", jsonBody(types.ChangePasswordInput{
+			CurrentPassword: "not my password",
+			NewPassword:     newPassword,
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusForbidden, w.Code)
+	})
+	t.Run("POST /api/v1/user/password - new password must meet the policy", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/password", jsonBody(types.ChangePasswordInput{
+			CurrentPassword: models.TestUserPassword,
+			NewPassword:     "short",
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnprocessableEntity, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Contains(jsonResponse.Message, "must be at least 8 characters in length")
+	})
+	t.Run("POST /api/v1/user/password - changes the password and revokes old tokens", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		oldToken := fixtures.Token(t, fixtures.Guest())
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/password", jsonBody(types.ChangePasswordInput{
+			CurrentPassword: models.TestUserPassword,
+			NewPassword:     newPassword,
+		}))
+		req.Header.Set("auth-token", oldToken)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_AUTH
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.NotEmpty(jsonResponse.Data.Token)
+
+		u := models.User{Email: fixtures.Guest().Email}
+		store.FindUser(context.Background(), &u)
+		assert.True(helper.VerifyPassword(u.Password, newPassword))
+		assert.False(helper.VerifyPassword(u.Password, models.TestUserPassword))
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", oldToken)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", jsonResponse.Data.Token)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+	})
+	t.Run("POST /api/v1/user/email - wrong current password is rejected", func(t *testing.T) {
+		h := NewHandler(fixtures.NewStore())
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email", jsonBody(types.ChangeEmailInput{
+			CurrentPassword: "not my password",
+			NewEmail:        "new@fakedomain.com",
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusForbidden, w.Code)
+		assert.Empty(sender.Sent())
+	})
+	t.Run("POST /api/v1/user/email - email of another user is a conflict", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email", jsonBody(types.ChangeEmailInput{
+			CurrentPassword: models.TestUserPassword,
+			NewEmail:        fixtures.Admin().Email,
+		}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusConflict, w.Code)
+	})
+	t.Run("POST /api/v1/user/email - email is only changed once the new address is confirmed", func(t *testing.T) {
+		store := fixtures.NewStore()
+		h := NewHandler(store)
+		sender := mail.NewMemorySender()
+		h.Mail = sender
+		router := paveRoutes(h)
+		oldToken := fixtures.Token(t, fixtures.Guest())
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email", jsonBody(types.ChangeEmailInput{
+			CurrentPassword: models.TestUserPassword,
+			NewEmail:        "new@fakedomain.com",
+		}))
+		req.Header.Set("auth-token", oldToken)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Equal(fixtures.Guest().Email, users[0].Email)
+
+		sent := sender.Sent()
+		assert.Equal(1, len(sent))
+		assert.Equal("new@fakedomain.com", sent[0].To)
+		match := tokenInLink.FindStringSubmatch(sent[0].Body)
+		assert.Equal(2, len(match))
+		token := match[1]
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/user/email/confirm", jsonBody(types.ConfirmEmailChangeInput{Token: token}))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusAccepted, w.Code)
+		users, _ = store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Equal("new@fakedomain.com", users[0].Email)
+		// The old address is told about the change
+		sent = sender.Sent()
+		assert.Equal(2, len(sent))
+		assert.Equal(fixtures.Guest().Email, sent[1].To)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", oldToken)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		// Links only work once
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/api/v1/user/email/confirm", jsonBody(types.ConfirmEmailChangeInput{Token: token}))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("POST /api/v1/user/email/confirm - address taken since the request is a conflict", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateEmailChange(context.Background(), &models.EmailChange{
+			UserId:    fixtures.Guest().ID,
+			NewEmail:  fixtures.Planner().Email,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email/confirm", jsonBody(types.ConfirmEmailChangeInput{Token: token}))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusConflict, w.Code)
+	})
+	t.Run("POST /api/v1/user/email/confirm - expired link is rejected", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		token, tokenHash, _ := helper.NewOneTimeToken()
+		store.CreateEmailChange(context.Background(), &models.EmailChange{
+			UserId:    fixtures.Guest().ID,
+			NewEmail:  "new@fakedomain.com",
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email/confirm", jsonBody(types.ConfirmEmailChangeInput{Token: token}))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadRequest, w.Code)
+		users, _ := store.FindUsers(context.Background(), []uuid.UUID{fixtures.Guest().ID})
+		assert.Equal(fixtures.Guest().Email, users[0].Email)
+	})
+	t.Run("POST /api/v1/user/email/confirm - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/v1/user/email/confirm", jsonBody(types.ConfirmEmailChangeInput{Token: "some-token"}))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+}
@@ -30,7 +30,7 @@ type UserInviteeInput struct {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USER_INVITEES
 //	@Param 		  user_id  path string true "Inviting user ID" Format(uuid)
 //	@Router       /user/{user_id}/add-invitee [post]
-func CreateUserInvitee(c *gin.Context) {
+func (h *Handler) CreateUserInvitee(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USER_INVITEES{}
@@ -48,7 +48,7 @@ func CreateUserInvitee(c *gin.Context) {
 			FirstName: invitee.FirstName,
 			LastName:  invitee.LastName,
 		}
-		err := models.CreateUserInvitee(&ctx, &invitee)
+		err := h.Invitees.CreateUserInvitee(ctx, &invitee)
 		if err != nil {
 			status = http.StatusInternalServerError
 			response.Message = "Internal server error"
@@ -75,7 +75,7 @@ func CreateUserInvitee(c *gin.Context) {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USER_INVITEES
 //	@Param 		  user_id  path string true "Invitee search by inviting user ID" Format(uuid)
 //	@Router       /user/{user_id}/invitees [get]
-func GetInviteesForLoggedInUser(c *gin.Context) {
+func (h *Handler) GetInviteesForLoggedInUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USER_INVITEES{}
@@ -83,7 +83,7 @@ func GetInviteesForLoggedInUser(c *gin.Context) {
 	inviterId := c.GetString("uid")
 	inviterIdUUID, _ := uuid.Parse(inviterId)
 	status = http.StatusOK
-	data, err := models.FindInviteesForUser(&ctx, inviterIdUUID)
+	data, err := h.Invitees.FindInviteesForUser(ctx, inviterIdUUID)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -104,7 +104,7 @@ func GetInviteesForLoggedInUser(c *gin.Context) {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USER_INVITEES
 //	@Param 		  id  path string true "User ID of the invitee to delete" Format(uuid)
 //	@Router       /user/invitees/{id} [patch]
-func UpdateInviteeForLoggedInUser(c *gin.Context) {
+func (h *Handler) UpdateInviteeForLoggedInUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_RESPONSE_USER_INVITEES{}
@@ -139,7 +139,7 @@ func UpdateInviteeForLoggedInUser(c *gin.Context) {
 		LastName:  invInput.LastName,
 	}
 
-	err = models.UpdateInviteeForUser(&ctx, &invitee, inviterIdUUID)
+	err = h.Invitees.UpdateInviteeForUser(ctx, &invitee, inviterIdUUID)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -164,7 +164,7 @@ func UpdateInviteeForLoggedInUser(c *gin.Context) {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USER_INVITEES
 //	@Param 		  id  path string true "User ID of the invitee to delete" Format(uuid)
 //	@Router       /user/invitees/{id} [delete]
-func DeleteInviteeForLoggedInUser(c *gin.Context) {
+func (h *Handler) DeleteInviteeForLoggedInUser(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_DELETE_RESPONSE{}
@@ -181,7 +181,7 @@ func DeleteInviteeForLoggedInUser(c *gin.Context) {
 		return
 	}
 
-	result, err := models.DeleteInviteeForUser(&ctx, inviteeId, inviterIdUUID)
+	result, err := h.Invitees.DeleteInviteeForUser(ctx, inviteeId, inviterIdUUID)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -191,7 +191,7 @@ func DeleteInviteeForLoggedInUser(c *gin.Context) {
 	}
 
 	status = http.StatusAccepted
-	response.Data.DeletedRecords = int(*result)
+	response.Data.DeletedRecords = int(result)
 	response.Status = status
 	c.JSON(status, response)
 }
@@ -206,7 +206,7 @@ func DeleteInviteeForLoggedInUser(c *gin.Context) {
 //	@Failure      500  {object}  types.V1_API_RESPONSE_USER_INVITEES
 //	@Param 		  id  path string true "User ID of the invitee to delete" Format(uuid)
 //	@Router       /invitee/{id} [delete]
-func DeleteInvitee(c *gin.Context) {
+func (h *Handler) DeleteInvitee(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	response := types.V1_API_DELETE_RESPONSE{}
@@ -221,7 +221,7 @@ func DeleteInvitee(c *gin.Context) {
 		return
 	}
 
-	result, err := models.DeleteInvitee(&ctx, inviteeId)
+	result, err := h.Invitees.DeleteInvitee(ctx, inviteeId)
 	if err != nil {
 		status = http.StatusInternalServerError
 		response.Message = "Internal server error"
@@ -231,7 +231,7 @@ func DeleteInvitee(c *gin.Context) {
 	}
 
 	status = http.StatusAccepted
-	response.Data.DeletedRecords = int(*result)
+	response.Data.DeletedRecords = int(result)
 	response.Status = status
 	c.JSON(status, response)
 }
@@ -12,13 +12,14 @@ import (
 	"testing"
 
 	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_UseInviteeController_NoAuth_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	t.Run("GET /api/v1/user/:id/invitees - no auth - reject request", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/api/v1/user/invitees", nil)
@@ -52,7 +53,7 @@ func Test_UseInviteeController_NoAuth_Integration(t *testing.T) {
 
 func Test_UserInviteeController_Admin_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "admin@admin.admin")
 	t.Run("GET /api/v1/user/invitees - admin - can get users they invited", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -139,7 +140,7 @@ func Test_UserInviteeController_Admin_Integration(t *testing.T) {
 
 func Test_UserInviteeController_Guest_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "user_1@fakedomain.com")
 	t.Run("GET /api/v1/user/:id/invitees - guest - can get users they invited", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChangeUserRole change a user's role
+//
+//	@Summary      admin-only operation to promote or demote a user
+//	@Description  Changes the user's role and records the change. The user's existing tokens are revoked, so they must log in again to get a token with the new role. The last admin cannot be demoted.
+//	@Tags         user
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "User ID" Format(uuid)
+//	@Param		  data body types.UpdateUserRoleInput true "The new role"
+//	@Success      202  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      404  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      409  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Router       /user/{id}/role [put]
+func (h *Handler) ChangeUserRole(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_ROLE_CHANGES{}
+	var status int
+	var input types.UpdateUserRoleInput
+	userId, idErr := uuid.Parse(c.Param("id"))
+	changedById, uidErr := uuid.Parse(c.GetString("uid"))
+	if idErr != nil || uidErr != nil || c.ShouldBindJSON(&input) != nil {
+		status = http.StatusBadRequest
+		response.Message = "Invalid arguments."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	change := models.RoleChange{
+		UserId:      userId,
+		ChangedById: changedById,
+		NewRole:     input.Role,
+	}
+	err := h.Users.ChangeUserRole(ctx, &change)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "User not found"
+	case errors.Is(err, models.ErrLastAdmin):
+		status = http.StatusConflict
+		response.Message = "Cannot remove the last admin"
+	case err != nil:
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error changing user role: ", err.Error())
+	case change.OldRole == change.NewRole:
+		status = http.StatusAccepted
+		response.Message = "User already has this role"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Changed user role"
+		response.Data.RoleChanges = []models.RoleChange{change}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// GetRoleChanges gets the role changes for a user
+//
+//	@Summary      admin-only operation to get a user's role history
+//	@Description  Gets the role changes made to the user, oldest first
+//	@Tags         user
+//	@Produce      json
+//	@Param 		  id  path string true "User ID" Format(uuid)
+//	@Success      200  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_ROLE_CHANGES
+//	@Router       /user/{id}/role-changes [get]
+func (h *Handler) GetRoleChanges(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_ROLE_CHANGES{}
+	var status int
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Message = "Invalid arguments."
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+	changes, err := h.Users.FindRoleChanges(ctx, userId)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding role changes: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.RoleChanges = changes
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
@@ -0,0 +1,113 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UserRoleController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	roleJson := func(role string) *strings.Reader {
+		input, _ := json.Marshal(types.UpdateUserRoleInput{Role: role})
+		return strings.NewReader(string(input))
+	}
+	t.Run("PUT /api/v1/user/:id/role - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role", fixtures.Guest().ID)
+		req, err := http.NewRequest("PUT", routePath, roleJson(models.RoleInvitee))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_ROLE_CHANGES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("PUT /api/v1/user/:id/role - planner - cannot change roles", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role", fixtures.Planner().ID)
+		req, err := http.NewRequest("PUT", routePath, roleJson(models.RoleAdmin))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PUT /api/v1/user/:id/role - admin - unknown role returns error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role", fixtures.Guest().ID)
+		req, err := http.NewRequest("PUT", routePath, roleJson("SUPERUSER"))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/user/:id/role - admin - promotion revokes the user's old token", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		oldToken := fixtures.Token(t, fixtures.Guest())
+
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role", fixtures.Guest().ID)
+		req, err := http.NewRequest("PUT", routePath, roleJson(models.RoleAdmin))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_ROLE_CHANGES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(1, len(jsonResponse.Data.RoleChanges))
+		assert.Equal(models.RoleGuest, jsonResponse.Data.RoleChanges[0].OldRole)
+		assert.Equal(models.RoleAdmin, jsonResponse.Data.RoleChanges[0].NewRole)
+		assert.Equal(fixtures.Admin().ID, jsonResponse.Data.RoleChanges[0].ChangedById)
+
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", oldToken)
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+
+		changes, _ := store.FindRoleChanges(context.Background(), fixtures.Guest().ID)
+		assert.Equal(1, len(changes))
+	})
+	t.Run("PUT /api/v1/user/:id/role - admin - cannot demote the last admin", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role", fixtures.Admin().ID)
+		req, err := http.NewRequest("PUT", routePath, roleJson(models.RoleGuest))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusConflict, w.Code)
+	})
+	t.Run("GET /api/v1/user/:id/role-changes - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		routePath := fmt.Sprintf("/api/v1/user/%s/role-changes", fixtures.Guest().ID)
+		req, err := http.NewRequest("GET", routePath, nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
@@ -1,18 +1,393 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/ax-vasquez/wedding-site-api/models"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // GetHotelRoomReservationBlockLink gets the URL for guests to use to reserve rooms from the block of rooms
-func GetHotelRoomReservationBlockLink(c *gin.Context) {
+//
+//	@Summary      gets the room block's booking link
+//	@Description  Gets the booking URL of the first accommodation that has one (kept for clients that predate the accommodations list at /venue). Falls back to the RESERVATIONS_LINK setting when no accommodation has a booking URL.
+//	@Tags         venue
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_VENUE
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VENUE
+//	@Router       /venue/reservation-link [get]
+func (h *Handler) GetHotelRoomReservationBlockLink(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
 	response := types.V1_API_RESPONSE_VENUE{}
+	var status int
+
+	accommodations, err := h.Venues.FindAccommodations(ctx)
+	if err != nil {
+		log.Println("Error finding accommodations: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Status = status
+		response.Message = "Internal server error"
+		c.JSON(status, response)
+		return
+	}
+
 	link := os.Getenv("RESERVATIONS_LINK")
-	response.Status = http.StatusOK
+	for _, accommodation := range accommodations {
+		if accommodation.BookingUrl != "" {
+			link = accommodation.BookingUrl
+			break
+		}
+	}
+	status = http.StatusOK
+	response.Status = status
 	response.Data.Link = link
-	c.JSON(http.StatusOK, response)
+	c.JSON(status, response)
+}
+
+// GetVenueDetails gets every venue and accommodation
+//
+//	@Summary      gets the venues and accommodations
+//	@Description  Gets the venues the wedding is held at and the accommodations guests can stay at, each in the order they were added
+//	@Tags         venue
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_VENUE_DETAILS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VENUE_DETAILS
+//	@Router       /venue [get]
+func (h *Handler) GetVenueDetails(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_VENUE_DETAILS{}
+	var status int
+
+	venues, err := h.Venues.FindVenues(ctx)
+	var accommodations []models.Accommodation
+	if err == nil {
+		accommodations, err = h.Venues.FindAccommodations(ctx)
+	}
+	if err != nil {
+		log.Println("Error finding venue details: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusOK
+		response.Data.Venues = venues
+		response.Data.Accommodations = accommodations
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// Builds the venue described by the input
+func venueFromInput(input types.VenueInput) models.Venue {
+	return models.Venue{
+		Name:      input.Name,
+		Address:   input.Address,
+		Latitude:  input.Latitude,
+		Longitude: input.Longitude,
+		Notes:     input.Notes,
+	}
+}
+
+// Builds the accommodation described by the input; returns an error if it checks out before it checks in
+func accommodationFromInput(input types.AccommodationInput) (models.Accommodation, error) {
+	if input.CheckInDate != nil && input.CheckOutDate != nil && !input.CheckOutDate.After(*input.CheckInDate) {
+		return models.Accommodation{}, errors.New("check_out_date must be after check_in_date")
+	}
+	return models.Accommodation{
+		Name:            input.Name,
+		Address:         input.Address,
+		Latitude:        input.Latitude,
+		Longitude:       input.Longitude,
+		CheckInDate:     input.CheckInDate,
+		CheckOutDate:    input.CheckOutDate,
+		RoomBlockCode:   input.RoomBlockCode,
+		BookingUrl:      input.BookingUrl,
+		ContractedRooms: input.ContractedRooms,
+		CutoffDate:      input.CutoffDate,
+		Notes:           input.Notes,
+	}, nil
+}
+
+// CreateVenue creates a venue
+//
+//	@Summary      admin-only operation to add a venue
+//	@Description  Adds a venue and returns the new record's data to the caller
+//	@Tags         venue
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.VenueInput true "The venue (only `name` is required)"
+//	@Success      201  {object}  types.V1_API_RESPONSE_VENUES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_VENUES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VENUES
+//	@Router       /venue/venues [post]
+func (h *Handler) CreateVenue(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_VENUES{}
+	var status int
+
+	var input types.VenueInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	venue := venueFromInput(input)
+	if err := h.Venues.CreateVenue(ctx, &venue); err != nil {
+		log.Println("Error creating venue: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created venue"
+		response.Data.Venues = []models.Venue{venue}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateVenue replaces a venue's details
+//
+//	@Summary      admin-only operation to update a venue
+//	@Description  Replaces every detail of a venue
+//	@Tags         venue
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Venue ID" Format(uuid)
+//	@Param		  data body types.VenueInput true "The venue (only `name` is required)"
+//	@Success      202  {object}  types.V1_API_RESPONSE_VENUES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_VENUES
+//	@Failure      404  {object}  types.V1_API_RESPONSE_VENUES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VENUES
+//	@Router       /venue/venues/{id} [put]
+func (h *Handler) UpdateVenue(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_VENUES{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.VenueInput
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	venue := venueFromInput(input)
+	venue.ID = id
+	err = h.Venues.UpdateVenue(ctx, &venue)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Venue not found"
+	case err != nil:
+		log.Println("Error updating venue: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated venue"
+		response.Data.Venues = []models.Venue{venue}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteVenue deletes a venue
+//
+//	@Summary      admin-only operation to remove a venue
+//	@Description  Removes a venue
+//	@Tags         venue
+//	@Produce      json
+//	@Param 		  id  path string true "Venue ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /venue/venues/{id} [delete]
+func (h *Handler) DeleteVenue(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Venues.DeleteVenue(ctx, id)
+	if err != nil {
+		log.Println("Error deleting venue: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted venue"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// CreateAccommodation creates an accommodation
+//
+//	@Summary      admin-only operation to add an accommodation
+//	@Description  Adds a hotel (or other lodging) with a block of rooms for guests and returns the new record's data to the caller
+//	@Tags         venue
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.AccommodationInput true "The accommodation (only `name` is required)"
+//	@Success      201  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Router       /venue/accommodations [post]
+func (h *Handler) CreateAccommodation(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_ACCOMMODATIONS{}
+	var status int
+
+	var input types.AccommodationInput
+	var accommodation models.Accommodation
+	err := c.ShouldBindBodyWithJSON(&input)
+	if err == nil {
+		accommodation, err = accommodationFromInput(input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	if err := h.Venues.CreateAccommodation(ctx, &accommodation); err != nil {
+		log.Println("Error creating accommodation: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusCreated
+		response.Message = "Created accommodation"
+		response.Data.Accommodations = []models.Accommodation{accommodation}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateAccommodation replaces an accommodation's details
+//
+//	@Summary      admin-only operation to update an accommodation
+//	@Description  Replaces every detail of an accommodation
+//	@Tags         venue
+//	@Accept       json
+//	@Produce      json
+//	@Param 		  id  path string true "Accommodation ID" Format(uuid)
+//	@Param		  data body types.AccommodationInput true "The accommodation (only `name` is required)"
+//	@Success      202  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Failure      400  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Failure      404  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Failure      500  {object}  types.V1_API_RESPONSE_ACCOMMODATIONS
+//	@Router       /venue/accommodations/{id} [put]
+func (h *Handler) UpdateAccommodation(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_ACCOMMODATIONS{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	var input types.AccommodationInput
+	var accommodation models.Accommodation
+	if err == nil {
+		err = c.ShouldBindBodyWithJSON(&input)
+	}
+	if err == nil {
+		accommodation, err = accommodationFromInput(input)
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	accommodation.ID = id
+	err = h.Venues.UpdateAccommodation(ctx, &accommodation)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		response.Message = "Accommodation not found"
+	case err != nil:
+		log.Println("Error updating accommodation: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	default:
+		status = http.StatusAccepted
+		response.Message = "Updated accommodation"
+		response.Data.Accommodations = []models.Accommodation{accommodation}
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// DeleteAccommodation deletes an accommodation
+//
+//	@Summary      admin-only operation to remove an accommodation
+//	@Description  Removes an accommodation
+//	@Tags         venue
+//	@Produce      json
+//	@Param 		  id  path string true "Accommodation ID" Format(uuid)
+//	@Success      202  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      400  {object}  types.V1_API_DELETE_RESPONSE
+//	@Failure      500  {object}  types.V1_API_DELETE_RESPONSE
+//	@Router       /venue/accommodations/{id} [delete]
+func (h *Handler) DeleteAccommodation(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_DELETE_RESPONSE{}
+	var status int
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		status = http.StatusBadRequest
+		response.Status = status
+		response.Message = err.Error()
+		c.JSON(status, response)
+		return
+	}
+
+	result, err := h.Venues.DeleteAccommodation(ctx, id)
+	if err != nil {
+		log.Println("Error deleting accommodation: ", err.Error())
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Deleted accommodation"
+		response.Data.DeletedRecords = int(result)
+	}
+	response.Status = status
+	c.JSON(status, response)
 }
@@ -10,13 +10,14 @@ import (
 	"os"
 	"testing"
 
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/ax-vasquez/wedding-site-api/types"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_EventDetailsController_NoAuth_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	os.Setenv("RESERVATIONS_LINK", "www.hello.world")
 	t.Run("GET /api/v1/venue/reservation-link - user can get the reservation link", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -30,7 +31,7 @@ func Test_EventDetailsController_NoAuth_Integration(t *testing.T) {
 
 func Test_EventDetailsController_Admin_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "admin@admin.admin")
 	fakeLink := "www.hello.world"
 	os.Setenv("RESERVATIONS_LINK", fakeLink)
@@ -51,7 +52,7 @@ func Test_EventDetailsController_Admin_Integration(t *testing.T) {
 
 func Test_EventDetailsController_Guest_Integration(t *testing.T) {
 	assert := assert.New(t)
-	router := paveRoutes()
+	router := paveRoutes(NewHandler(repository.NewGormStore()))
 	token, _ := loginUser(router, assert, "user_1@fakedomain.com")
 	fakeLink := "www.hello.world"
 	os.Setenv("RESERVATIONS_LINK", fakeLink)
@@ -0,0 +1,141 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VenueController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	latitude, longitude := 41.8781, -87.6298
+	checkIn := time.Date(2027, time.June, 11, 0, 0, 0, 0, time.UTC)
+	checkOut := checkIn.Add(48 * time.Hour)
+	hotel := types.AccommodationInput{
+		VenueInput:    types.VenueInput{Name: "The Grand Hotel", Address: "2 Main St", Latitude: &latitude, Longitude: &longitude},
+		CheckInDate:   &checkIn,
+		CheckOutDate:  &checkOut,
+		RoomBlockCode: "SMITHJONES",
+		BookingUrl:    "https://hotel.example/book?group=smithjones",
+	}
+	router := paveRoutes(NewHandler(fixtures.NewStore()))
+	t.Run("GET /api/v1/venue - lists the venues and accommodations admins add", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/venue/venues", fixtures.Admin(), types.VenueInput{Name: "The Garden", Address: "1 Main St", Latitude: &latitude, Longitude: &longitude})
+		assert.Equal(http.StatusCreated, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), hotel)
+		assert.Equal(http.StatusCreated, w.Code)
+
+		w = fixtures.Serve(t, router, "GET", "/api/v1/venue", fixtures.Guest(), nil)
+		assert.Equal(http.StatusOK, w.Code)
+		var venueResponse types.V1_API_RESPONSE_VENUE_DETAILS
+		json.Unmarshal([]byte(w.Body.Bytes()), &venueResponse)
+		assert.Equal(1, len(venueResponse.Data.Venues))
+		assert.Equal("The Garden", venueResponse.Data.Venues[0].Name)
+		assert.Equal(latitude, *venueResponse.Data.Venues[0].Latitude)
+		assert.Equal(1, len(venueResponse.Data.Accommodations))
+		assert.Equal("SMITHJONES", venueResponse.Data.Accommodations[0].RoomBlockCode)
+		assert.True(checkIn.Equal(*venueResponse.Data.Accommodations[0].CheckInDate))
+	})
+	t.Run("GET /api/v1/venue/reservation-link - uses the first booking URL, falling back to RESERVATIONS_LINK", func(t *testing.T) {
+		t.Setenv("RESERVATIONS_LINK", "www.hello.world")
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		link := func() string {
+			w := fixtures.Serve(t, router, "GET", "/api/v1/venue/reservation-link", fixtures.Guest(), nil)
+			assert.Equal(http.StatusOK, w.Code)
+			var linkResponse types.V1_API_RESPONSE_VENUE
+			json.Unmarshal([]byte(w.Body.Bytes()), &linkResponse)
+			return linkResponse.Data.Link
+		}
+		assert.Equal("www.hello.world", link())
+
+		fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), types.AccommodationInput{VenueInput: types.VenueInput{Name: "Overflow Inn"}})
+		fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), hotel)
+		assert.Equal(hotel.BookingUrl, link())
+	})
+	t.Run("POST /api/v1/venue/accommodations - rejects invalid details", func(t *testing.T) {
+		backwards := hotel
+		backwards.CheckOutDate = &checkIn
+		w := fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), backwards)
+		assert.Equal(http.StatusBadRequest, w.Code)
+
+		badUrl := hotel
+		badUrl.BookingUrl = "not a url"
+		w = fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), badUrl)
+		assert.Equal(http.StatusBadRequest, w.Code)
+
+		// A latitude without a longitude can't be shown on a map
+		w = fixtures.Serve(t, router, "POST", "/api/v1/venue/venues", fixtures.Admin(), types.VenueInput{Name: "The Garden", Latitude: &latitude})
+		assert.Equal(http.StatusBadRequest, w.Code)
+		outOfRange := 91.0
+		w = fixtures.Serve(t, router, "POST", "/api/v1/venue/venues", fixtures.Admin(), types.VenueInput{Name: "The Garden", Latitude: &outOfRange, Longitude: &longitude})
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("Venue routes - only admins can make changes", func(t *testing.T) {
+		w := fixtures.Serve(t, router, "POST", "/api/v1/venue/venues", fixtures.Planner(), types.VenueInput{Name: "The Garden"})
+		assert.Equal(http.StatusUnauthorized, w.Code)
+		w = fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Guest(), hotel)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PUT /api/v1/venue/accommodations/:id - updates and deletes accommodations", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), hotel)
+		var accommodationResponse types.V1_API_RESPONSE_ACCOMMODATIONS
+		json.Unmarshal([]byte(w.Body.Bytes()), &accommodationResponse)
+		id := accommodationResponse.Data.Accommodations[0].ID.String()
+
+		updated := hotel
+		updated.Notes = "Breakfast is included"
+		updated.CheckInDate = nil
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/accommodations/"+id, fixtures.Admin(), updated)
+		assert.Equal(http.StatusAccepted, w.Code)
+		w = fixtures.Serve(t, router, "GET", "/api/v1/venue", fixtures.Guest(), nil)
+		var venueResponse types.V1_API_RESPONSE_VENUE_DETAILS
+		json.Unmarshal([]byte(w.Body.Bytes()), &venueResponse)
+		assert.Equal("Breakfast is included", venueResponse.Data.Accommodations[0].Notes)
+		assert.Nil(venueResponse.Data.Accommodations[0].CheckInDate)
+
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/accommodations/"+uuid.New().String(), fixtures.Admin(), updated)
+		assert.Equal(http.StatusNotFound, w.Code)
+		w = fixtures.Serve(t, router, "PUT", "/api/v1/venue/venues/"+uuid.New().String(), fixtures.Admin(), types.VenueInput{Name: "The Garden"})
+		assert.Equal(http.StatusNotFound, w.Code)
+
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/venue/accommodations/"+id, fixtures.Admin(), nil)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var deleteResponse types.V1_API_DELETE_RESPONSE
+		json.Unmarshal([]byte(w.Body.Bytes()), &deleteResponse)
+		assert.Equal(1, deleteResponse.Data.DeletedRecords)
+		w = fixtures.Serve(t, router, "DELETE", "/api/v1/venue/venues/not-a-uuid", fixtures.Admin(), nil)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("Venue routes - internal server errors", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New("arbitrary database error"))))
+		id := uuid.New().String()
+		for _, w := range []*httptest.ResponseRecorder{
+			fixtures.Serve(t, router, "GET", "/api/v1/venue", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "GET", "/api/v1/venue/reservation-link", fixtures.Guest(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/venue/venues", fixtures.Admin(), types.VenueInput{Name: "The Garden"}),
+			fixtures.Serve(t, router, "PUT", "/api/v1/venue/venues/"+id, fixtures.Admin(), types.VenueInput{Name: "The Garden"}),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/venue/venues/"+id, fixtures.Admin(), nil),
+			fixtures.Serve(t, router, "POST", "/api/v1/venue/accommodations", fixtures.Admin(), hotel),
+			fixtures.Serve(t, router, "PUT", "/api/v1/venue/accommodations/"+id, fixtures.Admin(), hotel),
+			fixtures.Serve(t, router, "DELETE", "/api/v1/venue/accommodations/"+id, fixtures.Admin(), nil),
+		} {
+			assert.Equal(http.StatusInternalServerError, w.Code)
+			var errResponse types.V1_API_RESPONSE
+			json.Unmarshal([]byte(w.Body.Bytes()), &errResponse)
+			assert.Equal("Internal server error", errResponse.Message)
+		}
+	})
+}
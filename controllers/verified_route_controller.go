@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/gin-gonic/gin"
+)
+
+// Routes under these prefixes can never require a verified email address, since users need them to verify their
+// address (and admins need them to undo a mistake)
+var unverifiableRoutePrefixes = []string{"/api/v1/auth/", "/api/v1/settings/"}
+
+// GetVerifiedRoutes gets the routes that require a verified email address
+//
+//	@Summary      admin-only operation to get the routes that require a verified email address
+//	@Description  Gets the routes (by method and path pattern) that can only be used by users who have verified their email address
+//	@Tags         settings
+//	@Produce      json
+//	@Success      200  {object}  types.V1_API_RESPONSE_VERIFIED_ROUTES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VERIFIED_ROUTES
+//	@Router       /settings/verified-routes [get]
+func (h *Handler) GetVerifiedRoutes(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_VERIFIED_ROUTES{}
+	var status int
+	routes, err := h.VerifiedRoutes.FindVerifiedRoutes(ctx)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error finding verified routes: ", err.Error())
+	} else {
+		status = http.StatusOK
+		response.Data.Routes = routes
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
+
+// UpdateVerifiedRoutes sets the routes that require a verified email address
+//
+//	@Summary      admin-only operation to set the routes that require a verified email address
+//	@Description  Replaces the routes that can only be used by users who have verified their email address. Paths are route patterns as registered with the router (e.g., `/api/v1/user/:id/role`). Routes under `/api/v1/auth/` and `/api/v1/settings/` can't be included.
+//	@Tags         settings
+//	@Accept       json
+//	@Produce      json
+//	@Param		  data body types.UpdateVerifiedRoutesInput true "Every route that should require a verified email address"
+//	@Success      202  {object}  types.V1_API_RESPONSE_VERIFIED_ROUTES
+//	@Failure      400  {object}  types.V1_API_RESPONSE_VERIFIED_ROUTES
+//	@Failure      500  {object}  types.V1_API_RESPONSE_VERIFIED_ROUTES
+//	@Router       /settings/verified-routes [put]
+func (h *Handler) UpdateVerifiedRoutes(c *gin.Context) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	response := types.V1_API_RESPONSE_VERIFIED_ROUTES{}
+	var status int
+	var input types.UpdateVerifiedRoutesInput
+	if err := c.ShouldBindBodyWithJSON(&input); err != nil {
+		status = http.StatusBadRequest
+		response.Message = err.Error()
+		response.Status = status
+		c.JSON(status, response)
+		return
+	}
+
+	routes := []models.VerifiedRoute{}
+	seen := map[models.VerifiedRoute]bool{}
+	for _, r := range input.Routes {
+		for _, prefix := range unverifiableRoutePrefixes {
+			if strings.HasPrefix(r.Path, prefix) {
+				status = http.StatusBadRequest
+				response.Message = "Routes under " + prefix + " can't require a verified email address"
+				response.Status = status
+				c.JSON(status, response)
+				return
+			}
+		}
+		route := models.VerifiedRoute{Method: r.Method, Path: r.Path}
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+
+	err := h.VerifiedRoutes.ReplaceVerifiedRoutes(ctx, &routes)
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Message = "Internal server error"
+		log.Println("Error replacing verified routes: ", err.Error())
+	} else {
+		status = http.StatusAccepted
+		response.Message = "Updated verified routes"
+		response.Data.Routes = routes
+	}
+	response.Status = status
+	c.JSON(status, response)
+}
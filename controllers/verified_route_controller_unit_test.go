@@ -0,0 +1,105 @@
+//go:build unit
+// +build unit
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository/repositorytest"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/ax-vasquez/wedding-site-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VerifiedRouteController_Unit(t *testing.T) {
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	apiErrMsg := "Internal server error"
+	router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg))))
+	routesJson := func(routes ...types.VerifiedRouteInput) *strings.Reader {
+		body, _ := json.Marshal(types.UpdateVerifiedRoutesInput{Routes: routes})
+		return strings.NewReader(string(body))
+	}
+	rsvp := types.VerifiedRouteInput{Method: "PATCH", Path: "/api/v1/user"}
+	t.Run("GET /api/v1/settings/verified-routes - internal server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/settings/verified-routes", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+
+		var jsonResponse types.V1_API_RESPONSE_VERIFIED_ROUTES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(apiErrMsg, jsonResponse.Message)
+	})
+	t.Run("PUT /api/v1/settings/verified-routes - planner - cannot change settings", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/verified-routes", routesJson(rsvp))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Planner()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusUnauthorized, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/verified-routes - admin - routes needed to verify can't be included", func(t *testing.T) {
+		router := paveRoutes(NewHandler(fixtures.NewStore()))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/verified-routes", routesJson(types.VerifiedRouteInput{Method: "POST", Path: "/api/v1/auth/verify-email/resend"}))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+	t.Run("PUT /api/v1/settings/verified-routes - admin - unverified users can't use chosen routes", func(t *testing.T) {
+		store := fixtures.NewStore()
+		router := paveRoutes(NewHandler(store))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/api/v1/settings/verified-routes", routesJson(rsvp, rsvp))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Admin()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusAccepted, w.Code)
+		var jsonResponse types.V1_API_RESPONSE_VERIFIED_ROUTES
+		json.Unmarshal([]byte(w.Body.Bytes()), &jsonResponse)
+		assert.Equal(1, len(jsonResponse.Data.Routes))
+
+		rsvpJson, _ := json.Marshal(types.UpdateUserInput{IsGoing: true})
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(rsvpJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusForbidden, w.Code)
+
+		// Other routes still work
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+
+		store.VerifyEmail(context.Background(), &models.EmailVerification{UserId: fixtures.Guest().ID}, time.Now())
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PATCH", "/api/v1/user", strings.NewReader(string(rsvpJson)))
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusAccepted, w.Code)
+	})
+	t.Run("GET /api/v1/user - error checking the route returns internal server error", func(t *testing.T) {
+		router := paveRoutes(NewHandler(repositorytest.NewFailingStore(errors.New(errMsg), "IsVerifiedRoute")))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/user", nil)
+		req.Header.Set("auth-token", fixtures.Token(t, fixtures.Guest()))
+		router.ServeHTTP(w, req)
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,136 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+)
+
+// How addresses are written in a country
+type addressFormat struct {
+	// The country's name, printed on the last line of mail sent there from another country
+	name string
+	// Whether addresses there must have a state, province or county
+	requireRegion bool
+	// Whether addresses there must have a postal code
+	requirePostalCode bool
+	// The shape of the country's postal codes; any postal code is accepted without one
+	postalCode *regexp.Regexp
+	// Lays out the lines under the street address (the city, region and postal code)
+	locality func(h models.Household) []string
+}
+
+// "City, Region PostalCode" (e.g., the US) or "City Region PostalCode" (e.g., Canada)
+func cityRegionPostalCode(separator string) func(h models.Household) []string {
+	return func(h models.Household) []string {
+		return []string{joinAddressParts(" ", joinAddressParts(separator, h.City, h.Region), h.PostalCode)}
+	}
+}
+
+// "PostalCode City Region" (e.g., most of Europe)
+func postalCodeCityRegion(h models.Household) []string {
+	return []string{joinAddressParts(" ", h.PostalCode, h.City, h.Region)}
+}
+
+// The city and postal code on their own lines (e.g., the UK)
+func cityThenPostalCode(h models.Household) []string {
+	return []string{h.City, h.PostalCode}
+}
+
+// How addresses are written in the countries guests are most likely to live in, by ISO 3166-1 alpha-2 code; other
+// countries use defaultAddressFormat
+var addressFormats = map[string]addressFormat{
+	"US": {name: "United States", requireRegion: true, requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`), locality: cityRegionPostalCode(", ")},
+	"CA": {name: "Canada", requireRegion: true, requirePostalCode: true, postalCode: regexp.MustCompile(`^[A-Za-z]\d[A-Za-z] ?\d[A-Za-z]\d$`), locality: cityRegionPostalCode(" ")},
+	"AU": {name: "Australia", requireRegion: true, requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{4}$`), locality: cityRegionPostalCode(" ")},
+	"MX": {name: "Mexico", requireRegion: true, requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}$`), locality: func(h models.Household) []string {
+		return []string{joinAddressParts(", ", joinAddressParts(" ", h.PostalCode, h.City), h.Region)}
+	}},
+	"GB": {name: "United Kingdom", requirePostalCode: true, postalCode: regexp.MustCompile(`^[A-Za-z]{1,2}\d[A-Za-z\d]? ?\d[A-Za-z]{2}$`), locality: cityThenPostalCode},
+	"IE": {name: "Ireland", locality: cityThenPostalCode},
+	"DE": {name: "Germany", requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}$`), locality: postalCodeCityRegion},
+	"FR": {name: "France", requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}$`), locality: postalCodeCityRegion},
+	"ES": {name: "Spain", requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}$`), locality: postalCodeCityRegion},
+	"IT": {name: "Italy", requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{5}$`), locality: postalCodeCityRegion},
+	"NL": {name: "Netherlands", requirePostalCode: true, postalCode: regexp.MustCompile(`^\d{4} ?[A-Za-z]{2}$`), locality: postalCodeCityRegion},
+}
+
+// Countries without an entry in addressFormats put the city, region and postal code on one line
+var defaultAddressFormat = addressFormat{locality: cityRegionPostalCode(" ")}
+
+// Joins the parts of an address that are filled in
+func joinAddressParts(separator string, parts ...string) string {
+	var filled []string
+	for _, part := range parts {
+		if part != "" {
+			filled = append(filled, part)
+		}
+	}
+	return strings.Join(filled, separator)
+}
+
+func findAddressFormat(country string) addressFormat {
+	if format, ok := addressFormats[country]; ok {
+		return format
+	}
+	return defaultAddressFormat
+}
+
+// MailingHomeCountry gets the country mail is sent from, as an ISO 3166-1 alpha-2 code; the country is read from
+// MAILING_HOME_COUNTRY, which defaults to "US"
+func MailingHomeCountry() string {
+	country := strings.ToUpper(os.Getenv("MAILING_HOME_COUNTRY"))
+	if country == "" {
+		return "US"
+	}
+	return country
+}
+
+// ValidateAddress checks that a household's address has everything mail to its country needs
+func ValidateAddress(h models.Household) error {
+	format := findAddressFormat(h.Country)
+	switch {
+	case h.AddressLine1 == "":
+		return errors.New("address_line_1 is required")
+	case h.City == "":
+		return errors.New("city is required")
+	case format.requireRegion && h.Region == "":
+		return fmt.Errorf("region is required for addresses in %s", h.Country)
+	case format.requirePostalCode && h.PostalCode == "":
+		return fmt.Errorf("postal_code is required for addresses in %s", h.Country)
+	case h.PostalCode != "" && format.postalCode != nil && !format.postalCode.MatchString(h.PostalCode):
+		return fmt.Errorf("postal_code %q is not valid for addresses in %s", h.PostalCode, h.Country)
+	}
+	return nil
+}
+
+// FormatAddress lays out a household's address the way it's written in its country, without the household's name
+//
+// Mail to other countries than homeCountry ends with the name of the country in capitals (or its code, for countries
+// without an entry in addressFormats).
+func FormatAddress(h models.Household, homeCountry string) []string {
+	format := findAddressFormat(h.Country)
+	var lines []string
+	for _, line := range append([]string{h.AddressLine1, h.AddressLine2, h.AddressLine3}, format.locality(h)...) {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if h.Country != homeCountry {
+		name := format.name
+		if name == "" {
+			name = h.Country
+		}
+		lines = append(lines, strings.ToUpper(name))
+	}
+	return lines
+}
+
+// MailingLabel gets the lines printed on a household's mailing label: its name and then its address
+func MailingLabel(h models.Household, homeCountry string) []string {
+	return append([]string{h.Name}, FormatAddress(h, homeCountry)...)
+}
@@ -0,0 +1,59 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AddressHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	domestic := models.Household{
+		Name:         "Alex and Sam Smith",
+		AddressLine1: "1 Main St",
+		AddressLine2: "Apt 2",
+		City:         "Springfield",
+		Region:       "IL",
+		PostalCode:   "62701",
+		Country:      "US",
+	}
+	t.Run("FormatAddress - leaves the country off of domestic mail", func(t *testing.T) {
+		assert.Equal([]string{"1 Main St", "Apt 2", "Springfield, IL 62701"}, FormatAddress(domestic, "US"))
+		assert.Equal([]string{"Alex and Sam Smith", "1 Main St", "Apt 2", "Springfield, IL 62701"}, MailingLabel(domestic, "US"))
+	})
+	t.Run("FormatAddress - lays out the address the way its country does, ending with the country's name", func(t *testing.T) {
+		german := models.Household{AddressLine1: "Hauptstraße 1", City: "Berlin", PostalCode: "10115", Country: "DE"}
+		assert.Equal([]string{"Hauptstraße 1", "10115 Berlin", "GERMANY"}, FormatAddress(german, "US"))
+		british := models.Household{AddressLine1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"}
+		assert.Equal([]string{"10 Downing St", "London", "SW1A 2AA", "UNITED KINGDOM"}, FormatAddress(british, "US"))
+		assert.Equal([]string{"1 Main St", "Apt 2", "Springfield, IL 62701", "UNITED STATES"}, FormatAddress(domestic, "CA"))
+		// Countries without a known format fall back to one line and their code
+		other := models.Household{AddressLine1: "1 Queen St", City: "Auckland", PostalCode: "1010", Country: "NZ"}
+		assert.Equal([]string{"1 Queen St", "Auckland 1010", "NZ"}, FormatAddress(other, "US"))
+	})
+	t.Run("ValidateAddress - requires what mail to the country needs", func(t *testing.T) {
+		assert.Nil(ValidateAddress(domestic))
+		noState := domestic
+		noState.Region = ""
+		assert.EqualError(ValidateAddress(noState), "region is required for addresses in US")
+		badZip := domestic
+		badZip.PostalCode = "6270"
+		assert.EqualError(ValidateAddress(badZip), `postal_code "6270" is not valid for addresses in US`)
+		noStreet := domestic
+		noStreet.AddressLine1 = ""
+		assert.EqualError(ValidateAddress(noStreet), "address_line_1 is required")
+		// Irish addresses don't always have an Eircode
+		assert.Nil(ValidateAddress(models.Household{AddressLine1: "1 Main St", City: "Galway", Country: "IE"}))
+		assert.NotNil(ValidateAddress(models.Household{AddressLine1: "1 Main St", City: "London", Country: "GB"}))
+	})
+	t.Run("MailingHomeCountry - defaults to the US", func(t *testing.T) {
+		t.Setenv("MAILING_HOME_COUNTRY", "")
+		assert.Equal("US", MailingHomeCountry())
+		t.Setenv("MAILING_HOME_COUNTRY", "ca")
+		assert.Equal("CA", MailingHomeCountry())
+	})
+}
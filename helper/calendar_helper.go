@@ -0,0 +1,186 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	// Embeds the time zone database so calendars can use any time zone, even on hosts without one installed
+	_ "time/tzdata"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+)
+
+// Identifies this API as the product that made a calendar, and as the domain of the events' UIDs
+const calendarProductId = "wedding-site-api"
+
+// The longest a line of a calendar can be in octets (RFC 5545 section 3.1); longer lines are folded
+const calendarLineLength = 75
+
+const (
+	calendarUtcFormat   = "20060102T150405Z"
+	calendarLocalFormat = "20060102T150405"
+)
+
+// Escapes TEXT values (RFC 5545 section 3.3.11)
+var calendarTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// CalendarLocation gets the time zone event times are shown in on calendars
+//
+// The time zone is read from CALENDAR_TIMEZONE, which defaults to PGSQL_TIMEZONE (e.g., "US/Central") and then "UTC".
+func CalendarLocation() (*time.Location, error) {
+	name := os.Getenv("CALENDAR_TIMEZONE")
+	if name == "" {
+		name = os.Getenv("PGSQL_TIMEZONE")
+	}
+	return time.LoadLocation(name)
+}
+
+// CalendarEventUID gets the UID of an event on calendars; it never changes, so calendar apps replace their copy of the
+// event when it's updated instead of adding another
+func CalendarEventUID(event models.Event) string {
+	return event.ID.String() + "@" + calendarProductId
+}
+
+// BuildCalendar creates an iCalendar (RFC 5545) calendar of the given events, with their times in the given time zone
+//
+// The calendar's name is read from CALENDAR_NAME, which defaults to "Wedding".
+func BuildCalendar(events []models.Event, loc *time.Location, now time.Time) string {
+	name := os.Getenv("CALENDAR_NAME")
+	if name == "" {
+		name = "Wedding"
+	}
+	utc := loc.String() == "UTC"
+	w := calendarWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//"+calendarProductId+"//Events//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("NAME", calendarTextEscaper.Replace(name))
+	w.line("X-WR-CALNAME", calendarTextEscaper.Replace(name))
+	// How often calendar apps subscribed to the calendar should check for changes
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	w.line("X-PUBLISHED-TTL", "PT1H")
+	if !utc && len(events) > 0 {
+		w.line("X-WR-TIMEZONE", loc.String())
+		w.timezone(events, loc)
+	}
+	for _, event := range events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", CalendarEventUID(event))
+		w.line("DTSTAMP", now.UTC().Format(calendarUtcFormat))
+		w.line("CREATED", event.CreatedAt.UTC().Format(calendarUtcFormat))
+		w.line("LAST-MODIFIED", event.UpdatedAt.UTC().Format(calendarUtcFormat))
+		w.line("SEQUENCE", fmt.Sprint(event.Sequence))
+		if utc {
+			w.line("DTSTART", event.StartsAt.UTC().Format(calendarUtcFormat))
+			w.line("DTEND", event.EndsAt.UTC().Format(calendarUtcFormat))
+		} else {
+			w.line("DTSTART;TZID="+loc.String(), event.StartsAt.In(loc).Format(calendarLocalFormat))
+			w.line("DTEND;TZID="+loc.String(), event.EndsAt.In(loc).Format(calendarLocalFormat))
+		}
+		w.line("SUMMARY", calendarTextEscaper.Replace(event.Name))
+		if event.Location != "" {
+			w.line("LOCATION", calendarTextEscaper.Replace(event.Location))
+		}
+		if description := calendarEventDescription(event); description != "" {
+			w.line("DESCRIPTION", calendarTextEscaper.Replace(description))
+		}
+		w.line("END", "VEVENT")
+	}
+	w.line("END", "VCALENDAR")
+	return w.String()
+}
+
+// The event's description, followed by its dress code
+func calendarEventDescription(event models.Event) string {
+	parts := []string{}
+	if event.Description != "" {
+		parts = append(parts, event.Description)
+	}
+	if event.DressCode != "" {
+		parts = append(parts, "Dress code: "+event.DressCode)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+type calendarWriter struct {
+	strings.Builder
+}
+
+// Writes a content line, folding it so no line is longer than calendarLineLength octets (without splitting characters)
+func (w *calendarWriter) line(name string, value string) {
+	length := 0
+	for _, r := range name + ":" + value {
+		size := len(string(r))
+		if length+size > calendarLineLength {
+			w.WriteString("\r\n ")
+			length = 1
+		}
+		w.WriteRune(r)
+		length += size
+	}
+	w.WriteString("\r\n")
+}
+
+// Writes the VTIMEZONE for the given time zone, with every change of offset from the start of the first event's year
+// until the end of the last event's year
+func (w *calendarWriter) timezone(events []models.Event, loc *time.Location) {
+	first, last := events[0].StartsAt, events[0].EndsAt
+	for _, event := range events {
+		if event.StartsAt.Before(first) {
+			first = event.StartsAt
+		}
+		if event.EndsAt.After(last) {
+			last = event.EndsAt
+		}
+	}
+	from := time.Date(first.In(loc).Year(), time.January, 1, 0, 0, 0, 0, loc)
+	to := time.Date(last.In(loc).Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", loc.String())
+	_, offset := from.Zone()
+	w.observance(from, offset)
+	for t := from; ; {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+		w.observance(end, offset)
+		_, offset = end.Zone()
+		t = end
+	}
+	w.line("END", "VTIMEZONE")
+}
+
+// Writes the STANDARD or DAYLIGHT observance that starts at the given time, when the offset changes from offsetFrom
+func (w *calendarWriter) observance(start time.Time, offsetFrom int) {
+	name, offset := start.Zone()
+	kind := "STANDARD"
+	if start.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN", kind)
+	// The start is written in the local time before the change
+	w.line("DTSTART", start.In(time.FixedZone("", offsetFrom)).Format(calendarLocalFormat))
+	w.line("TZOFFSETFROM", calendarOffset(offsetFrom))
+	w.line("TZOFFSETTO", calendarOffset(offset))
+	w.line("TZNAME", name)
+	w.line("END", kind)
+}
+
+// Formats an offset from UTC in seconds as a UTC-OFFSET value (e.g., "-0500")
+func calendarOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
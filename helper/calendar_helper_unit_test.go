@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalendarHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2027, time.January, 2, 3, 4, 5, 0, time.UTC)
+	startsAt := time.Date(2027, time.June, 12, 21, 0, 0, 0, time.UTC)
+	event := models.Event{
+		BaseModel: models.BaseModel{ID: uuid.MustParse("5c0a4c58-4a3e-4e64-9a53-6f7e0b1f2d11"), CreatedAt: now, UpdatedAt: now},
+		Name:      "Ceremony",
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(time.Hour),
+		Location:  "The Garden, 1 Main St; Springfield",
+		DressCode: "Black tie",
+		Sequence:  2,
+	}
+	t.Run("BuildCalendar - times are local to the time zone, which is described by a VTIMEZONE", func(t *testing.T) {
+		loc, _ := time.LoadLocation("America/Chicago")
+		calendar := BuildCalendar([]models.Event{event}, loc, now)
+
+		assert.True(strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+		assert.Contains(calendar, "UID:5c0a4c58-4a3e-4e64-9a53-6f7e0b1f2d11@wedding-site-api\r\n")
+		assert.Contains(calendar, "SEQUENCE:2\r\n")
+		assert.Contains(calendar, "DTSTAMP:20270102T030405Z\r\n")
+		assert.Contains(calendar, "DTSTART;TZID=America/Chicago:20270612T160000\r\n")
+		assert.Contains(calendar, "DTEND;TZID=America/Chicago:20270612T170000\r\n")
+		assert.Contains(calendar, "LOCATION:The Garden\\, 1 Main St\\; Springfield\r\n")
+		assert.Contains(calendar, "DESCRIPTION:Dress code: Black tie\r\n")
+		// Daylight saving time starts on March 14th and ends on November 7th in 2027
+		assert.Contains(calendar, "BEGIN:VTIMEZONE\r\nTZID:America/Chicago\r\n")
+		assert.Contains(calendar, "BEGIN:DAYLIGHT\r\nDTSTART:20270314T020000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0500\r\nTZNAME:CDT\r\nEND:DAYLIGHT\r\n")
+		assert.Contains(calendar, "BEGIN:STANDARD\r\nDTSTART:20271107T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0600\r\nTZNAME:CST\r\nEND:STANDARD\r\n")
+	})
+	t.Run("BuildCalendar - UTC times don't need a VTIMEZONE", func(t *testing.T) {
+		calendar := BuildCalendar([]models.Event{event}, time.UTC, now)
+		assert.Contains(calendar, "DTSTART:20270612T210000Z\r\n")
+		assert.NotContains(calendar, "VTIMEZONE")
+	})
+	t.Run("BuildCalendar - long lines are folded without splitting characters", func(t *testing.T) {
+		long := event
+		long.Description = strings.Repeat("é", 60) + "\nBring an umbrella"
+		calendar := BuildCalendar([]models.Event{long}, time.UTC, now)
+		for _, line := range strings.Split(calendar, "\r\n") {
+			assert.LessOrEqual(len(line), 75)
+		}
+		unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+		assert.Contains(unfolded, "DESCRIPTION:"+strings.Repeat("é", 60)+"\\nBring an umbrella\\n\\nDress code: Black tie\r\n")
+	})
+	t.Run("CalendarLocation - defaults to the database time zone", func(t *testing.T) {
+		t.Setenv("CALENDAR_TIMEZONE", "")
+		t.Setenv("PGSQL_TIMEZONE", "US/Central")
+		loc, err := CalendarLocation()
+		assert.Nil(err)
+		assert.Equal("US/Central", loc.String())
+		t.Setenv("CALENDAR_TIMEZONE", "Not/AZone")
+		_, err = CalendarLocation()
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,87 @@
+package helper
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+)
+
+// LoginThrottlePolicy decides how long a client must wait after failed login attempts
+//
+// After FreeAttempts failures, each further attempt must wait BaseDelay, doubling with every failure (up to MaxDelay).
+// Once LockoutThreshold failures are reached, attempts are refused for LockoutDuration. Failures older than ResetAfter
+// are forgotten.
+type LoginThrottlePolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	ResetAfter       time.Duration
+}
+
+// The policy for failed attempts against a single email address
+var AccountLoginPolicy = LoginThrottlePolicy{
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         5 * time.Minute,
+	LockoutThreshold: 10,
+	LockoutDuration:  15 * time.Minute,
+	ResetAfter:       time.Hour,
+}
+
+// The policy for failed attempts from a single IP address (more lenient, since guests may share a network)
+var IPLoginPolicy = LoginThrottlePolicy{
+	FreeAttempts:     10,
+	BaseDelay:        time.Second,
+	MaxDelay:         5 * time.Minute,
+	LockoutThreshold: 50,
+	LockoutDuration:  time.Hour,
+	ResetAfter:       time.Hour,
+}
+
+// Hash compared against when a login is attempted for an email that doesn't belong to any user, so the response
+// takes as long as it would for a real user (it's made the same way as new password hashes)
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := HashPassword("dummy password used to equalise login timing")
+	if err != nil {
+		log.Println("Error creating dummy password hash: ", err.Error())
+	}
+	return hash
+})
+
+// VerifyDummyPassword spends the same time as VerifyPassword would for a real user, and always fails
+func VerifyDummyPassword(providedPassword string) bool {
+	VerifyPassword(dummyPasswordHash(), providedPassword)
+	return false
+}
+
+// StaleBefore is the time before which failures are forgotten
+func (p LoginThrottlePolicy) StaleBefore(now time.Time) time.Time {
+	return now.Add(-p.ResetAfter)
+}
+
+// IsLockedOut checks if the throttle has reached the lockout threshold and the lockout hasn't expired
+func (p LoginThrottlePolicy) IsLockedOut(t models.LoginThrottle, now time.Time) bool {
+	return t.Failures >= p.LockoutThreshold && now.Before(t.LastFailureAt.Add(p.LockoutDuration))
+}
+
+// RetryAfter returns how long the client must wait before it may attempt to log in again (0 if it may try now)
+func (p LoginThrottlePolicy) RetryAfter(t *models.LoginThrottle, now time.Time) time.Duration {
+	if t == nil || t.Failures <= p.FreeAttempts || t.LastFailureAt.Before(p.StaleBefore(now)) {
+		return 0
+	}
+	var wait time.Duration
+	if t.Failures >= p.LockoutThreshold {
+		wait = p.LockoutDuration
+	} else {
+		wait = p.MaxDelay
+		// Guard the shift so a large failure count can't overflow
+		if extra := t.Failures - p.FreeAttempts - 1; extra < 32 {
+			wait = min(p.BaseDelay<<extra, p.MaxDelay)
+		}
+	}
+	return max(t.LastFailureAt.Add(wait).Sub(now), 0)
+}
@@ -0,0 +1,46 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginThrottlePolicy_Unit(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	policy := LoginThrottlePolicy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         10 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		ResetAfter:       time.Hour,
+	}
+	t.Run("RetryAfter - no throttle or free attempts left - can try now", func(t *testing.T) {
+		assert.Equal(time.Duration(0), policy.RetryAfter(nil, now))
+		assert.Equal(time.Duration(0), policy.RetryAfter(&models.LoginThrottle{Failures: 3, LastFailureAt: now}, now))
+	})
+	t.Run("RetryAfter - delay doubles with each failure", func(t *testing.T) {
+		assert.Equal(time.Second, policy.RetryAfter(&models.LoginThrottle{Failures: 4, LastFailureAt: now}, now))
+		assert.Equal(4*time.Second, policy.RetryAfter(&models.LoginThrottle{Failures: 6, LastFailureAt: now}, now))
+		assert.Equal(time.Duration(0), policy.RetryAfter(&models.LoginThrottle{Failures: 4, LastFailureAt: now.Add(-2 * time.Second)}, now))
+	})
+	t.Run("RetryAfter - delay is capped", func(t *testing.T) {
+		assert.Equal(10*time.Second, policy.RetryAfter(&models.LoginThrottle{Failures: 9, LastFailureAt: now}, now))
+	})
+	t.Run("RetryAfter - locked out after the threshold", func(t *testing.T) {
+		throttle := models.LoginThrottle{Failures: 10, LastFailureAt: now}
+		assert.Equal(15*time.Minute, policy.RetryAfter(&throttle, now))
+		assert.True(policy.IsLockedOut(throttle, now))
+		assert.False(policy.IsLockedOut(throttle, now.Add(16*time.Minute)))
+	})
+	t.Run("RetryAfter - stale failures are forgotten", func(t *testing.T) {
+		assert.Equal(time.Duration(0), policy.RetryAfter(&models.LoginThrottle{Failures: 500, LastFailureAt: now.Add(-2 * time.Hour)}, now))
+	})
+}
@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Mailing labels are laid out for Avery 5160 (and compatible) sheets: US Letter paper with 3 columns of 10 labels, each
+// 2 5/8" by 1". PDF sizes are in points (1/72").
+const (
+	labelPageWidth   = 612
+	labelPageHeight  = 792
+	labelColumns     = 3
+	labelRows        = 10
+	labelWidth       = 189
+	labelHeight      = 72
+	labelLeftMargin  = 13.5
+	labelTopMargin   = 36
+	labelColumnPitch = 198
+	labelRowPitch    = 72
+	labelPadding     = 9
+	labelFontSize    = 10
+	labelMinFontSize = 6
+	labelLineSpacing = 1.2
+	// The space kept clear above and below each label's lines
+	labelVerticalMargin = 4
+)
+
+// Roughly how wide a character of Helvetica is, as a fraction of the font size; long lines are shrunk to fit the
+// label using this average rather than the widths of their actual characters
+const labelAverageCharWidth = 0.5
+
+// Escapes the characters with special meaning in PDF strings (PDF 1.7 section 7.3.4.2)
+var pdfStringEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`)
+
+// Converts text to the WinAnsiEncoding used by the label font; characters it can't encode are printed as "?"
+func pdfText(s string) string {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		// WinAnsiEncoding matches Latin-1 outside of the 0x80-0x9F range
+		if r < 0x20 || (r >= 0x7F && r < 0xA0) || r > 0xFF {
+			encoded = append(encoded, '?')
+			continue
+		}
+		encoded = append(encoded, byte(r))
+	}
+	return pdfStringEscaper.Replace(string(encoded))
+}
+
+// Draws one label's lines, centered vertically in the label whose top-left corner is at (x, top)
+//
+// Every line is printed (the last is often the country, without which the mail can't be delivered); when there are
+// too many to fit at the normal size, they're all printed smaller.
+func writeLabel(content *bytes.Buffer, lines []string, x float64, top float64) {
+	fontSize := min(labelFontSize, (labelHeight-2*labelVerticalMargin)/(float64(len(lines))*labelLineSpacing))
+	leading := fontSize * labelLineSpacing
+	blockHeight := leading * float64(len(lines))
+	baseline := top - (labelHeight-blockHeight)/2 - fontSize
+	available := float64(labelWidth - 2*labelPadding)
+	for _, line := range lines {
+		size := fontSize
+		if width := float64(len([]rune(line))) * labelAverageCharWidth * size; width > available {
+			size = min(max(size*available/width, labelMinFontSize), fontSize)
+		}
+		fmt.Fprintf(content, "BT /F1 %.2f Tf %.2f %.2f Td (%s) Tj ET\n", size, x+labelPadding, baseline, pdfText(line))
+		baseline -= leading
+	}
+}
+
+// BuildMailingLabels creates a PDF of mailing labels for Avery 5160 sheets, one label per entry of the given lines
+//
+// Labels fill each row before moving to the next; labels with more than 5 lines are printed smaller so every line fits.
+func BuildMailingLabels(labels [][]string) []byte {
+	perPage := labelColumns * labelRows
+	var pages []string
+	for start := 0; start < len(labels) || start == 0; start += perPage {
+		var content bytes.Buffer
+		for i := start; i < len(labels) && i < start+perPage; i++ {
+			row := (i - start) / labelColumns
+			column := (i - start) % labelColumns
+			x := labelLeftMargin + float64(column)*labelColumnPitch
+			top := labelPageHeight - labelTopMargin - float64(row)*labelRowPitch
+			writeLabel(&content, labels[i], x, top)
+		}
+		pages = append(pages, content.String())
+	}
+
+	// Objects 1-3 are the catalog, the page tree and the font; each page is then a page object followed by its
+	// content stream
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", labelPageWidth, labelPageHeight, 5+2*i))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
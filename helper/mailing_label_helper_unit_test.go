@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MailingLabelHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	t.Run("BuildMailingLabels - fits 30 labels on each page", func(t *testing.T) {
+		labels := make([][]string, 31)
+		for i := range labels {
+			labels[i] = []string{fmt.Sprintf("Household %d", i), "1 Main St", "Springfield, IL 62701"}
+		}
+
+		pdf := BuildMailingLabels(labels)
+
+		assert.True(bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+		assert.True(bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+		assert.Contains(string(pdf), "/Count 2")
+		// The first label is in the top-left corner and the 31st starts the second page
+		assert.Contains(string(pdf), "22.50 728.00 Td (Household 0) Tj")
+		assert.Contains(string(pdf), "22.50 728.00 Td (Household 30) Tj")
+		assert.Contains(string(pdf), "220.50 728.00 Td (Household 1) Tj")
+	})
+	t.Run("BuildMailingLabels - the cross-reference table points at each object", func(t *testing.T) {
+		pdf := BuildMailingLabels([][]string{{"Alex (and Sam)", `C:\Smith`, "Zoë"}})
+
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+		offset, _ := strconv.Atoi(string(startxref[1]))
+		assert.True(bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+		for i, match := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf, -1) {
+			offset, _ := strconv.Atoi(string(match[1]))
+			assert.True(bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+		}
+		assert.Contains(string(pdf), `(Alex \(and Sam\))`)
+		assert.Contains(string(pdf), `(C:\\Smith)`)
+		// Text is encoded as WinAnsi, where "ë" is one byte
+		assert.Contains(string(pdf), "(Zo\xeb)")
+	})
+	t.Run("BuildMailingLabels - prints every line of long foreign addresses, including the country", func(t *testing.T) {
+		british := models.Household{Name: "Alex and Sam Smith", AddressLine1: "Flat 3", AddressLine2: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"}
+		lines := MailingLabel(british, "US")
+		assert.Equal(6, len(lines))
+
+		pdf := string(BuildMailingLabels([][]string{lines}))
+
+		drawn := regexp.MustCompile(`/F1 ([\d.]+) Tf [\d.]+ ([\d.]+) Td \((.*)\) Tj`).FindAllStringSubmatch(pdf, -1)
+		assert.Equal(len(lines), len(drawn))
+		for i, match := range drawn {
+			assert.Equal(lines[i], match[3])
+			size, _ := strconv.ParseFloat(match[1], 64)
+			baseline, _ := strconv.ParseFloat(match[2], 64)
+			assert.Less(size, float64(labelFontSize))
+			// The first label spans from 756 (the top of the page less its margin) down to 684
+			assert.LessOrEqual(baseline+size, 756.0)
+			assert.GreaterOrEqual(baseline, 684.0)
+		}
+		assert.Equal("UNITED KINGDOM", drawn[len(drawn)-1][3])
+	})
+	t.Run("BuildMailingLabels - shrinks lines that are too long for the label", func(t *testing.T) {
+		pdf := BuildMailingLabels([][]string{{"The Extraordinarily Long Household Name Family"}})
+
+		assert.Regexp(`/F1 [6-9]\.\d\d Tf`, string(pdf))
+	})
+}
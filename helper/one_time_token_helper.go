@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOneTimeToken creates a random token to send to a user (e.g., in an email confirmation link), along with the
+// hash to store in its place
+//
+// Only the hash is stored, so a leaked database can't be used to confirm anything.
+func NewOneTimeToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOneTimeToken(token), nil
+}
+
+// HashOneTimeToken hashes a token created by NewOneTimeToken so it can be looked up
+//
+// The token is random and long, so a fast hash is enough (unlike passwords).
+func HashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,220 @@
+package helper
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy describes what makes a password acceptable
+type PasswordPolicy struct {
+	// Minimum length, in characters
+	MinLength int
+	// Maximum length, in bytes. argon2id has no such limit, but bcrypt ignores anything past 72 bytes; the cap keeps the
+	// bcrypt hashes stored before the switch to argon2id (and any made with HashBcrypt) from ignoring part of a password.
+	MaxLength    int
+	MinUpperCase int
+	MinLowerCase int
+	MinDigits    int
+	// Minimum number of punctuation or symbol characters
+	MinSpecial int
+	// Reject passwords found in the embedded list of common and breached passwords
+	RejectCommon bool
+}
+
+// The password policy used when signing up and changing passwords
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	MaxLength:    72,
+	MinUpperCase: 2,
+	MinDigits:    2,
+	MinSpecial:   2,
+	RejectCommon: true,
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = sync.OnceValue(func() map[string]bool {
+	passwords := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			passwords[line] = true
+		}
+	}
+	return passwords
+})
+
+// Check returns the requirements the password doesn't meet (or nothing, if it's acceptable)
+//
+// The password also must not be the same as any of the given personal details (e.g., the user's email and name), or the
+// part of an email before the "@".
+func (p PasswordPolicy) Check(password string, personalDetails ...string) []string {
+	var problems []string
+	var upper, lower, digits, special int
+	for _, c := range password {
+		switch {
+		case unicode.IsNumber(c):
+			digits++
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			special++
+		}
+	}
+	if digits < p.MinDigits {
+		problems = append(problems, fmt.Sprintf("must have %d or more digits", p.MinDigits))
+	}
+	if special < p.MinSpecial {
+		problems = append(problems, fmt.Sprintf("must have %d or more special characters", p.MinSpecial))
+	}
+	if upper < p.MinUpperCase {
+		problems = append(problems, fmt.Sprintf("must have %d or more capital letters", p.MinUpperCase))
+	}
+	if lower < p.MinLowerCase {
+		problems = append(problems, fmt.Sprintf("must have %d or more lower-case letters", p.MinLowerCase))
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters in length", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes in length", p.MaxLength))
+	}
+	normalized := strings.ToLower(password)
+	if p.RejectCommon && commonPasswords()[normalized] {
+		problems = append(problems, "is too common")
+	}
+	for _, detail := range personalDetails {
+		detail = strings.ToLower(strings.TrimSpace(detail))
+		local, _, _ := strings.Cut(detail, "@")
+		if detail != "" && (normalized == detail || normalized == local) {
+			problems = append(problems, "must not be your email address or name")
+			break
+		}
+	}
+	return problems
+}
+
+// The algorithms passwords can be hashed with
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// PasswordHashing describes how new password hashes are made
+//
+// Stored hashes that were made differently (e.g., with bcrypt, or a lower cost) still verify, but are replaced on the
+// user's next login (see NeedsRehash).
+type PasswordHashing struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2id parameters: passes over memory, memory in KiB and parallelism
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+// The hashing used for new password hashes (argon2id, with the minimum parameters recommended by OWASP)
+var DefaultPasswordHashing = PasswordHashing{
+	Algorithm:     HashArgon2id,
+	BcryptCost:    14,
+	Argon2Time:    2,
+	Argon2Memory:  19 * 1024,
+	Argon2Threads: 1,
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var errInvalidHash = errors.New("the password hash is not in a recognized format")
+
+// HashPassword hashes the password using DefaultPasswordHashing
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHashing.Hash(password)
+}
+
+// Hash hashes the password with the configured algorithm
+func (h PasswordHashing) Hash(password string) (string, error) {
+	if h.Algorithm == HashBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(bytes), err
+	}
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Argon2Time, h.Argon2Memory, h.Argon2Threads, argon2KeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Argon2Memory,
+		h.Argon2Time,
+		h.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash checks if the hash was made with a different algorithm or cost than the configured one
+func (h PasswordHashing) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, _, _, err := decodeArgon2idHash(hash)
+		return err != nil || h.Algorithm != HashArgon2id ||
+			params.Argon2Time != h.Argon2Time || params.Argon2Memory != h.Argon2Memory || params.Argon2Threads != h.Argon2Threads
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || h.Algorithm != HashBcrypt || cost != h.BcryptCost
+}
+
+// VerifyPassword checks the plain-text password against a bcrypt or argon2id hash
+func VerifyPassword(hash string, providedPassword string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(providedPassword)) == nil
+	}
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+	providedKey := argon2.IDKey([]byte(providedPassword), salt, params.Argon2Time, params.Argon2Memory, params.Argon2Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, providedKey) == 1
+}
+
+// Parses a hash in the PHC string format used by Hash
+func decodeArgon2idHash(hash string) (params PasswordHashing, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errInvalidHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+	params.Algorithm = HashArgon2id
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	return params, salt, key, nil
+}
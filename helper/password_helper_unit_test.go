@@ -0,0 +1,65 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PasswordHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	t.Run("PasswordPolicy.Check - acceptable password has no problems", func(t *testing.T) {
+		assert.Empty(DefaultPasswordPolicy.Check("Wed!ding#2O26xY", "booples@email.place", "Booples", "McFadden"))
+	})
+	t.Run("PasswordPolicy.Check - reports every unmet requirement", func(t *testing.T) {
+		problems := DefaultPasswordPolicy.Check("abc")
+		assert.Contains(problems, "must have 2 or more digits")
+		assert.Contains(problems, "must have 2 or more special characters")
+		assert.Contains(problems, "must have 2 or more capital letters")
+		assert.Contains(problems, "must be at least 8 characters in length")
+	})
+	t.Run("PasswordPolicy.Check - too long", func(t *testing.T) {
+		problems := DefaultPasswordPolicy.Check("AB12!@" + strings.Repeat("a", 80))
+		assert.Equal([]string{"must be at most 72 bytes in length"}, problems)
+	})
+	t.Run("PasswordPolicy.Check - common passwords are rejected regardless of case", func(t *testing.T) {
+		assert.Contains(DefaultPasswordPolicy.Check("P@SSWORD123"), "is too common")
+		assert.Contains(DefaultPasswordPolicy.Check("AB12!@"), "is too common")
+	})
+	t.Run("PasswordPolicy.Check - cannot match personal details", func(t *testing.T) {
+		policy := PasswordPolicy{}
+		assert.Equal([]string{"must not be your email address or name"}, policy.Check("BOOPLES", "booples@email.place"))
+		assert.Equal([]string{"must not be your email address or name"}, policy.Check("McFadden", "booples@email.place", "Booples", "McFadden"))
+	})
+	t.Run("PasswordHashing - argon2id hashes verify", func(t *testing.T) {
+		hash, err := DefaultPasswordHashing.Hash("Wed!ding#2O26")
+		assert.Nil(err)
+		assert.True(strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+		assert.True(VerifyPassword(hash, "Wed!ding#2O26"))
+		assert.False(VerifyPassword(hash, "wed!ding#2O26"))
+		assert.False(DefaultPasswordHashing.NeedsRehash(hash))
+	})
+	t.Run("PasswordHashing - outdated hashes need rehashing", func(t *testing.T) {
+		bcryptHashing := PasswordHashing{Algorithm: HashBcrypt, BcryptCost: 4}
+		bcryptHash, err := bcryptHashing.Hash("Wed!ding#2O26")
+		assert.Nil(err)
+		assert.True(VerifyPassword(bcryptHash, "Wed!ding#2O26"))
+		assert.False(bcryptHashing.NeedsRehash(bcryptHash))
+		assert.True(DefaultPasswordHashing.NeedsRehash(bcryptHash))
+		assert.True(PasswordHashing{Algorithm: HashBcrypt, BcryptCost: 5}.NeedsRehash(bcryptHash))
+
+		weakerHashing := DefaultPasswordHashing
+		weakerHashing.Argon2Time = 1
+		weakerHash, _ := weakerHashing.Hash("Wed!ding#2O26")
+		assert.True(VerifyPassword(weakerHash, "Wed!ding#2O26"))
+		assert.True(DefaultPasswordHashing.NeedsRehash(weakerHash))
+	})
+	t.Run("VerifyPassword - malformed hash never verifies", func(t *testing.T) {
+		assert.False(VerifyPassword("$argon2id$v=19$garbage", ""))
+		assert.False(VerifyPassword("", ""))
+	})
+}
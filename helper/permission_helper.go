@@ -0,0 +1,188 @@
+package helper
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// A capability that can be granted to a role (formatted as "<resource>:<action>")
+type Permission string
+
+const (
+	// View and update your own user record
+	PermProfileManageOwn Permission = "profile:manage_own"
+	// View any user's record
+	PermUsersReadAll Permission = "users:read_all"
+	// Create users and update any user's record
+	PermUsersWrite Permission = "users:write"
+	// Delete users
+	PermUsersDelete Permission = "users:delete"
+	// Change users' roles
+	PermUsersManageRoles Permission = "users:manage_roles"
+	// View and lift login lockouts
+	PermLockoutsManage Permission = "lockouts:manage"
+	// Change site settings (e.g., which routes require a verified email address)
+	PermSettingsManage Permission = "settings:manage"
+	// Add, update and remove your own invitees
+	PermInviteesManageOwn Permission = "invitees:manage_own"
+	// Remove any user's invitees
+	PermInviteesManageAll Permission = "invitees:manage_all"
+	// View the entree and hors doeuvres options
+	PermMenuRead Permission = "menu:read"
+	// Add and remove entree and hors doeuvres options
+	PermMenuWrite Permission = "menu:write"
+	// Arrange guest seating
+	PermSeatingWrite Permission = "seating:write"
+	// View reports (headcounts, selections, etc.)
+	PermReportsRead Permission = "reports:read"
+	// View venue details
+	PermVenueRead Permission = "venue:read"
+	// Add, update and remove venues and accommodations
+	PermVenueWrite Permission = "venue:write"
+	// View the events you're invited to and respond for yourself and your invitees
+	PermEventsRespond Permission = "events:respond"
+	// View every event and its guest list
+	PermEventsReadAll Permission = "events:read_all"
+	// Create, update and delete events and manage their guest lists
+	PermEventsWrite Permission = "events:write"
+	// View the shuttle runs and sign up yourself and your invitees
+	PermShuttlesRide Permission = "shuttles:ride"
+	// Add, update and remove shuttle runs
+	PermShuttlesWrite Permission = "shuttles:write"
+	// View the gift registry and claim gifts
+	PermRegistryClaim Permission = "registry:claim"
+	// Add, update and remove registry items
+	PermRegistryWrite Permission = "registry:write"
+	// Record the gifts the couple received and track their thank-you notes
+	PermGiftsManage Permission = "gifts:manage"
+	// Manage households, their addresses and what has been mailed to them
+	PermHouseholdsManage Permission = "households:manage"
+	// Ask the DJ to play songs and vote for the songs others asked for
+	PermSongsRequest Permission = "songs:request"
+	// Approve and reject song requests and keep the do-not-play list
+	PermSongsModerate Permission = "songs:moderate"
+	// Leave messages in the guestbook and read the public ones
+	PermGuestbookWrite Permission = "guestbook:write"
+	// Read every guestbook message and approve or reject them
+	PermGuestbookModerate Permission = "guestbook:moderate"
+)
+
+var errNotAuthorized = errors.New("you are not authorised to access this resource")
+
+// The permissions granted to each role
+//
+// Roles that aren't listed here (including an empty role) have no permissions.
+var RolePermissions = map[string][]Permission{
+	models.RoleGuest: {
+		PermProfileManageOwn,
+		PermInviteesManageOwn,
+		PermMenuRead,
+		PermVenueRead,
+		PermEventsRespond,
+		PermShuttlesRide,
+		PermRegistryClaim,
+		PermSongsRequest,
+		PermGuestbookWrite,
+	},
+	models.RoleInvitee: {
+		PermProfileManageOwn,
+		PermInviteesManageOwn,
+		PermMenuRead,
+		PermVenueRead,
+		PermEventsRespond,
+		PermShuttlesRide,
+		PermRegistryClaim,
+		PermSongsRequest,
+		PermGuestbookWrite,
+	},
+	// Planners can see everything and arrange the menu and seating, but can't manage users
+	models.RolePlanner: {
+		PermProfileManageOwn,
+		PermInviteesManageOwn,
+		PermUsersReadAll,
+		PermMenuRead,
+		PermMenuWrite,
+		PermSeatingWrite,
+		PermReportsRead,
+		PermVenueRead,
+		PermEventsRespond,
+		PermEventsReadAll,
+		PermShuttlesRide,
+		PermRegistryClaim,
+		PermSongsRequest,
+		PermGuestbookWrite,
+	},
+	models.RoleAdmin: {
+		PermProfileManageOwn,
+		PermInviteesManageOwn,
+		PermInviteesManageAll,
+		PermUsersReadAll,
+		PermUsersWrite,
+		PermUsersDelete,
+		PermUsersManageRoles,
+		PermLockoutsManage,
+		PermSettingsManage,
+		PermMenuRead,
+		PermMenuWrite,
+		PermSeatingWrite,
+		PermReportsRead,
+		PermVenueRead,
+		PermVenueWrite,
+		PermEventsRespond,
+		PermEventsReadAll,
+		PermEventsWrite,
+		PermShuttlesRide,
+		PermShuttlesWrite,
+		PermRegistryClaim,
+		PermRegistryWrite,
+		PermGiftsManage,
+		PermHouseholdsManage,
+		PermSongsRequest,
+		PermSongsModerate,
+		PermGuestbookWrite,
+		PermGuestbookModerate,
+	},
+}
+
+// RoleHasPermission checks if the given role is granted the given permission
+func RoleHasPermission(role string, permission Permission) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
+
+// CheckPermissions checks if the role in the context is granted all of the given permissions
+//
+// If any permission is missing, an error is returned.
+func CheckPermissions(c *gin.Context, permissions ...Permission) error {
+	role := c.GetString("user_role")
+	for _, permission := range permissions {
+		if !RoleHasPermission(role, permission) {
+			return errNotAuthorized
+		}
+	}
+	return nil
+}
+
+// CheckOwnership checks that every given user ID matches the uid in the context
+//
+// Empty IDs are skipped since they mean the request doesn't target a specific user (and therefore targets the
+// logged in user). If any ID belongs to a different user (or isn't a valid UUID), an error is returned.
+func CheckOwnership(c *gin.Context, userIds ...string) error {
+	uid, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		return errNotAuthorized
+	}
+	for _, idStr := range userIds {
+		if idStr == "" {
+			continue
+		}
+		id, err := uuid.Parse(idStr)
+		if err != nil || id != uid {
+			return errNotAuthorized
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Splits text into lowercase words, so "Hello, World!" is "hello" and "world"
+func profanityWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// NormalizeBlockedWords lowercases and trims each word or phrase on a blocked word list, dropping blanks and duplicates
+func NormalizeBlockedWords(words []string) []string {
+	normalized := []string{}
+	for _, word := range words {
+		if word = strings.Join(profanityWords(word), " "); word != "" {
+			normalized = append(normalized, word)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// FindBlockedWords gets the words and phrases on the blocked list that appear in the text
+//
+// Matching ignores case and punctuation, and only matches whole words so innocent words that contain a blocked one
+// (e.g., "class" and "ass") aren't caught.
+func FindBlockedWords(text string, blocked []string) []string {
+	words := profanityWords(text)
+	found := []string{}
+	for _, entry := range blocked {
+		phrase := profanityWords(entry)
+		if len(phrase) == 0 {
+			continue
+		}
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			if slices.Equal(words[i:i+len(phrase)], phrase) {
+				found = append(found, strings.Join(phrase, " "))
+				break
+			}
+		}
+	}
+	return found
+}
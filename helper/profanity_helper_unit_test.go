@@ -0,0 +1,25 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProfanityHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	blocked := []string{"darn", "heck", "what the heck"}
+	t.Run("FindBlockedWords - matches whole words and phrases, ignoring case and punctuation", func(t *testing.T) {
+		assert.Equal([]string{"darn"}, FindBlockedWords("Well, DARN it!", blocked))
+		assert.Equal([]string{"heck", "what the heck"}, FindBlockedWords("What the... heck?", blocked))
+		assert.Equal([]string{}, FindBlockedWords("Congratulations to the happy couple", blocked))
+		// Words that only contain a blocked word aren't caught
+		assert.Equal([]string{}, FindBlockedWords("Darning socks in Checkley", blocked))
+	})
+	t.Run("NormalizeBlockedWords - lowercases and removes blanks and duplicates", func(t *testing.T) {
+		assert.Equal([]string{"darn", "what the heck"}, NormalizeBlockedWords([]string{" Darn", "darn", "", "What  the HECK", "!"}))
+	})
+}
@@ -0,0 +1,229 @@
+package helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RSA keys shorter than this are refused
+const minRSAKeyBits = 2048
+
+// SigningKey is a key tokens are signed or verified with, identified by the "kid" header of the tokens it signs
+type SigningKey struct {
+	ID string
+	// RS256 for RSA keys, EdDSA for Ed25519 keys
+	Method jwt.SigningMethod
+	// Nil for keys that are only used to verify tokens (e.g., a key that has been rotated out)
+	Private interface{}
+	Public  interface{}
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are accepted from
+//
+// To rotate keys, add the new key, make it the signing key and keep the old key's public half in the set until the
+// tokens it signed have expired.
+type KeySet struct {
+	signing      *SigningKey
+	verification map[string]*SigningKey
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 curve and public key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, which other services use to verify our tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var activeKeySet atomic.Pointer[KeySet]
+
+// Used until SetKeySet is called (e.g., in tests and local development); tokens it signs stop working on restart
+var ephemeralKeySet = sync.OnceValue(func() *KeySet {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Panic(err)
+	}
+	key, _ := newSigningKey("ephemeral", private)
+	keys, _ := NewKeySet(key.ID, key)
+	return keys
+})
+
+// SetKeySet sets the keys tokens are signed and verified with
+func SetKeySet(keys *KeySet) {
+	activeKeySet.Store(keys)
+}
+
+func currentKeySet() *KeySet {
+	if keys := activeKeySet.Load(); keys != nil {
+		return keys
+	}
+	return ephemeralKeySet()
+}
+
+// NewKeySet creates a key set that signs tokens with the key with the given ID and accepts tokens from any of the keys
+func NewKeySet(signingKeyID string, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{verification: map[string]*SigningKey{}}
+	for _, key := range keys {
+		if _, exists := set.verification[key.ID]; exists {
+			return nil, fmt.Errorf("more than one key has the ID %q", key.ID)
+		}
+		set.verification[key.ID] = key
+	}
+	set.signing = set.verification[signingKeyID]
+	if set.signing == nil {
+		return nil, fmt.Errorf("there is no key with the ID %q to sign tokens with", signingKeyID)
+	}
+	if set.signing.Private == nil {
+		return nil, fmt.Errorf("the signing key %q is a public key", signingKeyID)
+	}
+	return set, nil
+}
+
+// LoadKeySet loads every PEM file in dir as a key, using the file name (without ".pem") as the key ID
+//
+// Files can hold RSA or Ed25519 private keys (PKCS #8, or PKCS #1 for RSA) or public keys (PKIX); public keys are
+// only used to verify tokens. If signingKeyID is empty, the only private key in the directory signs tokens.
+func LoadKeySet(dir string, signingKeyID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	var keys []*SigningKey
+	var privateKeyIDs []string
+	for _, path := range paths {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+		if key.Private != nil {
+			privateKeyIDs = append(privateKeyIDs, key.ID)
+		}
+	}
+	if signingKeyID == "" {
+		if len(privateKeyIDs) != 1 {
+			return nil, fmt.Errorf("found %d private keys in %s; choose the one to sign tokens with", len(privateKeyIDs), dir)
+		}
+		signingKeyID = privateKeyIDs[0]
+	}
+	return NewKeySet(signingKeyID, keys...)
+}
+
+func loadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(strings.TrimSuffix(filepath.Base(path), ".pem"), key)
+}
+
+// Works out the signing method and public key for an RSA or Ed25519 key (private or public)
+func newSigningKey(id string, key interface{}) (*SigningKey, error) {
+	signingKey := &SigningKey{ID: id}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signingKey.Method, signingKey.Private, signingKey.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		signingKey.Method, signingKey.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		signingKey.Method, signingKey.Private, signingKey.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		signingKey.Method, signingKey.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use RSA or Ed25519)", key)
+	}
+	if rsaKey, ok := signingKey.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+	}
+	return signingKey, nil
+}
+
+// Signs a token with the current signing key, setting its "kid" header
+func signToken(claims jwt.Claims) (string, error) {
+	key := currentKeySet().signing
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Finds the key a token was signed with from its "kid" header, refusing tokens signed with any other algorithm than
+// the key's (so, e.g., a public key can't be used as an HMAC secret)
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := currentKeySet().verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q (key %q uses %q)", token.Method.Alg(), kid, key.Method.Alg())
+	}
+	return key.Public, nil
+}
+
+// CurrentJWKS gets the public keys tokens are accepted from, in JWKS format
+func CurrentJWKS() JWKS {
+	keys := currentKeySet().verification
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch k := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
@@ -0,0 +1,132 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SigningKeyHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	writePEM := func(dir string, name string, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		os.WriteFile(filepath.Join(dir, name+".pem"), data, 0600)
+	}
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaDer, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	edDer, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	edPublicDer, _ := x509.MarshalPKIXPublicKey(edPublic)
+	useKeys := func(t *testing.T, keys *KeySet) {
+		SetKeySet(keys)
+		t.Cleanup(func() { activeKeySet.Store(nil) })
+	}
+	newToken := func() string {
+		token, _, err := GenerateAllTokens("booples@email.place", "Booples", "McFadden", "GUEST", uuid.New(), 0, uuid.New())
+		assert.Nil(err)
+		return token
+	}
+	t.Run("LoadKeySet - signs with the chosen key and verifies with all of them", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(dir, "rsa-2026", "PRIVATE KEY", rsaDer)
+		writePEM(dir, "ed-2026", "PRIVATE KEY", edDer)
+		_, err := LoadKeySet(dir, "")
+		assert.ErrorContains(err, "found 2 private keys")
+
+		keys, err := LoadKeySet(dir, "rsa-2026")
+		assert.Nil(err)
+		useKeys(t, keys)
+		rsaToken := newToken()
+		parsed, _, _ := jwt.NewParser().ParseUnverified(rsaToken, &CustomClaims{})
+		assert.Equal("RS256", parsed.Header["alg"])
+		assert.Equal("rsa-2026", parsed.Header["kid"])
+
+		keys, _ = LoadKeySet(dir, "ed-2026")
+		useKeys(t, keys)
+		edToken := newToken()
+		parsed, _, _ = jwt.NewParser().ParseUnverified(edToken, &CustomClaims{})
+		assert.Equal("EdDSA", parsed.Header["alg"])
+		_, msg := ValidateToken(rsaToken)
+		assert.Empty(msg)
+		_, msg = ValidateToken(edToken)
+		assert.Empty(msg)
+	})
+	t.Run("LoadKeySet - rotated out keys still verify until removed", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(dir, "old", "PRIVATE KEY", edDer)
+		keys, _ := LoadKeySet(dir, "")
+		useKeys(t, keys)
+		oldToken := newToken()
+
+		writePEM(dir, "old", "PUBLIC KEY", edPublicDer)
+		writePEM(dir, "new", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+		keys, err := LoadKeySet(dir, "")
+		assert.Nil(err)
+		useKeys(t, keys)
+		_, msg := ValidateToken(oldToken)
+		assert.Empty(msg)
+		_, err = LoadKeySet(dir, "old")
+		assert.ErrorContains(err, "is a public key")
+
+		os.Remove(filepath.Join(dir, "old.pem"))
+		keys, _ = LoadKeySet(dir, "")
+		useKeys(t, keys)
+		_, msg = ValidateToken(oldToken)
+		assert.Contains(msg, `unknown signing key "old"`)
+	})
+	t.Run("LoadKeySet - small RSA keys are refused", func(t *testing.T) {
+		dir := t.TempDir()
+		small, _ := rsa.GenerateKey(rand.Reader, 1024)
+		writePEM(dir, "small", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small))
+		_, err := LoadKeySet(dir, "")
+		assert.ErrorContains(err, "at least 2048 bits")
+	})
+	t.Run("ValidateToken - tokens with another alg than their key are rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(dir, "rsa", "PRIVATE KEY", rsaDer)
+		keys, _ := LoadKeySet(dir, "")
+		useKeys(t, keys)
+		claims := &CustomClaims{RegisteredClaims: newRegisteredClaims("someone", time.Now(), time.Hour)}
+
+		// The classic attack: sign with HMAC, using the public key as the secret
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		forged.Header["kid"] = "rsa"
+		publicDer, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		signed, _ := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}))
+		_, msg := ValidateToken(signed)
+		assert.Contains(msg, `unexpected signing method "HS256"`)
+
+		unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+		unsigned.Header["kid"] = "rsa"
+		signed, _ = unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		_, msg = ValidateToken(signed)
+		assert.Contains(msg, `unexpected signing method "none"`)
+	})
+	t.Run("CurrentJWKS - lists the public keys", func(t *testing.T) {
+		rsaSigning, _ := newSigningKey("rsa", rsaKey)
+		edVerifying, _ := newSigningKey("ed", edPublic)
+		keys, _ := NewKeySet("rsa", rsaSigning, edVerifying)
+		useKeys(t, keys)
+
+		jwks := CurrentJWKS()
+		assert.Equal(2, len(jwks.Keys))
+		assert.Equal(JWK{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+		assert.NotEmpty(jwks.Keys[0].X)
+		assert.Equal("RSA", jwks.Keys[1].Kty)
+		assert.Equal("RS256", jwks.Keys[1].Alg)
+		assert.Equal("AQAB", jwks.Keys[1].E)
+	})
+}
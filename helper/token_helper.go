@@ -4,37 +4,85 @@ import (
 	"log"
 	"os"
 	"time"
-	"unicode"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+)
+
+// The kinds of token GenerateAllTokens creates (the "token_type" claim)
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// How far the clocks of the server that issued a token and the one checking it can drift apart before the token's
+// times (expiry, not before and issued at) are rejected
+const TokenLeeway = 30 * time.Second
+
+// How long access and refresh tokens last
+const (
+	AccessTokenTTL  = 24 * time.Hour
+	RefreshTokenTTL = 168 * time.Hour
 )
 
 type CustomClaims struct {
-	*jwt.StandardClaims
-	TokenType string
+	// The standard claims; the subject is the user's ID and the JWT ID (jti) is unique to each token.
+	jwt.RegisteredClaims
+	// Either AccessToken or RefreshToken.
+	TokenType string    `json:"token_type"`
 	ID        uuid.UUID `json:"id"`
-	// The user's role, which can be "GUEST", "INVITEE" or "ADMIN". Defaults to "GUEST".
+	// The user's role, which can be "GUEST", "INVITEE", "PLANNER" or "ADMIN". Defaults to "GUEST".
 	Role string `json:"role"`
 	// The user's first name.
 	FirstName string `json:"first_name"`
 	// The user's last name.
 	LastName string `json:"last_name"`
+	// The user's token version when the token was issued; the token is rejected once the user's version changes.
+	TokenVersion int `json:"token_version"`
+	// The ID of the session the token belongs to (access tokens only); the token is rejected once the session is
+	// revoked.
+	SessionID uuid.UUID `json:"sid"`
 }
 
-var JWT_SECRET_KEY string = os.Getenv("JWT_SECRET_KEY")
-var secretKey = []byte(JWT_SECRET_KEY)
+// The issuer ("iss") of our tokens, read from JWT_ISSUER (defaults to "wedding-site-api")
+func tokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "wedding-site-api"
+}
+
+// The audience ("aud") of our tokens, read from JWT_AUDIENCE (defaults to "wedding-site")
+func tokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "wedding-site"
+}
+
+// Creates the standard claims for a new token
+func newRegisteredClaims(subject string, now time.Time, ttl time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    tokenIssuer(),
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{tokenAudience()},
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        uuid.NewString(),
+	}
+}
 
 // CreateToken creates a signed JWT string for the given email that expires in 24 hours
 func CreateToken(email string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"username": email,
-			"exp":      time.Now().Add(time.Hour * 24).Unix(),
-		})
-
-	tokenString, err := token.SignedString(secretKey)
+	claims := struct {
+		jwt.RegisteredClaims
+		Username string `json:"username"`
+	}{
+		RegisteredClaims: newRegisteredClaims(email, time.Now(), time.Hour*24),
+		Username:         email,
+	}
+	tokenString, err := signToken(claims)
 	if err != nil {
 		return "", err
 	}
@@ -42,33 +90,35 @@ func CreateToken(email string) (string, error) {
 	return tokenString, nil
 }
 
-// GenerateAllTokens generates a signed token and signed refresh token
-func GenerateAllTokens(email string, firstName string, lastName string, userType string, uid uuid.UUID) (signedToken string, signedRefreshToken string, err error) {
+// GenerateAllTokens generates a signed token and signed refresh token for the given session
+func GenerateAllTokens(email string, firstName string, lastName string, userType string, uid uuid.UUID, tokenVersion int, sessionId uuid.UUID) (signedToken string, signedRefreshToken string, err error) {
+
+	now := time.Now()
 
 	// Claims to be stored in the token
 	claims := &CustomClaims{
-		ID:        uid,
-		FirstName: firstName,
-		LastName:  lastName,
-		Role:      userType,
-		StandardClaims: &jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
-		},
+		RegisteredClaims: newRegisteredClaims(uid.String(), now, AccessTokenTTL),
+		TokenType:        AccessToken,
+		ID:               uid,
+		FirstName:        firstName,
+		LastName:         lastName,
+		Role:             userType,
+		TokenVersion:     tokenVersion,
+		SessionID:        sessionId,
 	}
 
 	// Claims to be stored in the refresh token
 	refreshClaims := &CustomClaims{
-		StandardClaims: &jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(168)).Unix(),
-		},
+		RegisteredClaims: newRegisteredClaims(uid.String(), now, RefreshTokenTTL),
+		TokenType:        RefreshToken,
 	}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+	token, err := signToken(claims)
 	if err != nil {
 		log.Panic(err)
 		return "", "", err
 	}
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(secretKey)
+	refreshToken, err := signToken(refreshClaims)
 	if err != nil {
 		log.Panic(err)
 		return "", "", err
@@ -77,15 +127,21 @@ func GenerateAllTokens(email string, firstName string, lastName string, userType
 	return token, refreshToken, nil
 }
 
+// ValidateToken checks a token's signature (against the key named by its "kid" header) and standard claims
+//
+// Tokens whose "alg" header doesn't match their key's algorithm are rejected, as are tokens from another issuer or for
+// another audience. Times are checked with TokenLeeway.
 func ValidateToken(signedToken string) (claims *CustomClaims, msg string) {
 
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&CustomClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-
-		},
+		verificationKey,
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithAudience(tokenAudience()),
+		jwt.WithLeeway(TokenLeeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
 	)
 
 	if err != nil {
@@ -94,63 +150,11 @@ func ValidateToken(signedToken string) (claims *CustomClaims, msg string) {
 	}
 
 	claims, ok := token.Claims.(*CustomClaims)
-	if !ok {
+	// Access tokens are for the user named by their subject
+	if !ok || (claims.TokenType == AccessToken && claims.Subject != claims.ID.String()) {
 		msg = "the token is invalid"
 		return nil, msg
 	}
 
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = "token is expired"
-		return nil, msg
-	}
-
 	return claims, msg
 }
-
-func VerifyPassword(userPassword string, providedPassword string) bool {
-	if err := bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(providedPassword)); err != nil {
-		return false
-	}
-	return true
-}
-
-type PasswordComplexityResults struct {
-	// Whether or not the password meets the criteria for expected number of upper-case characters
-	HasExpectedUpperCaseCt bool
-	// Whether or not the password meets the criteria for expected number of special characters
-	HasExpectedSpecialCaseCt bool
-	// Whether or not the password meets the criteria for expected number of digit characters
-	HasExpectedDigitCt bool
-	// Whether or not the password meets the criteria for expected minimum length
-	HasMinLength bool
-}
-
-func VerifyPasswordComplexity(password string, digitCt int, specialCt int, upperCaseCt int, minLength int) *PasswordComplexityResults {
-	var result PasswordComplexityResults
-	capitals := 0
-	digits := 0
-	specials := 0
-	for _, c := range password {
-		switch {
-		case unicode.IsNumber(c):
-			digits++
-		case unicode.IsUpper(c):
-			capitals++
-		case unicode.IsPunct(c) || unicode.IsSymbol(c):
-			specials++
-		}
-	}
-	result.HasExpectedDigitCt = digits >= digitCt
-	result.HasExpectedSpecialCaseCt = specials >= specialCt
-	result.HasExpectedUpperCaseCt = capitals >= upperCaseCt
-	result.HasMinLength = len(password) >= minLength
-	return &result
-}
-
-func HashPassword(password string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	if err != nil {
-		log.Panic(err)
-	}
-	return string(bytes)
-}
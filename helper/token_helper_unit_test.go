@@ -0,0 +1,91 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	uid := uuid.New()
+	// Signs access token claims for uid, letting the test change them first
+	signAccessToken := func(change func(claims *CustomClaims)) string {
+		claims := &CustomClaims{
+			RegisteredClaims: newRegisteredClaims(uid.String(), time.Now(), time.Hour),
+			TokenType:        AccessToken,
+			ID:               uid,
+		}
+		change(claims)
+		token, err := signToken(claims)
+		assert.Nil(err)
+		return token
+	}
+	t.Run("GenerateAllTokens - sets the standard claims", func(t *testing.T) {
+		t.Setenv("JWT_ISSUER", "https://api.wedding.test")
+		t.Setenv("JWT_AUDIENCE", "https://wedding.test")
+		sessionId := uuid.New()
+		token, refreshToken, err := GenerateAllTokens("booples@email.place", "Booples", "McFadden", "GUEST", uid, 3, sessionId)
+		assert.Nil(err)
+
+		claims, msg := ValidateToken(token)
+		assert.Empty(msg)
+		assert.Equal(AccessToken, claims.TokenType)
+		assert.Equal("https://api.wedding.test", claims.Issuer)
+		assert.Equal(jwt.ClaimStrings{"https://wedding.test"}, claims.Audience)
+		assert.Equal(uid.String(), claims.Subject)
+		assert.NotEmpty(claims.RegisteredClaims.ID)
+		assert.NotNil(claims.IssuedAt)
+		assert.NotNil(claims.NotBefore)
+		assert.Equal(3, claims.TokenVersion)
+		assert.Equal(sessionId, claims.SessionID)
+
+		refreshClaims, msg := ValidateToken(refreshToken)
+		assert.Empty(msg)
+		assert.Equal(RefreshToken, refreshClaims.TokenType)
+		assert.NotEqual(claims.RegisteredClaims.ID, refreshClaims.RegisteredClaims.ID)
+	})
+	t.Run("ValidateToken - tokens from another issuer or for another audience are rejected", func(t *testing.T) {
+		token := signAccessToken(func(claims *CustomClaims) { claims.Issuer = "someone-else" })
+		_, msg := ValidateToken(token)
+		assert.Contains(msg, "token has invalid issuer")
+
+		token = signAccessToken(func(claims *CustomClaims) { claims.Audience = jwt.ClaimStrings{"another-site"} })
+		_, msg = ValidateToken(token)
+		assert.Contains(msg, "token has invalid audience")
+	})
+	t.Run("ValidateToken - times are checked with leeway", func(t *testing.T) {
+		token := signAccessToken(func(claims *CustomClaims) {
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-TokenLeeway / 2))
+		})
+		_, msg := ValidateToken(token)
+		assert.Empty(msg)
+
+		token = signAccessToken(func(claims *CustomClaims) {
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * TokenLeeway))
+		})
+		_, msg = ValidateToken(token)
+		assert.Contains(msg, "token is expired")
+
+		token = signAccessToken(func(claims *CustomClaims) {
+			claims.NotBefore = jwt.NewNumericDate(time.Now().Add(2 * TokenLeeway))
+		})
+		_, msg = ValidateToken(token)
+		assert.Contains(msg, "token is not valid yet")
+
+		token = signAccessToken(func(claims *CustomClaims) { claims.ExpiresAt = nil })
+		_, msg = ValidateToken(token)
+		assert.Contains(msg, "token is missing required claim")
+	})
+	t.Run("ValidateToken - access tokens must be for the user named by their subject", func(t *testing.T) {
+		token := signAccessToken(func(claims *CustomClaims) { claims.ID = uuid.New() })
+		_, msg := ValidateToken(token)
+		assert.Equal("the token is invalid", msg)
+	})
+}
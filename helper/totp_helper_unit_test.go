@@ -0,0 +1,49 @@
+//go:build unit
+// +build unit
+
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TOTPHelper_Unit(t *testing.T) {
+	assert := assert.New(t)
+	// The RFC 6238 test secret ("12345678901234567890")
+	rfcSecret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	t.Run("TOTPCode - matches the RFC 6238 test vectors", func(t *testing.T) {
+		code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(59, 0)))
+		assert.Nil(err)
+		assert.Equal("287082", code)
+		code, _ = TOTPCode(rfcSecret, TOTPStep(time.Unix(1111111109, 0)))
+		assert.Equal("081804", code)
+	})
+	t.Run("ValidateTOTP - accepts codes from the neighbouring periods only", func(t *testing.T) {
+		now := time.Unix(1111111109, 0)
+		step, ok := ValidateTOTP(rfcSecret, "081804", now.Add(30*time.Second))
+		assert.True(ok)
+		assert.Equal(TOTPStep(now), step)
+		_, ok = ValidateTOTP(rfcSecret, "081804", now.Add(90*time.Second))
+		assert.False(ok)
+		_, ok = ValidateTOTP(rfcSecret, "not a code", now)
+		assert.False(ok)
+	})
+	t.Run("TOTPURI - includes the secret and issuer", func(t *testing.T) {
+		t.Setenv("MFA_ISSUER", "Our Wedding")
+		uri := TOTPURI("booples@email.place", rfcSecret)
+		assert.True(strings.HasPrefix(uri, "otpauth://totp/Our%20Wedding:booples@email.place?"))
+		assert.Contains(uri, "secret="+rfcSecret)
+		assert.Contains(uri, "issuer=Our+Wedding")
+	})
+	t.Run("NewRecoveryCodes - hashes ignore formatting", func(t *testing.T) {
+		codes, hashes, err := NewRecoveryCodes()
+		assert.Nil(err)
+		assert.Equal(RecoveryCodeCount, len(codes))
+		assert.Equal(hashes[0], HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))))
+		assert.NotEqual(hashes[0], hashes[1])
+	})
+}
@@ -0,0 +1,111 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails (e.g., email confirmation links)
+//
+// Use NewSenderFromEnv to get the sender configured for the environment; implement this interface to send
+// through another provider.
+type Sender interface {
+	Send(c context.Context, m Message) error
+}
+
+// NewSenderFromEnv returns an SMTPSender when SMTP_HOST is set, otherwise a LogSender
+func NewSenderFromEnv() Sender {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return LogSender{}
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return SMTPSender{
+		Addr:     host + ":" + port,
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+// LogSender writes emails to the log instead of sending them (for local development only, since the log will
+// contain any links or tokens in the message)
+type LogSender struct{}
+
+func (LogSender) Send(c context.Context, m Message) error {
+	log.Printf("Email to %s (not sent; SMTP_HOST is not set)\nSubject: %s\n%s", m.To, m.Subject, m.Body)
+	return nil
+}
+
+// SMTPSender sends emails through an SMTP server
+type SMTPSender struct {
+	// The server address, including the port
+	Addr string
+	// The server host name (used to authenticate)
+	Host     string
+	Username string
+	Password string
+	// The address emails are sent from
+	From string
+}
+
+func (s SMTPSender) Send(c context.Context, m Message) error {
+	// Header values can't contain line breaks, or the recipient could inject extra headers
+	if strings.ContainsAny(m.To+m.Subject, "\r\n") {
+		return fmt.Errorf("email header contains a line break")
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		s.From,
+		m.To,
+		m.Subject,
+		m.Body,
+	)
+	return smtp.SendMail(s.Addr, auth, s.From, []string{m.To}, []byte(msg))
+}
+
+// MemorySender keeps emails in memory instead of sending them, intended for unit tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+var _ Sender = (*MemorySender)(nil)
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(c context.Context, m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+// Sent returns the emails sent so far, oldest first
+func (s *MemorySender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// Cookies used in cookie mode, and the header the CSRF cookie's value has to be echoed in
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+	CSRFTokenHeader    = "X-CSRF-Token"
+)
+
+// Set in the context by AuthenticateV1 when the token came from the access token cookie
+const authenticatedByCookieKey = "authenticated_by_cookie"
+
+// AuthCookieSettings controls cookie mode, where auth tokens are sent as HttpOnly cookies instead of in response bodies
+// (so scripts on the page can't read them)
+type AuthCookieSettings struct {
+	Enabled bool
+	// The domain the cookies are sent to; empty for the API's own host only
+	Domain   string
+	SameSite http.SameSite
+}
+
+// AuthCookieSettingsFromEnv reads the cookie mode settings: AUTH_COOKIES turns cookie mode on, AUTH_COOKIE_DOMAIN sets
+// the cookies' domain and AUTH_COOKIE_SAME_SITE ("strict", "lax" or "none") sets their SameSite attribute (defaults to
+// "strict")
+func AuthCookieSettingsFromEnv() AuthCookieSettings {
+	enabled, _ := strconv.ParseBool(os.Getenv("AUTH_COOKIES"))
+	settings := AuthCookieSettings{
+		Enabled:  enabled,
+		Domain:   os.Getenv("AUTH_COOKIE_DOMAIN"),
+		SameSite: http.SameSiteStrictMode,
+	}
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAME_SITE")) {
+	case "lax":
+		settings.SameSite = http.SameSiteLaxMode
+	case "none":
+		settings.SameSite = http.SameSiteNoneMode
+	}
+	return settings
+}
+
+// SetAuthCookies sets the access and refresh token cookies, along with a new CSRF token cookie
+//
+// The token cookies are HttpOnly; the CSRF cookie isn't, since the frontend has to read it to send it back in the
+// X-CSRF-Token header.
+func (s AuthCookieSettings) SetAuthCookies(c *gin.Context, token string, refreshToken string) error {
+	csrfToken, _, err := helper.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+	// The cookies last as long as the tokens they hold
+	s.setCookie(c, AccessTokenCookie, token, "/api/v1", helper.AccessTokenTTL, true)
+	// Nothing reads the refresh token outside of the auth routes, so it isn't sent anywhere else
+	s.setCookie(c, RefreshTokenCookie, refreshToken, "/api/v1/auth", helper.RefreshTokenTTL, true)
+	s.setCookie(c, CSRFTokenCookie, csrfToken, "/", helper.RefreshTokenTTL, false)
+	return nil
+}
+
+// ClearAuthCookies removes the cookies set by SetAuthCookies
+func (s AuthCookieSettings) ClearAuthCookies(c *gin.Context) {
+	s.setCookie(c, AccessTokenCookie, "", "/api/v1", 0, true)
+	s.setCookie(c, RefreshTokenCookie, "", "/api/v1/auth", 0, true)
+	s.setCookie(c, CSRFTokenCookie, "", "/", 0, false)
+}
+
+// Sets a cookie; a ttl of 0 deletes it
+func (s AuthCookieSettings) setCookie(c *gin.Context, name string, value string, path string, ttl time.Duration, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if maxAge <= 0 {
+		// A Max-Age of 0 would leave it out of the header (making a session cookie) instead of deleting the cookie
+		maxAge = -1
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.Domain,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: s.SameSite,
+	})
+}
+
+// RequireCSRFToken rejects mutating requests authenticated by the access token cookie unless the X-CSRF-Token header
+// matches the CSRF token cookie (double-submit; other sites can make the browser send our cookies, but can't read them)
+//
+// Use it after AuthenticateV1; requests authenticated with a header aren't affected, since browsers never add those
+// on their own.
+func RequireCSRFToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if !c.GetBool(authenticatedByCookieKey) {
+			c.Next()
+			return
+		}
+		cookie, _ := c.Cookie(CSRFTokenCookie)
+		header := c.GetHeader(CSRFTokenHeader)
+		if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.JSON(http.StatusForbidden, V1_API_RESPONSE{
+				Status:  http.StatusForbidden,
+				Message: "Missing or invalid CSRF token",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
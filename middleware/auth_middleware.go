@@ -1,12 +1,18 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/repository"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // TODO: Alter definition setup since we can't import from controllers here (invalid import cycle)
@@ -16,22 +22,15 @@ type V1_API_RESPONSE struct {
 	Data    gin.H  `json:"data"`
 }
 
-func AuthenticateV1() gin.HandlerFunc {
+// AuthenticateV1 validates the auth token and sets the user's details from its claims in the context
+//
+// The token is read from the "Authorization: Bearer" header, the older "auth-token" header or the access token cookie
+// (in cookie mode). Refresh tokens, tokens issued with an older token version than the user's current one (e.g.,
+// before their role changed) and tokens whose session has been revoked or has expired are rejected.
+func AuthenticateV1(users repository.UserRepository, sessions repository.SessionRepository) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
-		test_env_str, _ := os.LookupEnv("USE_MOCK_DB")
-		isUnitTest, _ := strconv.ParseBool(test_env_str)
-		// If the route is being hit during unit testing, then there is no underlying DB - skip authentication since it doesn't work on unit tests
-		if isUnitTest {
-			// when using gin.CreateTestContextOnly, it only places values in the REQUEST context, not the gin context. Need to grab the values from the request context.
-			uid := c.Request.Context().Value("uid")
-			role := c.Request.Context().Value("user_role")
-			c.Set("uid", uid)
-			c.Set("user_role", role)
-			c.Next()
-			return
-		}
-		clientToken := c.Request.Header.Get("auth-token")
+		clientToken := BearerToken(c)
 		if clientToken == "" {
 			c.JSON(http.StatusUnauthorized, V1_API_RESPONSE{
 				Status:  http.StatusUnauthorized,
@@ -41,9 +40,12 @@ func AuthenticateV1() gin.HandlerFunc {
 			return
 		}
 
-		// NOTE: If a manual change to the user has been made (for example, "GUEST" to "ADMIN") after the JWT was generated, the user should
-		// sign out and back in to generate a new token with new claims (so we don't have to hit the DB for the latest data each time).
+		// NOTE: If a manual change to the user has been made (for example, "GUEST" to "ADMIN") after the JWT was generated, bump the user's
+		// token_version so their existing tokens are rejected; they'll need to sign back in to get a token with the new claims.
 		claims, err := helper.ValidateToken(clientToken)
+		if err == "" && claims.TokenType != helper.AccessToken {
+			err = "the token is not an access token"
+		}
 		if err != "" {
 			c.JSON(http.StatusInternalServerError, V1_API_RESPONSE{
 				Status:  http.StatusUnauthorized,
@@ -53,10 +55,65 @@ func AuthenticateV1() gin.HandlerFunc {
 			return
 		}
 
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		tokenVersion, versionErr := users.FindTokenVersion(ctx, claims.ID)
+		if versionErr != nil && !errors.Is(versionErr, gorm.ErrRecordNotFound) {
+			log.Println("Error finding token version: ", versionErr.Error())
+			c.JSON(http.StatusInternalServerError, V1_API_RESPONSE{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal server error",
+			})
+			c.Abort()
+			return
+		}
+		// Deleted users have no token version, so their tokens are rejected too
+		if versionErr != nil || tokenVersion != claims.TokenVersion {
+			c.JSON(http.StatusUnauthorized, V1_API_RESPONSE{
+				Status:  http.StatusUnauthorized,
+				Message: "token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		// Tokens issued before sessions were added don't belong to one, so they only go through the version check
+		if claims.SessionID != uuid.Nil {
+			active, sessionErr := sessions.IsActiveSession(ctx, claims.SessionID, time.Now())
+			if sessionErr != nil {
+				abortInternalServerError(c, "Error checking session: ", sessionErr)
+				return
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, V1_API_RESPONSE{
+					Status:  http.StatusUnauthorized,
+					Message: "token has been revoked",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("first_name", claims.FirstName)
 		c.Set("last_name", claims.LastName)
 		c.Set("uid", claims.ID.String())
 		c.Set("user_role", claims.Role)
+		c.Set("sid", claims.SessionID.String())
 		c.Next()
 	}
 }
+
+// BearerToken gets the token from the "Authorization: Bearer" header, falling back to the "auth-token" header and then
+// the access token cookie (noting in the context when the cookie is used, for RequireCSRFToken)
+func BearerToken(c *gin.Context) string {
+	scheme, token, found := strings.Cut(c.Request.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if token := c.Request.Header.Get("auth-token"); token != "" {
+		return token
+	}
+	token, _ = c.Cookie(AccessTokenCookie)
+	c.Set(authenticatedByCookieKey, token != "")
+	return token
+}
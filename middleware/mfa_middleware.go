@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Routes under this prefix stay usable without two-factor authentication, since users need them to set it up
+const mfaExemptRoutePrefix = "/api/v1/auth/"
+
+// RequireMfaEnrollment stops users whose role admins have chosen to require two-factor authentication for from using
+// the API until they've set up an authenticator (use it after AuthenticateV1); other roles aren't affected
+func RequireMfaEnrollment(mfa repository.MfaRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		if strings.HasPrefix(c.FullPath(), mfaExemptRoutePrefix) {
+			c.Next()
+			return
+		}
+		required, err := mfa.IsMfaRequiredRole(ctx, c.GetString("user_role"))
+		if err != nil {
+			abortInternalServerError(c, "Error checking if role requires two-factor authentication: ", err)
+			return
+		}
+		if !required {
+			c.Next()
+			return
+		}
+		uid, _ := uuid.Parse(c.GetString("uid"))
+		enrollment, err := mfa.FindMfaEnrollment(ctx, uid)
+		if err != nil {
+			abortInternalServerError(c, "Error finding two-factor authentication enrollment: ", err)
+			return
+		}
+		if enrollment == nil || enrollment.ConfirmedAt == nil {
+			c.JSON(http.StatusForbidden, V1_API_RESPONSE{
+				Status:  http.StatusForbidden,
+				Message: "Set up two-factor authentication to do this",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// OwnerIdSource reads the user IDs targeted by a request
+//
+// If the request can't be read (e.g., the ID field in the body isn't a string), ok is false and the request is rejected.
+type OwnerIdSource func(c *gin.Context) (ids []string, ok bool)
+
+// FromParam reads the targeted user ID from the given path parameter
+func FromParam(name string) OwnerIdSource {
+	return func(c *gin.Context) ([]string, bool) {
+		return []string{c.Param(name)}, true
+	}
+}
+
+// FromQuery reads the targeted user IDs from the given comma-separated query string parameter
+func FromQuery(name string) OwnerIdSource {
+	return func(c *gin.Context) ([]string, bool) {
+		return strings.Split(c.Query(name), ","), true
+	}
+}
+
+// FromBodyField reads the targeted user ID from the given top-level field in the JSON body
+//
+// The body is cached so it can still be bound by the handler using ShouldBindBodyWithJSON. A body that isn't JSON or
+// doesn't have the field can't be checked, so it's rejected.
+func FromBodyField(name string) OwnerIdSource {
+	return func(c *gin.Context) ([]string, bool) {
+		var body map[string]any
+		if err := c.ShouldBindBodyWith(&body, binding.JSON); err != nil {
+			return nil, false
+		}
+		id, isString := body[name].(string)
+		return []string{id}, isString && id != ""
+	}
+}
+
+// RequirePermission only allows the request if the logged in user's role is granted all of the given permissions
+func RequirePermission(permissions ...helper.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.CheckPermissions(c, permissions...); err != nil {
+			abortNotAuthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOwnerOrPermission only allows the request if the logged in user's role is granted the given permission, or if every
+// user ID targeted by the request belongs to the logged in user
+//
+// When none of the sources contain an ID, the request targets the logged in user and is allowed.
+func RequireOwnerOrPermission(permission helper.Permission, sources ...OwnerIdSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.CheckPermissions(c, permission); err == nil {
+			c.Next()
+			return
+		}
+		var ids []string
+		for _, source := range sources {
+			sourceIds, ok := source(c)
+			if !ok {
+				abortNotAuthorized(c)
+				return
+			}
+			ids = append(ids, sourceIds...)
+		}
+		if err := helper.CheckOwnership(c, ids...); err != nil {
+			abortNotAuthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortNotAuthorized(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, V1_API_RESPONSE{
+		Status:  http.StatusUnauthorized,
+		Message: "not authorized",
+	})
+	c.Abort()
+}
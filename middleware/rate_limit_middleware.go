@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKey picks the bucket a request is counted against; an empty key means the request isn't limited
+type RateLimitKey func(c *gin.Context) string
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXIES, a comma-separated list of the IP addresses or CIDR ranges (e.g., the
+// load balancer's subnets) that are trusted to set X-Forwarded-For; returns nil (no proxies are trusted) when it's unset
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// ByClientIP counts requests per client IP address
+//
+// Behind a load balancer, the engine has to trust it (see TrustedProxiesFromEnv) or every request is counted against
+// the load balancer's address.
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser counts requests per logged in user (use it after AuthenticateV1; requests without a uid aren't limited)
+func ByUser(c *gin.Context) string {
+	uid := c.GetString("uid")
+	if uid == "" {
+		return ""
+	}
+	return "uid:" + uid
+}
+
+// RateLimit only allows requests while the bucket for the request's key has tokens left
+//
+// Limits with different names never share buckets, so each route group can have its own limit. When the limit is
+// exceeded, a 429 is returned with a Retry-After header (in seconds). If the store fails, the request is allowed.
+func RateLimit(store RateLimitStore, name string, key RateLimitKey, rate Rate) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		allowed, retryAfter, err := store.Take(ctx, name+":"+k, rate, time.Now())
+		if err != nil {
+			log.Println("Error checking rate limit: ", err.Error())
+			c.Next()
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, V1_API_RESPONSE{
+				Status:  http.StatusTooManyRequests,
+				Message: "Too many requests",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,94 @@
+//go:build unit
+// +build unit
+
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimit_Unit(t *testing.T) {
+	assert := assert.New(t)
+	t.Run("MemoryRateLimitStore - allows a burst, then refills over time", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		rate := Rate{Requests: 2, Per: time.Minute}
+		now := time.Now()
+		allowed, _, _ := store.Take(context.Background(), "key", rate, now)
+		assert.True(allowed)
+		allowed, _, _ = store.Take(context.Background(), "key", rate, now)
+		assert.True(allowed)
+		allowed, retryAfter, _ := store.Take(context.Background(), "key", rate, now)
+		assert.False(allowed)
+		assert.Equal(30*time.Second, retryAfter)
+		allowed, _, _ = store.Take(context.Background(), "other-key", rate, now)
+		assert.True(allowed)
+		allowed, _, _ = store.Take(context.Background(), "key", rate, now.Add(30*time.Second))
+		assert.True(allowed)
+	})
+	t.Run("RateLimit - returns 429 with Retry-After once the limit is reached", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/", RateLimit(NewMemoryRateLimitStore(), "test", ByClientIP, Rate{Requests: 1, Per: time.Minute}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(http.StatusTooManyRequests, w.Code)
+		assert.Equal("60", w.Header().Get("Retry-After"))
+		var jsonResponse V1_API_RESPONSE
+		json.Unmarshal(w.Body.Bytes(), &jsonResponse)
+		assert.Equal(http.StatusTooManyRequests, jsonResponse.Status)
+	})
+	t.Run("ByClientIP - uses X-Forwarded-For only from trusted proxies", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/16 , 10.1.0.7")
+		assert.Equal([]string{"10.0.0.0/16", "10.1.0.7"}, TrustedProxiesFromEnv())
+		r := gin.New()
+		assert.Nil(r.SetTrustedProxies(TrustedProxiesFromEnv()))
+		r.GET("/", RateLimit(NewMemoryRateLimitStore(), "test", ByClientIP, Rate{Requests: 1, Per: time.Minute}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		get := func(remoteAddr string, forwardedFor string) int {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		// Clients behind the load balancer each get their own limit
+		assert.Equal(http.StatusOK, get("10.0.3.4:5000", "203.0.113.1"))
+		assert.Equal(http.StatusOK, get("10.0.3.4:5000", "203.0.113.2"))
+		assert.Equal(http.StatusOK, get("10.1.0.7:5000", "203.0.113.3"))
+		assert.Equal(http.StatusTooManyRequests, get("10.1.0.7:5000", "203.0.113.1"))
+		// Anyone else is counted by their own address, whatever they claim to be
+		assert.Equal(http.StatusOK, get("198.51.100.9:5000", "203.0.113.4"))
+		assert.Equal(http.StatusTooManyRequests, get("198.51.100.9:5000", "203.0.113.5"))
+
+		t.Setenv("TRUSTED_PROXIES", "")
+		assert.Nil(TrustedProxiesFromEnv())
+	})
+	t.Run("RateLimit - ByUser skips requests without a uid", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/", RateLimit(NewMemoryRateLimitStore(), "test", ByUser, Rate{Requests: 1, Per: time.Minute}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(http.StatusOK, w.Code)
+		}
+	})
+}
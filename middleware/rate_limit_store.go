@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rate is the number of requests allowed per period, refilled continuously (up to Requests can be made in a burst)
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimitStore holds the token buckets used by RateLimit
+//
+// The in-memory store is enough for a single instance; implement this interface on top of a shared backend (e.g., Redis)
+// to share limits across instances.
+type RateLimitStore interface {
+	// Take a token from the bucket for the given key; when the bucket is empty, allowed is false and retryAfter is
+	// how long until the next token is available
+	Take(c context.Context, key string, rate Rate, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	per    time.Duration
+}
+
+// MemoryRateLimitStore keeps token buckets in memory
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	takes   int
+}
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// How many takes happen between sweeps for idle buckets
+const bucketSweepInterval = 1024
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(c context.Context, key string, rate Rate, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.takes++
+	if s.takes%bucketSweepInterval == 0 {
+		s.sweep(now)
+	}
+	capacity := float64(rate.Requests)
+	perToken := rate.Per / time.Duration(rate.Requests)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now, per: rate.Per}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))/float64(perToken))
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(perToken)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// Drops the buckets that have been idle long enough to refill completely (they're equivalent to new buckets)
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.per {
+			delete(s.buckets, key)
+		}
+	}
+}
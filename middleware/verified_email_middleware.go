@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireVerifiedEmail only allows users who have verified their email address through the routes admins have
+// chosen to require it for (use it after AuthenticateV1); other routes aren't affected
+func RequireVerifiedEmail(users repository.UserRepository, routes repository.VerifiedRouteRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		required, err := routes.IsVerifiedRoute(ctx, c.Request.Method, c.FullPath())
+		if err != nil {
+			abortInternalServerError(c, "Error checking if route requires a verified email: ", err)
+			return
+		}
+		if !required {
+			c.Next()
+			return
+		}
+		uid, _ := uuid.Parse(c.GetString("uid"))
+		found, err := users.FindUsers(ctx, []uuid.UUID{uid})
+		if err != nil {
+			abortInternalServerError(c, "Error finding user: ", err)
+			return
+		}
+		if len(found) == 0 || found[0].EmailVerifiedAt == nil {
+			c.JSON(http.StatusForbidden, V1_API_RESPONSE{
+				Status:  http.StatusForbidden,
+				Message: "Verify your email address to do this",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortInternalServerError(c *gin.Context, logPrefix string, err error) {
+	log.Println(logPrefix, err.Error())
+	c.JSON(http.StatusInternalServerError, V1_API_RESPONSE{
+		Status:  http.StatusInternalServerError,
+		Message: "Internal server error",
+	})
+	c.Abort()
+}
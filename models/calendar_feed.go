@@ -0,0 +1,47 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeed table; a secret link a user's calendar app can subscribe to without logging in
+type CalendarFeed struct {
+	BaseModel
+	// The ID of the user whose events the feed contains; a user has at most one feed.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// A hash of the token in the feed's link (the token itself is never stored).
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+}
+
+// Create a calendar feed, replacing the feed the user already has (so its link stops working)
+func CreateCalendarFeed(c context.Context, feed *CalendarFeed) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", feed.UserId).Delete(&CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	})
+}
+
+// Find the calendar feed with the given token hash; returns nil if there isn't one
+func FindCalendarFeed(c context.Context, tokenHash string) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	result := db.WithContext(c).Where("token_hash = ?", tokenHash).First(&feed)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &feed, nil
+}
+
+// Delete the user's calendar feed; returns the number of deleted records
+func DeleteCalendarFeedForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Unscoped().Where("user_id = ?", userId).Delete(&CalendarFeed{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,55 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalendarFeedModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindCalendarFeed - missing feed returns nil", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "calendar_feeds" WHERE token_hash = $1 AND "calendar_feeds"."deleted_at" IS NULL ORDER BY "calendar_feeds"."id" LIMIT $2`)).WithArgs(
+			"hash", 1,
+		).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		feed, err := FindCalendarFeed(ctx, "hash")
+
+		assert.Nil(feed)
+		assert.Nil(err)
+	})
+	t.Run("CreateCalendarFeed - database error rolls back the replaced feed's deletion", func(t *testing.T) {
+		_, mock, _ := Setup()
+		feed := CalendarFeed{UserId: uuid.New(), TokenHash: "hash"}
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "calendar_feeds" WHERE user_id = $1`)).WithArgs(
+			feed.UserId,
+		).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "calendar_feeds"`)).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		err := CreateCalendarFeed(ctx, &feed)
+
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
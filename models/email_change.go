@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrEmailTaken is returned when a user's email would be changed to an address another user already has
+var ErrEmailTaken = errors.New("a user with this email address already exists")
+
+// EmailChange table; a pending change to a user's email address, waiting for the new address to be confirmed
+type EmailChange struct {
+	BaseModel
+	// The ID of the user whose email is being changed; a user has at most one pending change.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// The email address the user's email will be changed to once confirmed.
+	NewEmail string `json:"new_email"`
+	// A hash of the token sent to the new address (the token itself is never stored).
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// The time after which the change can no longer be confirmed.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Create a pending email change, replacing any pending change the user already has
+func CreateEmailChange(c context.Context, change *EmailChange) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", change.UserId).Delete(&EmailChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// Find the pending email change with the given token hash; returns nil if there isn't one
+func FindEmailChange(c context.Context, tokenHash string) (*EmailChange, error) {
+	var changes []EmailChange
+	result := db.WithContext(c).Where("token_hash = ?", tokenHash).Limit(1).Find(&changes)
+	if result.Error != nil || len(changes) == 0 {
+		return nil, result.Error
+	}
+	return &changes[0], nil
+}
+
+// Switch the user's email to the new address of the pending change and delete the change
+//
+// The new address counts as verified, since the change was confirmed using it. The user's token version is bumped,
+// since their tokens were issued for the old address. Returns ErrEmailTaken
+// (and leaves the change pending) if another user has taken the new address since the change was requested.
+func ConfirmEmailChange(c context.Context, change *EmailChange) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&User{}).Where("email = ? AND id <> ?", change.NewEmail, change.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+		if err := tx.Model(&User{}).Where("id = ?", change.UserId).Updates(map[string]interface{}{
+			"email":             change.NewEmail,
+			"email_verified_at": time.Now(),
+			"token_version":     gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", change.UserId).Delete(&EmailChange{}).Error
+	})
+}
@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmailChangeModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindEmailChange - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "email_changes" WHERE token_hash = $1 AND "email_changes"."deleted_at" IS NULL LIMIT $2`)).WithArgs(
+			"some-hash",
+			1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		change, err := FindEmailChange(ctx, "some-hash")
+
+		assert.Nil(change)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("ConfirmEmailChange - address taken by another user returns ErrEmailTaken", func(t *testing.T) {
+		_, mock, _ := Setup()
+		userId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE (email = $1 AND id <> $2) AND "users"."deleted_at" IS NULL`)).WithArgs(
+			"new@email.place",
+			userId,
+		).WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := ConfirmEmailChange(ctx, &EmailChange{UserId: userId, NewEmail: "new@email.place"})
+
+		assert.Equal(ErrEmailTaken, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("ChangePassword - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		userId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "users" SET "password"=$1,"token_version"=token_version + 1,"updated_at"=$2 WHERE id = $3 AND "users"."deleted_at" IS NULL`)).WithArgs(
+			"some-hash",
+			test.AnyTime{},
+			userId,
+		).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		err := ChangePassword(ctx, userId, "some-hash")
+
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailVerification table; a pending check that a user owns their email address
+type EmailVerification struct {
+	BaseModel
+	// The ID of the user whose email address is being verified; a user has at most one pending verification.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// A hash of the token emailed to the user (the token itself is never stored).
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// The time after which the token can no longer be used.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Create a pending verification, replacing any pending verification the user already has
+func CreateEmailVerification(c context.Context, verification *EmailVerification) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", verification.UserId).Delete(&EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(verification).Error
+	})
+}
+
+// Find the pending verification with the given token hash; returns nil if there isn't one
+func FindEmailVerification(c context.Context, tokenHash string) (*EmailVerification, error) {
+	var verifications []EmailVerification
+	result := db.WithContext(c).Where("token_hash = ?", tokenHash).Limit(1).Find(&verifications)
+	if result.Error != nil || len(verifications) == 0 {
+		return nil, result.Error
+	}
+	return &verifications[0], nil
+}
+
+// Mark the user's email address as verified at the given time and delete the pending verification
+func VerifyEmail(c context.Context, verification *EmailVerification, verifiedAt time.Time) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).Where("id = ?", verification.UserId).Update("email_verified_at", verifiedAt).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", verification.UserId).Delete(&EmailVerification{}).Error
+	})
+}
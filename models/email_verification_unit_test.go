@@ -0,0 +1,57 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmailVerificationModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindEmailVerification - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "email_verifications" WHERE token_hash = $1 AND "email_verifications"."deleted_at" IS NULL LIMIT $2`)).WithArgs(
+			"some-hash",
+			1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		verification, err := FindEmailVerification(ctx, "some-hash")
+
+		assert.Nil(verification)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("VerifyEmail - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		userId := uuid.New()
+		verifiedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "users" SET "email_verified_at"=$1,"updated_at"=$2 WHERE id = $3 AND "users"."deleted_at" IS NULL`)).WithArgs(
+			test.AnyTime{},
+			test.AnyTime{},
+			userId,
+		).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		err := VerifyEmail(ctx, &EmailVerification{UserId: userId}, verifiedAt)
+
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Who is invited to an event
+const (
+	// Every user (and their invitees) is invited
+	EventScopeAll = "ALL"
+	// Only the users on the event's guest list (and their invitees) are invited
+	EventScopeSelected = "SELECTED"
+)
+
+// Event table; one part of the wedding itinerary (e.g., the welcome dinner, ceremony, reception or brunch)
+type Event struct {
+	BaseModel
+	// The event's name.
+	Name string `json:"name"`
+	// When the event starts.
+	StartsAt time.Time `json:"starts_at"`
+	// When the event ends.
+	EndsAt time.Time `json:"ends_at"`
+	// Where the event is held.
+	Location string `json:"location"`
+	// What guests should wear (e.g., "Black tie").
+	DressCode string `json:"dress_code"`
+	// Anything else guests should know about the event.
+	Description string `json:"description"`
+	// Who is invited, which can be "ALL" or "SELECTED" (only the users on the event's guest list). Defaults to "ALL".
+	InviteScope string `json:"invite_scope" sql:"type:ENUM('ALL', 'SELECTED')" gorm:"default:ALL"`
+	// How many times the event has been updated; calendar apps use it to tell which copy of the event is the latest.
+	Sequence int `json:"-"`
+}
+
+// EventGuest table; a user on the guest list of an event whose invite scope is "SELECTED"
+type EventGuest struct {
+	BaseModel
+	// The ID of the event.
+	EventId uuid.UUID `json:"event_id" gorm:"uniqueIndex:idx_event_guest"`
+	// The ID of the invited user (their invitees are invited too).
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex:idx_event_guest;index"`
+}
+
+// EventRsvp table; whether a guest or one of their invitees is attending an event
+type EventRsvp struct {
+	BaseModel
+	// The ID of the event.
+	EventId uuid.UUID `json:"event_id" gorm:"uniqueIndex:idx_event_rsvp"`
+	// The ID of the user who responded (for themselves or one of their invitees).
+	UserId uuid.UUID `json:"user_id" gorm:"index"`
+	// The ID of the person the response is for: the user's own ID, or the ID of one of their invitees.
+	AttendeeId uuid.UUID `json:"attendee_id" gorm:"uniqueIndex:idx_event_rsvp"`
+	// Whether the person is attending.
+	Attending bool `json:"attending"`
+}
+
+// EventHeadcount is the number of people invited to, attending and not attending an event (not a table)
+type EventHeadcount struct {
+	EventId uuid.UUID `json:"event_id"`
+	Name    string    `json:"name"`
+	// The number of users and invitees invited to the event.
+	Invited int64 `json:"invited"`
+	// The number of people who said they're attending.
+	Attending int64 `json:"attending"`
+	// The number of people who said they aren't attending.
+	Declined int64 `json:"declined"`
+}
+
+// Create an event
+func CreateEvent(c context.Context, event *Event) error {
+	return db.WithContext(c).Create(event).Error
+}
+
+// Find all events, earliest first
+func FindEvents(c context.Context) ([]Event, error) {
+	var events []Event
+	result := db.WithContext(c).Order("starts_at").Find(&events)
+	return events, result.Error
+}
+
+// Find the event with the given ID; returns nil if there isn't one
+func FindEventById(c context.Context, id uuid.UUID) (*Event, error) {
+	var event Event
+	result := db.WithContext(c).Where("id = ?", id).First(&event)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &event, nil
+}
+
+// Replace every detail of an event; returns gorm.ErrRecordNotFound if there is no event with its ID
+//
+// If the event is limited to its guest list, responses from people who aren't on it are deleted.
+func UpdateEvent(c context.Context, event *Event) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Event{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+			"name":         event.Name,
+			"starts_at":    event.StartsAt,
+			"ends_at":      event.EndsAt,
+			"location":     event.Location,
+			"dress_code":   event.DressCode,
+			"description":  event.Description,
+			"invite_scope": event.InviteScope,
+			"sequence":     gorm.Expr("sequence + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return pruneEventRsvps(tx, event.ID, event.InviteScope)
+	})
+}
+
+// Delete an event along with its guest list and responses; returns the number of deleted events
+func DeleteEvent(c context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Event{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		for _, record := range []interface{}{&EventGuest{}, &EventRsvp{}} {
+			if err := tx.Unscoped().Where("event_id = ?", id).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// Find the IDs of the users on an event's guest list
+func FindEventGuests(c context.Context, eventId uuid.UUID) ([]uuid.UUID, error) {
+	var userIds []uuid.UUID
+	result := db.WithContext(c).Model(&EventGuest{}).Where("event_id = ?", eventId).Order("created_at").Pluck("user_id", &userIds)
+	return userIds, result.Error
+}
+
+// Replace an event's guest list
+//
+// If the event is limited to its guest list, responses from people who are no longer on it are deleted.
+func ReplaceEventGuests(c context.Context, eventId uuid.UUID, userIds []uuid.UUID) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var event Event
+		if err := tx.Select("id", "invite_scope").Where("id = ?", eventId).First(&event).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("event_id = ?", eventId).Delete(&EventGuest{}).Error; err != nil {
+			return err
+		}
+		if len(userIds) > 0 {
+			guests := make([]EventGuest, len(userIds))
+			for i, userId := range userIds {
+				guests[i].EventId = eventId
+				guests[i].UserId = userId
+			}
+			if err := tx.Create(&guests).Error; err != nil {
+				return err
+			}
+		}
+		return pruneEventRsvps(tx, eventId, event.InviteScope)
+	})
+}
+
+// Deletes the responses from people who aren't on the guest list of an event that's limited to it
+func pruneEventRsvps(tx *gorm.DB, eventId uuid.UUID, inviteScope string) error {
+	if inviteScope != EventScopeSelected {
+		return nil
+	}
+	guests := tx.Model(&EventGuest{}).Select("user_id").Where("event_id = ?", eventId)
+	return tx.Unscoped().Where("event_id = ? AND user_id NOT IN (?)", eventId, guests).Delete(&EventRsvp{}).Error
+}
+
+// Find the events the user (and their invitees) are invited to, earliest first
+func FindEventsForUser(c context.Context, userId uuid.UUID) ([]Event, error) {
+	var events []Event
+	guests := db.Model(&EventGuest{}).Select("event_id").Where("user_id = ?", userId)
+	result := db.WithContext(c).Where("invite_scope = ? OR id IN (?)", EventScopeAll, guests).Order("starts_at").Find(&events)
+	return events, result.Error
+}
+
+// Find the responses the user has given (for themselves and their invitees) across every event
+func FindEventRsvpsForUser(c context.Context, userId uuid.UUID) ([]EventRsvp, error) {
+	var rsvps []EventRsvp
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&rsvps)
+	return rsvps, result.Error
+}
+
+// Save a response, replacing any earlier response for the same person and event
+func SaveEventRsvp(c context.Context, rsvp *EventRsvp) error {
+	return db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}, {Name: "attendee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "attending", "updated_at"}),
+	}).Create(rsvp).Error
+}
+
+// Responses for users and invitees that have since been deleted aren't counted
+const rsvpFromCurrentAttendee = `(EXISTS (SELECT 1 FROM users WHERE users.id = event_rsvps.attendee_id AND users.deleted_at IS NULL) OR
+		EXISTS (SELECT 1 FROM user_invitees WHERE user_invitees.id = event_rsvps.attendee_id AND user_invitees.deleted_at IS NULL))`
+
+// Count the people invited to, attending and not attending each event, earliest event first
+func FindEventHeadcounts(c context.Context) ([]EventHeadcount, error) {
+	var headcounts []EventHeadcount
+	result := db.WithContext(c).Model(&Event{}).Select(`events.id AS event_id, events.name,
+		CASE WHEN events.invite_scope = ? THEN
+			(SELECT count(*) FROM users WHERE users.deleted_at IS NULL) +
+			(SELECT count(*) FROM user_invitees WHERE user_invitees.deleted_at IS NULL)
+		ELSE
+			(SELECT count(*) FROM event_guests JOIN users ON users.id = event_guests.user_id AND users.deleted_at IS NULL WHERE event_guests.event_id = events.id) +
+			(SELECT count(*) FROM event_guests JOIN user_invitees ON user_invitees.inviter_id = event_guests.user_id AND user_invitees.deleted_at IS NULL WHERE event_guests.event_id = events.id)
+		END AS invited,
+		(SELECT count(*) FROM event_rsvps WHERE event_rsvps.event_id = events.id AND event_rsvps.attending AND `+rsvpFromCurrentAttendee+`) AS attending,
+		(SELECT count(*) FROM event_rsvps WHERE event_rsvps.event_id = events.id AND NOT event_rsvps.attending AND `+rsvpFromCurrentAttendee+`) AS declined`, EventScopeAll).
+		Order("events.starts_at").Scan(&headcounts)
+	return headcounts, result.Error
+}
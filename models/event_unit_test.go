@@ -0,0 +1,80 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_EventModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindEventById - missing event returns nil", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "events" WHERE id = $1 AND "events"."deleted_at" IS NULL ORDER BY "events"."id" LIMIT $2`)).WithArgs(
+			id, 1,
+		).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		event, err := FindEventById(ctx, id)
+
+		assert.Nil(event)
+		assert.Nil(err)
+	})
+	t.Run("UpdateEvent - missing event returns gorm.ErrRecordNotFound", func(t *testing.T) {
+		_, mock, _ := Setup()
+		event := Event{BaseModel: BaseModel{ID: uuid.New()}, Name: "Ceremony", InviteScope: EventScopeSelected}
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "events" SET`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := UpdateEvent(ctx, &event)
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("UpdateEvent - limiting an event to its guest list deletes responses from everyone else", func(t *testing.T) {
+		_, mock, _ := Setup()
+		event := Event{BaseModel: BaseModel{ID: uuid.New()}, Name: "Ceremony", InviteScope: EventScopeSelected}
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "events" SET`)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "event_rsvps" WHERE event_id = $1 AND user_id NOT IN (SELECT "user_id" FROM "event_guests" WHERE event_id = $2`)).WithArgs(
+			event.ID, event.ID,
+		).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := UpdateEvent(ctx, &event)
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("FindEventHeadcounts - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT events.id AS event_id, events.name`)).WillReturnError(fmt.Errorf(errMsg))
+
+		headcounts, err := FindEventHeadcounts(ctx)
+
+		assert.Nil(headcounts)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// A gift imported from a registry claim
+	GiftSourceRegistry = "REGISTRY"
+	// A gift entered by hand (e.g., cash or something that wasn't on the registry)
+	GiftSourceManual = "MANUAL"
+)
+
+const (
+	ThankYouNotStarted = "NOT_STARTED"
+	ThankYouDrafted    = "DRAFTED"
+	ThankYouSent       = "SENT"
+)
+
+// Gift table; a gift the couple received, and whether they've thanked the giver for it
+type Gift struct {
+	BaseModel
+	// Where the gift was recorded from; one of GiftSourceRegistry or GiftSourceManual.
+	Source string `json:"source"`
+	// The ID of the registry claim the gift was imported from; is null for gifts entered by hand.
+	RegistryClaimId *uuid.UUID `json:"registry_claim_id" gorm:"uniqueIndex"`
+	// What the gift was.
+	Description string `json:"description"`
+	// How much cash (or a check) was given, in cents.
+	CashCents int64 `json:"cash_cents"`
+	// The ID of the user who gave the gift; is null if it was only from one of their invitees.
+	UserId *uuid.UUID `json:"user_id" gorm:"index"`
+	// The ID of the invitee who gave the gift (or gave it along with the user).
+	InviteeId *uuid.UUID `json:"invitee_id" gorm:"index"`
+	// When the gift arrived; is null until it does.
+	ReceivedAt *time.Time `json:"received_at"`
+	// Where to send the thank-you note; the thank-you queue uses the address of the giver's household when this is empty.
+	MailingAddress string `json:"mailing_address"`
+	// One of ThankYouNotStarted, ThankYouDrafted or ThankYouSent.
+	ThankYouStatus string `json:"thank_you_status"`
+	// When the thank-you note was sent; is null until it is.
+	ThankYouSentAt *time.Time `json:"thank_you_sent_at"`
+	Notes          string     `json:"notes"`
+}
+
+// ThankYouQueueEntry is a gift that still needs a thank-you note, along with who gave it (not a table)
+type ThankYouQueueEntry struct {
+	Gift
+	UserFirstName    string `json:"user_first_name"`
+	UserLastName     string `json:"user_last_name"`
+	InviteeFirstName string `json:"invitee_first_name"`
+	InviteeLastName  string `json:"invitee_last_name"`
+	// The giver's email address (the inviter's, for gifts only from an invitee).
+	Email string `json:"email"`
+}
+
+// RegistryGiftDescription describes a gift imported from a claim on a registry item
+func RegistryGiftDescription(itemName string, quantity int) string {
+	if quantity > 1 {
+		return fmt.Sprintf("%s (%d)", itemName, quantity)
+	}
+	return itemName
+}
+
+// Create a gift; its thank-you note is not started unless a status is given
+func CreateGift(c context.Context, gift *Gift) error {
+	if gift.ThankYouStatus == "" {
+		gift.ThankYouStatus = ThankYouNotStarted
+	}
+	return db.WithContext(c).Create(gift).Error
+}
+
+// Find all gifts, in the order they were recorded
+func FindGifts(c context.Context) ([]Gift, error) {
+	var gifts []Gift
+	result := db.WithContext(c).Order("created_at").Find(&gifts)
+	return gifts, result.Error
+}
+
+// Find the gift with the given ID; returns nil if there isn't one
+func FindGiftById(c context.Context, id uuid.UUID) (*Gift, error) {
+	var gift Gift
+	result := db.WithContext(c).Where("id = ?", id).First(&gift)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &gift, nil
+}
+
+// Replace the details of a gift (but not where it came from or its thank-you note); returns gorm.ErrRecordNotFound if
+// there is no gift with its ID
+func UpdateGift(c context.Context, gift *Gift) error {
+	result := db.WithContext(c).Model(&Gift{}).Where("id = ?", gift.ID).Updates(map[string]interface{}{
+		"description":     gift.Description,
+		"cash_cents":      gift.CashCents,
+		"user_id":         gift.UserId,
+		"invitee_id":      gift.InviteeId,
+		"received_at":     gift.ReceivedAt,
+		"mailing_address": gift.MailingAddress,
+		"notes":           gift.Notes,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Set the status of a gift's thank-you note; returns gorm.ErrRecordNotFound if there is no gift with the ID
+func UpdateGiftThankYou(c context.Context, id uuid.UUID, status string, sentAt *time.Time) error {
+	result := db.WithContext(c).Model(&Gift{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"thank_you_status":  status,
+		"thank_you_sent_at": sentAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a gift; returns the number of deleted gifts
+//
+// Gifts are deleted outright so a registry claim whose gift was deleted can be imported again.
+func DeleteGift(c context.Context, id uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Unscoped().Delete(&Gift{}, id)
+	return result.RowsAffected, result.Error
+}
+
+// Record a gift for each registry claim that doesn't have one yet; returns the new gifts
+func ImportRegistryGifts(c context.Context) ([]Gift, error) {
+	gifts := []Gift{}
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var claims []struct {
+			ID       uuid.UUID
+			UserId   uuid.UUID
+			ItemName string
+			Quantity int
+		}
+		if err := tx.Model(&RegistryClaim{}).Select("registry_claims.id, registry_claims.user_id, registry_items.name AS item_name, registry_claims.quantity").
+			Joins("JOIN registry_items ON registry_items.id = registry_claims.registry_item_id AND registry_items.deleted_at IS NULL").
+			Where(claimFromCurrentUser + ` AND NOT EXISTS (SELECT 1 FROM gifts WHERE gifts.registry_claim_id = registry_claims.id)`).
+			Order("registry_items.created_at, registry_claims.created_at").Scan(&claims).Error; err != nil {
+			return err
+		}
+		for _, claim := range claims {
+			gifts = append(gifts, Gift{
+				Source:          GiftSourceRegistry,
+				RegistryClaimId: &claim.ID,
+				Description:     RegistryGiftDescription(claim.ItemName, claim.Quantity),
+				UserId:          &claim.UserId,
+				ThankYouStatus:  ThankYouNotStarted,
+			})
+		}
+		if len(gifts) == 0 {
+			return nil
+		}
+		return tx.Create(&gifts).Error
+	})
+	return gifts, err
+}
+
+// Find the gifts that still need a thank-you note, the ones that arrived first first (then ones that haven't arrived
+// yet, in the order they were recorded)
+func FindThankYouQueue(c context.Context) ([]ThankYouQueueEntry, error) {
+	var queue []ThankYouQueueEntry
+	result := db.WithContext(c).Model(&Gift{}).Select(`gifts.*,
+		COALESCE(users.first_name, '') AS user_first_name, COALESCE(users.last_name, '') AS user_last_name,
+		COALESCE(user_invitees.first_name, '') AS invitee_first_name, COALESCE(user_invitees.last_name, '') AS invitee_last_name,
+		COALESCE(users.email, inviters.email, '') AS email`).
+		Joins("LEFT JOIN users ON users.id = gifts.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN user_invitees ON user_invitees.id = gifts.invitee_id AND user_invitees.deleted_at IS NULL").
+		Joins("LEFT JOIN users AS inviters ON inviters.id = user_invitees.inviter_id AND inviters.deleted_at IS NULL").
+		Where("gifts.thank_you_status <> ?", ThankYouSent).
+		Order("gifts.received_at NULLS LAST, gifts.created_at").Scan(&queue)
+	return queue, result.Error
+}
@@ -0,0 +1,87 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_GiftModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("RegistryGiftDescription - includes the quantity when there's more than one", func(t *testing.T) {
+		assert.Equal("Bath towels", RegistryGiftDescription("Bath towels", 1))
+		assert.Equal("Bath towels (3)", RegistryGiftDescription("Bath towels", 3))
+	})
+	t.Run("ImportRegistryGifts - records a gift for each claim without one", func(t *testing.T) {
+		_, mock, _ := Setup()
+		claimId := uuid.New()
+		userId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT registry_claims.id, registry_claims.user_id, registry_items.name AS item_name, registry_claims.quantity FROM "registry_claims"`) + `.*` + regexp.QuoteMeta(`NOT EXISTS (SELECT 1 FROM gifts WHERE gifts.registry_claim_id = registry_claims.id)`)).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "item_name", "quantity"}).AddRow(claimId, userId, "Bath towels", 2))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "gifts"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		gifts, err := ImportRegistryGifts(ctx)
+
+		assert.Nil(err)
+		assert.Equal(1, len(gifts))
+		assert.Equal("Bath towels (2)", gifts[0].Description)
+		assert.Equal(claimId, *gifts[0].RegistryClaimId)
+		assert.Equal(userId, *gifts[0].UserId)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("ImportRegistryGifts - inserts nothing when every claim has a gift", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`FROM "registry_claims"`)).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "item_name", "quantity"}))
+		mock.ExpectCommit()
+
+		gifts, err := ImportRegistryGifts(ctx)
+
+		assert.Nil(err)
+		assert.Equal(0, len(gifts))
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("UpdateGiftThankYou - returns gorm.ErrRecordNotFound for missing gifts", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "gifts" SET "thank_you_sent_at"=$1,"thank_you_status"=$2,"updated_at"=$3 WHERE id = $4`)).WithArgs(nil, ThankYouDrafted, sqlmock.AnyArg(), id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := UpdateGiftThankYou(ctx, id, ThankYouDrafted, nil)
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("FindThankYouQueue - leaves out sent notes", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`FROM "gifts" LEFT JOIN users ON users.id = gifts.user_id`) + `.*` + regexp.QuoteMeta(`WHERE gifts.thank_you_status <> $1 AND "gifts"."deleted_at" IS NULL ORDER BY gifts.received_at NULLS LAST, gifts.created_at`)).WithArgs(ThankYouSent).WillReturnRows(sqlmock.NewRows([]string{"id", "description", "email"}).AddRow(uuid.New(), "Vase", "guest@example.com"))
+
+		queue, err := FindThankYouQueue(ctx)
+
+		assert.Nil(err)
+		assert.Equal(1, len(queue))
+		assert.Equal("Vase", queue[0].Description)
+		assert.Equal("guest@example.com", queue[0].Email)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
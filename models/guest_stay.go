@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GuestStay table; where a user (and their invitees) are staying for the wedding
+type GuestStay struct {
+	BaseModel
+	// The ID of the user who is staying; a user has at most one stay.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// The ID of the accommodation the user is staying at; is null if they're staying somewhere else.
+	AccommodationId *uuid.UUID `json:"accommodation_id" gorm:"index"`
+	// Where the user is staying when it isn't one of the accommodations (e.g., "With family").
+	OtherAccommodation string `json:"other_accommodation"`
+	// The day the user arrives.
+	ArrivalDate time.Time `json:"arrival_date"`
+	// The day the user leaves.
+	DepartureDate time.Time `json:"departure_date"`
+	// Whether the user booked a room from the accommodation's room block.
+	UsedRoomBlock bool `json:"used_room_block"`
+}
+
+// RoomBlockUtilization is how much of an accommodation's room block has been booked (not a table)
+type RoomBlockUtilization struct {
+	AccommodationId uuid.UUID `json:"accommodation_id"`
+	Name            string    `json:"name"`
+	// The number of rooms in the block under contract with the hotel.
+	ContractedRooms int `json:"contracted_rooms"`
+	// The number of guests who booked a room from the block (each guest's stay counts as one room).
+	BlockBookings int64 `json:"block_bookings"`
+	// The number of guests staying at the accommodation who didn't book from the block.
+	OtherBookings int64 `json:"other_bookings"`
+}
+
+// Save the user's stay, replacing the stay they already have
+func SaveGuestStay(c context.Context, stay *GuestStay) error {
+	return db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"accommodation_id", "other_accommodation", "arrival_date", "departure_date", "used_room_block", "updated_at"}),
+	}).Create(stay).Error
+}
+
+// Find the user's stay; returns nil if they haven't said where they're staying
+func FindGuestStayForUser(c context.Context, userId uuid.UUID) (*GuestStay, error) {
+	var stay GuestStay
+	result := db.WithContext(c).Where("user_id = ?", userId).First(&stay)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &stay, nil
+}
+
+// Delete the user's stay; returns the number of deleted records
+func DeleteGuestStayForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Unscoped().Where("user_id = ?", userId).Delete(&GuestStay{})
+	return result.RowsAffected, result.Error
+}
+
+// Stays for users that have since been deleted aren't reported
+const stayFromCurrentUser = `EXISTS (SELECT 1 FROM users WHERE users.id = guest_stays.user_id AND users.deleted_at IS NULL)`
+
+// Find the stays of every user, earliest arrival first
+func FindGuestStays(c context.Context) ([]GuestStay, error) {
+	var stays []GuestStay
+	result := db.WithContext(c).Where(stayFromCurrentUser).Order("arrival_date").Find(&stays)
+	return stays, result.Error
+}
+
+// Count the rooms booked from each accommodation's room block, in the order the accommodations were added
+func FindRoomBlockUtilization(c context.Context) ([]RoomBlockUtilization, error) {
+	var utilization []RoomBlockUtilization
+	result := db.WithContext(c).Model(&Accommodation{}).Select(`accommodations.id AS accommodation_id, accommodations.name, accommodations.contracted_rooms,
+		(SELECT count(*) FROM guest_stays WHERE guest_stays.accommodation_id = accommodations.id AND guest_stays.used_room_block AND guest_stays.deleted_at IS NULL AND ` + stayFromCurrentUser + `) AS block_bookings,
+		(SELECT count(*) FROM guest_stays WHERE guest_stays.accommodation_id = accommodations.id AND NOT guest_stays.used_room_block AND guest_stays.deleted_at IS NULL AND ` + stayFromCurrentUser + `) AS other_bookings`).
+		Order("accommodations.created_at").Scan(&utilization)
+	return utilization, result.Error
+}
@@ -0,0 +1,47 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GuestStayModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("SaveGuestStay - replaces the user's existing stay", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "guest_stays"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT ("user_id") DO UPDATE SET "accommodation_id"="excluded"."accommodation_id"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := SaveGuestStay(ctx, &GuestStay{UserId: uuid.New(), OtherAccommodation: "With family"})
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("FindGuestStayForUser - returns nil if the user hasn't said where they're staying", func(t *testing.T) {
+		_, mock, _ := Setup()
+		userId := uuid.New()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "guest_stays" WHERE user_id = $1`)).WithArgs(userId, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		stay, err := FindGuestStayForUser(ctx, userId)
+
+		assert.Nil(err)
+		assert.Nil(stay)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
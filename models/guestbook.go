@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// Only the couple (admins) can read the entry
+	GuestbookPrivate = "PRIVATE"
+	// Everyone on the site can read the entry once it's approved
+	GuestbookPublic = "PUBLIC"
+)
+
+const (
+	// Waiting for an admin to look at it (e.g., because it contains a blocked word)
+	GuestbookPending  = "PENDING"
+	GuestbookApproved = "APPROVED"
+	GuestbookRejected = "REJECTED"
+)
+
+// GuestbookEntry table; a message a guest left for the couple
+type GuestbookEntry struct {
+	BaseModel
+	// The ID of the user who wrote the message.
+	UserId  uuid.UUID `json:"user_id" gorm:"index"`
+	Message string    `json:"message"`
+	// One of GuestbookPrivate or GuestbookPublic.
+	Visibility string `json:"visibility"`
+	// One of GuestbookPending, GuestbookApproved or GuestbookRejected.
+	Status string `json:"status" gorm:"index"`
+	// Whether the message contained a word on the blocked word list when it was written.
+	Flagged bool `json:"flagged"`
+}
+
+// GuestbookBlockedWord table; a word or phrase that holds guestbook entries for moderation (admins choose these)
+type GuestbookBlockedWord struct {
+	BaseModel
+	// The word or phrase, in lowercase.
+	Word string `json:"word" gorm:"uniqueIndex"`
+}
+
+// PublicGuestbookEntry is a guestbook entry as it's shown on the site, with the first and last name of who wrote it
+// (not a table)
+type PublicGuestbookEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Message   string    `json:"message"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Create a guestbook entry
+func CreateGuestbookEntry(c context.Context, entry *GuestbookEntry) error {
+	return db.WithContext(c).Create(entry).Error
+}
+
+// Find all guestbook entries, in the order they were written
+func FindGuestbookEntries(c context.Context) ([]GuestbookEntry, error) {
+	var entries []GuestbookEntry
+	result := db.WithContext(c).Order("created_at").Find(&entries)
+	return entries, result.Error
+}
+
+// Find the guestbook entries the user wrote, in the order they wrote them
+func FindGuestbookEntriesForUser(c context.Context, userId uuid.UUID) ([]GuestbookEntry, error) {
+	var entries []GuestbookEntry
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&entries)
+	return entries, result.Error
+}
+
+// Find the guestbook entry with the given ID; returns nil if there isn't one
+func FindGuestbookEntryById(c context.Context, id uuid.UUID) (*GuestbookEntry, error) {
+	var entry GuestbookEntry
+	result := db.WithContext(c).Where("id = ?", id).First(&entry)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+// Find a page of the approved, public guestbook entries, newest first; also returns how many there are in all
+//
+// Entries from users that have since been deleted are left out.
+func FindPublicGuestbookEntries(c context.Context, limit int, offset int) ([]PublicGuestbookEntry, int64, error) {
+	query := func() *gorm.DB {
+		return db.WithContext(c).Model(&GuestbookEntry{}).
+			Joins("JOIN users ON users.id = guestbook_entries.user_id AND users.deleted_at IS NULL").
+			Where("guestbook_entries.visibility = ? AND guestbook_entries.status = ?", GuestbookPublic, GuestbookApproved)
+	}
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []PublicGuestbookEntry
+	result := query().Select("guestbook_entries.id, guestbook_entries.message, users.first_name, users.last_name, guestbook_entries.created_at").
+		Order("guestbook_entries.created_at DESC, guestbook_entries.id").Limit(limit).Offset(offset).Scan(&entries)
+	return entries, total, result.Error
+}
+
+// Set the status of a guestbook entry; returns gorm.ErrRecordNotFound if there is no entry with the ID
+func UpdateGuestbookEntryStatus(c context.Context, id uuid.UUID, status string) error {
+	result := db.WithContext(c).Model(&GuestbookEntry{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a guestbook entry; returns the number of deleted entries
+func DeleteGuestbookEntry(c context.Context, id uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Delete(&GuestbookEntry{}, id)
+	return result.RowsAffected, result.Error
+}
+
+// Delete a guestbook entry the user wrote; returns the number of deleted entries (0 if the user didn't write it)
+func DeleteGuestbookEntryForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Where("id = ? AND user_id = ?", id, userId).Delete(&GuestbookEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// Find the words and phrases that hold guestbook entries for moderation, in alphabetical order
+func FindGuestbookBlockedWords(c context.Context) ([]string, error) {
+	var words []string
+	result := db.WithContext(c).Model(&GuestbookBlockedWord{}).Order("word").Pluck("word", &words)
+	return words, result.Error
+}
+
+// Replace the words and phrases that hold guestbook entries for moderation
+func ReplaceGuestbookBlockedWords(c context.Context, words []string) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&GuestbookBlockedWord{}).Error; err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return nil
+		}
+		records := make([]GuestbookBlockedWord, len(words))
+		for i, word := range words {
+			records[i].Word = word
+		}
+		return tx.Create(&records).Error
+	})
+}
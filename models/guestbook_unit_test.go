@@ -0,0 +1,81 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_GuestbookModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindPublicGuestbookEntries - counts the approved, public entries and gets a page of them", func(t *testing.T) {
+		_, mock, _ := Setup()
+		where := regexp.QuoteMeta(`FROM "guestbook_entries" JOIN users ON users.id = guestbook_entries.user_id AND users.deleted_at IS NULL WHERE (guestbook_entries.visibility = $1 AND guestbook_entries.status = $2) AND "guestbook_entries"."deleted_at" IS NULL`)
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) `)+where).WithArgs(GuestbookPublic, GuestbookApproved).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+		mock.ExpectQuery(
+			where+regexp.QuoteMeta(` ORDER BY guestbook_entries.created_at DESC, guestbook_entries.id LIMIT $3 OFFSET $4`)).WithArgs(GuestbookPublic, GuestbookApproved, 5, 5).WillReturnRows(sqlmock.NewRows([]string{"id", "message", "first_name"}).AddRow(uuid.New(), "Congratulations!", "Alex"))
+
+		entries, total, err := FindPublicGuestbookEntries(ctx, 5, 5)
+
+		assert.Nil(err)
+		assert.Equal(int64(7), total)
+		assert.Equal(1, len(entries))
+		assert.Equal("Alex", entries[0].FirstName)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("UpdateGuestbookEntryStatus - returns gorm.ErrRecordNotFound when there is no entry", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "guestbook_entries" SET "status"=$1,"updated_at"=$2 WHERE id = $3`)).WithArgs(GuestbookApproved, sqlmock.AnyArg(), id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := UpdateGuestbookEntryStatus(ctx, id, GuestbookApproved)
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("DeleteGuestbookEntryForUser - only deletes the user's own entry", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id, userId := uuid.New(), uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "guestbook_entries" SET "deleted_at"=$1 WHERE (id = $2 AND user_id = $3)`)).WithArgs(sqlmock.AnyArg(), id, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		deleted, err := DeleteGuestbookEntryForUser(ctx, id, userId)
+
+		assert.Nil(err)
+		assert.Equal(int64(1), deleted)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("ReplaceGuestbookBlockedWords - replaces the whole list", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "guestbook_blocked_words" WHERE 1 = 1`)).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "guestbook_blocked_words"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := ReplaceGuestbookBlockedWords(ctx, []string{"darn", "heck"})
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Household table; the people who share a mailing address and get one invitation
+type Household struct {
+	BaseModel
+	// Who the mail is addressed to (e.g., "Mr. and Mrs. Alex Smith").
+	Name string `json:"name"`
+	// The street address; the second and third lines are optional (e.g., for an apartment or a building name).
+	AddressLine1 string `json:"address_line_1"`
+	AddressLine2 string `json:"address_line_2"`
+	AddressLine3 string `json:"address_line_3"`
+	City         string `json:"city"`
+	// The state, province or county.
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	// The ISO 3166-1 alpha-2 code of the country (e.g., "US").
+	Country string `json:"country"`
+	// When the save-the-date was mailed; is null until it is.
+	SaveTheDateMailedAt *time.Time `json:"save_the_date_mailed_at"`
+	// When the save-the-date came back as undeliverable; is null unless it did.
+	SaveTheDateReturnedAt *time.Time `json:"save_the_date_returned_at"`
+	// When the invitation was mailed; is null until it is.
+	InvitationMailedAt *time.Time `json:"invitation_mailed_at"`
+	// When the invitation came back as undeliverable; is null unless it did.
+	InvitationReturnedAt *time.Time `json:"invitation_returned_at"`
+	Notes                string     `json:"notes"`
+}
+
+// HouseholdMember table; a user or invitee living in a household
+type HouseholdMember struct {
+	BaseModel
+	// The ID of the household.
+	HouseholdId uuid.UUID `json:"household_id" gorm:"index"`
+	// The ID of the user; is null for invitees. A user lives in at most one household.
+	UserId *uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// The ID of the invitee; is null for users. An invitee lives in at most one household.
+	InviteeId *uuid.UUID `json:"invitee_id" gorm:"uniqueIndex"`
+}
+
+// Create a household
+func CreateHousehold(c context.Context, household *Household) error {
+	return db.WithContext(c).Create(household).Error
+}
+
+// Find all households, by name
+func FindHouseholds(c context.Context) ([]Household, error) {
+	var households []Household
+	result := db.WithContext(c).Order("name, created_at").Find(&households)
+	return households, result.Error
+}
+
+// Find the household with the given ID; returns nil if there isn't one
+func FindHouseholdById(c context.Context, id uuid.UUID) (*Household, error) {
+	var household Household
+	result := db.WithContext(c).Where("id = ?", id).First(&household)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &household, nil
+}
+
+// Replace a household's name, address and notes (but not its mailing dates); returns gorm.ErrRecordNotFound if there
+// is no household with its ID
+func UpdateHousehold(c context.Context, household *Household) error {
+	result := db.WithContext(c).Model(&Household{}).Where("id = ?", household.ID).Updates(map[string]interface{}{
+		"name":           household.Name,
+		"address_line_1": household.AddressLine1,
+		"address_line_2": household.AddressLine2,
+		"address_line_3": household.AddressLine3,
+		"city":           household.City,
+		"region":         household.Region,
+		"postal_code":    household.PostalCode,
+		"country":        household.Country,
+		"notes":          household.Notes,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Replace when a household's save-the-date and invitation were mailed and returned; returns gorm.ErrRecordNotFound if
+// there is no household with its ID
+func UpdateHouseholdMailings(c context.Context, household *Household) error {
+	result := db.WithContext(c).Model(&Household{}).Where("id = ?", household.ID).Updates(map[string]interface{}{
+		"save_the_date_mailed_at":   household.SaveTheDateMailedAt,
+		"save_the_date_returned_at": household.SaveTheDateReturnedAt,
+		"invitation_mailed_at":      household.InvitationMailedAt,
+		"invitation_returned_at":    household.InvitationReturnedAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a household along with its members; returns the number of deleted households
+func DeleteHousehold(c context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Household{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Unscoped().Where("household_id = ?", id).Delete(&HouseholdMember{}).Error
+	})
+	return deleted, err
+}
+
+// Replace the users and invitees living in a household, moving any that lived in another household; returns
+// gorm.ErrRecordNotFound if there is no household with the ID
+func ReplaceHouseholdMembers(c context.Context, householdId uuid.UUID, userIds []uuid.UUID, inviteeIds []uuid.UUID) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var household Household
+		if err := tx.Where("id = ?", householdId).First(&household).Error; err != nil {
+			return err
+		}
+		moved := tx.Unscoped().Where("household_id = ?", householdId)
+		if len(userIds) > 0 {
+			moved = moved.Or("user_id IN ?", userIds)
+		}
+		if len(inviteeIds) > 0 {
+			moved = moved.Or("invitee_id IN ?", inviteeIds)
+		}
+		if err := moved.Delete(&HouseholdMember{}).Error; err != nil {
+			return err
+		}
+		var members []HouseholdMember
+		for _, userId := range userIds {
+			members = append(members, HouseholdMember{HouseholdId: householdId, UserId: &userId})
+		}
+		for _, inviteeId := range inviteeIds {
+			members = append(members, HouseholdMember{HouseholdId: householdId, InviteeId: &inviteeId})
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.Create(&members).Error
+	})
+}
+
+// Find the members of every household, in the order they were added
+func FindHouseholdMembers(c context.Context) ([]HouseholdMember, error) {
+	var members []HouseholdMember
+	result := db.WithContext(c).Order("created_at").Find(&members)
+	return members, result.Error
+}
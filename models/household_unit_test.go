@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_HouseholdModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("ReplaceHouseholdMembers - moves members out of their old household", func(t *testing.T) {
+		_, mock, _ := Setup()
+		householdId := uuid.New()
+		userId := uuid.New()
+		inviteeId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "households" WHERE id = $1`)).WithArgs(householdId, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(householdId))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "household_members" WHERE household_id = $1 OR user_id IN ($2) OR invitee_id IN ($3)`)).WithArgs(householdId, userId, inviteeId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "household_members"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := ReplaceHouseholdMembers(ctx, householdId, []uuid.UUID{userId}, []uuid.UUID{inviteeId})
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("ReplaceHouseholdMembers - returns gorm.ErrRecordNotFound for missing households", func(t *testing.T) {
+		_, mock, _ := Setup()
+		householdId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "households" WHERE id = $1`)).WithArgs(householdId, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := ReplaceHouseholdMembers(ctx, householdId, []uuid.UUID{uuid.New()}, nil)
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("UpdateHouseholdMailings - returns gorm.ErrRecordNotFound for missing households", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "households" SET`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := UpdateHouseholdMailings(ctx, &Household{BaseModel: BaseModel{ID: id}})
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("DeleteHousehold - deletes its members too", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "households" SET "deleted_at"=$1 WHERE "households"."id" = $2`)).WithArgs(sqlmock.AnyArg(), id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "household_members" WHERE household_id = $1`)).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		deleted, err := DeleteHousehold(ctx, id)
+
+		assert.Nil(err)
+		assert.Equal(int64(1), deleted)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// What a login throttle counts failed attempts for
+const (
+	// Failed attempts for an email address (whether or not a user has that email)
+	LoginThrottleAccount = "account"
+	// Failed attempts from a client IP address
+	LoginThrottleIP = "ip"
+)
+
+// LoginThrottle table; counts the recent failed login attempts for an account or IP address
+//
+// Whether the attempts result in a backoff or lockout is decided by the policies in the helper package.
+type LoginThrottle struct {
+	BaseModel
+	// The kind of key being throttled, which can be "account" or "ip".
+	Kind string `json:"kind" gorm:"uniqueIndex:idx_login_throttles_kind_key"`
+	// The (lower-case) email address or IP address being throttled.
+	Key string `json:"key" gorm:"uniqueIndex:idx_login_throttles_kind_key"`
+	// The number of failed attempts since the counter was last reset.
+	Failures int `json:"failures"`
+	// The time of the most recent failed attempt.
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+// Find the throttle for the given key; returns nil if there have been no failed attempts for it
+func FindLoginThrottle(c context.Context, kind string, key string) (*LoginThrottle, error) {
+	var throttles []LoginThrottle
+	result := db.WithContext(c).Where("kind = ? AND key = ?", kind, key).Limit(1).Find(&throttles)
+	if result.Error != nil || len(throttles) == 0 {
+		return nil, result.Error
+	}
+	return &throttles[0], nil
+}
+
+// Find the throttles of the given kind that have failed attempts
+func FindLoginThrottles(c context.Context, kind string) ([]LoginThrottle, error) {
+	var throttles []LoginThrottle
+	result := db.WithContext(c).Where("kind = ? AND failures > 0", kind).Order("last_failure_at DESC").Find(&throttles)
+	return throttles, result.Error
+}
+
+// Atomically count a failed attempt for the given key and return the updated throttle
+//
+// If the previous failure happened before staleBefore, the count starts over.
+func RecordLoginFailure(c context.Context, kind string, key string, now time.Time, staleBefore time.Time) (*LoginThrottle, error) {
+	throttle := LoginThrottle{
+		Kind:          kind,
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+	result := db.WithContext(c).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END", staleBefore),
+				"last_failure_at": now,
+				"updated_at":      now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&throttle)
+	return &throttle, result.Error
+}
+
+// Reset the failed attempt count for the given key (e.g., after a successful login or an admin unlock)
+func ResetLoginThrottle(c context.Context, kind string, key string) error {
+	result := db.WithContext(c).Model(&LoginThrottle{}).Where("kind = ? AND key = ?", kind, key).Update("failures", 0)
+	return result.Error
+}
@@ -0,0 +1,56 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginThrottleModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindLoginThrottle - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "login_throttles" WHERE (kind = $1 AND key = $2) AND "login_throttles"."deleted_at" IS NULL LIMIT $3`)).WithArgs(
+			LoginThrottleAccount,
+			"fake@email.place",
+			1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		throttle, err := FindLoginThrottle(ctx, LoginThrottleAccount, "fake@email.place")
+
+		assert.Nil(throttle)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("ResetLoginThrottle - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "login_throttles" SET "failures"=$1,"updated_at"=$2 WHERE (kind = $3 AND key = $4) AND "login_throttles"."deleted_at" IS NULL`)).WithArgs(
+			0,
+			test.AnyTime{},
+			LoginThrottleIP,
+			"127.0.0.1",
+		).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		err := ResetLoginThrottle(ctx, LoginThrottleIP, "127.0.0.1")
+
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MagicLink table; a single-use link a user can log in with instead of their password
+type MagicLink struct {
+	BaseModel
+	// The ID of the user the link logs in; a user has at most one unused link.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// A hash of the token in the link (the token itself is never stored).
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// The time after which the link can no longer be used.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MagicLinkRole table; a role whose users are allowed to log in with magic links (admins choose these)
+type MagicLinkRole struct {
+	BaseModel
+	// The role, which can be "GUEST", "INVITEE", "PLANNER" or "ADMIN".
+	Role string `json:"role" gorm:"uniqueIndex"`
+}
+
+// Create a magic link, replacing any unused link the user already has
+func CreateMagicLink(c context.Context, link *MagicLink) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", link.UserId).Delete(&MagicLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(link).Error
+	})
+}
+
+// Delete and return the magic link with the given token hash; returns nil if there isn't one
+//
+// The link is deleted in the same statement that finds it, so it can only ever be used once (even by concurrent
+// requests). Whether it has expired is left to the caller.
+func ConsumeMagicLink(c context.Context, tokenHash string) (*MagicLink, error) {
+	var links []MagicLink
+	result := db.WithContext(c).Unscoped().Clauses(clause.Returning{}).Where("token_hash = ?", tokenHash).Delete(&links)
+	if result.Error != nil || len(links) == 0 {
+		return nil, result.Error
+	}
+	return &links[0], nil
+}
+
+// Find the roles whose users are allowed to log in with magic links
+func FindMagicLinkRoles(c context.Context) ([]string, error) {
+	var roles []string
+	result := db.WithContext(c).Model(&MagicLinkRole{}).Order("role").Pluck("role", &roles)
+	return roles, result.Error
+}
+
+// Check if users with the given role are allowed to log in with magic links
+func IsMagicLinkRole(c context.Context, role string) (bool, error) {
+	var count int64
+	result := db.WithContext(c).Model(&MagicLinkRole{}).Where("role = ?", role).Count(&count)
+	return count > 0, result.Error
+}
+
+// Replace the roles whose users are allowed to log in with magic links
+func ReplaceMagicLinkRoles(c context.Context, roles []string) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&MagicLinkRole{}).Error; err != nil {
+			return err
+		}
+		if len(roles) == 0 {
+			return nil
+		}
+		records := make([]MagicLinkRole, len(roles))
+		for i, role := range roles {
+			records[i].Role = role
+		}
+		return tx.Create(&records).Error
+	})
+}
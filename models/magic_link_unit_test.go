@@ -0,0 +1,51 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MagicLinkModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("ConsumeMagicLink - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`DELETE FROM "magic_links" WHERE token_hash = $1 RETURNING *`)).WithArgs(
+			"some-hash",
+		).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		link, err := ConsumeMagicLink(ctx, "some-hash")
+
+		assert.Nil(link)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("IsMagicLinkRole - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "magic_link_roles" WHERE role = $1 AND "magic_link_roles"."deleted_at" IS NULL`)).WithArgs(
+			RoleGuest,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		enabled, err := IsMagicLinkRole(ctx, RoleGuest)
+
+		assert.False(enabled)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
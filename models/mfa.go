@@ -0,0 +1,224 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MfaEnrollment table; a user's authenticator app for two-factor authentication (TOTP)
+type MfaEnrollment struct {
+	BaseModel
+	// The ID of the user the authenticator belongs to; a user has at most one.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// The secret shared with the authenticator app (base32).
+	Secret string `json:"-"`
+	// When the user proved their authenticator works; two-factor authentication isn't enabled until then.
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// The time step of the last code the user logged in with; codes for this step or earlier are rejected so they
+	// can't be replayed.
+	LastUsedStep int64 `json:"-"`
+}
+
+// MfaRecoveryCode table; a single-use code a user can log in with when they don't have their authenticator
+type MfaRecoveryCode struct {
+	BaseModel
+	// The ID of the user the code belongs to.
+	UserId uuid.UUID `json:"user_id" gorm:"index"`
+	// A hash of the code (the code itself is never stored).
+	CodeHash string `json:"-" gorm:"uniqueIndex"`
+}
+
+// MfaChallenge table; issued when a user with two-factor authentication enabled gets their password right, and
+// exchanged (along with a code) for auth tokens
+type MfaChallenge struct {
+	BaseModel
+	// The ID of the user logging in; a user has at most one challenge.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex"`
+	// A hash of the challenge token (the token itself is never stored).
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// The time after which the challenge can no longer be used.
+	ExpiresAt time.Time `json:"expires_at"`
+	// How many codes have been entered for the challenge.
+	Attempts int `json:"attempts"`
+}
+
+// MfaRequiredRole table; a role whose users must set up two-factor authentication before they can use the API
+// (admins choose these)
+type MfaRequiredRole struct {
+	BaseModel
+	// The role, which can be "GUEST", "INVITEE", "PLANNER" or "ADMIN".
+	Role string `json:"role" gorm:"uniqueIndex"`
+}
+
+// Find the user's authenticator; returns nil if they haven't started setting one up
+func FindMfaEnrollment(c context.Context, userId uuid.UUID) (*MfaEnrollment, error) {
+	var enrollment MfaEnrollment
+	result := db.WithContext(c).Where("user_id = ?", userId).First(&enrollment)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &enrollment, nil
+}
+
+// Start setting up an authenticator, replacing any the user hasn't confirmed yet
+//
+// Fails if the user already has a confirmed authenticator (it has to be removed first).
+func CreateMfaEnrollment(c context.Context, enrollment *MfaEnrollment) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ? AND confirmed_at IS NULL", enrollment.UserId).Delete(&MfaEnrollment{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(enrollment).Error
+	})
+}
+
+// Confirm the user's authenticator (enabling two-factor authentication) and replace their recovery codes
+//
+// The step of the code used to confirm it counts as used. Returns gorm.ErrRecordNotFound if the user has no
+// unconfirmed authenticator.
+func ConfirmMfaEnrollment(c context.Context, userId uuid.UUID, confirmedAt time.Time, step int64, codeHashes []string) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&MfaEnrollment{}).Where("user_id = ? AND confirmed_at IS NULL", userId).Updates(map[string]interface{}{
+			"confirmed_at":   confirmedAt,
+			"last_used_step": step,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+// Record that the user logged in with a code for the given step; returns false if a code for that step (or a later
+// one) was already used
+func UseMfaStep(c context.Context, userId uuid.UUID, step int64) (bool, error) {
+	result := db.WithContext(c).Model(&MfaEnrollment{}).Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userId, step).Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete one of the user's recovery codes; returns false if they don't have a code with the given hash
+func UseRecoveryCode(c context.Context, userId uuid.UUID, codeHash string) (bool, error) {
+	result := db.WithContext(c).Unscoped().Where("user_id = ? AND code_hash = ?", userId, codeHash).Delete(&MfaRecoveryCode{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Count the recovery codes the user has left
+func CountRecoveryCodes(c context.Context, userId uuid.UUID) (int64, error) {
+	var count int64
+	result := db.WithContext(c).Model(&MfaRecoveryCode{}).Where("user_id = ?", userId).Count(&count)
+	return count, result.Error
+}
+
+// Replace the user's recovery codes (the old ones stop working)
+func ReplaceRecoveryCodes(c context.Context, userId uuid.UUID, codeHashes []string) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userId uuid.UUID, codeHashes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userId).Delete(&MfaRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	codes := make([]MfaRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i].UserId = userId
+		codes[i].CodeHash = hash
+	}
+	return tx.Create(&codes).Error
+}
+
+// Remove the user's authenticator, recovery codes and login challenge (disabling two-factor authentication)
+func DeleteMfaEnrollment(c context.Context, userId uuid.UUID) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		for _, record := range []interface{}{&MfaEnrollment{}, &MfaRecoveryCode{}, &MfaChallenge{}} {
+			if err := tx.Unscoped().Where("user_id = ?", userId).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Create a login challenge, replacing any the user already has
+func CreateMfaChallenge(c context.Context, challenge *MfaChallenge) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", challenge.UserId).Delete(&MfaChallenge{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(challenge).Error
+	})
+}
+
+// Find the login challenge with the given token hash; returns nil if there isn't one
+func FindMfaChallenge(c context.Context, tokenHash string) (*MfaChallenge, error) {
+	var challenge MfaChallenge
+	result := db.WithContext(c).Where("token_hash = ?", tokenHash).First(&challenge)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &challenge, nil
+}
+
+// Atomically count an attempt at a login challenge's code, setting challenge.Attempts to the new count; returns
+// gorm.ErrRecordNotFound if the challenge has been deleted
+func CountMfaChallengeAttempt(c context.Context, challenge *MfaChallenge) error {
+	result := db.WithContext(c).Model(challenge).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// Delete a login challenge (once it's been used or too many wrong codes were entered)
+func DeleteMfaChallenge(c context.Context, id uuid.UUID) error {
+	return db.WithContext(c).Unscoped().Delete(&MfaChallenge{}, id).Error
+}
+
+// Find the roles whose users must set up two-factor authentication
+func FindMfaRequiredRoles(c context.Context) ([]string, error) {
+	var roles []string
+	result := db.WithContext(c).Model(&MfaRequiredRole{}).Order("role").Pluck("role", &roles)
+	return roles, result.Error
+}
+
+// Check if users with the given role must set up two-factor authentication
+func IsMfaRequiredRole(c context.Context, role string) (bool, error) {
+	var count int64
+	result := db.WithContext(c).Model(&MfaRequiredRole{}).Where("role = ?", role).Count(&count)
+	return count > 0, result.Error
+}
+
+// Replace the roles whose users must set up two-factor authentication
+func ReplaceMfaRequiredRoles(c context.Context, roles []string) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&MfaRequiredRole{}).Error; err != nil {
+			return err
+		}
+		if len(roles) == 0 {
+			return nil
+		}
+		records := make([]MfaRequiredRole, len(roles))
+		for i, role := range roles {
+			records[i].Role = role
+		}
+		return tx.Create(&records).Error
+	})
+}
@@ -0,0 +1,93 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_MfaModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	userId := uuid.New()
+	t.Run("FindMfaEnrollment - missing enrollment returns nil", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "mfa_enrollments" WHERE user_id = $1 AND "mfa_enrollments"."deleted_at" IS NULL ORDER BY "mfa_enrollments"."id" LIMIT $2`)).WithArgs(
+			userId, 1,
+		).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		enrollment, err := FindMfaEnrollment(ctx, userId)
+
+		assert.Nil(enrollment)
+		assert.Nil(err)
+	})
+	t.Run("FindMfaEnrollment - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "mfa_enrollments" WHERE user_id = $1`)).WithArgs(
+			userId, 1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		enrollment, err := FindMfaEnrollment(ctx, userId)
+
+		assert.Nil(enrollment)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("CountMfaChallengeAttempt - counts the attempt in the database and returns the new count", func(t *testing.T) {
+		_, mock, _ := Setup()
+		challenge := MfaChallenge{BaseModel: BaseModel{ID: uuid.New()}, Attempts: 1}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`UPDATE "mfa_challenges" SET "attempts"=attempts + 1,"updated_at"=$1 WHERE "mfa_challenges"."deleted_at" IS NULL AND "id" = $2 RETURNING "attempts"`)).WithArgs(
+			sqlmock.AnyArg(), challenge.ID,
+		).WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(4))
+		mock.ExpectCommit()
+
+		err := CountMfaChallengeAttempt(ctx, &challenge)
+
+		assert.Nil(err)
+		assert.Equal(4, challenge.Attempts)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("CountMfaChallengeAttempt - returns gorm.ErrRecordNotFound when the challenge is gone", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`UPDATE "mfa_challenges"`)).WillReturnRows(sqlmock.NewRows([]string{"attempts"}))
+		mock.ExpectCommit()
+
+		err := CountMfaChallengeAttempt(ctx, &MfaChallenge{BaseModel: BaseModel{ID: uuid.New()}})
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("IsMfaRequiredRole - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "mfa_required_roles" WHERE role = $1 AND "mfa_required_roles"."deleted_at" IS NULL`)).WithArgs(
+			RoleAdmin,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		required, err := IsMfaRequiredRole(ctx, RoleAdmin)
+
+		assert.False(required)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+}
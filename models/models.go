@@ -38,11 +38,53 @@ var newLogger = logger.New(
 )
 
 func Migrate() error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&Entree{},
 		&HorsDoeuvres{},
 		&User{},
-		&UserUserInvitee{})
+		&UserUserInvitee{},
+		&RoleChange{},
+		&LoginThrottle{},
+		&EmailChange{},
+		&EmailVerification{},
+		&VerifiedRoute{},
+		&MagicLink{},
+		&MagicLinkRole{},
+		&MfaEnrollment{},
+		&MfaRecoveryCode{},
+		&MfaChallenge{},
+		&MfaRequiredRole{},
+		&Session{},
+		&Event{},
+		&EventGuest{},
+		&EventRsvp{},
+		&CalendarFeed{},
+		&Venue{},
+		&Accommodation{},
+		&GuestStay{},
+		&ShuttleRun{},
+		&ShuttleRider{},
+		&RegistryItem{},
+		&RegistryClaim{},
+		&Gift{},
+		&Household{},
+		&HouseholdMember{},
+		&SongRequest{},
+		&SongVote{},
+		&GuestbookEntry{},
+		&GuestbookBlockedWord{})
+	if err != nil {
+		return err
+	}
+	// Raw tokens used to be stored on users; sessions only store hashes of refresh tokens now
+	for _, column := range []string{"token", "refresh_token"} {
+		if db.Migrator().HasColumn(&User{}, column) {
+			if err := db.Migrator().DropColumn(&User{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func Setup() (*sql.DB, sqlmock.Sqlmock, error) {
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRegistryItemUnavailable is returned when a claim would reserve more of a registry item than the couple asked for
+var ErrRegistryItemUnavailable = errors.New("not enough of the registry item is left to claim")
+
+// RegistryItem table; a gift the couple would like
+type RegistryItem struct {
+	BaseModel
+	// The gift's name.
+	Name string `json:"name"`
+	// More about the gift (e.g., the color or size).
+	Description string `json:"description"`
+	// Where the gift can be bought.
+	Url string `json:"url"`
+	// Roughly what the gift costs, in cents.
+	PriceCents int64 `json:"price_cents"`
+	// How many of the gift the couple would like.
+	QuantityDesired int `json:"quantity_desired"`
+}
+
+// RegistryClaim table; how many of a registry item a user has said they'll give
+type RegistryClaim struct {
+	BaseModel
+	// The ID of the registry item.
+	RegistryItemId uuid.UUID `json:"registry_item_id" gorm:"uniqueIndex:idx_registry_claim"`
+	// The ID of the user giving the gift; a user has at most one claim per item.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex:idx_registry_claim;index"`
+	// How many of the item the user is giving.
+	Quantity int `json:"quantity"`
+}
+
+// RegistryClaimTotal is how much of a registry item has been claimed (not a table)
+type RegistryClaimTotal struct {
+	RegistryItemId uuid.UUID `json:"registry_item_id"`
+	Claimed        int64     `json:"claimed"`
+}
+
+// RegistryClaimReportEntry is who is giving what, for thank-you notes (not a table)
+type RegistryClaimReportEntry struct {
+	RegistryItemId uuid.UUID `json:"registry_item_id"`
+	ItemName       string    `json:"item_name"`
+	UserId         uuid.UUID `json:"user_id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email"`
+	Quantity       int       `json:"quantity"`
+	ClaimedAt      time.Time `json:"claimed_at"`
+}
+
+// Claims from users that have since been deleted don't reserve anything
+const claimFromCurrentUser = `EXISTS (SELECT 1 FROM users WHERE users.id = registry_claims.user_id AND users.deleted_at IS NULL)`
+
+// Create a registry item
+func CreateRegistryItem(c context.Context, item *RegistryItem) error {
+	return db.WithContext(c).Create(item).Error
+}
+
+// Find all registry items, in the order they were added
+func FindRegistryItems(c context.Context) ([]RegistryItem, error) {
+	var items []RegistryItem
+	result := db.WithContext(c).Order("created_at").Find(&items)
+	return items, result.Error
+}
+
+// Replace every detail of a registry item; returns gorm.ErrRecordNotFound if there is no item with its ID
+//
+// Lowering the quantity desired doesn't take away claims that have already been made.
+func UpdateRegistryItem(c context.Context, item *RegistryItem) error {
+	result := db.WithContext(c).Model(&RegistryItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"name":             item.Name,
+		"description":      item.Description,
+		"url":              item.Url,
+		"price_cents":      item.PriceCents,
+		"quantity_desired": item.QuantityDesired,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a registry item along with its claims; returns the number of deleted items
+func DeleteRegistryItem(c context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&RegistryItem{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Unscoped().Where("registry_item_id = ?", id).Delete(&RegistryClaim{}).Error
+	})
+	return deleted, err
+}
+
+// Save a user's claim on a registry item, replacing the claim they already have (a quantity of 0 removes it);
+// returns gorm.ErrRecordNotFound if there is no item with its ID, or ErrRegistryItemUnavailable if other users have
+// already claimed too much of it
+//
+// The item is locked while its claims are counted, so concurrent claims can't reserve more than the couple asked for.
+func SaveRegistryClaim(c context.Context, claim *RegistryClaim) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var item RegistryItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", claim.RegistryItemId).First(&item).Error; err != nil {
+			return err
+		}
+		if claim.Quantity == 0 {
+			return tx.Unscoped().Where("registry_item_id = ? AND user_id = ?", claim.RegistryItemId, claim.UserId).Delete(&RegistryClaim{}).Error
+		}
+		var claimedByOthers int64
+		if err := tx.Model(&RegistryClaim{}).Select("COALESCE(SUM(quantity), 0)").
+			Where("registry_item_id = ? AND user_id <> ? AND "+claimFromCurrentUser, claim.RegistryItemId, claim.UserId).
+			Scan(&claimedByOthers).Error; err != nil {
+			return err
+		}
+		if claimedByOthers+int64(claim.Quantity) > int64(item.QuantityDesired) {
+			return ErrRegistryItemUnavailable
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "registry_item_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(claim).Error
+	})
+}
+
+// Find the claims the user has made, in the order they made them
+func FindRegistryClaimsForUser(c context.Context, userId uuid.UUID) ([]RegistryClaim, error) {
+	var claims []RegistryClaim
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&claims)
+	return claims, result.Error
+}
+
+// Count how much of each registry item has been claimed, in the order the items were added
+func FindRegistryClaimTotals(c context.Context) ([]RegistryClaimTotal, error) {
+	var totals []RegistryClaimTotal
+	result := db.WithContext(c).Model(&RegistryItem{}).Select(`registry_items.id AS registry_item_id,
+		(SELECT COALESCE(SUM(quantity), 0) FROM registry_claims WHERE registry_claims.registry_item_id = registry_items.id AND registry_claims.deleted_at IS NULL AND ` + claimFromCurrentUser + `) AS claimed`).
+		Order("registry_items.created_at").Scan(&totals)
+	return totals, result.Error
+}
+
+// Find who claimed each registry item, in the order the items were added and then the order the claims were made
+func FindRegistryClaimReport(c context.Context) ([]RegistryClaimReportEntry, error) {
+	var report []RegistryClaimReportEntry
+	result := db.WithContext(c).Model(&RegistryClaim{}).Select(`registry_claims.registry_item_id, registry_items.name AS item_name,
+		registry_claims.user_id, users.first_name, users.last_name, users.email, registry_claims.quantity, registry_claims.updated_at AS claimed_at`).
+		Joins("JOIN registry_items ON registry_items.id = registry_claims.registry_item_id AND registry_items.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = registry_claims.user_id AND users.deleted_at IS NULL").
+		Order("registry_items.created_at, registry_claims.created_at").Scan(&report)
+	return report, result.Error
+}
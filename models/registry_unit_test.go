@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegistryModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("SaveRegistryClaim - locks the item and refuses to over-reserve it", func(t *testing.T) {
+		_, mock, _ := Setup()
+		claim := RegistryClaim{RegistryItemId: uuid.New(), UserId: uuid.New(), Quantity: 2}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "registry_items" WHERE id = $1 AND "registry_items"."deleted_at" IS NULL ORDER BY "registry_items"."id" LIMIT $2 FOR UPDATE`)).WithArgs(claim.RegistryItemId, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "quantity_desired"}).AddRow(claim.RegistryItemId, 4))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT COALESCE(SUM(quantity), 0) FROM "registry_claims" WHERE (registry_item_id = $1 AND user_id <> $2 AND`)).WithArgs(claim.RegistryItemId, claim.UserId).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(3))
+		mock.ExpectRollback()
+
+		err := SaveRegistryClaim(ctx, &claim)
+
+		assert.Equal(ErrRegistryItemUnavailable, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("SaveRegistryClaim - replaces the user's existing claim", func(t *testing.T) {
+		_, mock, _ := Setup()
+		claim := RegistryClaim{RegistryItemId: uuid.New(), UserId: uuid.New(), Quantity: 2}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "registry_items"`)).WillReturnRows(sqlmock.NewRows([]string{"id", "quantity_desired"}).AddRow(claim.RegistryItemId, 4))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT COALESCE(SUM(quantity), 0) FROM "registry_claims"`)).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(2))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "registry_claims"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT ("registry_item_id","user_id") DO UPDATE SET "quantity"="excluded"."quantity"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := SaveRegistryClaim(ctx, &claim)
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("SaveRegistryClaim - claiming none removes the claim", func(t *testing.T) {
+		_, mock, _ := Setup()
+		claim := RegistryClaim{RegistryItemId: uuid.New(), UserId: uuid.New()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "registry_items"`)).WillReturnRows(sqlmock.NewRows([]string{"id", "quantity_desired"}).AddRow(claim.RegistryItemId, 4))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "registry_claims" WHERE registry_item_id = $1 AND user_id = $2`)).WithArgs(claim.RegistryItemId, claim.UserId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := SaveRegistryClaim(ctx, &claim)
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastAdmin is returned when a role change or deletion would leave the site without any admins
+var ErrLastAdmin = errors.New("cannot remove the last admin")
+
+// RoleChange table; a record of an admin changing a user's role
+type RoleChange struct {
+	BaseModel
+	// The ID of the user whose role was changed.
+	UserId uuid.UUID `gorm:"index" json:"user_id"`
+	// The ID of the admin who changed the role.
+	ChangedById uuid.UUID `json:"changed_by_id"`
+	// The user's role before the change.
+	OldRole string `json:"old_role"`
+	// The user's role after the change.
+	NewRole string `json:"new_role"`
+}
+
+// Find the IDs of the admins, locking their rows until the transaction ends
+//
+// The admins are locked first (in a stable order) so concurrent demotions or deletions can't each see another admin left.
+func lockAdminIds(tx *gorm.DB) ([]uuid.UUID, error) {
+	var adminIds []uuid.UUID
+	err := tx.Model(&User{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("role = ?", RoleAdmin).Order("id").Pluck("id", &adminIds).Error
+	return adminIds, err
+}
+
+// Change a user's role and record the change
+//
+// The user's token version is bumped so any tokens issued with the old role are rejected. OldRole is set on
+// the given change from the user's current role; if the role is unchanged, nothing is updated or recorded.
+// Demoting the only admin returns ErrLastAdmin.
+func ChangeUserRole(c context.Context, change *RoleChange) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		adminIds, err := lockAdminIds(tx)
+		if err != nil {
+			return err
+		}
+		var u User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "role").First(&u, change.UserId).Error; err != nil {
+			return err
+		}
+		change.OldRole = u.Role
+		if u.Role == change.NewRole {
+			return nil
+		}
+		if u.Role == RoleAdmin && len(adminIds) <= 1 {
+			return ErrLastAdmin
+		}
+		if err := tx.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"role":          change.NewRole,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// Find the role changes for the given user, oldest first
+func FindRoleChanges(c context.Context, userId uuid.UUID) ([]RoleChange, error) {
+	var changes []RoleChange
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&changes)
+	return changes, result.Error
+}
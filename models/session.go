@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session table; a device a user has logged in on, which stays logged in for as long as it keeps refreshing its tokens
+type Session struct {
+	BaseModel
+	// The ID of the user who logged in.
+	UserId uuid.UUID `json:"user_id" gorm:"index"`
+	// A hash of the session's current refresh token (the token itself is never stored); it changes every time the
+	// tokens are refreshed.
+	RefreshTokenHash string `json:"-" gorm:"uniqueIndex"`
+	// The user's token version when they logged in; the session ends once it changes (e.g., when their password changes).
+	TokenVersion int `json:"-"`
+	// The User-Agent header sent the last time the session logged in or refreshed its tokens.
+	UserAgent string `json:"user_agent"`
+	// The IP address the session last logged in or refreshed its tokens from.
+	IpAddress string `json:"ip_address"`
+	// When the session last logged in or refreshed its tokens.
+	LastUsedAt time.Time `json:"last_used_at"`
+	// When the session's refresh token expires (after which the user has to log in again).
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Create a session
+func CreateSession(c context.Context, session *Session) error {
+	return db.WithContext(c).Create(session).Error
+}
+
+// Find the session with the given refresh token hash; returns nil if there isn't one
+func FindSession(c context.Context, refreshTokenHash string) (*Session, error) {
+	var session Session
+	result := db.WithContext(c).Where("refresh_token_hash = ?", refreshTokenHash).First(&session)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+// Check if the session with the given ID exists and hasn't expired
+func IsActiveSession(c context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	var count int64
+	result := db.WithContext(c).Model(&Session{}).Where("id = ? AND expires_at > ?", id, now).Count(&count)
+	return count > 0, result.Error
+}
+
+// Find the user's sessions that were started with their current token version and haven't expired, most recently
+// used first
+func FindSessionsForUser(c context.Context, userId uuid.UUID, tokenVersion int, now time.Time) ([]Session, error) {
+	var sessions []Session
+	result := db.WithContext(c).Where("user_id = ? AND token_version = ? AND expires_at > ?", userId, tokenVersion, now).Order("last_used_at DESC").Find(&sessions)
+	return sessions, result.Error
+}
+
+// Save the session's new refresh token hash, device details and times, but only if its refresh token hash is still
+// previousHash; returns false if it isn't (e.g., the same refresh token was used twice at once)
+func RefreshSession(c context.Context, session *Session, previousHash string) (bool, error) {
+	result := db.WithContext(c).Model(&Session{}).Where("id = ? AND refresh_token_hash = ?", session.ID, previousHash).Updates(map[string]interface{}{
+		"refresh_token_hash": session.RefreshTokenHash,
+		"user_agent":         session.UserAgent,
+		"ip_address":         session.IpAddress,
+		"last_used_at":       session.LastUsedAt,
+		"expires_at":         session.ExpiresAt,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete a session, but only if it belongs to the given user; returns the number of deleted records
+func DeleteSessionForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Unscoped().Where("id = ? AND user_id = ?", id, userId).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,79 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("FindSession - missing session returns nil", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "sessions" WHERE refresh_token_hash = $1 AND "sessions"."deleted_at" IS NULL ORDER BY "sessions"."id" LIMIT $2`)).WithArgs(
+			"hash", 1,
+		).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		session, err := FindSession(ctx, "hash")
+
+		assert.Nil(session)
+		assert.Nil(err)
+	})
+	t.Run("FindSession - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "sessions" WHERE refresh_token_hash = $1`)).WithArgs(
+			"hash", 1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		session, err := FindSession(ctx, "hash")
+
+		assert.Nil(session)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("IsActiveSession - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		id := uuid.New()
+		now := time.Now()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "sessions" WHERE (id = $1 AND expires_at > $2) AND "sessions"."deleted_at" IS NULL`)).WithArgs(
+			id, now,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		active, err := IsActiveSession(ctx, id, now)
+
+		assert.False(active)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("RefreshSession - only updates the session if its refresh token hash hasn't changed", func(t *testing.T) {
+		_, mock, _ := Setup()
+		session := Session{BaseModel: BaseModel{ID: uuid.New()}, RefreshTokenHash: "new"}
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "sessions" SET`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		refreshed, err := RefreshSession(ctx, &session, "old")
+
+		assert.False(refreshed)
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
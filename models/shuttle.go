@@ -0,0 +1,216 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShuttleRun table; one departure of a shuttle (e.g., from the hotel to the venue)
+type ShuttleRun struct {
+	BaseModel
+	// Where the shuttle goes (e.g., "The Grand Hotel to The Garden").
+	Route string `json:"route"`
+	// When the shuttle leaves.
+	DepartsAt time.Time `json:"departs_at"`
+	// The number of seats on the shuttle; once they're taken, people who sign up are waitlisted.
+	Capacity int `json:"capacity"`
+	// Anything else riders should know (e.g., where to wait).
+	Notes string `json:"notes"`
+}
+
+// ShuttleRider table; a user or one of their invitees signed up for a shuttle run
+type ShuttleRider struct {
+	BaseModel
+	// The ID of the shuttle run.
+	ShuttleRunId uuid.UUID `json:"shuttle_run_id" gorm:"uniqueIndex:idx_shuttle_rider"`
+	// The ID of the user who signed up (for themselves or one of their invitees).
+	UserId uuid.UUID `json:"user_id" gorm:"index"`
+	// The ID of the person riding: the user's own ID, or the ID of one of their invitees.
+	RiderId uuid.UUID `json:"rider_id" gorm:"uniqueIndex:idx_shuttle_rider"`
+	// Whether the person is waiting for a seat; waitlisted riders get seats as they free up, in the order they signed up.
+	Waitlisted bool `json:"waitlisted"`
+}
+
+// ShuttleHeadcount is the number of people seated on and waitlisted for a shuttle run (not a table)
+type ShuttleHeadcount struct {
+	ShuttleRunId uuid.UUID `json:"shuttle_run_id"`
+	// The number of people with a seat.
+	Seated int64 `json:"seated"`
+	// The number of people waiting for a seat.
+	Waitlisted int64 `json:"waitlisted"`
+}
+
+// ShuttleManifestEntry is a person signed up for a shuttle run, with the details the driver needs (not a table)
+type ShuttleManifestEntry struct {
+	RiderId   uuid.UUID `json:"rider_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	// The ID of the user who signed the rider up.
+	UserId uuid.UUID `json:"user_id"`
+	// The email address of the user who signed the rider up.
+	Email      string    `json:"email"`
+	Waitlisted bool      `json:"waitlisted"`
+	SignedUpAt time.Time `json:"signed_up_at"`
+}
+
+// Riders for users and invitees that have since been deleted don't take up seats
+const riderIsCurrent = `(EXISTS (SELECT 1 FROM users WHERE users.id = shuttle_riders.rider_id AND users.deleted_at IS NULL) OR
+		EXISTS (SELECT 1 FROM user_invitees WHERE user_invitees.id = shuttle_riders.rider_id AND user_invitees.deleted_at IS NULL))`
+
+// Create a shuttle run
+func CreateShuttleRun(c context.Context, run *ShuttleRun) error {
+	return db.WithContext(c).Create(run).Error
+}
+
+// Find all shuttle runs, earliest departure first
+func FindShuttleRuns(c context.Context) ([]ShuttleRun, error) {
+	var runs []ShuttleRun
+	result := db.WithContext(c).Order("departs_at").Find(&runs)
+	return runs, result.Error
+}
+
+// Find the shuttle run with the given ID; returns nil if there isn't one
+func FindShuttleRunById(c context.Context, id uuid.UUID) (*ShuttleRun, error) {
+	var run ShuttleRun
+	result := db.WithContext(c).Where("id = ?", id).First(&run)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &run, nil
+}
+
+// Replace every detail of a shuttle run; returns gorm.ErrRecordNotFound if there is no run with its ID
+//
+// Waitlisted riders are given any seats the new capacity frees up. Lowering the capacity doesn't take seats from
+// riders who already have them.
+func UpdateShuttleRun(c context.Context, run *ShuttleRun) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&ShuttleRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+			"route":      run.Route,
+			"departs_at": run.DepartsAt,
+			"capacity":   run.Capacity,
+			"notes":      run.Notes,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return fillShuttleSeats(tx, run.ID, run.Capacity)
+	})
+}
+
+// Delete a shuttle run along with its riders; returns the number of deleted runs
+func DeleteShuttleRun(c context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&ShuttleRun{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Unscoped().Where("shuttle_run_id = ?", id).Delete(&ShuttleRider{}).Error
+	})
+	return deleted, err
+}
+
+// Replace the people the user has signed up for a shuttle run (themselves and their invitees); returns
+// gorm.ErrRecordNotFound if there is no run with the given ID
+//
+// People who are still signed up keep their seat or place on the waitlist. New riders get any free seats and are
+// waitlisted once the run is full. The run is locked while its seats are counted, so concurrent sign-ups can't
+// overfill it.
+func ReplaceShuttleRidersForUser(c context.Context, runId uuid.UUID, userId uuid.UUID, riderIds []uuid.UUID) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var run ShuttleRun
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", runId).First(&run).Error; err != nil {
+			return err
+		}
+		removed := tx.Unscoped().Where("shuttle_run_id = ? AND user_id = ?", runId, userId)
+		if len(riderIds) > 0 {
+			removed = removed.Where("rider_id NOT IN ?", riderIds)
+		}
+		if err := removed.Delete(&ShuttleRider{}).Error; err != nil {
+			return err
+		}
+		var existing []uuid.UUID
+		if err := tx.Model(&ShuttleRider{}).Where("shuttle_run_id = ? AND user_id = ?", runId, userId).Pluck("rider_id", &existing).Error; err != nil {
+			return err
+		}
+		var riders []ShuttleRider
+		for _, riderId := range riderIds {
+			if !slices.Contains(existing, riderId) {
+				// Everyone joins the waitlist; the free seats are then given out in the order people signed up
+				riders = append(riders, ShuttleRider{ShuttleRunId: runId, UserId: userId, RiderId: riderId, Waitlisted: true})
+			}
+		}
+		if len(riders) > 0 {
+			if err := tx.Create(&riders).Error; err != nil {
+				return err
+			}
+		}
+		return fillShuttleSeats(tx, runId, run.Capacity)
+	})
+}
+
+// Gives the free seats on a shuttle run to the waitlisted riders who signed up first
+func fillShuttleSeats(tx *gorm.DB, runId uuid.UUID, capacity int) error {
+	var seated int64
+	if err := tx.Model(&ShuttleRider{}).Where("shuttle_run_id = ? AND NOT waitlisted AND "+riderIsCurrent, runId).Count(&seated).Error; err != nil {
+		return err
+	}
+	free := int64(capacity) - seated
+	if free <= 0 {
+		return nil
+	}
+	var promoted []uuid.UUID
+	if err := tx.Model(&ShuttleRider{}).Where("shuttle_run_id = ? AND waitlisted AND "+riderIsCurrent, runId).Order("created_at").Limit(int(free)).Pluck("id", &promoted).Error; err != nil {
+		return err
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+	return tx.Model(&ShuttleRider{}).Where("id IN ?", promoted).Update("waitlisted", false).Error
+}
+
+// Find the people the user has signed up (themselves and their invitees) across every shuttle run
+func FindShuttleRidersForUser(c context.Context, userId uuid.UUID) ([]ShuttleRider, error) {
+	var riders []ShuttleRider
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&riders)
+	return riders, result.Error
+}
+
+// Count the people seated on and waitlisted for each shuttle run, earliest departure first
+func FindShuttleHeadcounts(c context.Context) ([]ShuttleHeadcount, error) {
+	var headcounts []ShuttleHeadcount
+	result := db.WithContext(c).Model(&ShuttleRun{}).Select(`shuttle_runs.id AS shuttle_run_id,
+		(SELECT count(*) FROM shuttle_riders WHERE shuttle_riders.shuttle_run_id = shuttle_runs.id AND NOT shuttle_riders.waitlisted AND ` + riderIsCurrent + `) AS seated,
+		(SELECT count(*) FROM shuttle_riders WHERE shuttle_riders.shuttle_run_id = shuttle_runs.id AND shuttle_riders.waitlisted AND ` + riderIsCurrent + `) AS waitlisted`).
+		Order("shuttle_runs.departs_at").Scan(&headcounts)
+	return headcounts, result.Error
+}
+
+// Find the people signed up for a shuttle run: seated riders first, then the waitlist, each in the order they signed up
+func FindShuttleManifest(c context.Context, runId uuid.UUID) ([]ShuttleManifestEntry, error) {
+	var manifest []ShuttleManifestEntry
+	result := db.WithContext(c).Model(&ShuttleRider{}).Select(`shuttle_riders.rider_id,
+		COALESCE(riders.first_name, user_invitees.first_name) AS first_name,
+		COALESCE(riders.last_name, user_invitees.last_name) AS last_name,
+		shuttle_riders.user_id, parties.email, shuttle_riders.waitlisted, shuttle_riders.created_at AS signed_up_at`).
+		Joins("LEFT JOIN users AS riders ON riders.id = shuttle_riders.rider_id AND riders.deleted_at IS NULL").
+		Joins("LEFT JOIN user_invitees ON user_invitees.id = shuttle_riders.rider_id AND user_invitees.deleted_at IS NULL").
+		Joins("LEFT JOIN users AS parties ON parties.id = shuttle_riders.user_id").
+		Where("shuttle_riders.shuttle_run_id = ? AND (riders.id IS NOT NULL OR user_invitees.id IS NOT NULL)", runId).
+		Order("shuttle_riders.waitlisted, shuttle_riders.created_at").Scan(&manifest)
+	return manifest, result.Error
+}
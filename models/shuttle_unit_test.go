@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_ShuttleModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("ReplaceShuttleRidersForUser - locks the run and returns gorm.ErrRecordNotFound if it's missing", func(t *testing.T) {
+		_, mock, _ := Setup()
+		runId := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "shuttle_runs" WHERE id = $1 AND "shuttle_runs"."deleted_at" IS NULL ORDER BY "shuttle_runs"."id" LIMIT $2 FOR UPDATE`)).WithArgs(runId, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := ReplaceShuttleRidersForUser(ctx, runId, uuid.New(), []uuid.UUID{uuid.New()})
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("ReplaceShuttleRidersForUser - waitlists new riders, then seats the waitlist while there's room", func(t *testing.T) {
+		_, mock, _ := Setup()
+		runId, userId := uuid.New(), uuid.New()
+		waitlisted := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "shuttle_runs"`)).WillReturnRows(sqlmock.NewRows([]string{"id", "capacity"}).AddRow(runId, 2))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`DELETE FROM "shuttle_riders" WHERE (shuttle_run_id = $1 AND user_id = $2) AND rider_id NOT IN ($3)`)).WithArgs(runId, userId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "rider_id" FROM "shuttle_riders"`)).WillReturnRows(sqlmock.NewRows([]string{"rider_id"}))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "shuttle_riders"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(waitlisted))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "shuttle_riders" WHERE (shuttle_run_id = $1 AND NOT waitlisted AND`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "id" FROM "shuttle_riders" WHERE (shuttle_run_id = $1 AND waitlisted AND`)+`.*`+regexp.QuoteMeta(`ORDER BY created_at LIMIT $2`)).WithArgs(runId, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(waitlisted))
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "shuttle_riders" SET "waitlisted"=$1,"updated_at"=$2 WHERE id IN ($3)`)).WithArgs(false, sqlmock.AnyArg(), waitlisted).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := ReplaceShuttleRidersForUser(ctx, runId, userId, []uuid.UUID{userId})
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
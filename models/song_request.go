@@ -0,0 +1,208 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Postgres error code for a unique constraint violation
+const uniqueViolationCode = "23505"
+
+const (
+	// Waiting for an admin to look at it; only the guest who asked for it can see it
+	SongRequestPending = "PENDING"
+	// On the playlist; guests can see it and vote for it
+	SongRequestApproved = "APPROVED"
+	// Turned down (e.g., it doesn't fit the night)
+	SongRequestRejected = "REJECTED"
+	// The DJ must not play it, even if someone asks on the night
+	SongRequestDoNotPlay = "DO_NOT_PLAY"
+)
+
+var (
+	// ErrSongAlreadyRequested is returned when a song is requested again
+	ErrSongAlreadyRequested = errors.New("the song has already been requested")
+	// ErrSongDoNotPlay is returned when a song on the do-not-play list is requested
+	ErrSongDoNotPlay = errors.New("the song is on the do-not-play list")
+)
+
+// SongRequest table; a song a guest would like the DJ to play
+type SongRequest struct {
+	BaseModel
+	Title  string `json:"title"`
+	Artist string `json:"artist"`
+	// Where the song can be heard (e.g., a streaming link).
+	Link string `json:"link"`
+	// The ID of the user who asked for the song.
+	UserId uuid.UUID `json:"user_id" gorm:"index"`
+	// One of SongRequestPending, SongRequestApproved, SongRequestRejected or SongRequestDoNotPlay.
+	Status string `json:"status" gorm:"index"`
+	// The title and artist, normalized so the same song can't be requested twice.
+	SongKey string `json:"-" gorm:"uniqueIndex"`
+}
+
+// SongVote table; a user's vote for a song request
+type SongVote struct {
+	BaseModel
+	// The ID of the song request.
+	SongRequestId uuid.UUID `json:"song_request_id" gorm:"uniqueIndex:idx_song_vote"`
+	// The ID of the user who voted; a user has at most one vote per song.
+	UserId uuid.UUID `json:"user_id" gorm:"uniqueIndex:idx_song_vote;index"`
+}
+
+// SongVoteTotal is how many votes a song request has (not a table)
+type SongVoteTotal struct {
+	SongRequestId uuid.UUID `json:"song_request_id"`
+	Votes         int64     `json:"votes"`
+}
+
+// SongPlaylistEntry is a song for the DJ, with its votes and who asked for it (not a table)
+type SongPlaylistEntry struct {
+	SongRequest
+	Votes     int64  `json:"votes"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Votes from users that have since been deleted don't count
+const voteFromCurrentUser = `EXISTS (SELECT 1 FROM users WHERE users.id = song_votes.user_id AND users.deleted_at IS NULL)`
+
+// SongKey normalizes a song's title and artist so differences in case and spacing don't make it a different song
+func SongKey(title string, artist string) string {
+	normalize := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	return normalize(title) + "\n" + normalize(artist)
+}
+
+// Create a song request; it's pending unless a status is given. Returns ErrSongDoNotPlay if the song is on the
+// do-not-play list, or ErrSongAlreadyRequested if it has been requested before (including by a concurrent request
+// that inserts the same song first).
+func CreateSongRequest(c context.Context, song *SongRequest) error {
+	if song.Status == "" {
+		song.Status = SongRequestPending
+	}
+	song.SongKey = SongKey(song.Title, song.Artist)
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var existing []SongRequest
+		if err := tx.Where("song_key = ?", song.SongKey).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			if existing[0].Status == SongRequestDoNotPlay {
+				return ErrSongDoNotPlay
+			}
+			return ErrSongAlreadyRequested
+		}
+		if err := tx.Create(song).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+				return ErrSongAlreadyRequested
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// Find all song requests, in the order they were made
+func FindSongRequests(c context.Context) ([]SongRequest, error) {
+	var songs []SongRequest
+	result := db.WithContext(c).Order("created_at").Find(&songs)
+	return songs, result.Error
+}
+
+// Find the song request with the given ID; returns nil if there isn't one
+func FindSongRequestById(c context.Context, id uuid.UUID) (*SongRequest, error) {
+	var song SongRequest
+	result := db.WithContext(c).Where("id = ?", id).First(&song)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &song, nil
+}
+
+// Set the status of a song request; returns gorm.ErrRecordNotFound if there is no song request with the ID
+func UpdateSongRequestStatus(c context.Context, id uuid.UUID, status string) error {
+	result := db.WithContext(c).Model(&SongRequest{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a song request along with its votes; returns the number of deleted song requests
+//
+// Song requests are deleted outright so the song can be requested again.
+func DeleteSongRequest(c context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&SongRequest{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Unscoped().Where("song_request_id = ?", id).Delete(&SongVote{}).Error
+	})
+	return deleted, err
+}
+
+// Save a user's vote for an approved song request (voting again changes nothing); returns gorm.ErrRecordNotFound if
+// there is no approved song request with its ID
+func SaveSongVote(c context.Context, vote *SongVote) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var song SongRequest
+		if err := tx.Where("id = ? AND status = ?", vote.SongRequestId, SongRequestApproved).First(&song).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "song_request_id"}, {Name: "user_id"}},
+			DoNothing: true,
+		}).Create(vote).Error
+	})
+}
+
+// Remove a user's vote for a song request; returns the number of deleted votes
+func DeleteSongVote(c context.Context, songRequestId uuid.UUID, userId uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Unscoped().Where("song_request_id = ? AND user_id = ?", songRequestId, userId).Delete(&SongVote{})
+	return result.RowsAffected, result.Error
+}
+
+// Find the votes the user has made, in the order they made them
+func FindSongVotesForUser(c context.Context, userId uuid.UUID) ([]SongVote, error) {
+	var votes []SongVote
+	result := db.WithContext(c).Where("user_id = ?", userId).Order("created_at").Find(&votes)
+	return votes, result.Error
+}
+
+// Count the votes for each song request that has any
+func FindSongVoteTotals(c context.Context) ([]SongVoteTotal, error) {
+	var totals []SongVoteTotal
+	result := db.WithContext(c).Model(&SongVote{}).Select("song_request_id, COUNT(*) AS votes").
+		Where(voteFromCurrentUser).Group("song_request_id").Scan(&totals)
+	return totals, result.Error
+}
+
+// Find the songs for the DJ: approved songs, the most votes first, then the do-not-play list (songs with the same
+// number of votes are in the order they were requested)
+func FindSongPlaylist(c context.Context) ([]SongPlaylistEntry, error) {
+	var playlist []SongPlaylistEntry
+	result := db.WithContext(c).Model(&SongRequest{}).Select(`song_requests.*,
+		(SELECT COUNT(*) FROM song_votes WHERE song_votes.song_request_id = song_requests.id AND song_votes.deleted_at IS NULL AND `+voteFromCurrentUser+`) AS votes,
+		COALESCE(users.first_name, '') AS first_name, COALESCE(users.last_name, '') AS last_name`).
+		Joins("LEFT JOIN users ON users.id = song_requests.user_id AND users.deleted_at IS NULL").
+		Where("song_requests.status IN ?", []string{SongRequestApproved, SongRequestDoNotPlay}).
+		Order("song_requests.status = '" + SongRequestApproved + "' DESC, votes DESC, song_requests.created_at").
+		Scan(&playlist)
+	return playlist, result.Error
+}
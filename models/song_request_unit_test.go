@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_SongRequestModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("SongKey - ignores case and spacing", func(t *testing.T) {
+		assert.Equal(SongKey("September", "Earth, Wind & Fire"), SongKey(" september", "EARTH,  Wind &  Fire "))
+		assert.NotEqual(SongKey("September", "Earth, Wind & Fire"), SongKey("September", "Taylor Swift"))
+	})
+	t.Run("CreateSongRequest - creates a pending request", func(t *testing.T) {
+		_, mock, _ := Setup()
+		song := SongRequest{Title: "September", Artist: "Earth, Wind & Fire", UserId: uuid.New()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "song_requests" WHERE song_key = $1`)).WithArgs(SongKey(song.Title, song.Artist), 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "song_requests"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := CreateSongRequest(ctx, &song)
+
+		assert.Nil(err)
+		assert.Equal(SongRequestPending, song.Status)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("CreateSongRequest - returns ErrSongDoNotPlay for songs on the do-not-play list", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "song_requests" WHERE song_key = $1`)).WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(uuid.New(), SongRequestDoNotPlay))
+		mock.ExpectRollback()
+
+		err := CreateSongRequest(ctx, &SongRequest{Title: "Macarena", Artist: "Los del Río"})
+
+		assert.Equal(ErrSongDoNotPlay, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("CreateSongRequest - returns ErrSongAlreadyRequested when a concurrent request inserts the song first", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "song_requests" WHERE song_key = $1`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "song_requests"`)).WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := CreateSongRequest(ctx, &SongRequest{Title: "September", Artist: "Earth, Wind & Fire", UserId: uuid.New()})
+
+		assert.Equal(ErrSongAlreadyRequested, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("SaveSongVote - only counts one vote per user", func(t *testing.T) {
+		_, mock, _ := Setup()
+		vote := SongVote{SongRequestId: uuid.New(), UserId: uuid.New()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "song_requests" WHERE (id = $1 AND status = $2)`)).WithArgs(vote.SongRequestId, SongRequestApproved, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(vote.SongRequestId))
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`INSERT INTO "song_votes"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT ("song_request_id","user_id") DO NOTHING`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		err := SaveSongVote(ctx, &vote)
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("SaveSongVote - returns gorm.ErrRecordNotFound for songs that aren't approved", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT * FROM "song_requests"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := SaveSongVote(ctx, &SongVote{SongRequestId: uuid.New(), UserId: uuid.New()})
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("FindSongPlaylist - lists approved songs by votes, then the do-not-play list", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`FROM "song_requests" LEFT JOIN users ON users.id = song_requests.user_id`)+`.*`+regexp.QuoteMeta(`WHERE song_requests.status IN ($1,$2) AND "song_requests"."deleted_at" IS NULL ORDER BY song_requests.status = 'APPROVED' DESC, votes DESC, song_requests.created_at`)).WithArgs(SongRequestApproved, SongRequestDoNotPlay).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "votes", "first_name"}).AddRow(uuid.New(), "September", 3, "Alex"))
+
+		playlist, err := FindSongPlaylist(ctx)
+
+		assert.Nil(err)
+		assert.Equal(1, len(playlist))
+		assert.Equal(int64(3), playlist[0].Votes)
+		assert.Equal("Alex", playlist[0].FirstName)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -20,6 +20,9 @@ var NilUuid = "00000000-0000-0000-0000-000000000000"
 // Convenience variable to keep easy reference to the UUID of the first user in the test data set ("Rupinder McNiel")
 var FirstUserIdStr = "0ad1d80a-329b-4ffe-89c1-87af4d945953"
 
+// Convenience variable to keep easy reference to the UUID of the admin user in the test data set ("Turtle Cat")
+var FirstAdminIdStr = "15034fc9-3f7e-4c42-b6d3-2925330b1b76"
+
 // Convenience variable to keep easy reference to the UUID of the first user's invitee in the test data set ("Suman Sousa")
 var FirstUserInviteeIdStr = "007170d7-5633-4a44-9326-ddf9dce5a6ef"
 
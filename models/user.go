@@ -2,17 +2,27 @@ package models
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// User roles; see helper.RolePermissions for what each role is allowed to do
+const (
+	RoleGuest   = "GUEST"
+	RoleInvitee = "INVITEE"
+	RolePlanner = "PLANNER"
+	RoleAdmin   = "ADMIN"
+)
+
 // User table
 type User struct {
 	BaseModel
-	// The user's role, which can be "GUEST", "INVITEE" or "ADMIN". Defaults to "GUEST".
-	Role string `json:"role" sql:"type:ENUM('GUEST', 'INVITEE', 'ADMIN')" gorm:"default:GUEST"`
+	// The user's role, which can be "GUEST", "INVITEE", "PLANNER" or "ADMIN". Defaults to "GUEST".
+	Role string `json:"role" sql:"type:ENUM('GUEST', 'INVITEE', 'PLANNER', 'ADMIN')" gorm:"default:GUEST"`
 	// Whether or not the user is attending.
 	IsGoing bool `json:"is_going"`
 	// The user's first name.
@@ -23,10 +33,10 @@ type User struct {
 	Email string `json:"email" gorm:"uniqueIndex" binding:"required"`
 	// Either a hash of the user's password (when stored in the DB), or a plain-text representation of the password (plain-text version is never stored)
 	Password string
-	// The user's auth token.
-	Token string `json:"token"`
-	// The user's auth refresh token.
-	RefreshToken string `json:"refresh_token"`
+	// Incremented whenever the user's existing tokens must stop working (e.g., when their role changes); tokens carry the version they were issued with.
+	TokenVersion int `json:"-" gorm:"not null;default:0"`
+	// When the user confirmed they own their email address (using the link emailed to them); is null until they do.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 	// The ID of the hors doeuvres the user has selected; is null until the user makes a selection.
 	HorsDoeuvresSelectionId *uuid.UUID    `json:"hors_doeuvres_selection_id"`
 	HorsDoeuvresSelection   *HorsDoeuvres `gorm:"foreignKey:HorsDoeuvresSelectionId"`
@@ -98,17 +108,31 @@ func UpdateUser(c context.Context, u *User) error {
 }
 
 // Maybe delete a user (if no errors) and returns the number of deleted records
+//
+// Deleting the only admin returns ErrLastAdmin.
 func DeleteUser(c context.Context, id uuid.UUID) (int64, error) {
-	// Since our models have DeletedAt set, this makes Gorm "soft delete" records on normal delete operations.
-	// We can add .Unscoped() prior to the .Delete() call if we want to permanently-delete them.
-	result := db.WithContext(c).Delete(&User{}, id)
-	return result.RowsAffected, result.Error
+	var deleted int64
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		adminIds, err := lockAdminIds(tx)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(adminIds, id) && len(adminIds) <= 1 {
+			return ErrLastAdmin
+		}
+		// Since our models have DeletedAt set, this makes Gorm "soft delete" records on normal delete operations.
+		// We can add .Unscoped() prior to the .Delete() call if we want to permanently-delete them.
+		result := tx.Delete(&User{}, id)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
 }
 
 // Find Users by the given ids; returns a User slice
 func FindUsers(c context.Context, ids []uuid.UUID) ([]User, error) {
 	var users []User
-	result := db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "entree_selection_id", "hors_doeuvres_selection_id").Find(&users, ids)
+	result := db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "email_verified_at", "entree_selection_id", "hors_doeuvres_selection_id").Find(&users, ids)
 	return users, result.Error
 }
 
@@ -120,14 +144,38 @@ func FindUsers(c context.Context, ids []uuid.UUID) ([]User, error) {
 func FindUserSafe(c context.Context, u *User) error {
 	var result *gorm.DB
 	if u.Email != "" {
-		result = db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "entree_selection_id", "hors_doeuvres_selection_id").Where("email = ?", u.Email).First(&u)
+		result = db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "email_verified_at", "entree_selection_id", "hors_doeuvres_selection_id").Where("email = ?", u.Email).First(&u)
 	} else {
-		result = db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "entree_selection_id", "hors_doeuvres_selection_id").Find(&u)
+		result = db.WithContext(c).Select("id", "role", "is_going", "first_name", "last_name", "email", "email_verified_at", "entree_selection_id", "hors_doeuvres_selection_id").Find(&u)
 	}
 	return result.Error
 }
 
+// Get the current token version for the given user
+func FindTokenVersion(c context.Context, id uuid.UUID) (int, error) {
+	var u User
+	result := db.WithContext(c).Select("token_version").First(&u, id)
+	return u.TokenVersion, result.Error
+}
+
+// Load the full user record (including the password hash) for the email set on the given user
+//
+// If no email is set, the lookup is by ID instead.
 func FindUser(c context.Context, u *User) error {
-	var result = db.WithContext(c).Where("email = ?", u.Email).First(&u)
+	var result *gorm.DB
+	if u.Email != "" {
+		result = db.WithContext(c).Where("email = ?", u.Email).First(&u)
+	} else {
+		result = db.WithContext(c).Where("id = ?", u.ID).First(&u)
+	}
+	return result.Error
+}
+
+// Set the user's password hash and bump their token version, so the tokens issued before the change are rejected
+func ChangePassword(c context.Context, id uuid.UUID, passwordHash string) error {
+	result := db.WithContext(c).Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":      passwordHash,
+		"token_version": gorm.Expr("token_version + 1"),
+	})
 	return result.Error
 }
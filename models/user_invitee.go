@@ -2,9 +2,11 @@ package models
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -86,3 +88,16 @@ func FindInviteesForUser(c *context.Context, inviterId uuid.UUID) ([]UserInvitee
 	}
 	return users, nil
 }
+
+// Find the invitee with the given ID; returns nil if there isn't one
+func FindInviteeById(c context.Context, id uuid.UUID) (*UserInvitee, error) {
+	var invitee UserInvitee
+	result := db.WithContext(c).Where("id = ?", id).First(&invitee)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &invitee, nil
+}
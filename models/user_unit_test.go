@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/ax-vasquez/wedding-site-api/test"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -36,7 +37,7 @@ func Test_UserModel_Unit(t *testing.T) {
 		_, mock, _ := Setup()
 		mock.ExpectBegin()
 		mock.ExpectQuery(
-			regexp.QuoteMeta(`INSERT INTO "users" ("created_at","updated_at","deleted_at","role","is_going","first_name","last_name","email","password","token","refresh_token","hors_doeuvres_selection_id","entree_selection_id","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "id"`)).WithArgs(
+			regexp.QuoteMeta(`INSERT INTO "users" ("created_at","updated_at","deleted_at","role","is_going","first_name","last_name","email","password","token_version","email_verified_at","hors_doeuvres_selection_id","entree_selection_id","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "id"`)).WithArgs(
 			test.AnyTime{},
 			test.AnyTime{},
 			nil,
@@ -46,8 +47,8 @@ func Test_UserModel_Unit(t *testing.T) {
 			u.LastName,
 			u.Email,
 			u.Password,
-			u.Token,
-			u.RefreshToken,
+			u.TokenVersion,
+			u.EmailVerifiedAt,
 			u.HorsDoeuvresSelectionId,
 			u.EntreeSelectionId,
 			u.ID,
@@ -66,7 +67,7 @@ func Test_UserModel_Unit(t *testing.T) {
 		someId := uuid.New()
 		_, mock, _ := Setup()
 		mock.ExpectQuery(
-			regexp.QuoteMeta(`SELECT "id","role","is_going","first_name","last_name","email","entree_selection_id","hors_doeuvres_selection_id" FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL`)).WithArgs(
+			regexp.QuoteMeta(`SELECT "id","role","is_going","first_name","last_name","email","email_verified_at","entree_selection_id","hors_doeuvres_selection_id" FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL`)).WithArgs(
 			someId,
 		).WillReturnError(fmt.Errorf(errMsg))
 		mock.ExpectRollback()
@@ -82,6 +83,8 @@ func Test_UserModel_Unit(t *testing.T) {
 		someId := uuid.New()
 		_, mock, _ := Setup()
 		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE role = $1 AND "users"."deleted_at" IS NULL ORDER BY id FOR UPDATE`)).WithArgs(RoleAdmin).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
 		mock.ExpectExec(
 			regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).WithArgs(
 			test.AnyTime{},
@@ -96,6 +99,20 @@ func Test_UserModel_Unit(t *testing.T) {
 		assert.NotNil(err)
 		assert.Equal(errMsg, err.Error())
 	})
+	t.Run("DeleteUser - returns ErrLastAdmin for the only admin", func(t *testing.T) {
+		adminId := uuid.New()
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE role = $1 AND "users"."deleted_at" IS NULL ORDER BY id FOR UPDATE`)).WithArgs(RoleAdmin).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(adminId))
+		mock.ExpectRollback()
+
+		count, err := DeleteUser(ctx, adminId)
+
+		assert.Zero(count)
+		assert.Equal(ErrLastAdmin, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
 	t.Run("UpdateUser - database error returns error", func(t *testing.T) {
 		_, mock, _ := Setup()
 		mock.ExpectBegin()
@@ -131,6 +148,33 @@ func Test_UserModel_Unit(t *testing.T) {
 
 		err := SetIsGoing(ctx, &u)
 
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("FindTokenVersion - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "token_version" FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).WithArgs(
+			u.ID,
+			1,
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		_, err := FindTokenVersion(ctx, u.ID)
+
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("ChangeUserRole - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE role = $1 AND "users"."deleted_at" IS NULL ORDER BY id FOR UPDATE`)).WithArgs(
+			RoleAdmin,
+		).WillReturnError(fmt.Errorf(errMsg))
+		mock.ExpectRollback()
+
+		err := ChangeUserRole(ctx, &RoleChange{UserId: u.ID, NewRole: RoleAdmin})
+
 		assert.NotNil(err)
 		assert.Equal(errMsg, err.Error())
 	})
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Venue table; a place where part of the wedding is held (e.g., the ceremony or reception venue)
+type Venue struct {
+	BaseModel
+	// The venue's name.
+	Name string `json:"name"`
+	// The venue's street address.
+	Address string `json:"address"`
+	// The venue's latitude; is null if it hasn't been set.
+	Latitude *float64 `json:"latitude"`
+	// The venue's longitude; is null if it hasn't been set.
+	Longitude *float64 `json:"longitude"`
+	// Anything else guests should know about the venue (e.g., parking).
+	Notes string `json:"notes"`
+}
+
+// Accommodation table; a hotel (or other lodging) with a block of rooms reserved for guests
+type Accommodation struct {
+	BaseModel
+	// The hotel's name.
+	Name string `json:"name"`
+	// The hotel's street address.
+	Address string `json:"address"`
+	// The hotel's latitude; is null if it hasn't been set.
+	Latitude *float64 `json:"latitude"`
+	// The hotel's longitude; is null if it hasn't been set.
+	Longitude *float64 `json:"longitude"`
+	// The first night of the room block; is null if it hasn't been set.
+	CheckInDate *time.Time `json:"check_in_date"`
+	// The day guests check out after the last night of the room block; is null if it hasn't been set.
+	CheckOutDate *time.Time `json:"check_out_date"`
+	// The code guests give the hotel to book a room from the block.
+	RoomBlockCode string `json:"room_block_code"`
+	// The URL guests book rooms from the block at.
+	BookingUrl string `json:"booking_url"`
+	// The number of rooms in the block under contract with the hotel.
+	ContractedRooms int `json:"contracted_rooms"`
+	// The last day rooms can be booked from the block (after which the hotel releases them); is null if it hasn't been set.
+	CutoffDate *time.Time `json:"cutoff_date"`
+	// Anything else guests should know about the hotel (e.g., shuttles or breakfast).
+	Notes string `json:"notes"`
+}
+
+// Create a venue
+func CreateVenue(c context.Context, venue *Venue) error {
+	return db.WithContext(c).Create(venue).Error
+}
+
+// Find all venues, in the order they were added
+func FindVenues(c context.Context) ([]Venue, error) {
+	var venues []Venue
+	result := db.WithContext(c).Order("created_at").Find(&venues)
+	return venues, result.Error
+}
+
+// Replace every detail of a venue; returns gorm.ErrRecordNotFound if there is no venue with its ID
+func UpdateVenue(c context.Context, venue *Venue) error {
+	result := db.WithContext(c).Model(&Venue{}).Where("id = ?", venue.ID).Updates(map[string]interface{}{
+		"name":      venue.Name,
+		"address":   venue.Address,
+		"latitude":  venue.Latitude,
+		"longitude": venue.Longitude,
+		"notes":     venue.Notes,
+	})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// Delete a venue; returns the number of deleted records
+func DeleteVenue(c context.Context, id uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Delete(&Venue{}, id)
+	return result.RowsAffected, result.Error
+}
+
+// Create an accommodation
+func CreateAccommodation(c context.Context, accommodation *Accommodation) error {
+	return db.WithContext(c).Create(accommodation).Error
+}
+
+// Find all accommodations, in the order they were added
+func FindAccommodations(c context.Context) ([]Accommodation, error) {
+	var accommodations []Accommodation
+	result := db.WithContext(c).Order("created_at").Find(&accommodations)
+	return accommodations, result.Error
+}
+
+// Replace every detail of an accommodation; returns gorm.ErrRecordNotFound if there is no accommodation with its ID
+func UpdateAccommodation(c context.Context, accommodation *Accommodation) error {
+	result := db.WithContext(c).Model(&Accommodation{}).Where("id = ?", accommodation.ID).Updates(map[string]interface{}{
+		"name":             accommodation.Name,
+		"address":          accommodation.Address,
+		"latitude":         accommodation.Latitude,
+		"longitude":        accommodation.Longitude,
+		"check_in_date":    accommodation.CheckInDate,
+		"check_out_date":   accommodation.CheckOutDate,
+		"room_block_code":  accommodation.RoomBlockCode,
+		"booking_url":      accommodation.BookingUrl,
+		"contracted_rooms": accommodation.ContractedRooms,
+		"cutoff_date":      accommodation.CutoffDate,
+		"notes":            accommodation.Notes,
+	})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// Find the accommodation with the given ID; returns nil if there isn't one
+func FindAccommodationById(c context.Context, id uuid.UUID) (*Accommodation, error) {
+	var accommodation Accommodation
+	result := db.WithContext(c).Where("id = ?", id).First(&accommodation)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &accommodation, nil
+}
+
+// Delete an accommodation; returns the number of deleted records
+func DeleteAccommodation(c context.Context, id uuid.UUID) (int64, error) {
+	result := db.WithContext(c).Delete(&Accommodation{}, id)
+	return result.RowsAffected, result.Error
+}
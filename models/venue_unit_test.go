@@ -0,0 +1,51 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_VenueModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("UpdateVenue - missing venue returns gorm.ErrRecordNotFound", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "venues" SET`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := UpdateVenue(ctx, &Venue{BaseModel: BaseModel{ID: uuid.New()}, Name: "The Garden"})
+
+		assert.Equal(gorm.ErrRecordNotFound, err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("UpdateAccommodation - clears dates that aren't given", func(t *testing.T) {
+		_, mock, _ := Setup()
+		accommodation := Accommodation{BaseModel: BaseModel{ID: uuid.New()}, Name: "The Grand Hotel"}
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			regexp.QuoteMeta(`UPDATE "accommodations" SET "address"=$1,"booking_url"=$2,"check_in_date"=$3,"check_out_date"=$4,"contracted_rooms"=$5,"cutoff_date"=$6`)).WithArgs(
+			"", "", nil, nil, 0, nil, nil, nil, "The Grand Hotel", "", "", sqlmock.AnyArg(), accommodation.ID,
+		).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := UpdateAccommodation(ctx, &accommodation)
+
+		assert.Nil(err)
+		assert.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// VerifiedRoute table; a route that can only be used by users who have verified their email address
+//
+// Admins choose which routes these are (e.g., RSVP updates).
+type VerifiedRoute struct {
+	BaseModel
+	// The HTTP method of the route (e.g., "PATCH").
+	Method string `json:"method" gorm:"uniqueIndex:idx_verified_routes_method_path"`
+	// The route's path pattern, as registered with the router (e.g., "/api/v1/user/:id/role").
+	Path string `json:"path" gorm:"uniqueIndex:idx_verified_routes_method_path"`
+}
+
+// Find all routes that require a verified email address
+func FindVerifiedRoutes(c context.Context) ([]VerifiedRoute, error) {
+	var routes []VerifiedRoute
+	result := db.WithContext(c).Order("path, method").Find(&routes)
+	return routes, result.Error
+}
+
+// Check if the route with the given method and path pattern requires a verified email address
+func IsVerifiedRoute(c context.Context, method string, path string) (bool, error) {
+	var count int64
+	result := db.WithContext(c).Model(&VerifiedRoute{}).Where("method = ? AND path = ?", method, path).Count(&count)
+	return count > 0, result.Error
+}
+
+// Replace the routes that require a verified email address with the given routes
+func ReplaceVerifiedRoutes(c context.Context, routes *[]VerifiedRoute) error {
+	return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&VerifiedRoute{}).Error; err != nil {
+			return err
+		}
+		if len(*routes) == 0 {
+			return nil
+		}
+		return tx.Create(routes).Error
+	})
+}
@@ -0,0 +1,50 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VerifiedRouteModel_Unit(t *testing.T) {
+	os.Setenv("USE_MOCK_DB", "true")
+	assert := assert.New(t)
+	errMsg := "arbitrary database error"
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("IsVerifiedRoute - database error returns error", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "verified_routes" WHERE (method = $1 AND path = $2) AND "verified_routes"."deleted_at" IS NULL`)).WithArgs(
+			"PATCH",
+			"/api/v1/user",
+		).WillReturnError(fmt.Errorf(errMsg))
+
+		required, err := IsVerifiedRoute(ctx, "PATCH", "/api/v1/user")
+
+		assert.False(required)
+		assert.NotNil(err)
+		assert.Equal(errMsg, err.Error())
+	})
+	t.Run("IsVerifiedRoute - route is required when it has been chosen", func(t *testing.T) {
+		_, mock, _ := Setup()
+		mock.ExpectQuery(
+			regexp.QuoteMeta(`SELECT count(*) FROM "verified_routes" WHERE (method = $1 AND path = $2) AND "verified_routes"."deleted_at" IS NULL`)).WithArgs(
+			"PATCH",
+			"/api/v1/user",
+		).WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+
+		required, err := IsVerifiedRoute(ctx, "PATCH", "/api/v1/user")
+
+		assert.True(required)
+		assert.Nil(err)
+	})
+}
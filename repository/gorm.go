@@ -0,0 +1,617 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/google/uuid"
+)
+
+// GormStore is the Postgres-backed Store
+//
+// It delegates to the query functions in the models package, which operate on the connection
+// established by models.Setup().
+type GormStore struct{}
+
+var _ Store = GormStore{}
+
+func NewGormStore() GormStore {
+	return GormStore{}
+}
+
+func (GormStore) CreateUsers(c context.Context, users *[]models.User) error {
+	return models.CreateUsers(c, users)
+}
+
+func (GormStore) CountUsersByEmail(c context.Context, email string) (int64, error) {
+	return models.CountUsersByEmail(c, email)
+}
+
+func (GormStore) FindUsers(c context.Context, ids []uuid.UUID) ([]models.User, error) {
+	return models.FindUsers(c, ids)
+}
+
+func (GormStore) FindUser(c context.Context, u *models.User) error {
+	return models.FindUser(c, u)
+}
+
+func (GormStore) UpdateUser(c context.Context, u *models.User) error {
+	return models.UpdateUser(c, u)
+}
+
+func (GormStore) SetIsGoing(c context.Context, u *models.User) error {
+	return models.SetIsGoing(c, u)
+}
+
+func (GormStore) DeleteUser(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteUser(c, id)
+}
+
+func (GormStore) FindTokenVersion(c context.Context, id uuid.UUID) (int, error) {
+	return models.FindTokenVersion(c, id)
+}
+
+func (GormStore) ChangeUserRole(c context.Context, change *models.RoleChange) error {
+	return models.ChangeUserRole(c, change)
+}
+
+func (GormStore) FindRoleChanges(c context.Context, userId uuid.UUID) ([]models.RoleChange, error) {
+	return models.FindRoleChanges(c, userId)
+}
+
+func (GormStore) ChangePassword(c context.Context, id uuid.UUID, passwordHash string) error {
+	return models.ChangePassword(c, id, passwordHash)
+}
+
+func (GormStore) CreateEmailChange(c context.Context, change *models.EmailChange) error {
+	return models.CreateEmailChange(c, change)
+}
+
+func (GormStore) FindEmailChange(c context.Context, tokenHash string) (*models.EmailChange, error) {
+	return models.FindEmailChange(c, tokenHash)
+}
+
+func (GormStore) ConfirmEmailChange(c context.Context, change *models.EmailChange) error {
+	return models.ConfirmEmailChange(c, change)
+}
+
+func (GormStore) CreateEmailVerification(c context.Context, verification *models.EmailVerification) error {
+	return models.CreateEmailVerification(c, verification)
+}
+
+func (GormStore) FindEmailVerification(c context.Context, tokenHash string) (*models.EmailVerification, error) {
+	return models.FindEmailVerification(c, tokenHash)
+}
+
+func (GormStore) VerifyEmail(c context.Context, verification *models.EmailVerification, verifiedAt time.Time) error {
+	return models.VerifyEmail(c, verification, verifiedAt)
+}
+
+func (GormStore) CreateUserInvitee(c context.Context, invitee *models.UserInvitee) error {
+	return models.CreateUserInvitee(&c, invitee)
+}
+
+func (GormStore) FindInviteesForUser(c context.Context, inviterId uuid.UUID) ([]models.UserInvitee, error) {
+	return models.FindInviteesForUser(&c, inviterId)
+}
+
+func (GormStore) FindInviteeById(c context.Context, id uuid.UUID) (*models.UserInvitee, error) {
+	return models.FindInviteeById(c, id)
+}
+
+func (GormStore) UpdateInviteeForUser(c context.Context, invitee *models.UserInvitee, inviterId uuid.UUID) error {
+	return models.UpdateInviteeForUser(&c, invitee, inviterId)
+}
+
+func (GormStore) DeleteInviteeForUser(c context.Context, inviteeId uuid.UUID, inviterId uuid.UUID) (int64, error) {
+	return derefCount(models.DeleteInviteeForUser(&c, inviteeId, inviterId))
+}
+
+func (GormStore) DeleteInvitee(c context.Context, inviteeId uuid.UUID) (int64, error) {
+	return derefCount(models.DeleteInvitee(&c, inviteeId))
+}
+
+func (GormStore) FindEntrees(c context.Context) ([]models.Entree, error) {
+	return models.FindEntrees(c)
+}
+
+func (GormStore) FindEntreeById(c context.Context, id uuid.UUID) (*models.Entree, error) {
+	return models.FindEntreeById(c, id)
+}
+
+func (GormStore) FindEntreesForUser(c context.Context, userId uuid.UUID) ([]models.Entree, error) {
+	return models.FindEntreesForUser(c, userId)
+}
+
+func (GormStore) CreateEntrees(c context.Context, entrees *[]models.Entree) error {
+	return models.CreateEntrees(c, entrees)
+}
+
+func (GormStore) DeleteEntree(c context.Context, id uuid.UUID) (int64, error) {
+	return derefCount(models.DeleteEntree(c, id))
+}
+
+func (GormStore) FindHorsDoeuvres(c context.Context) ([]models.HorsDoeuvres, error) {
+	return models.FindHorsDoeuvres(c)
+}
+
+func (GormStore) FindHorsDoeuvresById(c context.Context, id uuid.UUID) (*models.HorsDoeuvres, error) {
+	return models.FindHorsDoeuvresById(c, id)
+}
+
+func (GormStore) FindHorsDoeuvresForUser(c context.Context, userId uuid.UUID) ([]models.HorsDoeuvres, error) {
+	return models.FindHorsDoeuvresForUser(c, userId)
+}
+
+func (GormStore) CreateHorsDoeuvres(c context.Context, horsDoeuvres *[]models.HorsDoeuvres) error {
+	return models.CreateHorsDoeuvres(c, horsDoeuvres)
+}
+
+func (GormStore) DeleteHorsDoeuvres(c context.Context, id uuid.UUID) (int64, error) {
+	return derefCount(models.DeleteHorsDoeuvres(c, id))
+}
+
+func (GormStore) FindLoginThrottle(c context.Context, kind string, key string) (*models.LoginThrottle, error) {
+	return models.FindLoginThrottle(c, kind, key)
+}
+
+func (GormStore) FindLoginThrottles(c context.Context, kind string) ([]models.LoginThrottle, error) {
+	return models.FindLoginThrottles(c, kind)
+}
+
+func (GormStore) RecordLoginFailure(c context.Context, kind string, key string, now time.Time, staleBefore time.Time) (*models.LoginThrottle, error) {
+	return models.RecordLoginFailure(c, kind, key, now, staleBefore)
+}
+
+func (GormStore) ResetLoginThrottle(c context.Context, kind string, key string) error {
+	return models.ResetLoginThrottle(c, kind, key)
+}
+
+// Some of the model delete functions return a pointer to the row count (which is nil on error)
+func derefCount(count *int64, err error) (int64, error) {
+	if count == nil {
+		return 0, err
+	}
+	return *count, err
+}
+
+func (GormStore) FindVerifiedRoutes(c context.Context) ([]models.VerifiedRoute, error) {
+	return models.FindVerifiedRoutes(c)
+}
+
+func (GormStore) IsVerifiedRoute(c context.Context, method string, path string) (bool, error) {
+	return models.IsVerifiedRoute(c, method, path)
+}
+
+func (GormStore) ReplaceVerifiedRoutes(c context.Context, routes *[]models.VerifiedRoute) error {
+	return models.ReplaceVerifiedRoutes(c, routes)
+}
+
+func (GormStore) CreateMagicLink(c context.Context, link *models.MagicLink) error {
+	return models.CreateMagicLink(c, link)
+}
+
+func (GormStore) ConsumeMagicLink(c context.Context, tokenHash string) (*models.MagicLink, error) {
+	return models.ConsumeMagicLink(c, tokenHash)
+}
+
+func (GormStore) FindMagicLinkRoles(c context.Context) ([]string, error) {
+	return models.FindMagicLinkRoles(c)
+}
+
+func (GormStore) IsMagicLinkRole(c context.Context, role string) (bool, error) {
+	return models.IsMagicLinkRole(c, role)
+}
+
+func (GormStore) ReplaceMagicLinkRoles(c context.Context, roles []string) error {
+	return models.ReplaceMagicLinkRoles(c, roles)
+}
+
+func (GormStore) FindMfaEnrollment(c context.Context, userId uuid.UUID) (*models.MfaEnrollment, error) {
+	return models.FindMfaEnrollment(c, userId)
+}
+
+func (GormStore) CreateMfaEnrollment(c context.Context, enrollment *models.MfaEnrollment) error {
+	return models.CreateMfaEnrollment(c, enrollment)
+}
+
+func (GormStore) ConfirmMfaEnrollment(c context.Context, userId uuid.UUID, confirmedAt time.Time, step int64, codeHashes []string) error {
+	return models.ConfirmMfaEnrollment(c, userId, confirmedAt, step, codeHashes)
+}
+
+func (GormStore) UseMfaStep(c context.Context, userId uuid.UUID, step int64) (bool, error) {
+	return models.UseMfaStep(c, userId, step)
+}
+
+func (GormStore) UseRecoveryCode(c context.Context, userId uuid.UUID, codeHash string) (bool, error) {
+	return models.UseRecoveryCode(c, userId, codeHash)
+}
+
+func (GormStore) CountRecoveryCodes(c context.Context, userId uuid.UUID) (int64, error) {
+	return models.CountRecoveryCodes(c, userId)
+}
+
+func (GormStore) ReplaceRecoveryCodes(c context.Context, userId uuid.UUID, codeHashes []string) error {
+	return models.ReplaceRecoveryCodes(c, userId, codeHashes)
+}
+
+func (GormStore) DeleteMfaEnrollment(c context.Context, userId uuid.UUID) error {
+	return models.DeleteMfaEnrollment(c, userId)
+}
+
+func (GormStore) CreateMfaChallenge(c context.Context, challenge *models.MfaChallenge) error {
+	return models.CreateMfaChallenge(c, challenge)
+}
+
+func (GormStore) FindMfaChallenge(c context.Context, tokenHash string) (*models.MfaChallenge, error) {
+	return models.FindMfaChallenge(c, tokenHash)
+}
+
+func (GormStore) CountMfaChallengeAttempt(c context.Context, challenge *models.MfaChallenge) error {
+	return models.CountMfaChallengeAttempt(c, challenge)
+}
+
+func (GormStore) DeleteMfaChallenge(c context.Context, id uuid.UUID) error {
+	return models.DeleteMfaChallenge(c, id)
+}
+
+func (GormStore) FindMfaRequiredRoles(c context.Context) ([]string, error) {
+	return models.FindMfaRequiredRoles(c)
+}
+
+func (GormStore) IsMfaRequiredRole(c context.Context, role string) (bool, error) {
+	return models.IsMfaRequiredRole(c, role)
+}
+
+func (GormStore) ReplaceMfaRequiredRoles(c context.Context, roles []string) error {
+	return models.ReplaceMfaRequiredRoles(c, roles)
+}
+
+func (GormStore) CreateSession(c context.Context, session *models.Session) error {
+	return models.CreateSession(c, session)
+}
+
+func (GormStore) FindSession(c context.Context, refreshTokenHash string) (*models.Session, error) {
+	return models.FindSession(c, refreshTokenHash)
+}
+
+func (GormStore) IsActiveSession(c context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	return models.IsActiveSession(c, id, now)
+}
+
+func (GormStore) FindSessionsForUser(c context.Context, userId uuid.UUID, tokenVersion int, now time.Time) ([]models.Session, error) {
+	return models.FindSessionsForUser(c, userId, tokenVersion, now)
+}
+
+func (GormStore) RefreshSession(c context.Context, session *models.Session, previousHash string) (bool, error) {
+	return models.RefreshSession(c, session, previousHash)
+}
+
+func (GormStore) DeleteSessionForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	return models.DeleteSessionForUser(c, id, userId)
+}
+
+func (GormStore) CreateEvent(c context.Context, event *models.Event) error {
+	return models.CreateEvent(c, event)
+}
+
+func (GormStore) FindEvents(c context.Context) ([]models.Event, error) {
+	return models.FindEvents(c)
+}
+
+func (GormStore) FindEventById(c context.Context, id uuid.UUID) (*models.Event, error) {
+	return models.FindEventById(c, id)
+}
+
+func (GormStore) UpdateEvent(c context.Context, event *models.Event) error {
+	return models.UpdateEvent(c, event)
+}
+
+func (GormStore) DeleteEvent(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteEvent(c, id)
+}
+
+func (GormStore) FindEventGuests(c context.Context, eventId uuid.UUID) ([]uuid.UUID, error) {
+	return models.FindEventGuests(c, eventId)
+}
+
+func (GormStore) ReplaceEventGuests(c context.Context, eventId uuid.UUID, userIds []uuid.UUID) error {
+	return models.ReplaceEventGuests(c, eventId, userIds)
+}
+
+func (GormStore) FindEventsForUser(c context.Context, userId uuid.UUID) ([]models.Event, error) {
+	return models.FindEventsForUser(c, userId)
+}
+
+func (GormStore) FindEventRsvpsForUser(c context.Context, userId uuid.UUID) ([]models.EventRsvp, error) {
+	return models.FindEventRsvpsForUser(c, userId)
+}
+
+func (GormStore) SaveEventRsvp(c context.Context, rsvp *models.EventRsvp) error {
+	return models.SaveEventRsvp(c, rsvp)
+}
+
+func (GormStore) FindEventHeadcounts(c context.Context) ([]models.EventHeadcount, error) {
+	return models.FindEventHeadcounts(c)
+}
+
+func (GormStore) CreateCalendarFeed(c context.Context, feed *models.CalendarFeed) error {
+	return models.CreateCalendarFeed(c, feed)
+}
+
+func (GormStore) FindCalendarFeed(c context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	return models.FindCalendarFeed(c, tokenHash)
+}
+
+func (GormStore) DeleteCalendarFeedForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	return models.DeleteCalendarFeedForUser(c, userId)
+}
+
+func (GormStore) CreateVenue(c context.Context, venue *models.Venue) error {
+	return models.CreateVenue(c, venue)
+}
+
+func (GormStore) FindVenues(c context.Context) ([]models.Venue, error) {
+	return models.FindVenues(c)
+}
+
+func (GormStore) UpdateVenue(c context.Context, venue *models.Venue) error {
+	return models.UpdateVenue(c, venue)
+}
+
+func (GormStore) DeleteVenue(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteVenue(c, id)
+}
+
+func (GormStore) CreateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	return models.CreateAccommodation(c, accommodation)
+}
+
+func (GormStore) FindAccommodations(c context.Context) ([]models.Accommodation, error) {
+	return models.FindAccommodations(c)
+}
+
+func (GormStore) UpdateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	return models.UpdateAccommodation(c, accommodation)
+}
+
+func (GormStore) DeleteAccommodation(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteAccommodation(c, id)
+}
+
+func (GormStore) FindAccommodationById(c context.Context, id uuid.UUID) (*models.Accommodation, error) {
+	return models.FindAccommodationById(c, id)
+}
+
+func (GormStore) SaveGuestStay(c context.Context, stay *models.GuestStay) error {
+	return models.SaveGuestStay(c, stay)
+}
+
+func (GormStore) FindGuestStayForUser(c context.Context, userId uuid.UUID) (*models.GuestStay, error) {
+	return models.FindGuestStayForUser(c, userId)
+}
+
+func (GormStore) DeleteGuestStayForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	return models.DeleteGuestStayForUser(c, userId)
+}
+
+func (GormStore) FindGuestStays(c context.Context) ([]models.GuestStay, error) {
+	return models.FindGuestStays(c)
+}
+
+func (GormStore) FindRoomBlockUtilization(c context.Context) ([]models.RoomBlockUtilization, error) {
+	return models.FindRoomBlockUtilization(c)
+}
+
+func (GormStore) CreateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	return models.CreateShuttleRun(c, run)
+}
+
+func (GormStore) FindShuttleRuns(c context.Context) ([]models.ShuttleRun, error) {
+	return models.FindShuttleRuns(c)
+}
+
+func (GormStore) FindShuttleRunById(c context.Context, id uuid.UUID) (*models.ShuttleRun, error) {
+	return models.FindShuttleRunById(c, id)
+}
+
+func (GormStore) UpdateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	return models.UpdateShuttleRun(c, run)
+}
+
+func (GormStore) DeleteShuttleRun(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteShuttleRun(c, id)
+}
+
+func (GormStore) ReplaceShuttleRidersForUser(c context.Context, runId uuid.UUID, userId uuid.UUID, riderIds []uuid.UUID) error {
+	return models.ReplaceShuttleRidersForUser(c, runId, userId, riderIds)
+}
+
+func (GormStore) FindShuttleRidersForUser(c context.Context, userId uuid.UUID) ([]models.ShuttleRider, error) {
+	return models.FindShuttleRidersForUser(c, userId)
+}
+
+func (GormStore) FindShuttleHeadcounts(c context.Context) ([]models.ShuttleHeadcount, error) {
+	return models.FindShuttleHeadcounts(c)
+}
+
+func (GormStore) FindShuttleManifest(c context.Context, runId uuid.UUID) ([]models.ShuttleManifestEntry, error) {
+	return models.FindShuttleManifest(c, runId)
+}
+
+func (GormStore) CreateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	return models.CreateRegistryItem(c, item)
+}
+
+func (GormStore) FindRegistryItems(c context.Context) ([]models.RegistryItem, error) {
+	return models.FindRegistryItems(c)
+}
+
+func (GormStore) UpdateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	return models.UpdateRegistryItem(c, item)
+}
+
+func (GormStore) DeleteRegistryItem(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteRegistryItem(c, id)
+}
+
+func (GormStore) SaveRegistryClaim(c context.Context, claim *models.RegistryClaim) error {
+	return models.SaveRegistryClaim(c, claim)
+}
+
+func (GormStore) FindRegistryClaimsForUser(c context.Context, userId uuid.UUID) ([]models.RegistryClaim, error) {
+	return models.FindRegistryClaimsForUser(c, userId)
+}
+
+func (GormStore) FindRegistryClaimTotals(c context.Context) ([]models.RegistryClaimTotal, error) {
+	return models.FindRegistryClaimTotals(c)
+}
+
+func (GormStore) FindRegistryClaimReport(c context.Context) ([]models.RegistryClaimReportEntry, error) {
+	return models.FindRegistryClaimReport(c)
+}
+
+func (GormStore) CreateGift(c context.Context, gift *models.Gift) error {
+	return models.CreateGift(c, gift)
+}
+
+func (GormStore) FindGifts(c context.Context) ([]models.Gift, error) {
+	return models.FindGifts(c)
+}
+
+func (GormStore) FindGiftById(c context.Context, id uuid.UUID) (*models.Gift, error) {
+	return models.FindGiftById(c, id)
+}
+
+func (GormStore) UpdateGift(c context.Context, gift *models.Gift) error {
+	return models.UpdateGift(c, gift)
+}
+
+func (GormStore) UpdateGiftThankYou(c context.Context, id uuid.UUID, status string, sentAt *time.Time) error {
+	return models.UpdateGiftThankYou(c, id, status, sentAt)
+}
+
+func (GormStore) DeleteGift(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteGift(c, id)
+}
+
+func (GormStore) ImportRegistryGifts(c context.Context) ([]models.Gift, error) {
+	return models.ImportRegistryGifts(c)
+}
+
+func (GormStore) FindThankYouQueue(c context.Context) ([]models.ThankYouQueueEntry, error) {
+	return models.FindThankYouQueue(c)
+}
+
+func (GormStore) CreateHousehold(c context.Context, household *models.Household) error {
+	return models.CreateHousehold(c, household)
+}
+
+func (GormStore) FindHouseholds(c context.Context) ([]models.Household, error) {
+	return models.FindHouseholds(c)
+}
+
+func (GormStore) FindHouseholdById(c context.Context, id uuid.UUID) (*models.Household, error) {
+	return models.FindHouseholdById(c, id)
+}
+
+func (GormStore) UpdateHousehold(c context.Context, household *models.Household) error {
+	return models.UpdateHousehold(c, household)
+}
+
+func (GormStore) UpdateHouseholdMailings(c context.Context, household *models.Household) error {
+	return models.UpdateHouseholdMailings(c, household)
+}
+
+func (GormStore) DeleteHousehold(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteHousehold(c, id)
+}
+
+func (GormStore) ReplaceHouseholdMembers(c context.Context, householdId uuid.UUID, userIds []uuid.UUID, inviteeIds []uuid.UUID) error {
+	return models.ReplaceHouseholdMembers(c, householdId, userIds, inviteeIds)
+}
+
+func (GormStore) FindHouseholdMembers(c context.Context) ([]models.HouseholdMember, error) {
+	return models.FindHouseholdMembers(c)
+}
+
+func (GormStore) CreateSongRequest(c context.Context, song *models.SongRequest) error {
+	return models.CreateSongRequest(c, song)
+}
+
+func (GormStore) FindSongRequests(c context.Context) ([]models.SongRequest, error) {
+	return models.FindSongRequests(c)
+}
+
+func (GormStore) FindSongRequestById(c context.Context, id uuid.UUID) (*models.SongRequest, error) {
+	return models.FindSongRequestById(c, id)
+}
+
+func (GormStore) UpdateSongRequestStatus(c context.Context, id uuid.UUID, status string) error {
+	return models.UpdateSongRequestStatus(c, id, status)
+}
+
+func (GormStore) DeleteSongRequest(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteSongRequest(c, id)
+}
+
+func (GormStore) SaveSongVote(c context.Context, vote *models.SongVote) error {
+	return models.SaveSongVote(c, vote)
+}
+
+func (GormStore) DeleteSongVote(c context.Context, songRequestId uuid.UUID, userId uuid.UUID) (int64, error) {
+	return models.DeleteSongVote(c, songRequestId, userId)
+}
+
+func (GormStore) FindSongVotesForUser(c context.Context, userId uuid.UUID) ([]models.SongVote, error) {
+	return models.FindSongVotesForUser(c, userId)
+}
+
+func (GormStore) FindSongVoteTotals(c context.Context) ([]models.SongVoteTotal, error) {
+	return models.FindSongVoteTotals(c)
+}
+
+func (GormStore) FindSongPlaylist(c context.Context) ([]models.SongPlaylistEntry, error) {
+	return models.FindSongPlaylist(c)
+}
+
+func (GormStore) CreateGuestbookEntry(c context.Context, entry *models.GuestbookEntry) error {
+	return models.CreateGuestbookEntry(c, entry)
+}
+
+func (GormStore) FindGuestbookEntries(c context.Context) ([]models.GuestbookEntry, error) {
+	return models.FindGuestbookEntries(c)
+}
+
+func (GormStore) FindGuestbookEntriesForUser(c context.Context, userId uuid.UUID) ([]models.GuestbookEntry, error) {
+	return models.FindGuestbookEntriesForUser(c, userId)
+}
+
+func (GormStore) FindGuestbookEntryById(c context.Context, id uuid.UUID) (*models.GuestbookEntry, error) {
+	return models.FindGuestbookEntryById(c, id)
+}
+
+func (GormStore) FindPublicGuestbookEntries(c context.Context, limit int, offset int) ([]models.PublicGuestbookEntry, int64, error) {
+	return models.FindPublicGuestbookEntries(c, limit, offset)
+}
+
+func (GormStore) UpdateGuestbookEntryStatus(c context.Context, id uuid.UUID, status string) error {
+	return models.UpdateGuestbookEntryStatus(c, id, status)
+}
+
+func (GormStore) DeleteGuestbookEntry(c context.Context, id uuid.UUID) (int64, error) {
+	return models.DeleteGuestbookEntry(c, id)
+}
+
+func (GormStore) DeleteGuestbookEntryForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	return models.DeleteGuestbookEntryForUser(c, id, userId)
+}
+
+func (GormStore) FindGuestbookBlockedWords(c context.Context) ([]string, error) {
+	return models.FindGuestbookBlockedWords(c)
+}
+
+func (GormStore) ReplaceGuestbookBlockedWords(c context.Context, words []string) error {
+	return models.ReplaceGuestbookBlockedWords(c, words)
+}
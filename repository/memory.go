@@ -0,0 +1,2137 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateEmail is returned by the in-memory store when a user is saved with an email that is already taken
+//
+// This mirrors the unique index on users.email in Postgres.
+var ErrDuplicateEmail = errors.New("a user with this email address already exists")
+
+// ErrDuplicateMfaEnrollment is returned by the in-memory store when a user who has already set up an authenticator
+// starts setting up another one
+//
+// This mirrors the unique index on mfa_enrollments.user_id in Postgres.
+var ErrDuplicateMfaEnrollment = errors.New("the user already has an authenticator")
+
+// MemoryStore is an in-memory Store, intended for unit tests and local experiments
+//
+// Records are kept in insertion order and behave like their Postgres counterparts where the handlers rely
+// on it (e.g., updates only touch non-zero fields and user lookups by ID never return auth details).
+type MemoryStore struct {
+	mu               sync.Mutex
+	users            []models.User
+	roleChanges      []models.RoleChange
+	emailChanges     []models.EmailChange
+	verifications    []models.EmailVerification
+	invitees         []models.UserInvitee
+	entrees          []models.Entree
+	horsDoeuvres     []models.HorsDoeuvres
+	throttles        []models.LoginThrottle
+	verifiedRoutes   []models.VerifiedRoute
+	magicLinks       []models.MagicLink
+	magicLinkRoles   []string
+	mfaEnrollments   []models.MfaEnrollment
+	recoveryCodes    []models.MfaRecoveryCode
+	mfaChallenges    []models.MfaChallenge
+	mfaRoles         []string
+	sessions         []models.Session
+	events           []models.Event
+	eventGuests      []models.EventGuest
+	eventRsvps       []models.EventRsvp
+	calendarFeeds    []models.CalendarFeed
+	venues           []models.Venue
+	accommodations   []models.Accommodation
+	guestStays       []models.GuestStay
+	shuttleRuns      []models.ShuttleRun
+	shuttleRiders    []models.ShuttleRider
+	registryItems    []models.RegistryItem
+	registryClaims   []models.RegistryClaim
+	gifts            []models.Gift
+	households       []models.Household
+	householdMembers []models.HouseholdMember
+	songRequests     []models.SongRequest
+	songVotes        []models.SongVote
+	guestbookEntries []models.GuestbookEntry
+	blockedWords     []string
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Sets the database-generated fields on a new record
+func newBaseModel(b models.BaseModel) models.BaseModel {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	now := time.Now()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	return b
+}
+
+func (s *MemoryStore) CreateUsers(c context.Context, users *[]models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Check every record first so a failed batch inserts nothing (as it would in a transaction)
+	taken := map[string]bool{}
+	for _, existing := range s.users {
+		taken[existing.Email] = true
+	}
+	for _, u := range *users {
+		if taken[u.Email] {
+			return ErrDuplicateEmail
+		}
+		taken[u.Email] = true
+	}
+	for i := range *users {
+		u := &(*users)[i]
+		u.BaseModel = newBaseModel(u.BaseModel)
+		if u.Role == "" {
+			u.Role = models.RoleGuest
+		}
+		s.users = append(s.users, *u)
+	}
+	return nil
+}
+
+func (s *MemoryStore) CountUsersByEmail(c context.Context, email string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, u := range s.users {
+		if u.Email == email {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) FindUsers(c context.Context, ids []uuid.UUID) ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var users []models.User
+	for _, u := range s.users {
+		for _, id := range ids {
+			if u.ID == id {
+				users = append(users, safeUser(u))
+				break
+			}
+		}
+	}
+	return users, nil
+}
+
+// Strips the fields that FindUsers doesn't select in Postgres
+func safeUser(u models.User) models.User {
+	u.Password = ""
+	return u
+}
+
+func (s *MemoryStore) FindUser(c context.Context, u *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if (u.Email != "" && existing.Email == u.Email) || (u.Email == "" && existing.ID == u.ID) {
+			*u = existing
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) UpdateUser(c context.Context, u *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.Email != "" {
+		for _, other := range s.users {
+			if other.Email == u.Email && other.ID != u.ID {
+				return ErrDuplicateEmail
+			}
+		}
+	}
+	for i := range s.users {
+		existing := &s.users[i]
+		if existing.ID != u.ID {
+			continue
+		}
+		if u.Role != "" {
+			existing.Role = u.Role
+		}
+		if u.FirstName != "" {
+			existing.FirstName = u.FirstName
+		}
+		if u.LastName != "" {
+			existing.LastName = u.LastName
+		}
+		if u.Email != "" {
+			existing.Email = u.Email
+		}
+		if u.Password != "" {
+			existing.Password = u.Password
+		}
+		if u.HorsDoeuvresSelectionId != nil {
+			existing.HorsDoeuvresSelectionId = u.HorsDoeuvresSelectionId
+		}
+		if u.EntreeSelectionId != nil {
+			existing.EntreeSelectionId = u.EntreeSelectionId
+		}
+		existing.UpdatedAt = time.Now()
+		// Mirror the RETURNING clause used in Postgres
+		u.Role = existing.Role
+		u.FirstName = existing.FirstName
+		u.LastName = existing.LastName
+		u.Email = existing.Email
+		u.HorsDoeuvresSelectionId = existing.HorsDoeuvresSelectionId
+		u.EntreeSelectionId = existing.EntreeSelectionId
+		return nil
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetIsGoing(c context.Context, u *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == u.ID {
+			s.users[i].IsGoing = u.IsGoing
+			s.users[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteUser(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	admins := 0
+	isAdmin := false
+	for _, u := range s.users {
+		if u.Role == models.RoleAdmin {
+			admins++
+			isAdmin = isAdmin || u.ID == id
+		}
+	}
+	if isAdmin && admins <= 1 {
+		return 0, models.ErrLastAdmin
+	}
+	var deleted int64
+	s.users, deleted = deleteWhere(s.users, func(u models.User) bool { return u.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindTokenVersion(c context.Context, id uuid.UUID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.ID == id {
+			return u.TokenVersion, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) ChangeUserRole(c context.Context, change *models.RoleChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	admins := 0
+	var target *models.User
+	for i := range s.users {
+		if s.users[i].Role == models.RoleAdmin {
+			admins++
+		}
+		if s.users[i].ID == change.UserId {
+			target = &s.users[i]
+		}
+	}
+	if target == nil {
+		return gorm.ErrRecordNotFound
+	}
+	change.OldRole = target.Role
+	if target.Role == change.NewRole {
+		return nil
+	}
+	if target.Role == models.RoleAdmin && admins <= 1 {
+		return models.ErrLastAdmin
+	}
+	target.Role = change.NewRole
+	target.TokenVersion++
+	target.UpdatedAt = time.Now()
+	change.BaseModel = newBaseModel(change.BaseModel)
+	s.roleChanges = append(s.roleChanges, *change)
+	return nil
+}
+
+func (s *MemoryStore) FindRoleChanges(c context.Context, userId uuid.UUID) ([]models.RoleChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var changes []models.RoleChange
+	for _, rc := range s.roleChanges {
+		if rc.UserId == userId {
+			changes = append(changes, rc)
+		}
+	}
+	return changes, nil
+}
+
+func (s *MemoryStore) ChangePassword(c context.Context, id uuid.UUID, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users[i].Password = passwordHash
+			s.users[i].TokenVersion++
+			s.users[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateEmailChange(c context.Context, change *models.EmailChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emailChanges, _ = deleteWhere(s.emailChanges, func(ec models.EmailChange) bool { return ec.UserId == change.UserId })
+	change.BaseModel = newBaseModel(change.BaseModel)
+	s.emailChanges = append(s.emailChanges, *change)
+	return nil
+}
+
+func (s *MemoryStore) FindEmailChange(c context.Context, tokenHash string) (*models.EmailChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ec := range s.emailChanges {
+		if ec.TokenHash == tokenHash {
+			return &ec, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) ConfirmEmailChange(c context.Context, change *models.EmailChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == change.NewEmail && u.ID != change.UserId {
+			return models.ErrEmailTaken
+		}
+	}
+	for i := range s.users {
+		if s.users[i].ID == change.UserId {
+			now := time.Now()
+			s.users[i].Email = change.NewEmail
+			s.users[i].EmailVerifiedAt = &now
+			s.users[i].TokenVersion++
+			s.users[i].UpdatedAt = time.Now()
+		}
+	}
+	s.emailChanges, _ = deleteWhere(s.emailChanges, func(ec models.EmailChange) bool { return ec.UserId == change.UserId })
+	return nil
+}
+
+func (s *MemoryStore) CreateEmailVerification(c context.Context, verification *models.EmailVerification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifications, _ = deleteWhere(s.verifications, func(v models.EmailVerification) bool { return v.UserId == verification.UserId })
+	verification.BaseModel = newBaseModel(verification.BaseModel)
+	s.verifications = append(s.verifications, *verification)
+	return nil
+}
+
+func (s *MemoryStore) FindEmailVerification(c context.Context, tokenHash string) (*models.EmailVerification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.verifications {
+		if v.TokenHash == tokenHash {
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) VerifyEmail(c context.Context, verification *models.EmailVerification, verifiedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == verification.UserId {
+			s.users[i].EmailVerifiedAt = &verifiedAt
+			s.users[i].UpdatedAt = time.Now()
+		}
+	}
+	s.verifications, _ = deleteWhere(s.verifications, func(v models.EmailVerification) bool { return v.UserId == verification.UserId })
+	return nil
+}
+
+func (s *MemoryStore) CreateUserInvitee(c context.Context, invitee *models.UserInvitee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invitee.BaseModel = newBaseModel(invitee.BaseModel)
+	s.invitees = append(s.invitees, *invitee)
+	return nil
+}
+
+func (s *MemoryStore) FindInviteesForUser(c context.Context, inviterId uuid.UUID) ([]models.UserInvitee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var invitees []models.UserInvitee
+	for _, i := range s.invitees {
+		if i.InviterId == inviterId {
+			invitees = append(invitees, i)
+		}
+	}
+	return invitees, nil
+}
+
+func (s *MemoryStore) FindInviteeById(c context.Context, id uuid.UUID) (*models.UserInvitee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, i := range s.invitees {
+		if i.ID == id {
+			return &i, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateInviteeForUser(c context.Context, invitee *models.UserInvitee, inviterId uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.invitees {
+		existing := &s.invitees[i]
+		if existing.ID != invitee.ID || existing.InviterId != inviterId {
+			continue
+		}
+		if invitee.FirstName != "" {
+			existing.FirstName = invitee.FirstName
+		}
+		if invitee.LastName != "" {
+			existing.LastName = invitee.LastName
+		}
+		if invitee.HorsDoeuvresSelectionId != nil {
+			existing.HorsDoeuvresSelectionId = invitee.HorsDoeuvresSelectionId
+		}
+		if invitee.EntreeSelectionId != nil {
+			existing.EntreeSelectionId = invitee.EntreeSelectionId
+		}
+		existing.UpdatedAt = time.Now()
+		*invitee = *existing
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteInviteeForUser(c context.Context, inviteeId uuid.UUID, inviterId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.invitees, deleted = deleteWhere(s.invitees, func(i models.UserInvitee) bool {
+		return i.ID == inviteeId && i.InviterId == inviterId
+	})
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteInvitee(c context.Context, inviteeId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.invitees, deleted = deleteWhere(s.invitees, func(i models.UserInvitee) bool { return i.ID == inviteeId })
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindEntrees(c context.Context) ([]models.Entree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Entree(nil), s.entrees...), nil
+}
+
+func (s *MemoryStore) FindEntreeById(c context.Context, id uuid.UUID) (*models.Entree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entrees {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) FindEntreesForUser(c context.Context, userId uuid.UUID) ([]models.Entree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entrees []models.Entree
+	for _, u := range s.users {
+		if u.ID != userId || u.EntreeSelectionId == nil {
+			continue
+		}
+		for _, e := range s.entrees {
+			if e.ID == *u.EntreeSelectionId {
+				entrees = append(entrees, e)
+			}
+		}
+	}
+	return entrees, nil
+}
+
+func (s *MemoryStore) CreateEntrees(c context.Context, entrees *[]models.Entree) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range *entrees {
+		e := &(*entrees)[i]
+		e.BaseModel = newBaseModel(e.BaseModel)
+		s.entrees = append(s.entrees, *e)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteEntree(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.entrees, deleted = deleteWhere(s.entrees, func(e models.Entree) bool { return e.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindHorsDoeuvres(c context.Context) ([]models.HorsDoeuvres, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.HorsDoeuvres(nil), s.horsDoeuvres...), nil
+}
+
+func (s *MemoryStore) FindHorsDoeuvresById(c context.Context, id uuid.UUID) (*models.HorsDoeuvres, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.horsDoeuvres {
+		if h.ID == id {
+			return &h, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) FindHorsDoeuvresForUser(c context.Context, userId uuid.UUID) ([]models.HorsDoeuvres, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var horsDoeuvres []models.HorsDoeuvres
+	for _, u := range s.users {
+		if u.ID != userId || u.HorsDoeuvresSelectionId == nil {
+			continue
+		}
+		for _, h := range s.horsDoeuvres {
+			if h.ID == *u.HorsDoeuvresSelectionId {
+				horsDoeuvres = append(horsDoeuvres, h)
+			}
+		}
+	}
+	return horsDoeuvres, nil
+}
+
+func (s *MemoryStore) CreateHorsDoeuvres(c context.Context, horsDoeuvres *[]models.HorsDoeuvres) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range *horsDoeuvres {
+		h := &(*horsDoeuvres)[i]
+		h.BaseModel = newBaseModel(h.BaseModel)
+		s.horsDoeuvres = append(s.horsDoeuvres, *h)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteHorsDoeuvres(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.horsDoeuvres, deleted = deleteWhere(s.horsDoeuvres, func(h models.HorsDoeuvres) bool { return h.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindLoginThrottle(c context.Context, kind string, key string) (*models.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.throttles {
+		if t.Kind == kind && t.Key == key {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) FindLoginThrottles(c context.Context, kind string) ([]models.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var throttles []models.LoginThrottle
+	for _, t := range s.throttles {
+		if t.Kind == kind && t.Failures > 0 {
+			throttles = append(throttles, t)
+		}
+	}
+	return throttles, nil
+}
+
+func (s *MemoryStore) RecordLoginFailure(c context.Context, kind string, key string, now time.Time, staleBefore time.Time) (*models.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.throttles {
+		t := &s.throttles[i]
+		if t.Kind != kind || t.Key != key {
+			continue
+		}
+		if t.LastFailureAt.Before(staleBefore) {
+			t.Failures = 1
+		} else {
+			t.Failures++
+		}
+		t.LastFailureAt = now
+		t.UpdatedAt = now
+		updated := *t
+		return &updated, nil
+	}
+	t := models.LoginThrottle{
+		BaseModel:     newBaseModel(models.BaseModel{}),
+		Kind:          kind,
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+	s.throttles = append(s.throttles, t)
+	return &t, nil
+}
+
+func (s *MemoryStore) ResetLoginThrottle(c context.Context, kind string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.throttles {
+		if s.throttles[i].Kind == kind && s.throttles[i].Key == key {
+			s.throttles[i].Failures = 0
+		}
+	}
+	return nil
+}
+
+// Removes the records matching the given predicate and returns the remaining records along with the number removed
+func deleteWhere[T any](records []T, match func(T) bool) ([]T, int64) {
+	var deleted int64
+	kept := records[:0]
+	for _, r := range records {
+		if match(r) {
+			deleted++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, deleted
+}
+
+func (s *MemoryStore) FindVerifiedRoutes(c context.Context) ([]models.VerifiedRoute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes := append([]models.VerifiedRoute(nil), s.verifiedRoutes...)
+	slices.SortFunc(routes, func(a, b models.VerifiedRoute) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Method, b.Method))
+	})
+	return routes, nil
+}
+
+func (s *MemoryStore) IsVerifiedRoute(c context.Context, method string, path string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.verifiedRoutes {
+		if r.Method == method && r.Path == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) ReplaceVerifiedRoutes(c context.Context, routes *[]models.VerifiedRoute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifiedRoutes = nil
+	for i := range *routes {
+		r := &(*routes)[i]
+		r.BaseModel = newBaseModel(r.BaseModel)
+		s.verifiedRoutes = append(s.verifiedRoutes, *r)
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateMagicLink(c context.Context, link *models.MagicLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.magicLinks, _ = deleteWhere(s.magicLinks, func(l models.MagicLink) bool { return l.UserId == link.UserId })
+	link.BaseModel = newBaseModel(link.BaseModel)
+	s.magicLinks = append(s.magicLinks, *link)
+	return nil
+}
+
+func (s *MemoryStore) ConsumeMagicLink(c context.Context, tokenHash string) (*models.MagicLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range s.magicLinks {
+		if l.TokenHash == tokenHash {
+			s.magicLinks, _ = deleteWhere(s.magicLinks, func(other models.MagicLink) bool { return other.ID == l.ID })
+			return &l, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) FindMagicLinkRoles(c context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roles := append([]string(nil), s.magicLinkRoles...)
+	slices.Sort(roles)
+	return roles, nil
+}
+
+func (s *MemoryStore) IsMagicLinkRole(c context.Context, role string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.magicLinkRoles, role), nil
+}
+
+func (s *MemoryStore) ReplaceMagicLinkRoles(c context.Context, roles []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.magicLinkRoles = append([]string(nil), roles...)
+	return nil
+}
+
+func (s *MemoryStore) FindMfaEnrollment(c context.Context, userId uuid.UUID) (*models.MfaEnrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.mfaEnrollments {
+		if e.UserId == userId {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) CreateMfaEnrollment(c context.Context, enrollment *models.MfaEnrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfaEnrollments, _ = deleteWhere(s.mfaEnrollments, func(e models.MfaEnrollment) bool {
+		return e.UserId == enrollment.UserId && e.ConfirmedAt == nil
+	})
+	if slices.ContainsFunc(s.mfaEnrollments, func(e models.MfaEnrollment) bool { return e.UserId == enrollment.UserId }) {
+		return ErrDuplicateMfaEnrollment
+	}
+	enrollment.BaseModel = newBaseModel(enrollment.BaseModel)
+	s.mfaEnrollments = append(s.mfaEnrollments, *enrollment)
+	return nil
+}
+
+func (s *MemoryStore) ConfirmMfaEnrollment(c context.Context, userId uuid.UUID, confirmedAt time.Time, step int64, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.mfaEnrollments, func(e models.MfaEnrollment) bool { return e.UserId == userId && e.ConfirmedAt == nil })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.mfaEnrollments[i].ConfirmedAt = &confirmedAt
+	s.mfaEnrollments[i].LastUsedStep = step
+	s.replaceRecoveryCodes(userId, codeHashes)
+	return nil
+}
+
+func (s *MemoryStore) UseMfaStep(c context.Context, userId uuid.UUID, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.mfaEnrollments {
+		if e.UserId == userId && e.ConfirmedAt != nil && e.LastUsedStep < step {
+			s.mfaEnrollments[i].LastUsedStep = step
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) UseRecoveryCode(c context.Context, userId uuid.UUID, codeHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.recoveryCodes, deleted = deleteWhere(s.recoveryCodes, func(r models.MfaRecoveryCode) bool {
+		return r.UserId == userId && r.CodeHash == codeHash
+	})
+	return deleted > 0, nil
+}
+
+func (s *MemoryStore) CountRecoveryCodes(c context.Context, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, r := range s.recoveryCodes {
+		if r.UserId == userId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) ReplaceRecoveryCodes(c context.Context, userId uuid.UUID, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaceRecoveryCodes(userId, codeHashes)
+	return nil
+}
+
+// Callers must hold s.mu
+func (s *MemoryStore) replaceRecoveryCodes(userId uuid.UUID, codeHashes []string) {
+	s.recoveryCodes, _ = deleteWhere(s.recoveryCodes, func(r models.MfaRecoveryCode) bool { return r.UserId == userId })
+	for _, hash := range codeHashes {
+		s.recoveryCodes = append(s.recoveryCodes, models.MfaRecoveryCode{
+			BaseModel: newBaseModel(models.BaseModel{}),
+			UserId:    userId,
+			CodeHash:  hash,
+		})
+	}
+}
+
+func (s *MemoryStore) DeleteMfaEnrollment(c context.Context, userId uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfaEnrollments, _ = deleteWhere(s.mfaEnrollments, func(e models.MfaEnrollment) bool { return e.UserId == userId })
+	s.recoveryCodes, _ = deleteWhere(s.recoveryCodes, func(r models.MfaRecoveryCode) bool { return r.UserId == userId })
+	s.mfaChallenges, _ = deleteWhere(s.mfaChallenges, func(ch models.MfaChallenge) bool { return ch.UserId == userId })
+	return nil
+}
+
+func (s *MemoryStore) CreateMfaChallenge(c context.Context, challenge *models.MfaChallenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfaChallenges, _ = deleteWhere(s.mfaChallenges, func(ch models.MfaChallenge) bool { return ch.UserId == challenge.UserId })
+	challenge.BaseModel = newBaseModel(challenge.BaseModel)
+	s.mfaChallenges = append(s.mfaChallenges, *challenge)
+	return nil
+}
+
+func (s *MemoryStore) FindMfaChallenge(c context.Context, tokenHash string) (*models.MfaChallenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.mfaChallenges {
+		if ch.TokenHash == tokenHash {
+			return &ch, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) CountMfaChallengeAttempt(c context.Context, challenge *models.MfaChallenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ch := range s.mfaChallenges {
+		if ch.ID == challenge.ID {
+			s.mfaChallenges[i].Attempts++
+			challenge.Attempts = s.mfaChallenges[i].Attempts
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteMfaChallenge(c context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfaChallenges, _ = deleteWhere(s.mfaChallenges, func(ch models.MfaChallenge) bool { return ch.ID == id })
+	return nil
+}
+
+func (s *MemoryStore) FindMfaRequiredRoles(c context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roles := append([]string(nil), s.mfaRoles...)
+	slices.Sort(roles)
+	return roles, nil
+}
+
+func (s *MemoryStore) IsMfaRequiredRole(c context.Context, role string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.mfaRoles, role), nil
+}
+
+func (s *MemoryStore) ReplaceMfaRequiredRoles(c context.Context, roles []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfaRoles = append([]string(nil), roles...)
+	return nil
+}
+
+func (s *MemoryStore) CreateSession(c context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.BaseModel = newBaseModel(session.BaseModel)
+	s.sessions = append(s.sessions, *session)
+	return nil
+}
+
+func (s *MemoryStore) FindSession(c context.Context, refreshTokenHash string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions {
+		if session.RefreshTokenHash == refreshTokenHash {
+			return &session, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) IsActiveSession(c context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.sessions, func(session models.Session) bool {
+		return session.ID == id && session.ExpiresAt.After(now)
+	}), nil
+}
+
+func (s *MemoryStore) FindSessionsForUser(c context.Context, userId uuid.UUID, tokenVersion int, now time.Time) ([]models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []models.Session
+	for _, session := range s.sessions {
+		if session.UserId == userId && session.TokenVersion == tokenVersion && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortStableFunc(sessions, func(a, b models.Session) int { return b.LastUsedAt.Compare(a.LastUsedAt) })
+	return sessions, nil
+}
+
+func (s *MemoryStore) RefreshSession(c context.Context, session *models.Session, previousHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.sessions {
+		if existing.ID == session.ID && existing.RefreshTokenHash == previousHash {
+			s.sessions[i].RefreshTokenHash = session.RefreshTokenHash
+			s.sessions[i].UserAgent = session.UserAgent
+			s.sessions[i].IpAddress = session.IpAddress
+			s.sessions[i].LastUsedAt = session.LastUsedAt
+			s.sessions[i].ExpiresAt = session.ExpiresAt
+			s.sessions[i].UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) DeleteSessionForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.sessions, deleted = deleteWhere(s.sessions, func(session models.Session) bool {
+		return session.ID == id && session.UserId == userId
+	})
+	return deleted, nil
+}
+
+// Orders events by start time, as the Postgres queries do
+func sortEvents(events []models.Event) {
+	slices.SortStableFunc(events, func(a, b models.Event) int { return a.StartsAt.Compare(b.StartsAt) })
+}
+
+func (s *MemoryStore) CreateEvent(c context.Context, event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.BaseModel = newBaseModel(event.BaseModel)
+	if event.InviteScope == "" {
+		event.InviteScope = models.EventScopeAll
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *MemoryStore) FindEvents(c context.Context) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := append([]models.Event(nil), s.events...)
+	sortEvents(events)
+	return events, nil
+}
+
+func (s *MemoryStore) FindEventById(c context.Context, id uuid.UUID) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.ID == id {
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateEvent(c context.Context, event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.events, func(e models.Event) bool { return e.ID == event.ID })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	existing := &s.events[i]
+	existing.Name = event.Name
+	existing.StartsAt = event.StartsAt
+	existing.EndsAt = event.EndsAt
+	existing.Location = event.Location
+	existing.DressCode = event.DressCode
+	existing.Description = event.Description
+	existing.InviteScope = event.InviteScope
+	existing.Sequence++
+	existing.UpdatedAt = time.Now()
+	s.pruneEventRsvps(event.ID, event.InviteScope)
+	return nil
+}
+
+func (s *MemoryStore) DeleteEvent(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.events, deleted = deleteWhere(s.events, func(e models.Event) bool { return e.ID == id })
+	s.eventGuests, _ = deleteWhere(s.eventGuests, func(g models.EventGuest) bool { return g.EventId == id })
+	s.eventRsvps, _ = deleteWhere(s.eventRsvps, func(r models.EventRsvp) bool { return r.EventId == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindEventGuests(c context.Context, eventId uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var userIds []uuid.UUID
+	for _, g := range s.eventGuests {
+		if g.EventId == eventId {
+			userIds = append(userIds, g.UserId)
+		}
+	}
+	return userIds, nil
+}
+
+func (s *MemoryStore) ReplaceEventGuests(c context.Context, eventId uuid.UUID, userIds []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.events, func(e models.Event) bool { return e.ID == eventId })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.eventGuests, _ = deleteWhere(s.eventGuests, func(g models.EventGuest) bool { return g.EventId == eventId })
+	for _, userId := range userIds {
+		s.eventGuests = append(s.eventGuests, models.EventGuest{
+			BaseModel: newBaseModel(models.BaseModel{}),
+			EventId:   eventId,
+			UserId:    userId,
+		})
+	}
+	s.pruneEventRsvps(eventId, s.events[i].InviteScope)
+	return nil
+}
+
+// Callers must hold s.mu
+func (s *MemoryStore) isEventGuest(eventId uuid.UUID, userId uuid.UUID) bool {
+	return slices.ContainsFunc(s.eventGuests, func(g models.EventGuest) bool { return g.EventId == eventId && g.UserId == userId })
+}
+
+// Callers must hold s.mu
+func (s *MemoryStore) pruneEventRsvps(eventId uuid.UUID, inviteScope string) {
+	if inviteScope != models.EventScopeSelected {
+		return
+	}
+	s.eventRsvps, _ = deleteWhere(s.eventRsvps, func(r models.EventRsvp) bool {
+		return r.EventId == eventId && !s.isEventGuest(eventId, r.UserId)
+	})
+}
+
+func (s *MemoryStore) FindEventsForUser(c context.Context, userId uuid.UUID) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []models.Event
+	for _, event := range s.events {
+		if event.InviteScope == models.EventScopeAll || s.isEventGuest(event.ID, userId) {
+			events = append(events, event)
+		}
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+func (s *MemoryStore) FindEventRsvpsForUser(c context.Context, userId uuid.UUID) ([]models.EventRsvp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rsvps []models.EventRsvp
+	for _, r := range s.eventRsvps {
+		if r.UserId == userId {
+			rsvps = append(rsvps, r)
+		}
+	}
+	return rsvps, nil
+}
+
+func (s *MemoryStore) SaveEventRsvp(c context.Context, rsvp *models.EventRsvp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.eventRsvps {
+		if existing.EventId == rsvp.EventId && existing.AttendeeId == rsvp.AttendeeId {
+			s.eventRsvps[i].UserId = rsvp.UserId
+			s.eventRsvps[i].Attending = rsvp.Attending
+			s.eventRsvps[i].UpdatedAt = time.Now()
+			*rsvp = s.eventRsvps[i]
+			return nil
+		}
+	}
+	rsvp.BaseModel = newBaseModel(rsvp.BaseModel)
+	s.eventRsvps = append(s.eventRsvps, *rsvp)
+	return nil
+}
+
+func (s *MemoryStore) FindEventHeadcounts(c context.Context) ([]models.EventHeadcount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := append([]models.Event(nil), s.events...)
+	sortEvents(events)
+	isUser := func(id uuid.UUID) bool {
+		return slices.ContainsFunc(s.users, func(u models.User) bool { return u.ID == id })
+	}
+	isInvitee := func(id uuid.UUID) bool {
+		return slices.ContainsFunc(s.invitees, func(i models.UserInvitee) bool { return i.ID == id })
+	}
+	headcounts := []models.EventHeadcount{}
+	for _, event := range events {
+		headcount := models.EventHeadcount{EventId: event.ID, Name: event.Name}
+		if event.InviteScope == models.EventScopeAll {
+			headcount.Invited = int64(len(s.users) + len(s.invitees))
+		} else {
+			for _, g := range s.eventGuests {
+				if g.EventId != event.ID || !isUser(g.UserId) {
+					continue
+				}
+				headcount.Invited++
+				for _, i := range s.invitees {
+					if i.InviterId == g.UserId {
+						headcount.Invited++
+					}
+				}
+			}
+		}
+		for _, r := range s.eventRsvps {
+			// Responses for users and invitees that have since been deleted aren't counted
+			if r.EventId != event.ID || !(isUser(r.AttendeeId) || isInvitee(r.AttendeeId)) {
+				continue
+			}
+			if r.Attending {
+				headcount.Attending++
+			} else {
+				headcount.Declined++
+			}
+		}
+		headcounts = append(headcounts, headcount)
+	}
+	return headcounts, nil
+}
+
+func (s *MemoryStore) CreateCalendarFeed(c context.Context, feed *models.CalendarFeed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calendarFeeds, _ = deleteWhere(s.calendarFeeds, func(f models.CalendarFeed) bool { return f.UserId == feed.UserId })
+	feed.BaseModel = newBaseModel(feed.BaseModel)
+	s.calendarFeeds = append(s.calendarFeeds, *feed)
+	return nil
+}
+
+func (s *MemoryStore) FindCalendarFeed(c context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.calendarFeeds {
+		if f.TokenHash == tokenHash {
+			return &f, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) DeleteCalendarFeedForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.calendarFeeds, deleted = deleteWhere(s.calendarFeeds, func(f models.CalendarFeed) bool { return f.UserId == userId })
+	return deleted, nil
+}
+
+func (s *MemoryStore) CreateVenue(c context.Context, venue *models.Venue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	venue.BaseModel = newBaseModel(venue.BaseModel)
+	s.venues = append(s.venues, *venue)
+	return nil
+}
+
+func (s *MemoryStore) FindVenues(c context.Context) ([]models.Venue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Venue(nil), s.venues...), nil
+}
+
+func (s *MemoryStore) UpdateVenue(c context.Context, venue *models.Venue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.venues {
+		if existing.ID == venue.ID {
+			venue.BaseModel = existing.BaseModel
+			venue.UpdatedAt = time.Now()
+			s.venues[i] = *venue
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteVenue(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.venues, deleted = deleteWhere(s.venues, func(v models.Venue) bool { return v.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) CreateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accommodation.BaseModel = newBaseModel(accommodation.BaseModel)
+	s.accommodations = append(s.accommodations, *accommodation)
+	return nil
+}
+
+func (s *MemoryStore) FindAccommodations(c context.Context) ([]models.Accommodation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Accommodation(nil), s.accommodations...), nil
+}
+
+func (s *MemoryStore) FindAccommodationById(c context.Context, id uuid.UUID) (*models.Accommodation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.accommodations {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.accommodations {
+		if existing.ID == accommodation.ID {
+			accommodation.BaseModel = existing.BaseModel
+			accommodation.UpdatedAt = time.Now()
+			s.accommodations[i] = *accommodation
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteAccommodation(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.accommodations, deleted = deleteWhere(s.accommodations, func(a models.Accommodation) bool { return a.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) SaveGuestStay(c context.Context, stay *models.GuestStay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.guestStays {
+		if existing.UserId == stay.UserId {
+			stay.BaseModel = existing.BaseModel
+			stay.UpdatedAt = time.Now()
+			s.guestStays[i] = *stay
+			return nil
+		}
+	}
+	stay.BaseModel = newBaseModel(stay.BaseModel)
+	s.guestStays = append(s.guestStays, *stay)
+	return nil
+}
+
+func (s *MemoryStore) FindGuestStayForUser(c context.Context, userId uuid.UUID) (*models.GuestStay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stay := range s.guestStays {
+		if stay.UserId == userId {
+			return &stay, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) DeleteGuestStayForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.guestStays, deleted = deleteWhere(s.guestStays, func(stay models.GuestStay) bool { return stay.UserId == userId })
+	return deleted, nil
+}
+
+// Stays for users that have since been deleted aren't reported; callers must hold s.mu
+func (s *MemoryStore) currentGuestStays() []models.GuestStay {
+	var stays []models.GuestStay
+	for _, stay := range s.guestStays {
+		if slices.ContainsFunc(s.users, func(u models.User) bool { return u.ID == stay.UserId }) {
+			stays = append(stays, stay)
+		}
+	}
+	return stays
+}
+
+func (s *MemoryStore) FindGuestStays(c context.Context) ([]models.GuestStay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stays := s.currentGuestStays()
+	slices.SortStableFunc(stays, func(a, b models.GuestStay) int { return a.ArrivalDate.Compare(b.ArrivalDate) })
+	return stays, nil
+}
+
+func (s *MemoryStore) FindRoomBlockUtilization(c context.Context) ([]models.RoomBlockUtilization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stays := s.currentGuestStays()
+	utilization := []models.RoomBlockUtilization{}
+	for _, a := range s.accommodations {
+		u := models.RoomBlockUtilization{AccommodationId: a.ID, Name: a.Name, ContractedRooms: a.ContractedRooms}
+		for _, stay := range stays {
+			if stay.AccommodationId == nil || *stay.AccommodationId != a.ID {
+				continue
+			}
+			if stay.UsedRoomBlock {
+				u.BlockBookings++
+			} else {
+				u.OtherBookings++
+			}
+		}
+		utilization = append(utilization, u)
+	}
+	return utilization, nil
+}
+
+// Orders shuttle runs by departure time, as the Postgres queries do
+func sortShuttleRuns(runs []models.ShuttleRun) {
+	slices.SortStableFunc(runs, func(a, b models.ShuttleRun) int { return a.DepartsAt.Compare(b.DepartsAt) })
+}
+
+func (s *MemoryStore) CreateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.BaseModel = newBaseModel(run.BaseModel)
+	s.shuttleRuns = append(s.shuttleRuns, *run)
+	return nil
+}
+
+func (s *MemoryStore) FindShuttleRuns(c context.Context) ([]models.ShuttleRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := append([]models.ShuttleRun(nil), s.shuttleRuns...)
+	sortShuttleRuns(runs)
+	return runs, nil
+}
+
+func (s *MemoryStore) FindShuttleRunById(c context.Context, id uuid.UUID) (*models.ShuttleRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.shuttleRuns {
+		if run.ID == id {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.shuttleRuns {
+		if existing.ID == run.ID {
+			run.BaseModel = existing.BaseModel
+			run.UpdatedAt = time.Now()
+			s.shuttleRuns[i] = *run
+			s.fillShuttleSeats(run.ID, run.Capacity)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteShuttleRun(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.shuttleRuns, deleted = deleteWhere(s.shuttleRuns, func(r models.ShuttleRun) bool { return r.ID == id })
+	s.shuttleRiders, _ = deleteWhere(s.shuttleRiders, func(r models.ShuttleRider) bool { return r.ShuttleRunId == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) ReplaceShuttleRidersForUser(c context.Context, runId uuid.UUID, userId uuid.UUID, riderIds []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.shuttleRuns, func(r models.ShuttleRun) bool { return r.ID == runId })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.shuttleRiders, _ = deleteWhere(s.shuttleRiders, func(r models.ShuttleRider) bool {
+		return r.ShuttleRunId == runId && r.UserId == userId && !slices.Contains(riderIds, r.RiderId)
+	})
+	for _, riderId := range riderIds {
+		if slices.ContainsFunc(s.shuttleRiders, func(r models.ShuttleRider) bool { return r.ShuttleRunId == runId && r.RiderId == riderId }) {
+			continue
+		}
+		s.shuttleRiders = append(s.shuttleRiders, models.ShuttleRider{
+			BaseModel:    newBaseModel(models.BaseModel{}),
+			ShuttleRunId: runId,
+			UserId:       userId,
+			RiderId:      riderId,
+			Waitlisted:   true,
+		})
+	}
+	s.fillShuttleSeats(runId, s.shuttleRuns[i].Capacity)
+	return nil
+}
+
+// Riders for users and invitees that have since been deleted don't take up seats; callers must hold s.mu
+func (s *MemoryStore) isCurrentRider(r models.ShuttleRider) bool {
+	return slices.ContainsFunc(s.users, func(u models.User) bool { return u.ID == r.RiderId }) ||
+		slices.ContainsFunc(s.invitees, func(i models.UserInvitee) bool { return i.ID == r.RiderId })
+}
+
+// Gives the free seats on a shuttle run to the waitlisted riders who signed up first (riders are kept in the order
+// they signed up); callers must hold s.mu
+func (s *MemoryStore) fillShuttleSeats(runId uuid.UUID, capacity int) {
+	free := capacity
+	for _, r := range s.shuttleRiders {
+		if r.ShuttleRunId == runId && !r.Waitlisted && s.isCurrentRider(r) {
+			free--
+		}
+	}
+	for i := range s.shuttleRiders {
+		if free <= 0 {
+			return
+		}
+		r := &s.shuttleRiders[i]
+		if r.ShuttleRunId == runId && r.Waitlisted && s.isCurrentRider(*r) {
+			r.Waitlisted = false
+			r.UpdatedAt = time.Now()
+			free--
+		}
+	}
+}
+
+func (s *MemoryStore) FindShuttleRidersForUser(c context.Context, userId uuid.UUID) ([]models.ShuttleRider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var riders []models.ShuttleRider
+	for _, r := range s.shuttleRiders {
+		if r.UserId == userId {
+			riders = append(riders, r)
+		}
+	}
+	return riders, nil
+}
+
+func (s *MemoryStore) FindShuttleHeadcounts(c context.Context) ([]models.ShuttleHeadcount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := append([]models.ShuttleRun(nil), s.shuttleRuns...)
+	sortShuttleRuns(runs)
+	headcounts := []models.ShuttleHeadcount{}
+	for _, run := range runs {
+		headcount := models.ShuttleHeadcount{ShuttleRunId: run.ID}
+		for _, r := range s.shuttleRiders {
+			if r.ShuttleRunId != run.ID || !s.isCurrentRider(r) {
+				continue
+			}
+			if r.Waitlisted {
+				headcount.Waitlisted++
+			} else {
+				headcount.Seated++
+			}
+		}
+		headcounts = append(headcounts, headcount)
+	}
+	return headcounts, nil
+}
+
+func (s *MemoryStore) FindShuttleManifest(c context.Context, runId uuid.UUID) ([]models.ShuttleManifestEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	manifest := []models.ShuttleManifestEntry{}
+	for _, r := range s.shuttleRiders {
+		if r.ShuttleRunId != runId {
+			continue
+		}
+		entry := models.ShuttleManifestEntry{RiderId: r.RiderId, UserId: r.UserId, Waitlisted: r.Waitlisted, SignedUpAt: r.CreatedAt}
+		if i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == r.RiderId }); i >= 0 {
+			entry.FirstName, entry.LastName = s.users[i].FirstName, s.users[i].LastName
+		} else if i := slices.IndexFunc(s.invitees, func(invitee models.UserInvitee) bool { return invitee.ID == r.RiderId }); i >= 0 {
+			entry.FirstName, entry.LastName = s.invitees[i].FirstName, s.invitees[i].LastName
+		} else {
+			continue
+		}
+		if i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == r.UserId }); i >= 0 {
+			entry.Email = s.users[i].Email
+		}
+		manifest = append(manifest, entry)
+	}
+	slices.SortStableFunc(manifest, func(a, b models.ShuttleManifestEntry) int {
+		if a.Waitlisted == b.Waitlisted {
+			return 0
+		}
+		if a.Waitlisted {
+			return 1
+		}
+		return -1
+	})
+	return manifest, nil
+}
+
+func (s *MemoryStore) CreateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item.BaseModel = newBaseModel(item.BaseModel)
+	s.registryItems = append(s.registryItems, *item)
+	return nil
+}
+
+func (s *MemoryStore) FindRegistryItems(c context.Context) ([]models.RegistryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.RegistryItem(nil), s.registryItems...), nil
+}
+
+func (s *MemoryStore) UpdateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.registryItems {
+		if existing.ID == item.ID {
+			item.BaseModel = existing.BaseModel
+			item.UpdatedAt = time.Now()
+			s.registryItems[i] = *item
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteRegistryItem(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.registryItems, deleted = deleteWhere(s.registryItems, func(i models.RegistryItem) bool { return i.ID == id })
+	s.registryClaims, _ = deleteWhere(s.registryClaims, func(claim models.RegistryClaim) bool { return claim.RegistryItemId == id })
+	return deleted, nil
+}
+
+// Claims from users that have since been deleted don't reserve anything; callers must hold s.mu
+func (s *MemoryStore) isCurrentClaim(claim models.RegistryClaim) bool {
+	return slices.ContainsFunc(s.users, func(u models.User) bool { return u.ID == claim.UserId })
+}
+
+func (s *MemoryStore) SaveRegistryClaim(c context.Context, claim *models.RegistryClaim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.registryItems, func(item models.RegistryItem) bool { return item.ID == claim.RegistryItemId })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	isUsersClaim := func(existing models.RegistryClaim) bool {
+		return existing.RegistryItemId == claim.RegistryItemId && existing.UserId == claim.UserId
+	}
+	if claim.Quantity == 0 {
+		s.registryClaims, _ = deleteWhere(s.registryClaims, isUsersClaim)
+		return nil
+	}
+	claimedByOthers := 0
+	for _, existing := range s.registryClaims {
+		if existing.RegistryItemId == claim.RegistryItemId && existing.UserId != claim.UserId && s.isCurrentClaim(existing) {
+			claimedByOthers += existing.Quantity
+		}
+	}
+	if claimedByOthers+claim.Quantity > s.registryItems[i].QuantityDesired {
+		return models.ErrRegistryItemUnavailable
+	}
+	if j := slices.IndexFunc(s.registryClaims, isUsersClaim); j >= 0 {
+		s.registryClaims[j].Quantity = claim.Quantity
+		s.registryClaims[j].UpdatedAt = time.Now()
+		*claim = s.registryClaims[j]
+		return nil
+	}
+	claim.BaseModel = newBaseModel(claim.BaseModel)
+	s.registryClaims = append(s.registryClaims, *claim)
+	return nil
+}
+
+func (s *MemoryStore) FindRegistryClaimsForUser(c context.Context, userId uuid.UUID) ([]models.RegistryClaim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claims []models.RegistryClaim
+	for _, claim := range s.registryClaims {
+		if claim.UserId == userId {
+			claims = append(claims, claim)
+		}
+	}
+	return claims, nil
+}
+
+func (s *MemoryStore) FindRegistryClaimTotals(c context.Context) ([]models.RegistryClaimTotal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	totals := []models.RegistryClaimTotal{}
+	for _, item := range s.registryItems {
+		total := models.RegistryClaimTotal{RegistryItemId: item.ID}
+		for _, claim := range s.registryClaims {
+			if claim.RegistryItemId == item.ID && s.isCurrentClaim(claim) {
+				total.Claimed += int64(claim.Quantity)
+			}
+		}
+		totals = append(totals, total)
+	}
+	return totals, nil
+}
+
+func (s *MemoryStore) FindRegistryClaimReport(c context.Context) ([]models.RegistryClaimReportEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := []models.RegistryClaimReportEntry{}
+	for _, item := range s.registryItems {
+		for _, claim := range s.registryClaims {
+			if claim.RegistryItemId != item.ID {
+				continue
+			}
+			i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == claim.UserId })
+			if i < 0 {
+				continue
+			}
+			report = append(report, models.RegistryClaimReportEntry{
+				RegistryItemId: item.ID,
+				ItemName:       item.Name,
+				UserId:         claim.UserId,
+				FirstName:      s.users[i].FirstName,
+				LastName:       s.users[i].LastName,
+				Email:          s.users[i].Email,
+				Quantity:       claim.Quantity,
+				ClaimedAt:      claim.UpdatedAt,
+			})
+		}
+	}
+	return report, nil
+}
+
+func (s *MemoryStore) CreateGift(c context.Context, gift *models.Gift) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gift.ThankYouStatus == "" {
+		gift.ThankYouStatus = models.ThankYouNotStarted
+	}
+	gift.BaseModel = newBaseModel(gift.BaseModel)
+	s.gifts = append(s.gifts, *gift)
+	return nil
+}
+
+func (s *MemoryStore) FindGifts(c context.Context) ([]models.Gift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Gift(nil), s.gifts...), nil
+}
+
+func (s *MemoryStore) FindGiftById(c context.Context, id uuid.UUID) (*models.Gift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, gift := range s.gifts {
+		if gift.ID == id {
+			return &gift, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateGift(c context.Context, gift *models.Gift) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.gifts {
+		if existing.ID == gift.ID {
+			existing.Description = gift.Description
+			existing.CashCents = gift.CashCents
+			existing.UserId = gift.UserId
+			existing.InviteeId = gift.InviteeId
+			existing.ReceivedAt = gift.ReceivedAt
+			existing.MailingAddress = gift.MailingAddress
+			existing.Notes = gift.Notes
+			existing.UpdatedAt = time.Now()
+			s.gifts[i] = existing
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) UpdateGiftThankYou(c context.Context, id uuid.UUID, status string, sentAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.gifts {
+		if existing.ID == id {
+			s.gifts[i].ThankYouStatus = status
+			s.gifts[i].ThankYouSentAt = sentAt
+			s.gifts[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteGift(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.gifts, deleted = deleteWhere(s.gifts, func(gift models.Gift) bool { return gift.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) ImportRegistryGifts(c context.Context) ([]models.Gift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gifts := []models.Gift{}
+	for _, item := range s.registryItems {
+		for _, claim := range s.registryClaims {
+			if claim.RegistryItemId != item.ID || !s.isCurrentClaim(claim) {
+				continue
+			}
+			if slices.ContainsFunc(s.gifts, func(gift models.Gift) bool {
+				return gift.RegistryClaimId != nil && *gift.RegistryClaimId == claim.ID
+			}) {
+				continue
+			}
+			gift := models.Gift{
+				BaseModel:       newBaseModel(models.BaseModel{}),
+				Source:          models.GiftSourceRegistry,
+				RegistryClaimId: &claim.ID,
+				Description:     models.RegistryGiftDescription(item.Name, claim.Quantity),
+				UserId:          &claim.UserId,
+				ThankYouStatus:  models.ThankYouNotStarted,
+			}
+			s.gifts = append(s.gifts, gift)
+			gifts = append(gifts, gift)
+		}
+	}
+	return gifts, nil
+}
+
+func (s *MemoryStore) FindThankYouQueue(c context.Context) ([]models.ThankYouQueueEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	findUser := func(id uuid.UUID) *models.User {
+		if i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == id }); i >= 0 {
+			return &s.users[i]
+		}
+		return nil
+	}
+	queue := []models.ThankYouQueueEntry{}
+	for _, gift := range s.gifts {
+		if gift.ThankYouStatus == models.ThankYouSent {
+			continue
+		}
+		entry := models.ThankYouQueueEntry{Gift: gift}
+		if gift.UserId != nil {
+			if user := findUser(*gift.UserId); user != nil {
+				entry.UserFirstName = user.FirstName
+				entry.UserLastName = user.LastName
+				entry.Email = user.Email
+			}
+		}
+		if gift.InviteeId != nil {
+			if i := slices.IndexFunc(s.invitees, func(invitee models.UserInvitee) bool { return invitee.ID == *gift.InviteeId }); i >= 0 {
+				entry.InviteeFirstName = s.invitees[i].FirstName
+				entry.InviteeLastName = s.invitees[i].LastName
+				if inviter := findUser(s.invitees[i].InviterId); inviter != nil && entry.Email == "" {
+					entry.Email = inviter.Email
+				}
+			}
+		}
+		queue = append(queue, entry)
+	}
+	// Gifts that haven't arrived yet go last; otherwise the order they were recorded in is kept
+	slices.SortStableFunc(queue, func(a, b models.ThankYouQueueEntry) int {
+		switch {
+		case a.ReceivedAt == nil && b.ReceivedAt == nil:
+			return 0
+		case a.ReceivedAt == nil:
+			return 1
+		case b.ReceivedAt == nil:
+			return -1
+		}
+		return a.ReceivedAt.Compare(*b.ReceivedAt)
+	})
+	return queue, nil
+}
+
+func (s *MemoryStore) CreateHousehold(c context.Context, household *models.Household) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	household.BaseModel = newBaseModel(household.BaseModel)
+	s.households = append(s.households, *household)
+	return nil
+}
+
+func (s *MemoryStore) FindHouseholds(c context.Context) ([]models.Household, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	households := append([]models.Household(nil), s.households...)
+	slices.SortStableFunc(households, func(a, b models.Household) int { return cmp.Compare(a.Name, b.Name) })
+	return households, nil
+}
+
+func (s *MemoryStore) FindHouseholdById(c context.Context, id uuid.UUID) (*models.Household, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, household := range s.households {
+		if household.ID == id {
+			return &household, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateHousehold(c context.Context, household *models.Household) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.households {
+		if existing.ID == household.ID {
+			existing.Name = household.Name
+			existing.AddressLine1 = household.AddressLine1
+			existing.AddressLine2 = household.AddressLine2
+			existing.AddressLine3 = household.AddressLine3
+			existing.City = household.City
+			existing.Region = household.Region
+			existing.PostalCode = household.PostalCode
+			existing.Country = household.Country
+			existing.Notes = household.Notes
+			existing.UpdatedAt = time.Now()
+			s.households[i] = existing
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) UpdateHouseholdMailings(c context.Context, household *models.Household) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.households {
+		if existing.ID == household.ID {
+			existing.SaveTheDateMailedAt = household.SaveTheDateMailedAt
+			existing.SaveTheDateReturnedAt = household.SaveTheDateReturnedAt
+			existing.InvitationMailedAt = household.InvitationMailedAt
+			existing.InvitationReturnedAt = household.InvitationReturnedAt
+			existing.UpdatedAt = time.Now()
+			s.households[i] = existing
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteHousehold(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.households, deleted = deleteWhere(s.households, func(h models.Household) bool { return h.ID == id })
+	s.householdMembers, _ = deleteWhere(s.householdMembers, func(m models.HouseholdMember) bool { return m.HouseholdId == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) ReplaceHouseholdMembers(c context.Context, householdId uuid.UUID, userIds []uuid.UUID, inviteeIds []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.ContainsFunc(s.households, func(h models.Household) bool { return h.ID == householdId }) {
+		return gorm.ErrRecordNotFound
+	}
+	s.householdMembers, _ = deleteWhere(s.householdMembers, func(m models.HouseholdMember) bool {
+		return m.HouseholdId == householdId ||
+			(m.UserId != nil && slices.Contains(userIds, *m.UserId)) ||
+			(m.InviteeId != nil && slices.Contains(inviteeIds, *m.InviteeId))
+	})
+	for _, userId := range userIds {
+		s.householdMembers = append(s.householdMembers, models.HouseholdMember{BaseModel: newBaseModel(models.BaseModel{}), HouseholdId: householdId, UserId: &userId})
+	}
+	for _, inviteeId := range inviteeIds {
+		s.householdMembers = append(s.householdMembers, models.HouseholdMember{BaseModel: newBaseModel(models.BaseModel{}), HouseholdId: householdId, InviteeId: &inviteeId})
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindHouseholdMembers(c context.Context) ([]models.HouseholdMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.HouseholdMember(nil), s.householdMembers...), nil
+}
+
+func (s *MemoryStore) CreateSongRequest(c context.Context, song *models.SongRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if song.Status == "" {
+		song.Status = models.SongRequestPending
+	}
+	song.SongKey = models.SongKey(song.Title, song.Artist)
+	if i := slices.IndexFunc(s.songRequests, func(existing models.SongRequest) bool { return existing.SongKey == song.SongKey }); i >= 0 {
+		if s.songRequests[i].Status == models.SongRequestDoNotPlay {
+			return models.ErrSongDoNotPlay
+		}
+		return models.ErrSongAlreadyRequested
+	}
+	song.BaseModel = newBaseModel(song.BaseModel)
+	s.songRequests = append(s.songRequests, *song)
+	return nil
+}
+
+func (s *MemoryStore) FindSongRequests(c context.Context) ([]models.SongRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.SongRequest(nil), s.songRequests...), nil
+}
+
+func (s *MemoryStore) FindSongRequestById(c context.Context, id uuid.UUID) (*models.SongRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, song := range s.songRequests {
+		if song.ID == id {
+			return &song, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) UpdateSongRequestStatus(c context.Context, id uuid.UUID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.songRequests {
+		if existing.ID == id {
+			s.songRequests[i].Status = status
+			s.songRequests[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteSongRequest(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.songRequests, deleted = deleteWhere(s.songRequests, func(song models.SongRequest) bool { return song.ID == id })
+	s.songVotes, _ = deleteWhere(s.songVotes, func(vote models.SongVote) bool { return vote.SongRequestId == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) SaveSongVote(c context.Context, vote *models.SongVote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.ContainsFunc(s.songRequests, func(song models.SongRequest) bool {
+		return song.ID == vote.SongRequestId && song.Status == models.SongRequestApproved
+	}) {
+		return gorm.ErrRecordNotFound
+	}
+	if slices.ContainsFunc(s.songVotes, func(existing models.SongVote) bool {
+		return existing.SongRequestId == vote.SongRequestId && existing.UserId == vote.UserId
+	}) {
+		return nil
+	}
+	vote.BaseModel = newBaseModel(vote.BaseModel)
+	s.songVotes = append(s.songVotes, *vote)
+	return nil
+}
+
+func (s *MemoryStore) DeleteSongVote(c context.Context, songRequestId uuid.UUID, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.songVotes, deleted = deleteWhere(s.songVotes, func(vote models.SongVote) bool {
+		return vote.SongRequestId == songRequestId && vote.UserId == userId
+	})
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindSongVotesForUser(c context.Context, userId uuid.UUID) ([]models.SongVote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var votes []models.SongVote
+	for _, vote := range s.songVotes {
+		if vote.UserId == userId {
+			votes = append(votes, vote)
+		}
+	}
+	return votes, nil
+}
+
+// Counts the votes for a song request from users that haven't been deleted; callers must hold s.mu
+func (s *MemoryStore) countSongVotes(songRequestId uuid.UUID) int64 {
+	var votes int64
+	for _, vote := range s.songVotes {
+		if vote.SongRequestId == songRequestId && slices.ContainsFunc(s.users, func(u models.User) bool { return u.ID == vote.UserId }) {
+			votes++
+		}
+	}
+	return votes
+}
+
+func (s *MemoryStore) FindSongVoteTotals(c context.Context) ([]models.SongVoteTotal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var totals []models.SongVoteTotal
+	for _, song := range s.songRequests {
+		if votes := s.countSongVotes(song.ID); votes > 0 {
+			totals = append(totals, models.SongVoteTotal{SongRequestId: song.ID, Votes: votes})
+		}
+	}
+	return totals, nil
+}
+
+func (s *MemoryStore) FindSongPlaylist(c context.Context) ([]models.SongPlaylistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var playlist []models.SongPlaylistEntry
+	for _, song := range s.songRequests {
+		if song.Status != models.SongRequestApproved && song.Status != models.SongRequestDoNotPlay {
+			continue
+		}
+		entry := models.SongPlaylistEntry{SongRequest: song, Votes: s.countSongVotes(song.ID)}
+		if i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == song.UserId }); i >= 0 {
+			entry.FirstName = s.users[i].FirstName
+			entry.LastName = s.users[i].LastName
+		}
+		playlist = append(playlist, entry)
+	}
+	slices.SortStableFunc(playlist, func(a, b models.SongPlaylistEntry) int {
+		if a.Status != b.Status {
+			if a.Status == models.SongRequestApproved {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Votes, a.Votes)
+	})
+	return playlist, nil
+}
+
+func (s *MemoryStore) CreateGuestbookEntry(c context.Context, entry *models.GuestbookEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.BaseModel = newBaseModel(entry.BaseModel)
+	s.guestbookEntries = append(s.guestbookEntries, *entry)
+	return nil
+}
+
+func (s *MemoryStore) FindGuestbookEntries(c context.Context) ([]models.GuestbookEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.GuestbookEntry(nil), s.guestbookEntries...), nil
+}
+
+func (s *MemoryStore) FindGuestbookEntriesForUser(c context.Context, userId uuid.UUID) ([]models.GuestbookEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []models.GuestbookEntry
+	for _, entry := range s.guestbookEntries {
+		if entry.UserId == userId {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *MemoryStore) FindGuestbookEntryById(c context.Context, id uuid.UUID) (*models.GuestbookEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.guestbookEntries {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) FindPublicGuestbookEntries(c context.Context, limit int, offset int) ([]models.PublicGuestbookEntry, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []models.PublicGuestbookEntry
+	for _, entry := range s.guestbookEntries {
+		if entry.Visibility != models.GuestbookPublic || entry.Status != models.GuestbookApproved {
+			continue
+		}
+		i := slices.IndexFunc(s.users, func(u models.User) bool { return u.ID == entry.UserId })
+		if i < 0 {
+			continue
+		}
+		entries = append(entries, models.PublicGuestbookEntry{
+			ID:        entry.ID,
+			Message:   entry.Message,
+			FirstName: s.users[i].FirstName,
+			LastName:  s.users[i].LastName,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	// Entries are kept in the order they were written, so the newest are last
+	slices.Reverse(entries)
+	total := int64(len(entries))
+	entries = entries[min(offset, len(entries)):]
+	return entries[:min(limit, len(entries))], total, nil
+}
+
+func (s *MemoryStore) UpdateGuestbookEntryStatus(c context.Context, id uuid.UUID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.guestbookEntries {
+		if existing.ID == id {
+			s.guestbookEntries[i].Status = status
+			s.guestbookEntries[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *MemoryStore) DeleteGuestbookEntry(c context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.guestbookEntries, deleted = deleteWhere(s.guestbookEntries, func(entry models.GuestbookEntry) bool { return entry.ID == id })
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteGuestbookEntryForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.guestbookEntries, deleted = deleteWhere(s.guestbookEntries, func(entry models.GuestbookEntry) bool {
+		return entry.ID == id && entry.UserId == userId
+	})
+	return deleted, nil
+}
+
+func (s *MemoryStore) FindGuestbookBlockedWords(c context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	words := append([]string(nil), s.blockedWords...)
+	slices.Sort(words)
+	return words, nil
+}
+
+func (s *MemoryStore) ReplaceGuestbookBlockedWords(c context.Context, words []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blockedWords = append([]string(nil), words...)
+	return nil
+}
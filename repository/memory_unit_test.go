@@ -0,0 +1,254 @@
+//go:build unit
+// +build unit
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_MemoryStore_Unit(t *testing.T) {
+	assert := assert.New(t)
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	t.Run("CreateUsers - sets IDs and default role", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{FirstName: "Booples", LastName: "McFadden", Email: "fake@email.place"}}
+		err := store.CreateUsers(ctx, &users)
+		assert.Nil(err)
+		assert.NotEqual(uuid.Nil, users[0].ID)
+		assert.Equal("GUEST", users[0].Role)
+	})
+	t.Run("CreateUsers - duplicate email inserts nothing", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.CreateUsers(ctx, &[]models.User{{Email: "fake@email.place"}})
+		err := store.CreateUsers(ctx, &[]models.User{{Email: "other@email.place"}, {Email: "fake@email.place"}})
+		assert.Equal(ErrDuplicateEmail, err)
+		count, _ := store.CountUsersByEmail(ctx, "other@email.place")
+		assert.Equal(int64(0), count)
+	})
+	t.Run("FindUsers - never returns auth details", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{Email: "fake@email.place", Password: "hash"}}
+		store.CreateUsers(ctx, &users)
+		found, err := store.FindUsers(ctx, []uuid.UUID{users[0].ID})
+		assert.Nil(err)
+		assert.Equal(1, len(found))
+		assert.Empty(found[0].Password)
+	})
+	t.Run("FindUser - unknown email returns record not found", func(t *testing.T) {
+		store := NewMemoryStore()
+		err := store.FindUser(ctx, &models.User{Email: "nobody@email.place"})
+		assert.ErrorIs(err, gorm.ErrRecordNotFound)
+	})
+	t.Run("UpdateUser - only updates non-zero fields", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{FirstName: "Booples", LastName: "McFadden", Email: "fake@email.place"}}
+		store.CreateUsers(ctx, &users)
+		u := models.User{BaseModel: models.BaseModel{ID: users[0].ID}, FirstName: "Newname"}
+		err := store.UpdateUser(ctx, &u)
+		assert.Nil(err)
+		assert.Equal("Newname", u.FirstName)
+		assert.Equal("McFadden", u.LastName)
+	})
+	t.Run("FindEntreesForUser - returns the user's selection", func(t *testing.T) {
+		store := NewMemoryStore()
+		entrees := []models.Entree{{OptionName: "Caprese pasta"}, {OptionName: "Banana Steak"}}
+		store.CreateEntrees(ctx, &entrees)
+		users := []models.User{{Email: "fake@email.place", EntreeSelectionId: &entrees[1].ID}}
+		store.CreateUsers(ctx, &users)
+		found, err := store.FindEntreesForUser(ctx, users[0].ID)
+		assert.Nil(err)
+		assert.Equal(1, len(found))
+		assert.Equal("Banana Steak", found[0].OptionName)
+	})
+	t.Run("DeleteInviteeForUser - only deletes the inviter's own invitees", func(t *testing.T) {
+		store := NewMemoryStore()
+		invitee := models.UserInvitee{InviterId: uuid.New(), FirstName: "Suman"}
+		store.CreateUserInvitee(ctx, &invitee)
+		deleted, err := store.DeleteInviteeForUser(ctx, invitee.ID, uuid.New())
+		assert.Nil(err)
+		assert.Equal(int64(0), deleted)
+		deleted, err = store.DeleteInviteeForUser(ctx, invitee.ID, invitee.InviterId)
+		assert.Nil(err)
+		assert.Equal(int64(1), deleted)
+	})
+	t.Run("ChangeUserRole - bumps the token version and keeps the last admin", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{Email: "admin@email.place", Role: models.RoleAdmin}, {Email: "fake@email.place"}}
+		store.CreateUsers(ctx, &users)
+		err := store.ChangeUserRole(ctx, &models.RoleChange{UserId: users[0].ID, NewRole: models.RoleGuest})
+		assert.ErrorIs(err, models.ErrLastAdmin)
+		err = store.ChangeUserRole(ctx, &models.RoleChange{UserId: users[1].ID, NewRole: models.RoleAdmin})
+		assert.Nil(err)
+		version, _ := store.FindTokenVersion(ctx, users[1].ID)
+		assert.Equal(1, version)
+		err = store.ChangeUserRole(ctx, &models.RoleChange{UserId: users[0].ID, NewRole: models.RoleGuest})
+		assert.Nil(err)
+		changes, _ := store.FindRoleChanges(ctx, users[0].ID)
+		assert.Equal(1, len(changes))
+		assert.Equal(models.RoleAdmin, changes[0].OldRole)
+	})
+	t.Run("CreateEmailChange - replaces the user's pending change", func(t *testing.T) {
+		store := NewMemoryStore()
+		userId := uuid.New()
+		store.CreateEmailChange(ctx, &models.EmailChange{UserId: userId, NewEmail: "first@email.place", TokenHash: "first"})
+		store.CreateEmailChange(ctx, &models.EmailChange{UserId: userId, NewEmail: "second@email.place", TokenHash: "second"})
+		change, err := store.FindEmailChange(ctx, "first")
+		assert.Nil(err)
+		assert.Nil(change)
+		change, _ = store.FindEmailChange(ctx, "second")
+		assert.Equal("second@email.place", change.NewEmail)
+	})
+	t.Run("ConfirmEmailChange - switches the email unless it was taken", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{Email: "fake@email.place"}, {Email: "other@email.place"}}
+		store.CreateUsers(ctx, &users)
+		err := store.ConfirmEmailChange(ctx, &models.EmailChange{UserId: users[0].ID, NewEmail: "other@email.place"})
+		assert.ErrorIs(err, models.ErrEmailTaken)
+		change := models.EmailChange{UserId: users[0].ID, NewEmail: "new@email.place", TokenHash: "hash"}
+		store.CreateEmailChange(ctx, &change)
+		err = store.ConfirmEmailChange(ctx, &change)
+		assert.Nil(err)
+		u := models.User{BaseModel: models.BaseModel{ID: users[0].ID}}
+		store.FindUser(ctx, &u)
+		assert.Equal("new@email.place", u.Email)
+		assert.Equal(1, u.TokenVersion)
+		pending, _ := store.FindEmailChange(ctx, "hash")
+		assert.Nil(pending)
+	})
+	t.Run("ReplaceVerifiedRoutes - replaces every chosen route", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.ReplaceVerifiedRoutes(ctx, &[]models.VerifiedRoute{{Method: "PATCH", Path: "/api/v1/user"}})
+		store.ReplaceVerifiedRoutes(ctx, &[]models.VerifiedRoute{{Method: "POST", Path: "/api/v1/user/add-invitee"}})
+		required, err := store.IsVerifiedRoute(ctx, "PATCH", "/api/v1/user")
+		assert.Nil(err)
+		assert.False(required)
+		required, _ = store.IsVerifiedRoute(ctx, "POST", "/api/v1/user/add-invitee")
+		assert.True(required)
+		routes, _ := store.FindVerifiedRoutes(ctx)
+		assert.Equal(1, len(routes))
+	})
+	t.Run("ConsumeMagicLink - links only work once", func(t *testing.T) {
+		store := NewMemoryStore()
+		userId := uuid.New()
+		store.CreateMagicLink(ctx, &models.MagicLink{UserId: userId, TokenHash: "old"})
+		store.CreateMagicLink(ctx, &models.MagicLink{UserId: userId, TokenHash: "new"})
+		link, err := store.ConsumeMagicLink(ctx, "old")
+		assert.Nil(err)
+		assert.Nil(link)
+		link, _ = store.ConsumeMagicLink(ctx, "new")
+		assert.Equal(userId, link.UserId)
+		link, _ = store.ConsumeMagicLink(ctx, "new")
+		assert.Nil(link)
+	})
+	t.Run("UseMfaStep - codes can't be replayed", func(t *testing.T) {
+		store := NewMemoryStore()
+		userId := uuid.New()
+		store.CreateMfaEnrollment(ctx, &models.MfaEnrollment{UserId: userId, Secret: "secret"})
+		used, _ := store.UseMfaStep(ctx, userId, 10)
+		assert.False(used)
+		store.ConfirmMfaEnrollment(ctx, userId, time.Now(), 10, []string{"a", "b"})
+		used, _ = store.UseMfaStep(ctx, userId, 10)
+		assert.False(used)
+		used, _ = store.UseMfaStep(ctx, userId, 11)
+		assert.True(used)
+		err := store.CreateMfaEnrollment(ctx, &models.MfaEnrollment{UserId: userId, Secret: "other"})
+		assert.ErrorIs(err, ErrDuplicateMfaEnrollment)
+		store.DeleteMfaEnrollment(ctx, userId)
+		count, _ := store.CountRecoveryCodes(ctx, userId)
+		assert.Equal(int64(0), count)
+	})
+	t.Run("RefreshSession - refresh tokens only work once", func(t *testing.T) {
+		store := NewMemoryStore()
+		now := time.Now()
+		session := models.Session{UserId: uuid.New(), RefreshTokenHash: "first", LastUsedAt: now, ExpiresAt: now.Add(time.Hour)}
+		store.CreateSession(ctx, &session)
+		session.RefreshTokenHash = "second"
+		refreshed, err := store.RefreshSession(ctx, &session, "first")
+		assert.Nil(err)
+		assert.True(refreshed)
+		session.RefreshTokenHash = "third"
+		refreshed, _ = store.RefreshSession(ctx, &session, "first")
+		assert.False(refreshed)
+		found, _ := store.FindSession(ctx, "first")
+		assert.Nil(found)
+		found, _ = store.FindSession(ctx, "second")
+		assert.Equal(session.ID, found.ID)
+	})
+	t.Run("FindSessionsForUser - skips expired sessions and sessions from older token versions", func(t *testing.T) {
+		store := NewMemoryStore()
+		userId := uuid.New()
+		now := time.Now()
+		sessions := []models.Session{
+			{UserId: userId, RefreshTokenHash: "older", TokenVersion: 1, LastUsedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+			{UserId: userId, RefreshTokenHash: "newer", TokenVersion: 1, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)},
+			{UserId: userId, RefreshTokenHash: "expired", TokenVersion: 1, LastUsedAt: now, ExpiresAt: now.Add(-time.Minute)},
+			{UserId: userId, RefreshTokenHash: "stale", TokenVersion: 0, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)},
+			{UserId: uuid.New(), RefreshTokenHash: "other", TokenVersion: 1, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)},
+		}
+		for i := range sessions {
+			store.CreateSession(ctx, &sessions[i])
+		}
+		found, err := store.FindSessionsForUser(ctx, userId, 1, now)
+		assert.Nil(err)
+		assert.Equal(2, len(found))
+		assert.Equal("newer", found[0].RefreshTokenHash)
+		assert.Equal("older", found[1].RefreshTokenHash)
+		active, _ := store.IsActiveSession(ctx, sessions[2].ID, now)
+		assert.False(active)
+		deleted, _ := store.DeleteSessionForUser(ctx, sessions[0].ID, uuid.New())
+		assert.Equal(int64(0), deleted)
+		deleted, _ = store.DeleteSessionForUser(ctx, sessions[0].ID, userId)
+		assert.Equal(int64(1), deleted)
+	})
+	t.Run("ReplaceEventGuests - responses from people taken off the guest list are deleted", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{Email: "kept@email.place"}, {Email: "removed@email.place"}}
+		store.CreateUsers(ctx, &users)
+		now := time.Now()
+		event := models.Event{Name: "Rehearsal dinner", StartsAt: now, EndsAt: now.Add(time.Hour), InviteScope: models.EventScopeSelected}
+		store.CreateEvent(ctx, &event)
+		store.ReplaceEventGuests(ctx, event.ID, []uuid.UUID{users[0].ID, users[1].ID})
+		for _, u := range users {
+			store.SaveEventRsvp(ctx, &models.EventRsvp{EventId: event.ID, UserId: u.ID, AttendeeId: u.ID, Attending: true})
+		}
+
+		err := store.ReplaceEventGuests(ctx, event.ID, []uuid.UUID{users[0].ID})
+		assert.Nil(err)
+		rsvps, _ := store.FindEventRsvpsForUser(ctx, users[1].ID)
+		assert.Equal(0, len(rsvps))
+		events, _ := store.FindEventsForUser(ctx, users[1].ID)
+		assert.Equal(0, len(events))
+		headcounts, _ := store.FindEventHeadcounts(ctx)
+		assert.Equal(int64(1), headcounts[0].Invited)
+		assert.Equal(int64(1), headcounts[0].Attending)
+		assert.Equal(gorm.ErrRecordNotFound, store.ReplaceEventGuests(ctx, uuid.New(), nil))
+	})
+	t.Run("SaveEventRsvp - replaces the earlier response for the same person", func(t *testing.T) {
+		store := NewMemoryStore()
+		users := []models.User{{Email: "fake@email.place"}}
+		store.CreateUsers(ctx, &users)
+		event := models.Event{Name: "Brunch"}
+		store.CreateEvent(ctx, &event)
+		assert.Equal(models.EventScopeAll, event.InviteScope)
+
+		first := models.EventRsvp{EventId: event.ID, UserId: users[0].ID, AttendeeId: users[0].ID, Attending: true}
+		store.SaveEventRsvp(ctx, &first)
+		second := models.EventRsvp{EventId: event.ID, UserId: users[0].ID, AttendeeId: users[0].ID, Attending: false}
+		err := store.SaveEventRsvp(ctx, &second)
+		assert.Nil(err)
+		assert.Equal(first.ID, second.ID)
+		headcounts, _ := store.FindEventHeadcounts(ctx)
+		assert.Equal(int64(1), headcounts[0].Invited)
+		assert.Equal(int64(0), headcounts[0].Attending)
+		assert.Equal(int64(1), headcounts[0].Declined)
+	})
+}
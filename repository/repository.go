@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/google/uuid"
+)
+
+// UserRepository persists registered users
+type UserRepository interface {
+	// Create users with the given data; IDs (and other database-generated fields) are set on the given records
+	CreateUsers(c context.Context, users *[]models.User) error
+	// Get the count of users whose email matches the given email (should only ever be 1 or 0)
+	CountUsersByEmail(c context.Context, email string) (int64, error)
+	// Find users by the given IDs; the password hash is never returned
+	FindUsers(c context.Context, ids []uuid.UUID) ([]models.User, error)
+	// Load the full user record (including the password hash) for the email set on the given user (or its ID, if no email is set)
+	FindUser(c context.Context, u *models.User) error
+	// Update the non-zero fields of the given user
+	UpdateUser(c context.Context, u *models.User) error
+	// Set is_going for the given user (this can't be done with UpdateUser when the value is false)
+	SetIsGoing(c context.Context, u *models.User) error
+	// Delete a user and return the number of deleted records; returns models.ErrLastAdmin if the last admin would be deleted
+	DeleteUser(c context.Context, id uuid.UUID) (int64, error)
+	// Get the user's current token version (tokens issued with any other version are stale)
+	FindTokenVersion(c context.Context, id uuid.UUID) (int, error)
+	// Change a user's role, bump their token version and record the change; returns models.ErrLastAdmin if the last admin would be demoted
+	ChangeUserRole(c context.Context, change *models.RoleChange) error
+	// Find the role changes for the given user, oldest first
+	FindRoleChanges(c context.Context, userId uuid.UUID) ([]models.RoleChange, error)
+	// Set the user's password hash and bump their token version
+	ChangePassword(c context.Context, id uuid.UUID, passwordHash string) error
+	// Create a pending email change, replacing any pending change the user already has
+	CreateEmailChange(c context.Context, change *models.EmailChange) error
+	// Find the pending email change with the given token hash; returns nil if there isn't one
+	FindEmailChange(c context.Context, tokenHash string) (*models.EmailChange, error)
+	// Switch the user's email to the change's new address, bump their token version and delete the change; returns models.ErrEmailTaken if the address was taken
+	ConfirmEmailChange(c context.Context, change *models.EmailChange) error
+	// Create a pending email verification, replacing any pending verification the user already has
+	CreateEmailVerification(c context.Context, verification *models.EmailVerification) error
+	// Find the pending email verification with the given token hash; returns nil if there isn't one
+	FindEmailVerification(c context.Context, tokenHash string) (*models.EmailVerification, error)
+	// Mark the user's email address as verified at the given time and delete the pending verification
+	VerifyEmail(c context.Context, verification *models.EmailVerification, verifiedAt time.Time) error
+}
+
+// InviteeRepository persists the invitees (plus-ones, children, etc.) added by users
+type InviteeRepository interface {
+	// Create an invitee; the ID is set on the given record
+	CreateUserInvitee(c context.Context, invitee *models.UserInvitee) error
+	// Find all invitees for the given inviting user ID
+	FindInviteesForUser(c context.Context, inviterId uuid.UUID) ([]models.UserInvitee, error)
+	// Find the invitee with the given ID; returns nil if there isn't one
+	FindInviteeById(c context.Context, id uuid.UUID) (*models.UserInvitee, error)
+	// Update an invitee, but only if it was added by the given inviter
+	UpdateInviteeForUser(c context.Context, invitee *models.UserInvitee, inviterId uuid.UUID) error
+	// Delete an invitee, but only if it was added by the given inviter; returns the number of deleted records
+	DeleteInviteeForUser(c context.Context, inviteeId uuid.UUID, inviterId uuid.UUID) (int64, error)
+	// Delete an invitee regardless of the inviter; returns the number of deleted records
+	DeleteInvitee(c context.Context, inviteeId uuid.UUID) (int64, error)
+}
+
+// MenuRepository persists the entree and hors doeuvres options guests choose from
+type MenuRepository interface {
+	FindEntrees(c context.Context) ([]models.Entree, error)
+	FindEntreeById(c context.Context, id uuid.UUID) (*models.Entree, error)
+	FindEntreesForUser(c context.Context, userId uuid.UUID) ([]models.Entree, error)
+	CreateEntrees(c context.Context, entrees *[]models.Entree) error
+	DeleteEntree(c context.Context, id uuid.UUID) (int64, error)
+	FindHorsDoeuvres(c context.Context) ([]models.HorsDoeuvres, error)
+	FindHorsDoeuvresById(c context.Context, id uuid.UUID) (*models.HorsDoeuvres, error)
+	FindHorsDoeuvresForUser(c context.Context, userId uuid.UUID) ([]models.HorsDoeuvres, error)
+	CreateHorsDoeuvres(c context.Context, horsDoeuvres *[]models.HorsDoeuvres) error
+	DeleteHorsDoeuvres(c context.Context, id uuid.UUID) (int64, error)
+}
+
+// LoginThrottleRepository counts failed login attempts per account and per IP address
+type LoginThrottleRepository interface {
+	// Find the throttle for the given key; returns nil if there have been no failed attempts for it
+	FindLoginThrottle(c context.Context, kind string, key string) (*models.LoginThrottle, error)
+	// Find the throttles of the given kind that have failed attempts
+	FindLoginThrottles(c context.Context, kind string) ([]models.LoginThrottle, error)
+	// Atomically count a failed attempt for the given key (starting over if the last one was before staleBefore)
+	RecordLoginFailure(c context.Context, kind string, key string, now time.Time, staleBefore time.Time) (*models.LoginThrottle, error)
+	// Reset the failed attempt count for the given key
+	ResetLoginThrottle(c context.Context, kind string, key string) error
+}
+
+// VerifiedRouteRepository persists the routes that require a verified email address
+type VerifiedRouteRepository interface {
+	// Find all routes that require a verified email address
+	FindVerifiedRoutes(c context.Context) ([]models.VerifiedRoute, error)
+	// Check if the route with the given method and path pattern requires a verified email address
+	IsVerifiedRoute(c context.Context, method string, path string) (bool, error)
+	// Replace the routes that require a verified email address with the given routes; IDs are set on the given records
+	ReplaceVerifiedRoutes(c context.Context, routes *[]models.VerifiedRoute) error
+}
+
+// MagicLinkRepository persists the links users can log in with instead of their password, and the roles allowed to use them
+type MagicLinkRepository interface {
+	// Create a magic link, replacing any unused link the user already has
+	CreateMagicLink(c context.Context, link *models.MagicLink) error
+	// Delete and return the magic link with the given token hash (so it can only be used once); returns nil if there isn't one
+	ConsumeMagicLink(c context.Context, tokenHash string) (*models.MagicLink, error)
+	// Find the roles whose users are allowed to log in with magic links
+	FindMagicLinkRoles(c context.Context) ([]string, error)
+	// Check if users with the given role are allowed to log in with magic links
+	IsMagicLinkRole(c context.Context, role string) (bool, error)
+	// Replace the roles whose users are allowed to log in with magic links
+	ReplaceMagicLinkRoles(c context.Context, roles []string) error
+}
+
+// MfaRepository persists users' two-factor authentication (authenticators, recovery codes and login challenges), and
+// the roles required to use it
+type MfaRepository interface {
+	// Find the user's authenticator; returns nil if they haven't started setting one up
+	FindMfaEnrollment(c context.Context, userId uuid.UUID) (*models.MfaEnrollment, error)
+	// Start setting up an authenticator, replacing any the user hasn't confirmed yet
+	CreateMfaEnrollment(c context.Context, enrollment *models.MfaEnrollment) error
+	// Confirm the user's authenticator and replace their recovery codes; returns gorm.ErrRecordNotFound if the user has no unconfirmed authenticator
+	ConfirmMfaEnrollment(c context.Context, userId uuid.UUID, confirmedAt time.Time, step int64, codeHashes []string) error
+	// Record that the user logged in with a code for the given step; returns false if a code for that step (or a later one) was already used
+	UseMfaStep(c context.Context, userId uuid.UUID, step int64) (bool, error)
+	// Delete one of the user's recovery codes; returns false if they don't have a code with the given hash
+	UseRecoveryCode(c context.Context, userId uuid.UUID, codeHash string) (bool, error)
+	// Count the recovery codes the user has left
+	CountRecoveryCodes(c context.Context, userId uuid.UUID) (int64, error)
+	// Replace the user's recovery codes (the old ones stop working)
+	ReplaceRecoveryCodes(c context.Context, userId uuid.UUID, codeHashes []string) error
+	// Remove the user's authenticator, recovery codes and login challenge
+	DeleteMfaEnrollment(c context.Context, userId uuid.UUID) error
+	// Create a login challenge, replacing any the user already has
+	CreateMfaChallenge(c context.Context, challenge *models.MfaChallenge) error
+	// Find the login challenge with the given token hash; returns nil if there isn't one
+	FindMfaChallenge(c context.Context, tokenHash string) (*models.MfaChallenge, error)
+	// Atomically count a code entered for a login challenge, setting challenge.Attempts to the new count (returns
+	// gorm.ErrRecordNotFound if the challenge is gone)
+	CountMfaChallengeAttempt(c context.Context, challenge *models.MfaChallenge) error
+	// Delete a login challenge
+	DeleteMfaChallenge(c context.Context, id uuid.UUID) error
+	// Find the roles whose users must set up two-factor authentication
+	FindMfaRequiredRoles(c context.Context) ([]string, error)
+	// Check if users with the given role must set up two-factor authentication
+	IsMfaRequiredRole(c context.Context, role string) (bool, error)
+	// Replace the roles whose users must set up two-factor authentication
+	ReplaceMfaRequiredRoles(c context.Context, roles []string) error
+}
+
+// SessionRepository persists the devices users are logged in on (sessions), identified by hashes of their refresh tokens
+type SessionRepository interface {
+	// Create a session; the ID is kept if it's set
+	CreateSession(c context.Context, session *models.Session) error
+	// Find the session with the given refresh token hash; returns nil if there isn't one
+	FindSession(c context.Context, refreshTokenHash string) (*models.Session, error)
+	// Check if the session with the given ID exists and hasn't expired
+	IsActiveSession(c context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// Find the user's sessions that were started with the given token version and haven't expired, most recently used first
+	FindSessionsForUser(c context.Context, userId uuid.UUID, tokenVersion int, now time.Time) ([]models.Session, error)
+	// Save the session's new refresh token hash, device details and times if its refresh token hash is still previousHash; returns false if it isn't
+	RefreshSession(c context.Context, session *models.Session, previousHash string) (bool, error)
+	// Delete a session, but only if it belongs to the given user; returns the number of deleted records
+	DeleteSessionForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error)
+}
+
+// EventRepository persists the wedding itinerary (events), who is invited to each event and guests' responses
+type EventRepository interface {
+	// Create an event; the ID is set on the given record
+	CreateEvent(c context.Context, event *models.Event) error
+	// Find all events, earliest first
+	FindEvents(c context.Context) ([]models.Event, error)
+	// Find the event with the given ID; returns nil if there isn't one
+	FindEventById(c context.Context, id uuid.UUID) (*models.Event, error)
+	// Replace every detail of an event (pruning responses from people who are no longer invited); returns gorm.ErrRecordNotFound if there is no event with its ID
+	UpdateEvent(c context.Context, event *models.Event) error
+	// Delete an event along with its guest list and responses; returns the number of deleted events
+	DeleteEvent(c context.Context, id uuid.UUID) (int64, error)
+	// Find the IDs of the users on an event's guest list
+	FindEventGuests(c context.Context, eventId uuid.UUID) ([]uuid.UUID, error)
+	// Replace an event's guest list (pruning responses from people who are no longer invited); returns gorm.ErrRecordNotFound if there is no event with the given ID
+	ReplaceEventGuests(c context.Context, eventId uuid.UUID, userIds []uuid.UUID) error
+	// Find the events the user (and their invitees) are invited to, earliest first
+	FindEventsForUser(c context.Context, userId uuid.UUID) ([]models.Event, error)
+	// Find the responses the user has given (for themselves and their invitees) across every event
+	FindEventRsvpsForUser(c context.Context, userId uuid.UUID) ([]models.EventRsvp, error)
+	// Save a response, replacing any earlier response for the same person and event
+	SaveEventRsvp(c context.Context, rsvp *models.EventRsvp) error
+	// Count the people invited to, attending and not attending each event, earliest event first
+	FindEventHeadcounts(c context.Context) ([]models.EventHeadcount, error)
+}
+
+// CalendarFeedRepository persists the secret links users' calendar apps subscribe to
+type CalendarFeedRepository interface {
+	// Create a calendar feed, replacing the feed the user already has
+	CreateCalendarFeed(c context.Context, feed *models.CalendarFeed) error
+	// Find the calendar feed with the given token hash; returns nil if there isn't one
+	FindCalendarFeed(c context.Context, tokenHash string) (*models.CalendarFeed, error)
+	// Delete the user's calendar feed; returns the number of deleted records
+	DeleteCalendarFeedForUser(c context.Context, userId uuid.UUID) (int64, error)
+}
+
+// VenueRepository persists the venues the wedding is held at and the accommodations guests can stay at
+type VenueRepository interface {
+	// Create a venue; the ID is set on the given record
+	CreateVenue(c context.Context, venue *models.Venue) error
+	// Find all venues, in the order they were added
+	FindVenues(c context.Context) ([]models.Venue, error)
+	// Replace every detail of a venue; returns gorm.ErrRecordNotFound if there is no venue with its ID
+	UpdateVenue(c context.Context, venue *models.Venue) error
+	// Delete a venue; returns the number of deleted records
+	DeleteVenue(c context.Context, id uuid.UUID) (int64, error)
+	// Create an accommodation; the ID is set on the given record
+	CreateAccommodation(c context.Context, accommodation *models.Accommodation) error
+	// Find all accommodations, in the order they were added
+	FindAccommodations(c context.Context) ([]models.Accommodation, error)
+	// Find the accommodation with the given ID; returns nil if there isn't one
+	FindAccommodationById(c context.Context, id uuid.UUID) (*models.Accommodation, error)
+	// Replace every detail of an accommodation; returns gorm.ErrRecordNotFound if there is no accommodation with its ID
+	UpdateAccommodation(c context.Context, accommodation *models.Accommodation) error
+	// Delete an accommodation; returns the number of deleted records
+	DeleteAccommodation(c context.Context, id uuid.UUID) (int64, error)
+}
+
+// StayRepository persists where users are staying for the wedding
+type StayRepository interface {
+	// Save the user's stay, replacing the stay they already have
+	SaveGuestStay(c context.Context, stay *models.GuestStay) error
+	// Find the user's stay; returns nil if they haven't said where they're staying
+	FindGuestStayForUser(c context.Context, userId uuid.UUID) (*models.GuestStay, error)
+	// Delete the user's stay; returns the number of deleted records
+	DeleteGuestStayForUser(c context.Context, userId uuid.UUID) (int64, error)
+	// Find the stays of every (non-deleted) user, earliest arrival first
+	FindGuestStays(c context.Context) ([]models.GuestStay, error)
+	// Count the rooms booked from each accommodation's room block, in the order the accommodations were added
+	FindRoomBlockUtilization(c context.Context) ([]models.RoomBlockUtilization, error)
+}
+
+// ShuttleRepository persists the shuttle runs between the hotels and venues and who is riding them
+type ShuttleRepository interface {
+	// Create a shuttle run; the ID is set on the given record
+	CreateShuttleRun(c context.Context, run *models.ShuttleRun) error
+	// Find all shuttle runs, earliest departure first
+	FindShuttleRuns(c context.Context) ([]models.ShuttleRun, error)
+	// Find the shuttle run with the given ID; returns nil if there isn't one
+	FindShuttleRunById(c context.Context, id uuid.UUID) (*models.ShuttleRun, error)
+	// Replace every detail of a shuttle run (giving waitlisted riders any seats that frees up); returns gorm.ErrRecordNotFound if there is no run with its ID
+	UpdateShuttleRun(c context.Context, run *models.ShuttleRun) error
+	// Delete a shuttle run along with its riders; returns the number of deleted runs
+	DeleteShuttleRun(c context.Context, id uuid.UUID) (int64, error)
+	// Atomically replace the people the user has signed up for a shuttle run, seating them while there's room and waitlisting them after; returns gorm.ErrRecordNotFound if there is no run with the given ID
+	ReplaceShuttleRidersForUser(c context.Context, runId uuid.UUID, userId uuid.UUID, riderIds []uuid.UUID) error
+	// Find the people the user has signed up (themselves and their invitees) across every shuttle run
+	FindShuttleRidersForUser(c context.Context, userId uuid.UUID) ([]models.ShuttleRider, error)
+	// Count the people seated on and waitlisted for each shuttle run, earliest departure first
+	FindShuttleHeadcounts(c context.Context) ([]models.ShuttleHeadcount, error)
+	// Find the people signed up for a shuttle run: seated riders first, then the waitlist, each in the order they signed up
+	FindShuttleManifest(c context.Context, runId uuid.UUID) ([]models.ShuttleManifestEntry, error)
+}
+
+// RegistryRepository persists the gifts on the couple's registry and which guests are giving them
+type RegistryRepository interface {
+	// Create a registry item; the ID is set on the given record
+	CreateRegistryItem(c context.Context, item *models.RegistryItem) error
+	// Find all registry items, in the order they were added
+	FindRegistryItems(c context.Context) ([]models.RegistryItem, error)
+	// Replace every detail of a registry item; returns gorm.ErrRecordNotFound if there is no item with its ID
+	UpdateRegistryItem(c context.Context, item *models.RegistryItem) error
+	// Delete a registry item along with its claims; returns the number of deleted items
+	DeleteRegistryItem(c context.Context, id uuid.UUID) (int64, error)
+	// Atomically save a user's claim on a registry item (a quantity of 0 removes it); returns gorm.ErrRecordNotFound if there is no item with its ID, or models.ErrRegistryItemUnavailable if other users have already claimed too much of it
+	SaveRegistryClaim(c context.Context, claim *models.RegistryClaim) error
+	// Find the claims the user has made, in the order they made them
+	FindRegistryClaimsForUser(c context.Context, userId uuid.UUID) ([]models.RegistryClaim, error)
+	// Count how much of each registry item has been claimed, in the order the items were added
+	FindRegistryClaimTotals(c context.Context) ([]models.RegistryClaimTotal, error)
+	// Find who claimed each registry item, in the order the items were added and then the order the claims were made
+	FindRegistryClaimReport(c context.Context) ([]models.RegistryClaimReportEntry, error)
+}
+
+// GiftRepository persists the gifts the couple received and their thank-you notes
+type GiftRepository interface {
+	// Create a gift; its thank-you note is not started unless a status is given. The ID is set on the given record
+	CreateGift(c context.Context, gift *models.Gift) error
+	// Find all gifts, in the order they were recorded
+	FindGifts(c context.Context) ([]models.Gift, error)
+	// Find the gift with the given ID; returns nil if there isn't one
+	FindGiftById(c context.Context, id uuid.UUID) (*models.Gift, error)
+	// Replace the details of a gift (but not where it came from or its thank-you note); returns gorm.ErrRecordNotFound if there is no gift with its ID
+	UpdateGift(c context.Context, gift *models.Gift) error
+	// Set the status of a gift's thank-you note; returns gorm.ErrRecordNotFound if there is no gift with the ID
+	UpdateGiftThankYou(c context.Context, id uuid.UUID, status string, sentAt *time.Time) error
+	// Delete a gift outright, so a registry claim whose gift was deleted can be imported again; returns the number of deleted gifts
+	DeleteGift(c context.Context, id uuid.UUID) (int64, error)
+	// Atomically record a gift for each registry claim that doesn't have one yet; returns the new gifts
+	ImportRegistryGifts(c context.Context) ([]models.Gift, error)
+	// Find the gifts that still need a thank-you note, the ones that arrived first first (then ones that haven't arrived yet, in the order they were recorded)
+	FindThankYouQueue(c context.Context) ([]models.ThankYouQueueEntry, error)
+}
+
+// HouseholdRepository persists the households invitations are mailed to and who lives in them
+type HouseholdRepository interface {
+	// Create a household; the ID is set on the given record
+	CreateHousehold(c context.Context, household *models.Household) error
+	// Find all households, by name
+	FindHouseholds(c context.Context) ([]models.Household, error)
+	// Find the household with the given ID; returns nil if there isn't one
+	FindHouseholdById(c context.Context, id uuid.UUID) (*models.Household, error)
+	// Replace a household's name, address and notes (but not its mailing dates); returns gorm.ErrRecordNotFound if there is no household with its ID
+	UpdateHousehold(c context.Context, household *models.Household) error
+	// Replace when a household's save-the-date and invitation were mailed and returned; returns gorm.ErrRecordNotFound if there is no household with its ID
+	UpdateHouseholdMailings(c context.Context, household *models.Household) error
+	// Delete a household along with its members; returns the number of deleted households
+	DeleteHousehold(c context.Context, id uuid.UUID) (int64, error)
+	// Atomically replace the users and invitees living in a household, moving any that lived in another household; returns gorm.ErrRecordNotFound if there is no household with the ID
+	ReplaceHouseholdMembers(c context.Context, householdId uuid.UUID, userIds []uuid.UUID, inviteeIds []uuid.UUID) error
+	// Find the members of every household, in the order they were added
+	FindHouseholdMembers(c context.Context) ([]models.HouseholdMember, error)
+}
+
+// SongRepository persists the songs guests ask the DJ to play and their votes
+type SongRepository interface {
+	// Atomically create a song request; it's pending unless a status is given. Returns models.ErrSongDoNotPlay if the song is on the do-not-play list, or models.ErrSongAlreadyRequested if it has been requested before. The ID is set on the given record
+	CreateSongRequest(c context.Context, song *models.SongRequest) error
+	// Find all song requests, in the order they were made
+	FindSongRequests(c context.Context) ([]models.SongRequest, error)
+	// Find the song request with the given ID; returns nil if there isn't one
+	FindSongRequestById(c context.Context, id uuid.UUID) (*models.SongRequest, error)
+	// Set the status of a song request; returns gorm.ErrRecordNotFound if there is no song request with the ID
+	UpdateSongRequestStatus(c context.Context, id uuid.UUID, status string) error
+	// Delete a song request outright along with its votes, so the song can be requested again; returns the number of deleted song requests
+	DeleteSongRequest(c context.Context, id uuid.UUID) (int64, error)
+	// Save a user's vote for an approved song request (voting again changes nothing); returns gorm.ErrRecordNotFound if there is no approved song request with its ID
+	SaveSongVote(c context.Context, vote *models.SongVote) error
+	// Remove a user's vote for a song request; returns the number of deleted votes
+	DeleteSongVote(c context.Context, songRequestId uuid.UUID, userId uuid.UUID) (int64, error)
+	// Find the votes the user has made, in the order they made them
+	FindSongVotesForUser(c context.Context, userId uuid.UUID) ([]models.SongVote, error)
+	// Count the votes for each song request that has any
+	FindSongVoteTotals(c context.Context) ([]models.SongVoteTotal, error)
+	// Find the songs for the DJ: approved songs, the most votes first, then the do-not-play list (songs with the same number of votes are in the order they were requested)
+	FindSongPlaylist(c context.Context) ([]models.SongPlaylistEntry, error)
+}
+
+// GuestbookRepository persists the messages guests leave for the couple and the words that hold them for moderation
+type GuestbookRepository interface {
+	// Create a guestbook entry; the ID is set on the given record
+	CreateGuestbookEntry(c context.Context, entry *models.GuestbookEntry) error
+	// Find all guestbook entries, in the order they were written
+	FindGuestbookEntries(c context.Context) ([]models.GuestbookEntry, error)
+	// Find the guestbook entries the user wrote, in the order they wrote them
+	FindGuestbookEntriesForUser(c context.Context, userId uuid.UUID) ([]models.GuestbookEntry, error)
+	// Find the guestbook entry with the given ID; returns nil if there isn't one
+	FindGuestbookEntryById(c context.Context, id uuid.UUID) (*models.GuestbookEntry, error)
+	// Find a page of the approved, public guestbook entries from current users, newest first; also returns how many there are in all
+	FindPublicGuestbookEntries(c context.Context, limit int, offset int) ([]models.PublicGuestbookEntry, int64, error)
+	// Set the status of a guestbook entry; returns gorm.ErrRecordNotFound if there is no entry with the ID
+	UpdateGuestbookEntryStatus(c context.Context, id uuid.UUID, status string) error
+	// Delete a guestbook entry; returns the number of deleted entries
+	DeleteGuestbookEntry(c context.Context, id uuid.UUID) (int64, error)
+	// Delete a guestbook entry the user wrote; returns the number of deleted entries (0 if the user didn't write it)
+	DeleteGuestbookEntryForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error)
+	// Find the words and phrases that hold guestbook entries for moderation, in alphabetical order
+	FindGuestbookBlockedWords(c context.Context) ([]string, error)
+	// Atomically replace the words and phrases that hold guestbook entries for moderation
+	ReplaceGuestbookBlockedWords(c context.Context, words []string) error
+}
+
+// Store is a single backend that implements every repository
+//
+// Method names are unique across the repositories so one backend can satisfy all of them.
+type Store interface {
+	UserRepository
+	InviteeRepository
+	MenuRepository
+	LoginThrottleRepository
+	VerifiedRouteRepository
+	MagicLinkRepository
+	MfaRepository
+	SessionRepository
+	EventRepository
+	CalendarFeedRepository
+	VenueRepository
+	StayRepository
+	ShuttleRepository
+	RegistryRepository
+	GiftRepository
+	HouseholdRepository
+	SongRepository
+	GuestbookRepository
+}
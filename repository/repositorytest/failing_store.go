@@ -0,0 +1,1073 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
+	"github.com/ax-vasquez/wedding-site-api/test/fixtures"
+	"github.com/google/uuid"
+)
+
+// FailingStore wraps a repository.Store and fails the named operations with Err
+//
+// Operations that aren't named pass through to the wrapped store. When no operations are named, every
+// operation fails except the ones in authOperations, so requests still make it through the auth middleware.
+// This is used in unit tests to exercise the error handling in the controllers.
+type FailingStore struct {
+	repository.Store
+	Err     error
+	Methods []string
+}
+
+var _ repository.Store = (*FailingStore)(nil)
+
+// Operations used by the auth middleware; these only fail when they're named
+var authOperations = []string{"FindTokenVersion", "IsActiveSession", "IsVerifiedRoute", "IsMfaRequiredRole"}
+
+// NewFailingStore creates a FailingStore backed by a new in-memory store that contains the fixture users
+func NewFailingStore(err error, methods ...string) *FailingStore {
+	return &FailingStore{
+		Store:   fixtures.NewStore(),
+		Err:     err,
+		Methods: methods,
+	}
+}
+
+func (s *FailingStore) fails(method string) bool {
+	if len(s.Methods) == 0 {
+		return !slices.Contains(authOperations, method)
+	}
+	return slices.Contains(s.Methods, method)
+}
+
+func (s *FailingStore) CreateUsers(c context.Context, users *[]models.User) error {
+	if s.fails("CreateUsers") {
+		return s.Err
+	}
+	return s.Store.CreateUsers(c, users)
+}
+
+func (s *FailingStore) CountUsersByEmail(c context.Context, email string) (int64, error) {
+	if s.fails("CountUsersByEmail") {
+		return 0, s.Err
+	}
+	return s.Store.CountUsersByEmail(c, email)
+}
+
+func (s *FailingStore) FindUsers(c context.Context, ids []uuid.UUID) ([]models.User, error) {
+	if s.fails("FindUsers") {
+		return nil, s.Err
+	}
+	return s.Store.FindUsers(c, ids)
+}
+
+func (s *FailingStore) FindUser(c context.Context, u *models.User) error {
+	if s.fails("FindUser") {
+		return s.Err
+	}
+	return s.Store.FindUser(c, u)
+}
+
+func (s *FailingStore) UpdateUser(c context.Context, u *models.User) error {
+	if s.fails("UpdateUser") {
+		return s.Err
+	}
+	return s.Store.UpdateUser(c, u)
+}
+
+func (s *FailingStore) SetIsGoing(c context.Context, u *models.User) error {
+	if s.fails("SetIsGoing") {
+		return s.Err
+	}
+	return s.Store.SetIsGoing(c, u)
+}
+
+func (s *FailingStore) DeleteUser(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteUser(c, id)
+}
+
+func (s *FailingStore) FindTokenVersion(c context.Context, id uuid.UUID) (int, error) {
+	if s.fails("FindTokenVersion") {
+		return 0, s.Err
+	}
+	return s.Store.FindTokenVersion(c, id)
+}
+
+func (s *FailingStore) ChangeUserRole(c context.Context, change *models.RoleChange) error {
+	if s.fails("ChangeUserRole") {
+		return s.Err
+	}
+	return s.Store.ChangeUserRole(c, change)
+}
+
+func (s *FailingStore) FindRoleChanges(c context.Context, userId uuid.UUID) ([]models.RoleChange, error) {
+	if s.fails("FindRoleChanges") {
+		return nil, s.Err
+	}
+	return s.Store.FindRoleChanges(c, userId)
+}
+
+func (s *FailingStore) ChangePassword(c context.Context, id uuid.UUID, passwordHash string) error {
+	if s.fails("ChangePassword") {
+		return s.Err
+	}
+	return s.Store.ChangePassword(c, id, passwordHash)
+}
+
+func (s *FailingStore) CreateEmailChange(c context.Context, change *models.EmailChange) error {
+	if s.fails("CreateEmailChange") {
+		return s.Err
+	}
+	return s.Store.CreateEmailChange(c, change)
+}
+
+func (s *FailingStore) FindEmailChange(c context.Context, tokenHash string) (*models.EmailChange, error) {
+	if s.fails("FindEmailChange") {
+		return nil, s.Err
+	}
+	return s.Store.FindEmailChange(c, tokenHash)
+}
+
+func (s *FailingStore) ConfirmEmailChange(c context.Context, change *models.EmailChange) error {
+	if s.fails("ConfirmEmailChange") {
+		return s.Err
+	}
+	return s.Store.ConfirmEmailChange(c, change)
+}
+
+func (s *FailingStore) CreateEmailVerification(c context.Context, verification *models.EmailVerification) error {
+	if s.fails("CreateEmailVerification") {
+		return s.Err
+	}
+	return s.Store.CreateEmailVerification(c, verification)
+}
+
+func (s *FailingStore) FindEmailVerification(c context.Context, tokenHash string) (*models.EmailVerification, error) {
+	if s.fails("FindEmailVerification") {
+		return nil, s.Err
+	}
+	return s.Store.FindEmailVerification(c, tokenHash)
+}
+
+func (s *FailingStore) VerifyEmail(c context.Context, verification *models.EmailVerification, verifiedAt time.Time) error {
+	if s.fails("VerifyEmail") {
+		return s.Err
+	}
+	return s.Store.VerifyEmail(c, verification, verifiedAt)
+}
+
+func (s *FailingStore) CreateUserInvitee(c context.Context, invitee *models.UserInvitee) error {
+	if s.fails("CreateUserInvitee") {
+		return s.Err
+	}
+	return s.Store.CreateUserInvitee(c, invitee)
+}
+
+func (s *FailingStore) FindInviteesForUser(c context.Context, inviterId uuid.UUID) ([]models.UserInvitee, error) {
+	if s.fails("FindInviteesForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindInviteesForUser(c, inviterId)
+}
+
+func (s *FailingStore) FindInviteeById(c context.Context, id uuid.UUID) (*models.UserInvitee, error) {
+	if s.fails("FindInviteeById") {
+		return nil, s.Err
+	}
+	return s.Store.FindInviteeById(c, id)
+}
+
+func (s *FailingStore) UpdateInviteeForUser(c context.Context, invitee *models.UserInvitee, inviterId uuid.UUID) error {
+	if s.fails("UpdateInviteeForUser") {
+		return s.Err
+	}
+	return s.Store.UpdateInviteeForUser(c, invitee, inviterId)
+}
+
+func (s *FailingStore) DeleteInviteeForUser(c context.Context, inviteeId uuid.UUID, inviterId uuid.UUID) (int64, error) {
+	if s.fails("DeleteInviteeForUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteInviteeForUser(c, inviteeId, inviterId)
+}
+
+func (s *FailingStore) DeleteInvitee(c context.Context, inviteeId uuid.UUID) (int64, error) {
+	if s.fails("DeleteInvitee") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteInvitee(c, inviteeId)
+}
+
+func (s *FailingStore) FindEntrees(c context.Context) ([]models.Entree, error) {
+	if s.fails("FindEntrees") {
+		return nil, s.Err
+	}
+	return s.Store.FindEntrees(c)
+}
+
+func (s *FailingStore) FindEntreeById(c context.Context, id uuid.UUID) (*models.Entree, error) {
+	if s.fails("FindEntreeById") {
+		return nil, s.Err
+	}
+	return s.Store.FindEntreeById(c, id)
+}
+
+func (s *FailingStore) FindEntreesForUser(c context.Context, userId uuid.UUID) ([]models.Entree, error) {
+	if s.fails("FindEntreesForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindEntreesForUser(c, userId)
+}
+
+func (s *FailingStore) CreateEntrees(c context.Context, entrees *[]models.Entree) error {
+	if s.fails("CreateEntrees") {
+		return s.Err
+	}
+	return s.Store.CreateEntrees(c, entrees)
+}
+
+func (s *FailingStore) DeleteEntree(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteEntree") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteEntree(c, id)
+}
+
+func (s *FailingStore) FindHorsDoeuvres(c context.Context) ([]models.HorsDoeuvres, error) {
+	if s.fails("FindHorsDoeuvres") {
+		return nil, s.Err
+	}
+	return s.Store.FindHorsDoeuvres(c)
+}
+
+func (s *FailingStore) FindHorsDoeuvresById(c context.Context, id uuid.UUID) (*models.HorsDoeuvres, error) {
+	if s.fails("FindHorsDoeuvresById") {
+		return nil, s.Err
+	}
+	return s.Store.FindHorsDoeuvresById(c, id)
+}
+
+func (s *FailingStore) FindHorsDoeuvresForUser(c context.Context, userId uuid.UUID) ([]models.HorsDoeuvres, error) {
+	if s.fails("FindHorsDoeuvresForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindHorsDoeuvresForUser(c, userId)
+}
+
+func (s *FailingStore) CreateHorsDoeuvres(c context.Context, horsDoeuvres *[]models.HorsDoeuvres) error {
+	if s.fails("CreateHorsDoeuvres") {
+		return s.Err
+	}
+	return s.Store.CreateHorsDoeuvres(c, horsDoeuvres)
+}
+
+func (s *FailingStore) DeleteHorsDoeuvres(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteHorsDoeuvres") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteHorsDoeuvres(c, id)
+}
+
+func (s *FailingStore) FindLoginThrottle(c context.Context, kind string, key string) (*models.LoginThrottle, error) {
+	if s.fails("FindLoginThrottle") {
+		return nil, s.Err
+	}
+	return s.Store.FindLoginThrottle(c, kind, key)
+}
+
+func (s *FailingStore) FindLoginThrottles(c context.Context, kind string) ([]models.LoginThrottle, error) {
+	if s.fails("FindLoginThrottles") {
+		return nil, s.Err
+	}
+	return s.Store.FindLoginThrottles(c, kind)
+}
+
+func (s *FailingStore) RecordLoginFailure(c context.Context, kind string, key string, now time.Time, staleBefore time.Time) (*models.LoginThrottle, error) {
+	if s.fails("RecordLoginFailure") {
+		return nil, s.Err
+	}
+	return s.Store.RecordLoginFailure(c, kind, key, now, staleBefore)
+}
+
+func (s *FailingStore) ResetLoginThrottle(c context.Context, kind string, key string) error {
+	if s.fails("ResetLoginThrottle") {
+		return s.Err
+	}
+	return s.Store.ResetLoginThrottle(c, kind, key)
+}
+
+func (s *FailingStore) FindVerifiedRoutes(c context.Context) ([]models.VerifiedRoute, error) {
+	if s.fails("FindVerifiedRoutes") {
+		return nil, s.Err
+	}
+	return s.Store.FindVerifiedRoutes(c)
+}
+
+func (s *FailingStore) IsVerifiedRoute(c context.Context, method string, path string) (bool, error) {
+	if s.fails("IsVerifiedRoute") {
+		return false, s.Err
+	}
+	return s.Store.IsVerifiedRoute(c, method, path)
+}
+
+func (s *FailingStore) ReplaceVerifiedRoutes(c context.Context, routes *[]models.VerifiedRoute) error {
+	if s.fails("ReplaceVerifiedRoutes") {
+		return s.Err
+	}
+	return s.Store.ReplaceVerifiedRoutes(c, routes)
+}
+
+func (s *FailingStore) CreateMagicLink(c context.Context, link *models.MagicLink) error {
+	if s.fails("CreateMagicLink") {
+		return s.Err
+	}
+	return s.Store.CreateMagicLink(c, link)
+}
+
+func (s *FailingStore) ConsumeMagicLink(c context.Context, tokenHash string) (*models.MagicLink, error) {
+	if s.fails("ConsumeMagicLink") {
+		return nil, s.Err
+	}
+	return s.Store.ConsumeMagicLink(c, tokenHash)
+}
+
+func (s *FailingStore) FindMagicLinkRoles(c context.Context) ([]string, error) {
+	if s.fails("FindMagicLinkRoles") {
+		return nil, s.Err
+	}
+	return s.Store.FindMagicLinkRoles(c)
+}
+
+func (s *FailingStore) IsMagicLinkRole(c context.Context, role string) (bool, error) {
+	if s.fails("IsMagicLinkRole") {
+		return false, s.Err
+	}
+	return s.Store.IsMagicLinkRole(c, role)
+}
+
+func (s *FailingStore) ReplaceMagicLinkRoles(c context.Context, roles []string) error {
+	if s.fails("ReplaceMagicLinkRoles") {
+		return s.Err
+	}
+	return s.Store.ReplaceMagicLinkRoles(c, roles)
+}
+
+func (s *FailingStore) FindMfaEnrollment(c context.Context, userId uuid.UUID) (*models.MfaEnrollment, error) {
+	if s.fails("FindMfaEnrollment") {
+		return nil, s.Err
+	}
+	return s.Store.FindMfaEnrollment(c, userId)
+}
+
+func (s *FailingStore) CreateMfaEnrollment(c context.Context, enrollment *models.MfaEnrollment) error {
+	if s.fails("CreateMfaEnrollment") {
+		return s.Err
+	}
+	return s.Store.CreateMfaEnrollment(c, enrollment)
+}
+
+func (s *FailingStore) ConfirmMfaEnrollment(c context.Context, userId uuid.UUID, confirmedAt time.Time, step int64, codeHashes []string) error {
+	if s.fails("ConfirmMfaEnrollment") {
+		return s.Err
+	}
+	return s.Store.ConfirmMfaEnrollment(c, userId, confirmedAt, step, codeHashes)
+}
+
+func (s *FailingStore) UseMfaStep(c context.Context, userId uuid.UUID, step int64) (bool, error) {
+	if s.fails("UseMfaStep") {
+		return false, s.Err
+	}
+	return s.Store.UseMfaStep(c, userId, step)
+}
+
+func (s *FailingStore) UseRecoveryCode(c context.Context, userId uuid.UUID, codeHash string) (bool, error) {
+	if s.fails("UseRecoveryCode") {
+		return false, s.Err
+	}
+	return s.Store.UseRecoveryCode(c, userId, codeHash)
+}
+
+func (s *FailingStore) CountRecoveryCodes(c context.Context, userId uuid.UUID) (int64, error) {
+	if s.fails("CountRecoveryCodes") {
+		return 0, s.Err
+	}
+	return s.Store.CountRecoveryCodes(c, userId)
+}
+
+func (s *FailingStore) ReplaceRecoveryCodes(c context.Context, userId uuid.UUID, codeHashes []string) error {
+	if s.fails("ReplaceRecoveryCodes") {
+		return s.Err
+	}
+	return s.Store.ReplaceRecoveryCodes(c, userId, codeHashes)
+}
+
+func (s *FailingStore) DeleteMfaEnrollment(c context.Context, userId uuid.UUID) error {
+	if s.fails("DeleteMfaEnrollment") {
+		return s.Err
+	}
+	return s.Store.DeleteMfaEnrollment(c, userId)
+}
+
+func (s *FailingStore) CreateMfaChallenge(c context.Context, challenge *models.MfaChallenge) error {
+	if s.fails("CreateMfaChallenge") {
+		return s.Err
+	}
+	return s.Store.CreateMfaChallenge(c, challenge)
+}
+
+func (s *FailingStore) FindMfaChallenge(c context.Context, tokenHash string) (*models.MfaChallenge, error) {
+	if s.fails("FindMfaChallenge") {
+		return nil, s.Err
+	}
+	return s.Store.FindMfaChallenge(c, tokenHash)
+}
+
+func (s *FailingStore) CountMfaChallengeAttempt(c context.Context, challenge *models.MfaChallenge) error {
+	if s.fails("CountMfaChallengeAttempt") {
+		return s.Err
+	}
+	return s.Store.CountMfaChallengeAttempt(c, challenge)
+}
+
+func (s *FailingStore) DeleteMfaChallenge(c context.Context, id uuid.UUID) error {
+	if s.fails("DeleteMfaChallenge") {
+		return s.Err
+	}
+	return s.Store.DeleteMfaChallenge(c, id)
+}
+
+func (s *FailingStore) FindMfaRequiredRoles(c context.Context) ([]string, error) {
+	if s.fails("FindMfaRequiredRoles") {
+		return nil, s.Err
+	}
+	return s.Store.FindMfaRequiredRoles(c)
+}
+
+func (s *FailingStore) IsMfaRequiredRole(c context.Context, role string) (bool, error) {
+	if s.fails("IsMfaRequiredRole") {
+		return false, s.Err
+	}
+	return s.Store.IsMfaRequiredRole(c, role)
+}
+
+func (s *FailingStore) ReplaceMfaRequiredRoles(c context.Context, roles []string) error {
+	if s.fails("ReplaceMfaRequiredRoles") {
+		return s.Err
+	}
+	return s.Store.ReplaceMfaRequiredRoles(c, roles)
+}
+
+func (s *FailingStore) CreateSession(c context.Context, session *models.Session) error {
+	if s.fails("CreateSession") {
+		return s.Err
+	}
+	return s.Store.CreateSession(c, session)
+}
+
+func (s *FailingStore) FindSession(c context.Context, refreshTokenHash string) (*models.Session, error) {
+	if s.fails("FindSession") {
+		return nil, s.Err
+	}
+	return s.Store.FindSession(c, refreshTokenHash)
+}
+
+func (s *FailingStore) IsActiveSession(c context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	if s.fails("IsActiveSession") {
+		return false, s.Err
+	}
+	return s.Store.IsActiveSession(c, id, now)
+}
+
+func (s *FailingStore) FindSessionsForUser(c context.Context, userId uuid.UUID, tokenVersion int, now time.Time) ([]models.Session, error) {
+	if s.fails("FindSessionsForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindSessionsForUser(c, userId, tokenVersion, now)
+}
+
+func (s *FailingStore) RefreshSession(c context.Context, session *models.Session, previousHash string) (bool, error) {
+	if s.fails("RefreshSession") {
+		return false, s.Err
+	}
+	return s.Store.RefreshSession(c, session, previousHash)
+}
+
+func (s *FailingStore) DeleteSessionForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	if s.fails("DeleteSessionForUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteSessionForUser(c, id, userId)
+}
+
+func (s *FailingStore) CreateEvent(c context.Context, event *models.Event) error {
+	if s.fails("CreateEvent") {
+		return s.Err
+	}
+	return s.Store.CreateEvent(c, event)
+}
+
+func (s *FailingStore) FindEvents(c context.Context) ([]models.Event, error) {
+	if s.fails("FindEvents") {
+		return nil, s.Err
+	}
+	return s.Store.FindEvents(c)
+}
+
+func (s *FailingStore) FindEventById(c context.Context, id uuid.UUID) (*models.Event, error) {
+	if s.fails("FindEventById") {
+		return nil, s.Err
+	}
+	return s.Store.FindEventById(c, id)
+}
+
+func (s *FailingStore) UpdateEvent(c context.Context, event *models.Event) error {
+	if s.fails("UpdateEvent") {
+		return s.Err
+	}
+	return s.Store.UpdateEvent(c, event)
+}
+
+func (s *FailingStore) DeleteEvent(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteEvent") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteEvent(c, id)
+}
+
+func (s *FailingStore) FindEventGuests(c context.Context, eventId uuid.UUID) ([]uuid.UUID, error) {
+	if s.fails("FindEventGuests") {
+		return nil, s.Err
+	}
+	return s.Store.FindEventGuests(c, eventId)
+}
+
+func (s *FailingStore) ReplaceEventGuests(c context.Context, eventId uuid.UUID, userIds []uuid.UUID) error {
+	if s.fails("ReplaceEventGuests") {
+		return s.Err
+	}
+	return s.Store.ReplaceEventGuests(c, eventId, userIds)
+}
+
+func (s *FailingStore) FindEventsForUser(c context.Context, userId uuid.UUID) ([]models.Event, error) {
+	if s.fails("FindEventsForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindEventsForUser(c, userId)
+}
+
+func (s *FailingStore) FindEventRsvpsForUser(c context.Context, userId uuid.UUID) ([]models.EventRsvp, error) {
+	if s.fails("FindEventRsvpsForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindEventRsvpsForUser(c, userId)
+}
+
+func (s *FailingStore) SaveEventRsvp(c context.Context, rsvp *models.EventRsvp) error {
+	if s.fails("SaveEventRsvp") {
+		return s.Err
+	}
+	return s.Store.SaveEventRsvp(c, rsvp)
+}
+
+func (s *FailingStore) FindEventHeadcounts(c context.Context) ([]models.EventHeadcount, error) {
+	if s.fails("FindEventHeadcounts") {
+		return nil, s.Err
+	}
+	return s.Store.FindEventHeadcounts(c)
+}
+
+func (s *FailingStore) CreateCalendarFeed(c context.Context, feed *models.CalendarFeed) error {
+	if s.fails("CreateCalendarFeed") {
+		return s.Err
+	}
+	return s.Store.CreateCalendarFeed(c, feed)
+}
+
+func (s *FailingStore) FindCalendarFeed(c context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	if s.fails("FindCalendarFeed") {
+		return nil, s.Err
+	}
+	return s.Store.FindCalendarFeed(c, tokenHash)
+}
+
+func (s *FailingStore) DeleteCalendarFeedForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	if s.fails("DeleteCalendarFeedForUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteCalendarFeedForUser(c, userId)
+}
+
+func (s *FailingStore) CreateVenue(c context.Context, venue *models.Venue) error {
+	if s.fails("CreateVenue") {
+		return s.Err
+	}
+	return s.Store.CreateVenue(c, venue)
+}
+
+func (s *FailingStore) FindVenues(c context.Context) ([]models.Venue, error) {
+	if s.fails("FindVenues") {
+		return nil, s.Err
+	}
+	return s.Store.FindVenues(c)
+}
+
+func (s *FailingStore) UpdateVenue(c context.Context, venue *models.Venue) error {
+	if s.fails("UpdateVenue") {
+		return s.Err
+	}
+	return s.Store.UpdateVenue(c, venue)
+}
+
+func (s *FailingStore) DeleteVenue(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteVenue") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteVenue(c, id)
+}
+
+func (s *FailingStore) CreateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	if s.fails("CreateAccommodation") {
+		return s.Err
+	}
+	return s.Store.CreateAccommodation(c, accommodation)
+}
+
+func (s *FailingStore) FindAccommodations(c context.Context) ([]models.Accommodation, error) {
+	if s.fails("FindAccommodations") {
+		return nil, s.Err
+	}
+	return s.Store.FindAccommodations(c)
+}
+
+func (s *FailingStore) UpdateAccommodation(c context.Context, accommodation *models.Accommodation) error {
+	if s.fails("UpdateAccommodation") {
+		return s.Err
+	}
+	return s.Store.UpdateAccommodation(c, accommodation)
+}
+
+func (s *FailingStore) DeleteAccommodation(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteAccommodation") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteAccommodation(c, id)
+}
+
+func (s *FailingStore) FindAccommodationById(c context.Context, id uuid.UUID) (*models.Accommodation, error) {
+	if s.fails("FindAccommodationById") {
+		return nil, s.Err
+	}
+	return s.Store.FindAccommodationById(c, id)
+}
+
+func (s *FailingStore) SaveGuestStay(c context.Context, stay *models.GuestStay) error {
+	if s.fails("SaveGuestStay") {
+		return s.Err
+	}
+	return s.Store.SaveGuestStay(c, stay)
+}
+
+func (s *FailingStore) FindGuestStayForUser(c context.Context, userId uuid.UUID) (*models.GuestStay, error) {
+	if s.fails("FindGuestStayForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestStayForUser(c, userId)
+}
+
+func (s *FailingStore) DeleteGuestStayForUser(c context.Context, userId uuid.UUID) (int64, error) {
+	if s.fails("DeleteGuestStayForUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteGuestStayForUser(c, userId)
+}
+
+func (s *FailingStore) FindGuestStays(c context.Context) ([]models.GuestStay, error) {
+	if s.fails("FindGuestStays") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestStays(c)
+}
+
+func (s *FailingStore) FindRoomBlockUtilization(c context.Context) ([]models.RoomBlockUtilization, error) {
+	if s.fails("FindRoomBlockUtilization") {
+		return nil, s.Err
+	}
+	return s.Store.FindRoomBlockUtilization(c)
+}
+
+func (s *FailingStore) CreateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	if s.fails("CreateShuttleRun") {
+		return s.Err
+	}
+	return s.Store.CreateShuttleRun(c, run)
+}
+
+func (s *FailingStore) FindShuttleRuns(c context.Context) ([]models.ShuttleRun, error) {
+	if s.fails("FindShuttleRuns") {
+		return nil, s.Err
+	}
+	return s.Store.FindShuttleRuns(c)
+}
+
+func (s *FailingStore) FindShuttleRunById(c context.Context, id uuid.UUID) (*models.ShuttleRun, error) {
+	if s.fails("FindShuttleRunById") {
+		return nil, s.Err
+	}
+	return s.Store.FindShuttleRunById(c, id)
+}
+
+func (s *FailingStore) UpdateShuttleRun(c context.Context, run *models.ShuttleRun) error {
+	if s.fails("UpdateShuttleRun") {
+		return s.Err
+	}
+	return s.Store.UpdateShuttleRun(c, run)
+}
+
+func (s *FailingStore) DeleteShuttleRun(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteShuttleRun") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteShuttleRun(c, id)
+}
+
+func (s *FailingStore) ReplaceShuttleRidersForUser(c context.Context, runId uuid.UUID, userId uuid.UUID, riderIds []uuid.UUID) error {
+	if s.fails("ReplaceShuttleRidersForUser") {
+		return s.Err
+	}
+	return s.Store.ReplaceShuttleRidersForUser(c, runId, userId, riderIds)
+}
+
+func (s *FailingStore) FindShuttleRidersForUser(c context.Context, userId uuid.UUID) ([]models.ShuttleRider, error) {
+	if s.fails("FindShuttleRidersForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindShuttleRidersForUser(c, userId)
+}
+
+func (s *FailingStore) FindShuttleHeadcounts(c context.Context) ([]models.ShuttleHeadcount, error) {
+	if s.fails("FindShuttleHeadcounts") {
+		return nil, s.Err
+	}
+	return s.Store.FindShuttleHeadcounts(c)
+}
+
+func (s *FailingStore) FindShuttleManifest(c context.Context, runId uuid.UUID) ([]models.ShuttleManifestEntry, error) {
+	if s.fails("FindShuttleManifest") {
+		return nil, s.Err
+	}
+	return s.Store.FindShuttleManifest(c, runId)
+}
+
+func (s *FailingStore) CreateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	if s.fails("CreateRegistryItem") {
+		return s.Err
+	}
+	return s.Store.CreateRegistryItem(c, item)
+}
+
+func (s *FailingStore) FindRegistryItems(c context.Context) ([]models.RegistryItem, error) {
+	if s.fails("FindRegistryItems") {
+		return nil, s.Err
+	}
+	return s.Store.FindRegistryItems(c)
+}
+
+func (s *FailingStore) UpdateRegistryItem(c context.Context, item *models.RegistryItem) error {
+	if s.fails("UpdateRegistryItem") {
+		return s.Err
+	}
+	return s.Store.UpdateRegistryItem(c, item)
+}
+
+func (s *FailingStore) DeleteRegistryItem(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteRegistryItem") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteRegistryItem(c, id)
+}
+
+func (s *FailingStore) SaveRegistryClaim(c context.Context, claim *models.RegistryClaim) error {
+	if s.fails("SaveRegistryClaim") {
+		return s.Err
+	}
+	return s.Store.SaveRegistryClaim(c, claim)
+}
+
+func (s *FailingStore) FindRegistryClaimsForUser(c context.Context, userId uuid.UUID) ([]models.RegistryClaim, error) {
+	if s.fails("FindRegistryClaimsForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindRegistryClaimsForUser(c, userId)
+}
+
+func (s *FailingStore) FindRegistryClaimTotals(c context.Context) ([]models.RegistryClaimTotal, error) {
+	if s.fails("FindRegistryClaimTotals") {
+		return nil, s.Err
+	}
+	return s.Store.FindRegistryClaimTotals(c)
+}
+
+func (s *FailingStore) FindRegistryClaimReport(c context.Context) ([]models.RegistryClaimReportEntry, error) {
+	if s.fails("FindRegistryClaimReport") {
+		return nil, s.Err
+	}
+	return s.Store.FindRegistryClaimReport(c)
+}
+
+func (s *FailingStore) CreateGift(c context.Context, gift *models.Gift) error {
+	if s.fails("CreateGift") {
+		return s.Err
+	}
+	return s.Store.CreateGift(c, gift)
+}
+
+func (s *FailingStore) FindGifts(c context.Context) ([]models.Gift, error) {
+	if s.fails("FindGifts") {
+		return nil, s.Err
+	}
+	return s.Store.FindGifts(c)
+}
+
+func (s *FailingStore) FindGiftById(c context.Context, id uuid.UUID) (*models.Gift, error) {
+	if s.fails("FindGiftById") {
+		return nil, s.Err
+	}
+	return s.Store.FindGiftById(c, id)
+}
+
+func (s *FailingStore) UpdateGift(c context.Context, gift *models.Gift) error {
+	if s.fails("UpdateGift") {
+		return s.Err
+	}
+	return s.Store.UpdateGift(c, gift)
+}
+
+func (s *FailingStore) UpdateGiftThankYou(c context.Context, id uuid.UUID, status string, sentAt *time.Time) error {
+	if s.fails("UpdateGiftThankYou") {
+		return s.Err
+	}
+	return s.Store.UpdateGiftThankYou(c, id, status, sentAt)
+}
+
+func (s *FailingStore) DeleteGift(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteGift") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteGift(c, id)
+}
+
+func (s *FailingStore) ImportRegistryGifts(c context.Context) ([]models.Gift, error) {
+	if s.fails("ImportRegistryGifts") {
+		return nil, s.Err
+	}
+	return s.Store.ImportRegistryGifts(c)
+}
+
+func (s *FailingStore) FindThankYouQueue(c context.Context) ([]models.ThankYouQueueEntry, error) {
+	if s.fails("FindThankYouQueue") {
+		return nil, s.Err
+	}
+	return s.Store.FindThankYouQueue(c)
+}
+
+func (s *FailingStore) CreateHousehold(c context.Context, household *models.Household) error {
+	if s.fails("CreateHousehold") {
+		return s.Err
+	}
+	return s.Store.CreateHousehold(c, household)
+}
+
+func (s *FailingStore) FindHouseholds(c context.Context) ([]models.Household, error) {
+	if s.fails("FindHouseholds") {
+		return nil, s.Err
+	}
+	return s.Store.FindHouseholds(c)
+}
+
+func (s *FailingStore) FindHouseholdById(c context.Context, id uuid.UUID) (*models.Household, error) {
+	if s.fails("FindHouseholdById") {
+		return nil, s.Err
+	}
+	return s.Store.FindHouseholdById(c, id)
+}
+
+func (s *FailingStore) UpdateHousehold(c context.Context, household *models.Household) error {
+	if s.fails("UpdateHousehold") {
+		return s.Err
+	}
+	return s.Store.UpdateHousehold(c, household)
+}
+
+func (s *FailingStore) UpdateHouseholdMailings(c context.Context, household *models.Household) error {
+	if s.fails("UpdateHouseholdMailings") {
+		return s.Err
+	}
+	return s.Store.UpdateHouseholdMailings(c, household)
+}
+
+func (s *FailingStore) DeleteHousehold(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteHousehold") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteHousehold(c, id)
+}
+
+func (s *FailingStore) ReplaceHouseholdMembers(c context.Context, householdId uuid.UUID, userIds []uuid.UUID, inviteeIds []uuid.UUID) error {
+	if s.fails("ReplaceHouseholdMembers") {
+		return s.Err
+	}
+	return s.Store.ReplaceHouseholdMembers(c, householdId, userIds, inviteeIds)
+}
+
+func (s *FailingStore) FindHouseholdMembers(c context.Context) ([]models.HouseholdMember, error) {
+	if s.fails("FindHouseholdMembers") {
+		return nil, s.Err
+	}
+	return s.Store.FindHouseholdMembers(c)
+}
+
+func (s *FailingStore) CreateSongRequest(c context.Context, song *models.SongRequest) error {
+	if s.fails("CreateSongRequest") {
+		return s.Err
+	}
+	return s.Store.CreateSongRequest(c, song)
+}
+
+func (s *FailingStore) FindSongRequests(c context.Context) ([]models.SongRequest, error) {
+	if s.fails("FindSongRequests") {
+		return nil, s.Err
+	}
+	return s.Store.FindSongRequests(c)
+}
+
+func (s *FailingStore) FindSongRequestById(c context.Context, id uuid.UUID) (*models.SongRequest, error) {
+	if s.fails("FindSongRequestById") {
+		return nil, s.Err
+	}
+	return s.Store.FindSongRequestById(c, id)
+}
+
+func (s *FailingStore) UpdateSongRequestStatus(c context.Context, id uuid.UUID, status string) error {
+	if s.fails("UpdateSongRequestStatus") {
+		return s.Err
+	}
+	return s.Store.UpdateSongRequestStatus(c, id, status)
+}
+
+func (s *FailingStore) DeleteSongRequest(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteSongRequest") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteSongRequest(c, id)
+}
+
+func (s *FailingStore) SaveSongVote(c context.Context, vote *models.SongVote) error {
+	if s.fails("SaveSongVote") {
+		return s.Err
+	}
+	return s.Store.SaveSongVote(c, vote)
+}
+
+func (s *FailingStore) DeleteSongVote(c context.Context, songRequestId uuid.UUID, userId uuid.UUID) (int64, error) {
+	if s.fails("DeleteSongVote") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteSongVote(c, songRequestId, userId)
+}
+
+func (s *FailingStore) FindSongVotesForUser(c context.Context, userId uuid.UUID) ([]models.SongVote, error) {
+	if s.fails("FindSongVotesForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindSongVotesForUser(c, userId)
+}
+
+func (s *FailingStore) FindSongVoteTotals(c context.Context) ([]models.SongVoteTotal, error) {
+	if s.fails("FindSongVoteTotals") {
+		return nil, s.Err
+	}
+	return s.Store.FindSongVoteTotals(c)
+}
+
+func (s *FailingStore) FindSongPlaylist(c context.Context) ([]models.SongPlaylistEntry, error) {
+	if s.fails("FindSongPlaylist") {
+		return nil, s.Err
+	}
+	return s.Store.FindSongPlaylist(c)
+}
+
+func (s *FailingStore) CreateGuestbookEntry(c context.Context, entry *models.GuestbookEntry) error {
+	if s.fails("CreateGuestbookEntry") {
+		return s.Err
+	}
+	return s.Store.CreateGuestbookEntry(c, entry)
+}
+
+func (s *FailingStore) FindGuestbookEntries(c context.Context) ([]models.GuestbookEntry, error) {
+	if s.fails("FindGuestbookEntries") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestbookEntries(c)
+}
+
+func (s *FailingStore) FindGuestbookEntriesForUser(c context.Context, userId uuid.UUID) ([]models.GuestbookEntry, error) {
+	if s.fails("FindGuestbookEntriesForUser") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestbookEntriesForUser(c, userId)
+}
+
+func (s *FailingStore) FindGuestbookEntryById(c context.Context, id uuid.UUID) (*models.GuestbookEntry, error) {
+	if s.fails("FindGuestbookEntryById") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestbookEntryById(c, id)
+}
+
+func (s *FailingStore) FindPublicGuestbookEntries(c context.Context, limit int, offset int) ([]models.PublicGuestbookEntry, int64, error) {
+	if s.fails("FindPublicGuestbookEntries") {
+		return nil, 0, s.Err
+	}
+	return s.Store.FindPublicGuestbookEntries(c, limit, offset)
+}
+
+func (s *FailingStore) UpdateGuestbookEntryStatus(c context.Context, id uuid.UUID, status string) error {
+	if s.fails("UpdateGuestbookEntryStatus") {
+		return s.Err
+	}
+	return s.Store.UpdateGuestbookEntryStatus(c, id, status)
+}
+
+func (s *FailingStore) DeleteGuestbookEntry(c context.Context, id uuid.UUID) (int64, error) {
+	if s.fails("DeleteGuestbookEntry") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteGuestbookEntry(c, id)
+}
+
+func (s *FailingStore) DeleteGuestbookEntryForUser(c context.Context, id uuid.UUID, userId uuid.UUID) (int64, error) {
+	if s.fails("DeleteGuestbookEntryForUser") {
+		return 0, s.Err
+	}
+	return s.Store.DeleteGuestbookEntryForUser(c, id, userId)
+}
+
+func (s *FailingStore) FindGuestbookBlockedWords(c context.Context) ([]string, error) {
+	if s.fails("FindGuestbookBlockedWords") {
+		return nil, s.Err
+	}
+	return s.Store.FindGuestbookBlockedWords(c)
+}
+
+func (s *FailingStore) ReplaceGuestbookBlockedWords(c context.Context, words []string) error {
+	if s.fails("ReplaceGuestbookBlockedWords") {
+		return s.Err
+	}
+	return s.Store.ReplaceGuestbookBlockedWords(c, words)
+}
@@ -7,7 +7,8 @@ EXPECTED_KEYS=(
     "PGSQL_DBNAME"
     "PGSQL_PORT"
     "PGSQL_TIMEZONE"
-    "JWT_SECRET_KEY"
+    "JWT_KEYS_DIR"
+    "APP_URL"
     "PORT"
 )
 
@@ -40,9 +41,22 @@ do
         elif [ ${key} = "PGSQL_TIMEZONE" ]; then
             echo "Using \"US/Central\" as default value for \"${key}\""
             echo "${key}=US/Central" >> .env
+        elif [ ${key} = "JWT_KEYS_DIR" ]; then
+            echo "Using \"keys\" as default value for \"${key}\""
+            echo "${key}=keys" >> .env
+        elif [ ${key} = "APP_URL" ]; then
+            echo "Using \"http://localhost:3000\" as default value for \"${key}\""
+            echo "${key}=http://localhost:3000" >> .env
         elif [ ${key} = "PORT" ]; then
             echo "Using \"US/Central\" as default value for \"${key}\""
             echo "${key}=8080" >> .env
         fi
     fi
 done
+
+# Creates a key to sign tokens with if there isn't one yet (the file name is the key's ID)
+if ! ls keys/*.pem > /dev/null 2>&1; then
+    echo "No signing key found - creating keys/local.pem"
+    mkdir -p keys
+    openssl genpkey -algorithm ed25519 -out keys/local.pem
+fi
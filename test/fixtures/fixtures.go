@@ -0,0 +1,106 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ax-vasquez/wedding-site-api/helper"
+	"github.com/ax-vasquez/wedding-site-api/models"
+	"github.com/ax-vasquez/wedding-site-api/repository"
+	"github.com/google/uuid"
+)
+
+// Hash of models.TestUserPassword (the same hash used by the users in /test-fixtures)
+const testUserPasswordHash = "$2a$14$lxCdKEMR/01ux8O2Qs4PEOoh5vDZ6zZIwk0bbnaabizNdedECn7VG"
+
+// Guest returns the first user in the test data set ("Rupinder McNiel")
+func Guest() models.User {
+	return models.User{
+		BaseModel: models.BaseModel{
+			ID: uuid.MustParse(models.FirstUserIdStr),
+		},
+		Role:      models.RoleGuest,
+		FirstName: "Rupinder",
+		LastName:  "McNiel",
+		Email:     "user_1@fakedomain.com",
+		Password:  testUserPasswordHash,
+	}
+}
+
+// Admin returns the admin user in the test data set ("Turtle Cat")
+func Admin() models.User {
+	return models.User{
+		BaseModel: models.BaseModel{
+			ID: uuid.MustParse(models.FirstAdminIdStr),
+		},
+		Role:      models.RoleAdmin,
+		FirstName: "Turtle",
+		LastName:  "Cat",
+		Email:     "admin@admin.admin",
+		Password:  testUserPasswordHash,
+	}
+}
+
+// Planner returns a wedding planner (not part of the test data set)
+func Planner() models.User {
+	return models.User{
+		BaseModel: models.BaseModel{
+			ID: uuid.MustParse("7a0b9a3e-5f2c-4a53-9d0e-2c1b6c7f4e18"),
+		},
+		Role:      models.RolePlanner,
+		FirstName: "Pat",
+		LastName:  "Planner",
+		Email:     "planner@fakedomain.com",
+		Password:  testUserPasswordHash,
+	}
+}
+
+// Seed adds the fixture users to the given repository; they can log in using models.TestUserPassword
+func Seed(c context.Context, users repository.UserRepository) error {
+	fixtureUsers := []models.User{Guest(), Admin(), Planner()}
+	return users.CreateUsers(c, &fixtureUsers)
+}
+
+// NewStore creates an in-memory store that contains the fixture users
+//
+// Requests authenticated with Token are rejected unless the user exists in the handler's store.
+func NewStore() *repository.MemoryStore {
+	store := repository.NewMemoryStore()
+	Seed(context.Background(), store)
+	return store
+}
+
+// Token mints a real signed auth token for the given user (use it as the "Authorization: Bearer" or "auth-token" header)
+//
+// The user doesn't need to exist in any store; the token is only signed with the user's details. The token doesn't
+// belong to a session, so it keeps working regardless of the user's sessions.
+func Token(t testing.TB, u models.User) string {
+	t.Helper()
+	token, _, err := helper.GenerateAllTokens(u.Email, u.FirstName, u.LastName, u.Role, u.ID, u.TokenVersion, uuid.Nil)
+	if err != nil {
+		t.Fatalf("could not mint token for fixture user %s: %s", u.ID, err.Error())
+	}
+	return token
+}
+
+// Serve sends a request to the router as the given user (with a token from Token), or without a token for the zero
+// User, encoding input as the JSON body unless it's nil, and returns the recorded response
+func Serve(t testing.TB, router http.Handler, method string, path string, user models.User, input any) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	body := ""
+	if input != nil {
+		encoded, _ := json.Marshal(input)
+		body = string(encoded)
+	}
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if user.ID != uuid.Nil {
+		req.Header.Set("Authorization", "Bearer "+Token(t, user))
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/ax-vasquez/wedding-site-api/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -24,6 +26,9 @@ type V1_API_DELETE_RESPONSE struct {
 type AuthDetails struct {
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
+	// Set instead of the tokens when the user has two-factor authentication enabled; exchange it (along with a code) for
+	// the tokens at /auth/mfa/verify
+	MfaToken string `json:"mfa_token,omitempty"`
 }
 
 type V1_API_RESPONSE_AUTH struct {
@@ -35,6 +40,21 @@ type UserData struct {
 	Users []models.User `json:"users"`
 }
 
+type RefreshTokensInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionData struct {
+	Sessions []models.Session `json:"sessions"`
+	// The ID of the session making the request (null if its token doesn't belong to a session)
+	CurrentSessionId *uuid.UUID `json:"current_session_id"`
+}
+
+type V1_API_RESPONSE_SESSIONS struct {
+	V1_API_RESPONSE
+	Data SessionData `json:"data"`
+}
+
 type V1_API_RESPONSE_USERS struct {
 	V1_API_RESPONSE
 	Data UserData `json:"data"`
@@ -77,6 +97,101 @@ type V1_API_RESPONSE_VENUE struct {
 	Data    VenueData `json:"data"`
 }
 
+// Coordinates are optional, but a latitude needs a longitude (and vice versa)
+type VenueInput struct {
+	Name      string   `json:"name" binding:"required"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Notes     string   `json:"notes"`
+}
+
+type VenueListData struct {
+	Venues []models.Venue `json:"venues"`
+}
+
+type V1_API_RESPONSE_VENUES struct {
+	V1_API_RESPONSE
+	Data VenueListData `json:"data"`
+}
+
+type AccommodationInput struct {
+	VenueInput
+	CheckInDate *time.Time `json:"check_in_date"`
+	// Must be after the check-in date
+	CheckOutDate  *time.Time `json:"check_out_date"`
+	RoomBlockCode string     `json:"room_block_code"`
+	BookingUrl    string     `json:"booking_url" binding:"omitempty,url"`
+	CutoffDate    *time.Time `json:"cutoff_date"`
+	// The number of rooms in the block under contract with the hotel
+	ContractedRooms int `json:"contracted_rooms" binding:"min=0"`
+}
+
+type AccommodationData struct {
+	Accommodations []models.Accommodation `json:"accommodations"`
+}
+
+type V1_API_RESPONSE_ACCOMMODATIONS struct {
+	V1_API_RESPONSE
+	Data AccommodationData `json:"data"`
+}
+
+type VenueDetailsData struct {
+	Venues         []models.Venue         `json:"venues"`
+	Accommodations []models.Accommodation `json:"accommodations"`
+}
+
+type V1_API_RESPONSE_VENUE_DETAILS struct {
+	V1_API_RESPONSE
+	Data VenueDetailsData `json:"data"`
+}
+
+// Leave out the accommodation ID when staying somewhere else (and describe it in other_accommodation instead)
+type GuestStayInput struct {
+	AccommodationId    *uuid.UUID `json:"accommodation_id"`
+	OtherAccommodation string     `json:"other_accommodation"`
+	ArrivalDate        time.Time  `json:"arrival_date" binding:"required"`
+	DepartureDate      time.Time  `json:"departure_date" binding:"required,gtfield=ArrivalDate"`
+	// Can only be set when staying at one of the accommodations
+	UsedRoomBlock bool `json:"used_room_block"`
+}
+
+type GuestStayData struct {
+	// Null if the user hasn't said where they're staying
+	Stay *models.GuestStay `json:"stay"`
+}
+
+type V1_API_RESPONSE_GUEST_STAY struct {
+	V1_API_RESPONSE
+	Data GuestStayData `json:"data"`
+}
+
+type GuestStayListData struct {
+	Stays []models.GuestStay `json:"stays"`
+}
+
+type V1_API_RESPONSE_GUEST_STAYS struct {
+	V1_API_RESPONSE
+	Data GuestStayListData `json:"data"`
+}
+
+type RoomBlockUsage struct {
+	models.RoomBlockUtilization
+	// The share of the contracted rooms booked from the block (e.g., 0.75); is 0 if no rooms are contracted
+	Utilization float64 `json:"utilization"`
+}
+
+type RoomBlockReportData struct {
+	RoomBlocks []RoomBlockUsage `json:"room_blocks"`
+	// The number of guests who aren't staying at any of the accommodations
+	StaysElsewhere int `json:"stays_elsewhere"`
+}
+
+type V1_API_RESPONSE_ROOM_BLOCKS struct {
+	V1_API_RESPONSE
+	Data RoomBlockReportData `json:"data"`
+}
+
 type UserLoginInput struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -89,11 +204,11 @@ type UserSignupInput struct {
 	InviteCode string `json:"invite_code" binding:"required"`
 }
 
+// The email address can't be changed this way; see ChangeEmailInput
 type UpdateUserInput struct {
 	IsGoing                 bool       `json:"is_going"`
 	FirstName               string     `json:"first_name"`
 	LastName                string     `json:"last_name"`
-	Email                   string     `json:"email"`
 	HorsDoeuvresSelectionId *uuid.UUID `json:"hors_douevres_selection_id"`
 	EntreeSelectionId       *uuid.UUID `json:"entree_selection_id"`
 }
@@ -107,3 +222,478 @@ type AdminUpdateUserInput struct {
 	HorsDoeuvresSelectionId *uuid.UUID `json:"hors_douevres_selection_id"`
 	EntreeSelectionId       *uuid.UUID `json:"entree_selection_id"`
 }
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ChangeEmailInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewEmail        string `json:"new_email" binding:"required,email"`
+}
+
+type ConfirmEmailChangeInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// A route (by method and path pattern, e.g. "PATCH" and "/api/v1/user") that should require a verified email address
+type VerifiedRouteInput struct {
+	Method string `json:"method" binding:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path   string `json:"path" binding:"required,startswith=/api/v1/"`
+}
+
+type UpdateVerifiedRoutesInput struct {
+	Routes []VerifiedRouteInput `json:"routes" binding:"required,dive"`
+}
+
+type VerifiedRouteData struct {
+	Routes []models.VerifiedRoute `json:"routes"`
+}
+
+type V1_API_RESPONSE_VERIFIED_ROUTES struct {
+	V1_API_RESPONSE
+	Data VerifiedRouteData `json:"data"`
+}
+
+type MagicLinkInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConsumeMagicLinkInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type UpdateMagicLinkRolesInput struct {
+	Roles []string `json:"roles" binding:"required,dive,oneof=GUEST INVITEE PLANNER ADMIN"`
+}
+
+type MagicLinkRoleData struct {
+	Roles []string `json:"roles"`
+}
+
+type V1_API_RESPONSE_MAGIC_LINK_ROLES struct {
+	V1_API_RESPONSE
+	Data MagicLinkRoleData `json:"data"`
+}
+
+// A code from the user's authenticator app, or one of their recovery codes
+type MfaCodeInput struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+type ConfirmMfaEnrollmentInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyMfaInput struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	MfaCodeInput
+}
+
+type MfaEnrollmentData struct {
+	// The secret to enter into the authenticator app (if it can't scan the URI)
+	Secret string `json:"secret"`
+	// The otpauth:// URI to show as a QR code for the authenticator app to scan
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+type V1_API_RESPONSE_MFA_ENROLLMENT struct {
+	V1_API_RESPONSE
+	Data MfaEnrollmentData `json:"data"`
+}
+
+type RecoveryCodeData struct {
+	// Shown once; only hashes are stored
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type V1_API_RESPONSE_RECOVERY_CODES struct {
+	V1_API_RESPONSE
+	Data RecoveryCodeData `json:"data"`
+}
+
+type MfaStatusData struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type V1_API_RESPONSE_MFA_STATUS struct {
+	V1_API_RESPONSE
+	Data MfaStatusData `json:"data"`
+}
+
+type UpdateMfaRequiredRolesInput struct {
+	Roles []string `json:"roles" binding:"required,dive,oneof=GUEST INVITEE PLANNER ADMIN"`
+}
+
+type MfaRequiredRoleData struct {
+	Roles []string `json:"roles"`
+}
+
+type V1_API_RESPONSE_MFA_REQUIRED_ROLES struct {
+	V1_API_RESPONSE
+	Data MfaRequiredRoleData `json:"data"`
+}
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=GUEST INVITEE PLANNER ADMIN"`
+}
+
+type RoleChangeData struct {
+	RoleChanges []models.RoleChange `json:"role_changes"`
+}
+
+type V1_API_RESPONSE_ROLE_CHANGES struct {
+	V1_API_RESPONSE
+	Data RoleChangeData `json:"data"`
+}
+
+type LockoutData struct {
+	Lockouts []Lockout `json:"lockouts"`
+}
+
+// An account that can't log in until LockedUntil because of failed login attempts
+type Lockout struct {
+	Email         string    `json:"email"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+type V1_API_RESPONSE_LOCKOUTS struct {
+	V1_API_RESPONSE
+	Data LockoutData `json:"data"`
+}
+
+type EventInput struct {
+	Name      string    `json:"name" binding:"required"`
+	StartsAt  time.Time `json:"starts_at" binding:"required"`
+	EndsAt    time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	Location  string    `json:"location"`
+	DressCode string    `json:"dress_code"`
+	// Anything else guests should know about the event
+	Description string `json:"description"`
+	// "ALL" (the default) or "SELECTED" (only the users on the event's guest list and their invitees)
+	InviteScope string `json:"invite_scope" binding:"omitempty,oneof=ALL SELECTED"`
+}
+
+type EventData struct {
+	Events []models.Event `json:"events"`
+}
+
+type V1_API_RESPONSE_EVENTS struct {
+	V1_API_RESPONSE
+	Data EventData `json:"data"`
+}
+
+type UpdateEventGuestsInput struct {
+	UserIds []uuid.UUID `json:"user_ids" binding:"required"`
+}
+
+type EventGuestData struct {
+	UserIds []uuid.UUID `json:"user_ids"`
+}
+
+type V1_API_RESPONSE_EVENT_GUESTS struct {
+	V1_API_RESPONSE
+	Data EventGuestData `json:"data"`
+}
+
+// Leave out the invitee ID to respond for yourself
+type EventRsvpInput struct {
+	Attending *bool      `json:"attending" binding:"required"`
+	InviteeId *uuid.UUID `json:"invitee_id"`
+}
+
+// An event the user is invited to, along with the responses they've given for it
+type UserEvent struct {
+	models.Event
+	Rsvps []models.EventRsvp `json:"rsvps"`
+}
+
+type UserEventData struct {
+	Events []UserEvent `json:"events"`
+}
+
+type V1_API_RESPONSE_USER_EVENTS struct {
+	V1_API_RESPONSE
+	Data UserEventData `json:"data"`
+}
+
+type EventRsvpData struct {
+	Rsvp models.EventRsvp `json:"rsvp"`
+}
+
+type V1_API_RESPONSE_EVENT_RSVP struct {
+	V1_API_RESPONSE
+	Data EventRsvpData `json:"data"`
+}
+
+type EventHeadcountData struct {
+	Headcounts []models.EventHeadcount `json:"headcounts"`
+}
+
+type V1_API_RESPONSE_EVENT_HEADCOUNTS struct {
+	V1_API_RESPONSE
+	Data EventHeadcountData `json:"data"`
+}
+
+type CalendarFeedData struct {
+	// The link for calendar apps to subscribe to; shown once (only a hash of its token is stored)
+	Url string `json:"url"`
+}
+
+type V1_API_RESPONSE_CALENDAR_FEED struct {
+	V1_API_RESPONSE
+	Data CalendarFeedData `json:"data"`
+}
+
+type ShuttleRunInput struct {
+	Route     string    `json:"route" binding:"required"`
+	DepartsAt time.Time `json:"departs_at" binding:"required"`
+	// The number of seats; people who sign up once they're taken are waitlisted
+	Capacity int    `json:"capacity" binding:"required,min=1"`
+	Notes    string `json:"notes"`
+}
+
+// A shuttle run along with the number of people seated on and waitlisted for it
+type ShuttleRunSummary struct {
+	models.ShuttleRun
+	Seated     int64 `json:"seated"`
+	Waitlisted int64 `json:"waitlisted"`
+}
+
+type ShuttleRunData struct {
+	Runs []ShuttleRunSummary `json:"runs"`
+}
+
+type V1_API_RESPONSE_SHUTTLE_RUNS struct {
+	V1_API_RESPONSE
+	Data ShuttleRunData `json:"data"`
+}
+
+// Everyone who should ride the shuttle; leave both fields out to cancel
+type ShuttleSignupInput struct {
+	// Whether you're riding
+	Self bool `json:"self"`
+	// The IDs of your invitees who are riding
+	InviteeIds []uuid.UUID `json:"invitee_ids"`
+}
+
+type ShuttleRiderData struct {
+	Riders []models.ShuttleRider `json:"riders"`
+}
+
+type V1_API_RESPONSE_SHUTTLE_RIDERS struct {
+	V1_API_RESPONSE
+	Data ShuttleRiderData `json:"data"`
+}
+
+type RegistryItemInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Url         string `json:"url" binding:"omitempty,url"`
+	// Roughly what the gift costs, in cents
+	PriceCents      int64 `json:"price_cents" binding:"min=0"`
+	QuantityDesired int   `json:"quantity_desired" binding:"required,min=1"`
+}
+
+// A registry item along with how much of it has been claimed (but not by whom)
+type RegistryItemSummary struct {
+	models.RegistryItem
+	Claimed   int64 `json:"claimed"`
+	Remaining int64 `json:"remaining"`
+}
+
+type RegistryItemData struct {
+	Items []RegistryItemSummary `json:"items"`
+}
+
+type V1_API_RESPONSE_REGISTRY_ITEMS struct {
+	V1_API_RESPONSE
+	Data RegistryItemData `json:"data"`
+}
+
+// Claim 0 to give up your claim
+type RegistryClaimInput struct {
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+type RegistryClaimData struct {
+	Claims []models.RegistryClaim `json:"claims"`
+}
+
+type V1_API_RESPONSE_REGISTRY_CLAIMS struct {
+	V1_API_RESPONSE
+	Data RegistryClaimData `json:"data"`
+}
+
+type RegistryClaimReportData struct {
+	// Who is giving what; empty while claims are hidden
+	Claims []models.RegistryClaimReportEntry `json:"claims"`
+	// When claims will be shown, if they're hidden
+	HiddenUntil *time.Time `json:"hidden_until"`
+}
+
+type V1_API_RESPONSE_REGISTRY_CLAIM_REPORT struct {
+	V1_API_RESPONSE
+	Data RegistryClaimReportData `json:"data"`
+}
+
+// A gift is from a user, one of their invitees, or both
+type GiftInput struct {
+	Description string `json:"description" binding:"required"`
+	// How much cash (or a check) was given, in cents
+	CashCents      int64      `json:"cash_cents" binding:"min=0"`
+	UserId         *uuid.UUID `json:"user_id" binding:"required_without=InviteeId"`
+	InviteeId      *uuid.UUID `json:"invitee_id"`
+	ReceivedAt     *time.Time `json:"received_at"`
+	MailingAddress string     `json:"mailing_address"`
+	Notes          string     `json:"notes"`
+}
+
+// Marking a note SENT without a date records it as sent now; other statuses clear the date
+type ThankYouInput struct {
+	Status string     `json:"status" binding:"required,oneof=NOT_STARTED DRAFTED SENT"`
+	SentAt *time.Time `json:"sent_at"`
+}
+
+type GiftData struct {
+	Gifts []models.Gift `json:"gifts"`
+}
+
+type V1_API_RESPONSE_GIFTS struct {
+	V1_API_RESPONSE
+	Data GiftData `json:"data"`
+}
+
+type ThankYouQueueData struct {
+	Notes []models.ThankYouQueueEntry `json:"notes"`
+}
+
+type V1_API_RESPONSE_THANK_YOU_QUEUE struct {
+	V1_API_RESPONSE
+	Data ThankYouQueueData `json:"data"`
+}
+
+// The address is checked against what mail to its country needs (e.g., a state and ZIP code in the US)
+type HouseholdInput struct {
+	Name         string `json:"name" binding:"required"`
+	AddressLine1 string `json:"address_line_1"`
+	AddressLine2 string `json:"address_line_2"`
+	AddressLine3 string `json:"address_line_3"`
+	City         string `json:"city"`
+	Region       string `json:"region"`
+	PostalCode   string `json:"postal_code"`
+	// An ISO 3166-1 alpha-2 code (e.g., "US")
+	Country string `json:"country" binding:"required,iso3166_1_alpha2"`
+	Notes   string `json:"notes"`
+}
+
+// Dates that are left out are cleared
+type HouseholdMailingInput struct {
+	SaveTheDateMailedAt   *time.Time `json:"save_the_date_mailed_at"`
+	SaveTheDateReturnedAt *time.Time `json:"save_the_date_returned_at"`
+	InvitationMailedAt    *time.Time `json:"invitation_mailed_at"`
+	InvitationReturnedAt  *time.Time `json:"invitation_returned_at"`
+}
+
+type HouseholdMembersInput struct {
+	UserIds    []uuid.UUID `json:"user_ids"`
+	InviteeIds []uuid.UUID `json:"invitee_ids"`
+}
+
+// A household along with who lives there and its address as it's printed on mail
+type HouseholdSummary struct {
+	models.Household
+	FormattedAddress []string    `json:"formatted_address"`
+	UserIds          []uuid.UUID `json:"user_ids"`
+	InviteeIds       []uuid.UUID `json:"invitee_ids"`
+}
+
+type HouseholdData struct {
+	Households []HouseholdSummary `json:"households"`
+}
+
+type V1_API_RESPONSE_HOUSEHOLDS struct {
+	V1_API_RESPONSE
+	Data HouseholdData `json:"data"`
+}
+
+type SongRequestInput struct {
+	Title  string `json:"title" binding:"required"`
+	Artist string `json:"artist" binding:"required"`
+	Link   string `json:"link" binding:"omitempty,url"`
+}
+
+type SongModerationInput struct {
+	Status string `json:"status" binding:"required,oneof=PENDING APPROVED REJECTED DO_NOT_PLAY"`
+}
+
+// A song request along with how many votes it has and whether the logged in user voted for it
+type SongRequestSummary struct {
+	models.SongRequest
+	Votes int64 `json:"votes"`
+	Voted bool  `json:"voted"`
+}
+
+type SongRequestData struct {
+	Songs []SongRequestSummary `json:"songs"`
+}
+
+type V1_API_RESPONSE_SONG_REQUESTS struct {
+	V1_API_RESPONSE
+	Data SongRequestData `json:"data"`
+}
+
+// Entries are private to the couple unless they're made public
+type GuestbookEntryInput struct {
+	Message    string `json:"message" binding:"required,max=2000"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=PRIVATE PUBLIC"`
+}
+
+type GuestbookModerationInput struct {
+	Status string `json:"status" binding:"required,oneof=PENDING APPROVED REJECTED"`
+}
+
+type GuestbookEntryData struct {
+	Entries []models.GuestbookEntry `json:"entries"`
+}
+
+type V1_API_RESPONSE_GUESTBOOK_ENTRIES struct {
+	V1_API_RESPONSE
+	Data GuestbookEntryData `json:"data"`
+}
+
+type PublicGuestbookData struct {
+	Entries []models.PublicGuestbookEntry `json:"entries"`
+	Page    int                           `json:"page"`
+	PerPage int                           `json:"per_page"`
+	// How many entries there are across every page
+	Total int64 `json:"total"`
+}
+
+type V1_API_RESPONSE_PUBLIC_GUESTBOOK struct {
+	V1_API_RESPONSE
+	Data PublicGuestbookData `json:"data"`
+}
+
+type UpdateGuestbookBlockedWordsInput struct {
+	Words []string `json:"words" binding:"required"`
+}
+
+type GuestbookBlockedWordData struct {
+	Words []string `json:"words"`
+}
+
+type V1_API_RESPONSE_GUESTBOOK_BLOCKED_WORDS struct {
+	V1_API_RESPONSE
+	Data GuestbookBlockedWordData `json:"data"`
+}